@@ -8,7 +8,10 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -18,6 +21,7 @@ import (
 	"github.com/zachsouder/rfp/shared/config"
 	"github.com/zachsouder/rfp/shared/db"
 	"github.com/zachsouder/rfp/shared/models"
+	"github.com/zachsouder/rfp/shared/r2"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -37,6 +41,7 @@ func main() {
 func init() {
 	rootCmd.AddCommand(discoveryCmd)
 	rootCmd.AddCommand(userCmd)
+	rootCmd.AddCommand(locationCmd)
 }
 
 // discoveryCmd is the parent command for discovery operations
@@ -54,6 +59,16 @@ func init() {
 	discoveryCmd.AddCommand(recentCmd)
 	discoveryCmd.AddCommand(exportCmd)
 	discoveryCmd.AddCommand(importCmd)
+	discoveryCmd.AddCommand(classifyCmd)
+	discoveryCmd.AddCommand(mergeCmd)
+	discoveryCmd.AddCommand(unmergeCmd)
+	discoveryCmd.AddCommand(snapshotCmd)
+	discoveryCmd.AddCommand(promptDiffCmd)
+	discoveryCmd.AddCommand(reviewCmd)
+	discoveryCmd.AddCommand(uploadCmd)
+	discoveryCmd.AddCommand(gazetteerCmd)
+	discoveryCmd.AddCommand(geocodeCmd)
+	discoveryCmd.AddCommand(agencyCmd)
 }
 
 // connectDB loads config and connects to the database.
@@ -123,7 +138,7 @@ var statsCmd = &cobra.Command{
 		err = database.QueryRow(ctx, `
 			SELECT
 				COUNT(*),
-				COUNT(*) FILTER (WHERE is_active = true),
+				COUNT(*) FILTER (WHERE lifecycle_status IN ('open', 'unknown')),
 				COUNT(*) FILTER (WHERE discovered_at > NOW() - INTERVAL '7 days')
 			FROM discovery.rfps
 		`).Scan(&totalRFPs, &activeRFPs, &rfpsLast7d)
@@ -131,6 +146,27 @@ var statsCmd = &cobra.Command{
 			return fmt.Errorf("failed to query RFP stats: %w", err)
 		}
 
+		// Get RFP lifecycle breakdown
+		lifecycleRows, err := database.Query(ctx, `
+			SELECT lifecycle_status, COUNT(*)
+			FROM discovery.rfps
+			GROUP BY lifecycle_status
+			ORDER BY COUNT(*) DESC
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to query lifecycle stats: %w", err)
+		}
+		defer lifecycleRows.Close()
+
+		var lifecycleCounts []statusCount
+		for lifecycleRows.Next() {
+			var sc statusCount
+			if err := lifecycleRows.Scan(&sc.Status, &sc.Count); err != nil {
+				return fmt.Errorf("failed to scan lifecycle count: %w", err)
+			}
+			lifecycleCounts = append(lifecycleCounts, sc)
+		}
+
 		// Print stats
 		fmt.Println("=== Discovery Statistics ===")
 		fmt.Println()
@@ -148,6 +184,9 @@ var statsCmd = &cobra.Command{
 		fmt.Printf("  Total:        %d\n", totalRFPs)
 		fmt.Printf("  Active:       %d\n", activeRFPs)
 		fmt.Printf("  Last 7 days:  %d\n", rfpsLast7d)
+		for _, sc := range lifecycleCounts {
+			fmt.Printf("  %-13s %d\n", sc.Status+":", sc.Count)
+		}
 
 		return nil
 	},
@@ -175,20 +214,33 @@ var inspectCmd = &cobra.Command{
 		// Get the search result
 		var sr models.SearchResult
 		err = database.QueryRow(ctx, `
-			SELECT id, query_id, url, title, snippet, url_validated, url_valid, final_url, content_type,
-			       hint_agency, hint_state, hint_due_date, research_status, promoted_rfp_id, duplicate_of_id, created_at
+			SELECT id, COALESCE(query_id, 0), url, title, snippet, url_validated, url_valid, final_url, content_type,
+			       COALESCE(hint_agency, ''), COALESCE(hint_state, ''), hint_due_date,
+			       COALESCE(portal, ''), COALESCE(portal_agency, ''), COALESCE(portal_id, ''), COALESCE(portal_page, ''),
+			       research_status, promoted_rfp_id, duplicate_of_id, parent_id, COALESCE(snapshot_key, ''), created_at
 			FROM discovery.search_results
 			WHERE id = $1
 		`, resultID).Scan(
 			&sr.ID, &sr.QueryID, &sr.URL, &sr.Title, &sr.Snippet,
 			&sr.URLValidated, &sr.URLValid, &sr.FinalURL, &sr.ContentType,
 			&sr.HintAgency, &sr.HintState, &sr.HintDueDate,
-			&sr.ResearchStatus, &sr.PromotedRFPID, &sr.DuplicateOfID, &sr.CreatedAt,
+			&sr.Portal, &sr.PortalAgency, &sr.PortalID, &sr.PortalPage,
+			&sr.ResearchStatus, &sr.PromotedRFPID, &sr.DuplicateOfID, &sr.ParentID, &sr.SnapshotKey, &sr.CreatedAt,
 		)
 		if err != nil {
 			return fmt.Errorf("search result not found: %w", err)
 		}
 
+		var childCount int
+		if sr.ResearchStatus == "expanded" {
+			err = database.QueryRow(ctx, `
+				SELECT COUNT(*) FROM discovery.search_results WHERE parent_id = $1
+			`, sr.ID).Scan(&childCount)
+			if err != nil {
+				return fmt.Errorf("failed to count listing entries: %w", err)
+			}
+		}
+
 		// Print result info
 		fmt.Printf("=== Search Result #%d ===\n", sr.ID)
 		fmt.Printf("URL:     %s\n", sr.URL)
@@ -200,16 +252,86 @@ var inspectCmd = &cobra.Command{
 		if sr.ContentType != "" {
 			fmt.Printf("Type:    %s\n", sr.ContentType)
 		}
+		if sr.HintAgency != "" || sr.HintState != "" || sr.HintDueDate != nil {
+			hintDue := ""
+			if sr.HintDueDate != nil {
+				hintDue = sr.HintDueDate.Format("2006-01-02")
+			}
+			fmt.Printf("Hints:   agency=%q state=%q due=%q\n", sr.HintAgency, sr.HintState, hintDue)
+		}
+		if sr.Portal != "" {
+			fmt.Printf("Portal:  %s agency=%q id=%q (%s)\n", sr.Portal, sr.PortalAgency, sr.PortalID, sr.PortalPage)
+		}
+		if sr.DuplicateOfID != nil {
+			fmt.Printf("Duplicate of RFP #%d\n", *sr.DuplicateOfID)
+		}
+		if sr.ParentID != nil {
+			fmt.Printf("Listed on search result #%d\n", *sr.ParentID)
+		}
+		if sr.ResearchStatus == "expanded" {
+			fmt.Printf("Listing expanded into %d results\n", childCount)
+		}
+		if sr.SnapshotKey != "" {
+			fmt.Printf("Snapshot: %s\n", sr.SnapshotKey)
+		}
 		fmt.Printf("Created: %s\n", sr.CreatedAt.Format(time.RFC3339))
 		fmt.Println()
 
-		// Get research steps
+		// Get research runs, newest first
+		runRows, err := database.Query(ctx, `
+			SELECT id, trigger, status, success, COALESCE(error_message, ''), steps_taken, total_tokens,
+			       COALESCE(duration_ms, 0), agent_version, COALESCE(model, ''), started_at
+			FROM discovery.research_runs
+			WHERE search_result_id = $1
+			ORDER BY id DESC
+		`, resultID)
+		if err != nil {
+			return fmt.Errorf("failed to query research runs: %w", err)
+		}
+		var runs []models.ResearchRun
+		for runRows.Next() {
+			var run models.ResearchRun
+			if err := runRows.Scan(
+				&run.ID, &run.Trigger, &run.Status, &run.Success, &run.ErrorMessage, &run.StepsTaken, &run.TotalTokens,
+				&run.DurationMs, &run.AgentVersion, &run.Model, &run.StartedAt,
+			); err != nil {
+				runRows.Close()
+				return fmt.Errorf("failed to scan research run: %w", err)
+			}
+			runs = append(runs, run)
+		}
+		runRows.Close()
+		if err := runRows.Err(); err != nil {
+			return fmt.Errorf("failed to query research runs: %w", err)
+		}
+
+		if len(runs) > 0 {
+			fmt.Println("Research Runs:")
+			for _, run := range runs {
+				fmt.Printf("  #%d %s %s: %s, %d steps, %d tokens, %dms (agent v%s, %s)\n",
+					run.ID, run.StartedAt.Format(time.RFC3339), run.Trigger, run.Status,
+					run.StepsTaken, run.TotalTokens, run.DurationMs, run.AgentVersion, run.Model)
+				if run.ErrorMessage != "" {
+					fmt.Printf("    Error: %s\n", run.ErrorMessage)
+				}
+			}
+			fmt.Println()
+		}
+
+		// Get the steps of the latest run, or those recorded before runs were
+		var latestRunID *int
+		if len(runs) > 0 {
+			latestRunID = &runs[0].ID
+		}
 		rows, err := database.Query(ctx, `
-			SELECT id, step_number, action, input_summary, output_summary, reasoning, success, error_message, created_at
+			SELECT id, research_run_id, step_number, action, COALESCE(input_summary, ''), COALESCE(output_summary, ''),
+			       COALESCE(reasoning, ''), COALESCE(success, false), COALESCE(error_message, ''),
+			       COALESCE(tokens_used, 0), COALESCE(duration_ms, 0),
+			       COALESCE(snapshot_key, ''), COALESCE(prompt_version, ''), created_at
 			FROM discovery.research_steps
-			WHERE search_result_id = $1
+			WHERE search_result_id = $1 AND research_run_id IS NOT DISTINCT FROM $2
 			ORDER BY step_number
-		`, resultID)
+		`, resultID, latestRunID)
 		if err != nil {
 			return fmt.Errorf("failed to query research steps: %w", err)
 		}
@@ -220,8 +342,9 @@ var inspectCmd = &cobra.Command{
 		for rows.Next() {
 			var step models.ResearchStep
 			if err := rows.Scan(
-				&step.ID, &step.StepNumber, &step.Action, &step.InputSummary, &step.OutputSummary,
-				&step.Reasoning, &step.Success, &step.ErrorMessage, &step.CreatedAt,
+				&step.ID, &step.ResearchRunID, &step.StepNumber, &step.Action, &step.InputSummary, &step.OutputSummary,
+				&step.Reasoning, &step.Success, &step.ErrorMessage, &step.TokensUsed, &step.DurationMs,
+				&step.SnapshotKey, &step.PromptVersion, &step.CreatedAt,
 			); err != nil {
 				return fmt.Errorf("failed to scan research step: %w", err)
 			}
@@ -231,7 +354,11 @@ var inspectCmd = &cobra.Command{
 			if !step.Success {
 				status = "FAIL"
 			}
-			fmt.Printf("\n  Step %d: %s [%s]\n", step.StepNumber, step.Action, status)
+			fmt.Printf("\n  Step %d: %s [%s] (%dms", step.StepNumber, step.Action, status, step.DurationMs)
+			if step.TokensUsed > 0 {
+				fmt.Printf(", %d tokens", step.TokensUsed)
+			}
+			fmt.Println(")")
 			if step.Reasoning != "" {
 				fmt.Printf("    Reason: %s\n", truncate(step.Reasoning, 100))
 			}
@@ -244,6 +371,12 @@ var inspectCmd = &cobra.Command{
 			if step.ErrorMessage != "" {
 				fmt.Printf("    Error:  %s\n", step.ErrorMessage)
 			}
+			if step.SnapshotKey != "" {
+				fmt.Printf("    Snapshot: %s\n", step.SnapshotKey)
+			}
+			if step.PromptVersion != "" {
+				fmt.Printf("    Prompt: %s\n", step.PromptVersion)
+			}
 		}
 
 		if stepCount == 0 {
@@ -260,11 +393,18 @@ var inspectCmd = &cobra.Command{
 }
 
 // Research command
+var researchSave bool
+
 var researchCmd = &cobra.Command{
 	Use:   "research [url]",
 	Short: "Manually research a URL",
-	Long:  `Run the research agent on a specific URL.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Run the research agent on a specific URL.
+
+With --save, the run and its steps are recorded against the URL's search result, which
+is created if the URL hasn't been found by a search. A created result is validated and
+queued, so the next discovery cycle researches and promotes it; an existing result's
+research status is not changed.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		url := args[0]
 		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
@@ -273,14 +413,23 @@ var researchCmd = &cobra.Command{
 
 		ctx := context.Background()
 		cfg := config.Load()
-		if cfg.GeminiAPIKey == "" {
-			return fmt.Errorf("GEMINI_API_KEY not set")
-		}
 
-		fmt.Printf("Researching: %s\n\n", url)
+		fmt.Printf("Researching: %s (model %s)\n\n", url, cfg.LLMExtractionModel)
 
 		// Run research via public API
-		res, err := cliapi.ResearchURL(ctx, cfg.GeminiAPIKey, url)
+		var res *cliapi.ResearchResult
+		var err error
+		if researchSave {
+			var database *db.DB
+			database, err = connectDB(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer database.Close()
+			res, err = cliapi.ResearchAndSave(ctx, cfg, database, url)
+		} else {
+			res, err = cliapi.ResearchURL(ctx, cfg, url)
+		}
 		if err != nil {
 			return fmt.Errorf("research failed: %w", err)
 		}
@@ -289,6 +438,7 @@ var researchCmd = &cobra.Command{
 		fmt.Printf("Status: %s\n", res.Status)
 		fmt.Printf("Steps:  %d\n", res.StepsTaken)
 		fmt.Printf("Tokens: %d\n", res.TotalTokens)
+		fmt.Printf("Time:   %dms (agent v%s)\n", res.DurationMs, res.AgentVersion)
 		fmt.Println()
 
 		for _, step := range res.Steps {
@@ -325,6 +475,7 @@ var researchCmd = &cobra.Command{
 			if res.ExtractedDetails.ScopeSummary != "" {
 				fmt.Printf("  Scope:    %s\n", truncate(res.ExtractedDetails.ScopeSummary, 100))
 			}
+			printExtractedRequirements(res.ExtractedDetails)
 		}
 
 		if len(res.FoundPDFs) > 0 {
@@ -334,10 +485,66 @@ var researchCmd = &cobra.Command{
 			}
 		}
 
+		if res.RunID != 0 {
+			fmt.Printf("\nSaved as research run #%d on search result #%d\n", res.RunID, res.ResultID)
+		}
+
 		return nil
 	},
 }
 
+func init() {
+	researchCmd.Flags().BoolVar(&researchSave, "save", false, "Record the run against the URL's search result")
+}
+
+// printExtractedRequirements prints the key dates and bid requirements.
+func printExtractedRequirements(d *cliapi.ExtractedDetails) {
+	if d.SolicitationNumber != "" {
+		fmt.Printf("  Number:   %s\n", d.SolicitationNumber)
+	}
+	if d.PreBidDate != "" {
+		mandatory := ""
+		if d.PreBidMandatory != nil && *d.PreBidMandatory {
+			mandatory = " (mandatory)"
+		}
+		fmt.Printf("  Pre-bid:  %s%s\n", d.PreBidDate, mandatory)
+	}
+	if d.QuestionsDeadline != "" {
+		fmt.Printf("  Questions: %s\n", d.QuestionsDeadline)
+	}
+	if d.SubmissionMethod != "" {
+		fmt.Printf("  Submit:   %s\n", d.SubmissionMethod)
+	}
+	if d.ContactName != "" || d.ContactEmail != "" {
+		fmt.Printf("  Contact:  %s <%s>\n", d.ContactName, d.ContactEmail)
+	}
+	if d.TermMonths != nil {
+		fmt.Printf("  Term:     %d months\n", *d.TermMonths)
+	}
+	if d.ContractTerm != "" {
+		fmt.Printf("  Term text: %s\n", truncate(d.ContractTerm, 100))
+	}
+	if d.BondRequirements != "" {
+		fmt.Printf("  Bonds:    %s\n", truncate(d.BondRequirements, 100))
+	}
+	if d.InsuranceRequirements != "" {
+		fmt.Printf("  Insurance: %s\n", truncate(d.InsuranceRequirements, 100))
+	}
+	if len(d.Evidence) > 0 {
+		fmt.Println("\nEvidence:")
+		for _, ev := range d.Evidence {
+			verified := ""
+			if !ev.Verified {
+				verified = " (not found in source)"
+			}
+			fmt.Printf("  %-22s %3.0f%%  %q%s\n", ev.Field, ev.Confidence*100, truncate(ev.Evidence, 70), verified)
+		}
+	}
+	if len(d.ReviewFields) > 0 {
+		fmt.Printf("\nNeeds review: %s\n", strings.Join(d.ReviewFields, ", "))
+	}
+}
+
 // Retry-failed command
 var retryLimit int
 
@@ -396,7 +603,8 @@ var recentCmd = &cobra.Command{
 		defer database.Close()
 
 		rows, err := database.Query(ctx, `
-			SELECT id, title, agency, state, city, source_url, due_date, category, venue_type, discovered_at, is_active
+			SELECT id, title, agency, state, city, source_url, due_date, category, venue_type, discovered_at,
+			       is_active, lifecycle_status
 			FROM discovery.rfps
 			WHERE discovered_at > NOW() - INTERVAL '1 day' * $1
 			ORDER BY discovered_at DESC
@@ -414,17 +622,17 @@ var recentCmd = &cobra.Command{
 			if err := rows.Scan(
 				&rfp.ID, &rfp.Title, &rfp.Agency, &rfp.State, &rfp.City,
 				&rfp.SourceURL, &rfp.DueDate, &rfp.Category, &rfp.VenueType,
-				&rfp.DiscoveredAt, &rfp.IsActive,
+				&rfp.DiscoveredAt, &rfp.IsActive, &rfp.LifecycleStatus,
 			); err != nil {
 				return fmt.Errorf("failed to scan RFP: %w", err)
 			}
 			count++
 
-			active := ""
-			if !rfp.IsActive {
-				active = " [inactive]"
+			status := ""
+			if rfp.LifecycleStatus != models.LifecycleOpen {
+				status = " [" + rfp.LifecycleStatus + "]"
 			}
-			fmt.Printf("#%d: %s%s\n", rfp.ID, truncate(rfp.Title, 60), active)
+			fmt.Printf("#%d: %s%s\n", rfp.ID, truncate(rfp.Title, 60), status)
 			if rfp.Agency != "" {
 				fmt.Printf("    Agency: %s\n", rfp.Agency)
 			}
@@ -477,7 +685,8 @@ var exportCmd = &cobra.Command{
 			SELECT id, title, agency, state, city, source_url, portal, portal_id,
 			       posted_date, due_date, category, venue_type, scope_keywords,
 			       term_months, estimated_value, incumbent, login_required,
-			       pdf_urls, discovered_at, is_active
+			       pdf_urls, discovered_at, is_active, lifecycle_status,
+			       awardee, award_amount, award_date
 			FROM discovery.rfps
 			WHERE 1=1
 		`
@@ -504,17 +713,22 @@ var exportCmd = &cobra.Command{
 		for rows.Next() {
 			var rfp exportRFP
 			var scopeKeywords, pdfURLs []string
+			var awardee *string
 			if err := rows.Scan(
 				&rfp.ID, &rfp.Title, &rfp.Agency, &rfp.State, &rfp.City,
 				&rfp.SourceURL, &rfp.Portal, &rfp.PortalID,
 				&rfp.PostedDate, &rfp.DueDate, &rfp.Category, &rfp.VenueType, &scopeKeywords,
 				&rfp.TermMonths, &rfp.EstimatedValue, &rfp.Incumbent, &rfp.LoginRequired,
-				&pdfURLs, &rfp.DiscoveredAt, &rfp.IsActive,
+				&pdfURLs, &rfp.DiscoveredAt, &rfp.IsActive, &rfp.LifecycleStatus,
+				&awardee, &rfp.AwardAmount, &rfp.AwardDate,
 			); err != nil {
 				return fmt.Errorf("failed to scan RFP: %w", err)
 			}
 			rfp.ScopeKeywords = scopeKeywords
 			rfp.PDFURLs = pdfURLs
+			if awardee != nil {
+				rfp.Awardee = *awardee
+			}
 			rfps = append(rfps, rfp)
 		}
 
@@ -530,26 +744,30 @@ var exportCmd = &cobra.Command{
 }
 
 type exportRFP struct {
-	ID             int        `json:"id"`
-	Title          string     `json:"title"`
-	Agency         string     `json:"agency"`
-	State          string     `json:"state"`
-	City           string     `json:"city"`
-	SourceURL      string     `json:"source_url"`
-	Portal         string     `json:"portal"`
-	PortalID       string     `json:"portal_id"`
-	PostedDate     *time.Time `json:"posted_date"`
-	DueDate        *time.Time `json:"due_date"`
-	Category       string     `json:"category"`
-	VenueType      string     `json:"venue_type"`
-	ScopeKeywords  []string   `json:"scope_keywords"`
-	TermMonths     *int       `json:"term_months"`
-	EstimatedValue *float64   `json:"estimated_value"`
-	Incumbent      string     `json:"incumbent"`
-	LoginRequired  bool       `json:"login_required"`
-	PDFURLs        []string   `json:"pdf_urls"`
-	DiscoveredAt   time.Time  `json:"discovered_at"`
-	IsActive       bool       `json:"is_active"`
+	ID              int        `json:"id"`
+	Title           string     `json:"title"`
+	Agency          string     `json:"agency"`
+	State           string     `json:"state"`
+	City            string     `json:"city"`
+	SourceURL       string     `json:"source_url"`
+	Portal          string     `json:"portal"`
+	PortalID        string     `json:"portal_id"`
+	PostedDate      *time.Time `json:"posted_date"`
+	DueDate         *time.Time `json:"due_date"`
+	Category        string     `json:"category"`
+	VenueType       string     `json:"venue_type"`
+	ScopeKeywords   []string   `json:"scope_keywords"`
+	TermMonths      *int       `json:"term_months"`
+	EstimatedValue  *float64   `json:"estimated_value"`
+	Incumbent       string     `json:"incumbent"`
+	LoginRequired   bool       `json:"login_required"`
+	PDFURLs         []string   `json:"pdf_urls"`
+	DiscoveredAt    time.Time  `json:"discovered_at"`
+	IsActive        bool       `json:"is_active"`
+	LifecycleStatus string     `json:"lifecycle_status"`
+	Awardee         string     `json:"awardee,omitempty"`
+	AwardAmount     *float64   `json:"award_amount,omitempty"`
+	AwardDate       *time.Time `json:"award_date,omitempty"`
 }
 
 func exportJSON(rfps []exportRFP) error {
@@ -566,6 +784,7 @@ func exportCSV(rfps []exportRFP) error {
 	if err := w.Write([]string{
 		"id", "title", "agency", "state", "city", "source_url",
 		"portal", "due_date", "category", "venue_type", "discovered_at", "is_active",
+		"lifecycle_status", "awardee",
 	}); err != nil {
 		return err
 	}
@@ -593,6 +812,8 @@ func exportCSV(rfps []exportRFP) error {
 			rfp.VenueType,
 			rfp.DiscoveredAt.Format(time.RFC3339),
 			active,
+			rfp.LifecycleStatus,
+			rfp.Awardee,
 		}); err != nil {
 			return err
 		}
@@ -755,35 +976,142 @@ func init() {
 	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Preview import without making changes")
 }
 
-// User commands
+// Classify command
+var (
+	classifyAll    bool
+	classifyDryRun bool
+)
 
-var userCmd = &cobra.Command{
-	Use:   "user",
-	Short: "User management",
-	Long:  `Commands for managing client application users.`,
+var classifyCmd = &cobra.Command{
+	Use:   "classify",
+	Short: "Derive scope keywords, category and venue type",
+	Long:  `Run the scope taxonomy over stored RFPs. By default only RFPs without scope keywords are classified.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		query := `
+			SELECT id, title, COALESCE(raw_content, ''), COALESCE(category, ''), COALESCE(venue_type, '')
+			FROM discovery.rfps
+		`
+		if !classifyAll {
+			query += ` WHERE scope_keywords IS NULL OR cardinality(scope_keywords) = 0`
+		}
+		query += ` ORDER BY id`
+
+		rows, err := database.Query(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to query RFPs: %w", err)
+		}
+
+		type classified struct {
+			id  int
+			cls cliapi.Classification
+		}
+		var updates []classified
+		for rows.Next() {
+			var id int
+			var title, content, category, venueType string
+			if err := rows.Scan(&id, &title, &content, &category, &venueType); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan RFP: %w", err)
+			}
+
+			cls := cliapi.Classify(title, content)
+			if cls.Category == "" {
+				cls.Category = category
+			}
+			if cls.VenueType == "" {
+				cls.VenueType = venueType
+			}
+			updates = append(updates, classified{id, cls})
+
+			fmt.Printf("%d: %s\n", id, truncate(title, 60))
+			fmt.Printf("    %s / %s  [%s]\n", cls.Category, cls.VenueType, strings.Join(cls.ScopeKeywords, ", "))
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read RFPs: %w", err)
+		}
+
+		if classifyDryRun {
+			fmt.Printf("\nDry run: %d RFPs would be updated\n", len(updates))
+			return nil
+		}
+
+		for _, u := range updates {
+			_, err := database.Exec(ctx, `
+				UPDATE discovery.rfps
+				SET scope_keywords = $2, category = NULLIF($3, ''), venue_type = NULLIF($4, '')
+				WHERE id = $1
+			`, u.id, u.cls.ScopeKeywords, u.cls.Category, u.cls.VenueType)
+			if err != nil {
+				return fmt.Errorf("failed to update RFP %d: %w", u.id, err)
+			}
+		}
+
+		fmt.Printf("\nClassified %d RFPs\n", len(updates))
+		return nil
+	},
 }
 
-var userCreateEmail string
-var userCreateFirstName string
-var userCreateLastName string
-var userCreateRole string
+func init() {
+	classifyCmd.Flags().BoolVar(&classifyAll, "all", false, "Reclassify every RFP, not just unclassified ones")
+	classifyCmd.Flags().BoolVar(&classifyDryRun, "dry-run", false, "Preview classifications without making changes")
+}
 
-var userCreateCmd = &cobra.Command{
-	Use:   "create",
-	Short: "Create a new user",
-	Long:  `Create a new user with a generated temporary password. Use this to bootstrap the first admin account.`,
+// Merge commands
+var mergeBy string
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [keep-id] [drop-id]",
+	Short: "Merge a duplicate RFP into another",
+	Long: `Fold the second RFP into the first. Empty fields are filled from the dropped RFP,
+search results and client tracking are re-pointed, notes and attachments are combined,
+and the dropped RFP is deleted. The merge is recorded and can be undone with unmerge.`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if userCreateEmail == "" {
-			return fmt.Errorf("--email is required")
+		keepID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid keep ID: %w", err)
 		}
-		if userCreateFirstName == "" {
-			return fmt.Errorf("--first-name is required")
+		dropID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid drop ID: %w", err)
 		}
-		if userCreateLastName == "" {
-			return fmt.Errorf("--last-name is required")
+
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
 		}
-		if userCreateRole != "admin" && userCreateRole != "user" {
-			return fmt.Errorf("--role must be 'admin' or 'user'")
+		defer database.Close()
+
+		res, err := cliapi.MergeRFPs(ctx, database, keepID, dropID, mergeBy)
+		if err != nil {
+			return fmt.Errorf("merge failed: %w", err)
+		}
+
+		fmt.Printf("Merged RFP %d into %d (merge #%d)\n", res.DroppedRFPID, res.KeptRFPID, res.MergeID)
+		printMergeResult(res)
+		fmt.Printf("\nUndo with: rfp-cli discovery unmerge %d\n", res.MergeID)
+		return nil
+	},
+}
+
+var unmergeCmd = &cobra.Command{
+	Use:   "unmerge [merge-id]",
+	Short: "Undo a recorded merge",
+	Long:  `Restore the dropped RFP and reset the kept RFP, its tracking, notes and attachments to their state before the merge.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mergeID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid merge ID: %w", err)
 		}
 
 		ctx := context.Background()
@@ -793,45 +1121,1072 @@ var userCreateCmd = &cobra.Command{
 		}
 		defer database.Close()
 
-		// Generate a temporary password
-		tokenBytes := make([]byte, 32)
-		if _, err := rand.Read(tokenBytes); err != nil {
-			return fmt.Errorf("failed to generate password: %w", err)
+		res, err := cliapi.UnmergeRFPs(ctx, database, mergeID)
+		if err != nil {
+			return fmt.Errorf("unmerge failed: %w", err)
 		}
-		tempPassword := base64.URLEncoding.EncodeToString(tokenBytes)[:12]
 
-		passwordHash, err := bcrypt.GenerateFromPassword([]byte(tempPassword), 12)
+		fmt.Printf("Restored RFP %d from merge #%d into %d\n", res.DroppedRFPID, res.MergeID, res.KeptRFPID)
+		printMergeResult(res)
+		return nil
+	},
+}
+
+func init() {
+	mergeCmd.Flags().StringVar(&mergeBy, "by", os.Getenv("USER"), "Who performed the merge")
+}
+
+func printMergeResult(res *cliapi.MergeResult) {
+	if len(res.FieldsFilled) > 0 {
+		fmt.Printf("  Fields filled:     %s\n", strings.Join(res.FieldsFilled, ", "))
+	}
+	fmt.Printf("  Search results:    %d\n", res.SearchResults)
+	if res.ReviewDecisions > 0 {
+		fmt.Printf("  Review decisions:  %d\n", res.ReviewDecisions)
+	}
+	if res.TrackingMoved {
+		fmt.Println("  Client tracking:   moved")
+	}
+	fmt.Printf("  Notes moved:       %d\n", res.NotesMoved)
+	fmt.Printf("  Attachments moved: %d\n", res.AttachmentsMoved)
+}
+
+// Snapshot command
+var (
+	snapshotBody   bool
+	snapshotOutput string
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot [result-id]",
+	Short: "Retrieve the archived page for a result",
+	Long: `Download the raw page archived for a search result: the last page fetched during
+research, or the page fetched at validation. Prints the response metadata and headers,
+followed by the body.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resultID, err := strconv.Atoi(args[0])
 		if err != nil {
-			return fmt.Errorf("failed to hash password: %w", err)
+			return fmt.Errorf("invalid result ID: %w", err)
 		}
 
-		var userID int
-		err = database.QueryRow(ctx, `
-			INSERT INTO client.users (email, password_hash, first_name, last_name, role)
-			VALUES ($1, $2, $3, $4, $5)
-			RETURNING id
-		`, userCreateEmail, string(passwordHash), userCreateFirstName, userCreateLastName, userCreateRole).Scan(&userID)
+		ctx := context.Background()
+		cfg := config.Load()
+		objects, err := r2.NewClient(cfg.R2AccountID, cfg.R2AccessKeyID, cfg.R2SecretAccessKey, cfg.R2Bucket)
 		if err != nil {
-			return fmt.Errorf("failed to create user (email may already exist): %w", err)
+			return err
 		}
 
-		fmt.Println("User created successfully!")
-		fmt.Printf("  ID:       %d\n", userID)
-		fmt.Printf("  Email:    %s\n", userCreateEmail)
-		fmt.Printf("  Name:     %s %s\n", userCreateFirstName, userCreateLastName)
-		fmt.Printf("  Role:     %s\n", userCreateRole)
-		fmt.Printf("  Password: %s\n", tempPassword)
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		snap, err := cliapi.GetSnapshot(ctx, database, objects, resultID)
+		if err != nil {
+			return fmt.Errorf("snapshot failed: %w", err)
+		}
+
+		if snapshotOutput != "" {
+			if err := os.WriteFile(snapshotOutput, snap.Body, 0o644); err != nil {
+				return fmt.Errorf("failed to write snapshot: %w", err)
+			}
+			fmt.Printf("Wrote %d bytes from %s to %s\n", len(snap.Body), snap.Key, snapshotOutput)
+			return nil
+		}
+		if snapshotBody {
+			_, err := os.Stdout.Write(snap.Body)
+			return err
+		}
+
+		fmt.Printf("Key:     %s (%s)\n", snap.Key, snap.Source)
+		fmt.Printf("URL:     %s\n", snap.URL)
+		if snap.FinalURL != snap.URL {
+			fmt.Printf("Final:   %s\n", snap.FinalURL)
+		}
+		fmt.Printf("Status:  %d\n", snap.StatusCode)
+		fmt.Printf("Fetched: %s\n", snap.FetchedAt.Format(time.RFC3339))
+		if snap.Truncated {
+			fmt.Println("Body was truncated when fetched")
+		}
 		fmt.Println()
-		fmt.Println("The user should change their password after first login.")
+		if err := snap.Header.Write(os.Stdout); err != nil {
+			return err
+		}
+		fmt.Println()
+		_, err = os.Stdout.Write(snap.Body)
+		return err
+	},
+}
+
+func init() {
+	snapshotCmd.Flags().BoolVar(&snapshotBody, "body", false, "Print only the page body")
+	snapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "", "Write the page body to a file")
+}
+
+// Prompt diff command
+var (
+	promptDiffSample  int
+	promptDiffAgainst string
+)
+
+var promptDiffCmd = &cobra.Command{
+	Use:   "prompt-diff [candidate-file]",
+	Short: "Compare a candidate extraction prompt against the current one",
+	Long: `Re-run RFP extraction on the page text of recently discovered RFPs with a candidate
+prompt template and with the current version, and show the fields that differ. The
+candidate file uses the same template fields as the embedded extract_rfp templates
+({{.URL}} and {{.Content}}).`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		candidate, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read candidate prompt: %w", err)
+		}
+
+		ctx := context.Background()
+		cfg := config.Load()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		cmp, err := cliapi.CompareExtractionPrompts(ctx, cfg, database, string(candidate), promptDiffAgainst, promptDiffSample)
+		if err != nil {
+			return fmt.Errorf("prompt comparison failed: %w", err)
+		}
+
+		fmt.Printf("Comparing %s against %s (model %s)\n", cmp.Candidate, cmp.Baseline, cmp.Model)
+
+		changed, failed := 0, 0
+		fieldCounts := make(map[string]int)
+		for _, s := range cmp.Samples {
+			fmt.Printf("\nRFP %d: %s\n", s.RFPID, truncate(s.Title, 80))
+			switch {
+			case s.Error != "":
+				failed++
+				fmt.Printf("  Error: %s\n", s.Error)
+			case len(s.Changes) == 0:
+				fmt.Println("  (no differences)")
+			default:
+				changed++
+				for _, c := range s.Changes {
+					fieldCounts[c.Field]++
+					fmt.Printf("  %s:\n", c.Field)
+					fmt.Printf("    - %s\n", truncate(c.Baseline, 100))
+					fmt.Printf("    + %s\n", truncate(c.Candidate, 100))
+				}
+			}
+		}
 
+		fmt.Printf("\n%d RFPs compared: %d changed, %d unchanged, %d failed\n",
+			len(cmp.Samples), changed, len(cmp.Samples)-changed-failed, failed)
+		if len(fieldCounts) > 0 {
+			fields := make([]string, 0, len(fieldCounts))
+			for f := range fieldCounts {
+				fields = append(fields, f)
+			}
+			sort.Strings(fields)
+			fmt.Println("Changed fields:")
+			for _, f := range fields {
+				fmt.Printf("  %-24s %d\n", f, fieldCounts[f])
+			}
+		}
 		return nil
 	},
 }
 
 func init() {
-	userCmd.AddCommand(userCreateCmd)
-	userCreateCmd.Flags().StringVar(&userCreateEmail, "email", "", "User email address")
-	userCreateCmd.Flags().StringVar(&userCreateFirstName, "first-name", "", "First name")
-	userCreateCmd.Flags().StringVar(&userCreateLastName, "last-name", "", "Last name")
-	userCreateCmd.Flags().StringVar(&userCreateRole, "role", "admin", "User role (admin or user)")
+	promptDiffCmd.Flags().IntVar(&promptDiffSample, "sample", 5, "Number of recent RFPs to re-extract")
+	promptDiffCmd.Flags().StringVar(&promptDiffAgainst, "against", "", "Prompt version to compare against (default: current)")
+}
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Work the manual review queue",
+	Long: `Results that research couldn't finish wait here: needs_manual, needs_manual_upload
+(login-walled) and research_exhausted. Each can be promoted to an RFP, marked as a
+duplicate of one, rejected, or sent back for research. Every decision records who
+made it and why.`,
+}
+
+var reviewListStatus string
+var reviewListLimit int
+
+var reviewListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List results awaiting review",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		items, err := cliapi.ListReviewQueue(ctx, database, reviewListStatus, reviewListLimit)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			fmt.Println("Review queue is empty")
+			return nil
+		}
+
+		for _, item := range items {
+			fmt.Printf("[%d] %s\n", item.ResultID, truncate(item.Title, 70))
+			fmt.Printf("    %s | %s\n", item.Status, item.URL)
+			if item.Reason != "" {
+				fmt.Printf("    Reason: %s\n", truncate(item.Reason, 100))
+			}
+		}
+		fmt.Printf("\n%d result(s)\n", len(items))
+		return nil
+	},
+}
+
+var reviewShowCmd = &cobra.Command{
+	Use:   "show [result-id]",
+	Short: "Show a queued result with its research trace",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resultID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid result ID: %w", err)
+		}
+
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		d, err := cliapi.GetReviewItem(ctx, database, resultID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("=== Result #%d ===\n", d.ResultID)
+		fmt.Printf("Title:   %s\n", d.Title)
+		fmt.Printf("URL:     %s\n", d.URL)
+		if d.FinalURL != "" && d.FinalURL != d.URL {
+			fmt.Printf("Final:   %s\n", d.FinalURL)
+		}
+		fmt.Printf("Status:  %s\n", d.Status)
+		if d.Portal != "" {
+			fmt.Printf("Portal:  %s\n", d.Portal)
+		}
+		if d.Reason != "" {
+			fmt.Printf("Reason:  %s\n", d.Reason)
+		}
+
+		if len(d.Steps) > 0 {
+			fmt.Println("\n--- Research Trace ---")
+			for _, s := range d.Steps {
+				status := "ok"
+				if !s.Success {
+					status = "failed"
+				}
+				fmt.Printf("  %d. %s (%s)\n", s.StepNumber, s.Action, status)
+				if s.InputSummary != "" {
+					fmt.Printf("     In:  %s\n", truncate(s.InputSummary, 120))
+				}
+				if s.OutputSummary != "" {
+					fmt.Printf("     Out: %s\n", truncate(s.OutputSummary, 120))
+				}
+				if s.Reasoning != "" {
+					fmt.Printf("     %s\n", truncate(s.Reasoning, 120))
+				}
+			}
+		}
+
+		if d.ExtractedDetails != nil {
+			fmt.Println("\n--- Extracted ---")
+			data, _ := json.MarshalIndent(d.ExtractedDetails, "  ", "  ")
+			fmt.Printf("  %s\n", data)
+		}
+		for _, pdf := range d.FoundPDFs {
+			fmt.Printf("PDF: %s\n", pdf)
+		}
+
+		if len(d.Decisions) > 0 {
+			fmt.Println("\n--- Decisions ---")
+			for _, dec := range d.Decisions {
+				fmt.Printf("  %s %s by %s: %s\n", dec.CreatedAt.Format("2006-01-02 15:04"), dec.Action, dec.Reviewer, dec.Reason)
+			}
+		}
+		return nil
+	},
+}
+
+var reviewBy string
+var reviewReason string
+
+// Fields a reviewer can enter when promoting
+var reviewFields cliapi.ExtractedDetails
+
+var reviewPromoteCmd = &cobra.Command{
+	Use:   "promote [result-id]",
+	Short: "Promote a queued result to an RFP",
+	Long: `Create an RFP from a queued result. Fields given as flags override what research
+extracted and are recorded as entered by the reviewer. A title is required, either
+extracted or entered.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resultID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid result ID: %w", err)
+		}
+		return runReviewDecision(func(ctx context.Context, database *db.DB) (*cliapi.ReviewDecision, error) {
+			return cliapi.ReviewPromote(ctx, database, resultID, &reviewFields, reviewBy, reviewReason)
+		})
+	},
+}
+
+var reviewDuplicateCmd = &cobra.Command{
+	Use:   "duplicate [result-id] [rfp-id]",
+	Short: "Mark a queued result as a duplicate of an existing RFP",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resultID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid result ID: %w", err)
+		}
+		rfpID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid RFP ID: %w", err)
+		}
+		return runReviewDecision(func(ctx context.Context, database *db.DB) (*cliapi.ReviewDecision, error) {
+			return cliapi.ReviewDuplicate(ctx, database, resultID, rfpID, reviewBy, reviewReason)
+		})
+	},
+}
+
+var reviewRejectCmd = &cobra.Command{
+	Use:   "reject [result-id]",
+	Short: "Reject a queued result as not a relevant RFP",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resultID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid result ID: %w", err)
+		}
+		return runReviewDecision(func(ctx context.Context, database *db.DB) (*cliapi.ReviewDecision, error) {
+			return cliapi.ReviewReject(ctx, database, resultID, reviewBy, reviewReason)
+		})
+	},
+}
+
+var reviewRequeueCmd = &cobra.Command{
+	Use:   "requeue [result-id]",
+	Short: "Send a queued result back for research",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resultID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid result ID: %w", err)
+		}
+		return runReviewDecision(func(ctx context.Context, database *db.DB) (*cliapi.ReviewDecision, error) {
+			return cliapi.ReviewRequeue(ctx, database, resultID, reviewBy, reviewReason)
+		})
+	},
+}
+
+// runReviewDecision connects, applies a decision and prints it.
+func runReviewDecision(fn func(ctx context.Context, database *db.DB) (*cliapi.ReviewDecision, error)) error {
+	ctx := context.Background()
+	database, err := connectDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer database.Close()
+
+	dec, err := fn(ctx, database)
+	if err != nil {
+		return fmt.Errorf("review failed: %w", err)
+	}
+
+	fmt.Printf("Result %d: %s by %s (decision #%d, was %s)\n", dec.SearchResultID, dec.Action, dec.Reviewer, dec.ID, dec.PreviousStatus)
+	if dec.RFPID != nil {
+		fmt.Printf("RFP: %d\n", *dec.RFPID)
+	}
+	return nil
+}
+
+func init() {
+	reviewCmd.AddCommand(reviewListCmd)
+	reviewCmd.AddCommand(reviewShowCmd)
+	reviewCmd.AddCommand(reviewPromoteCmd)
+	reviewCmd.AddCommand(reviewDuplicateCmd)
+	reviewCmd.AddCommand(reviewRejectCmd)
+	reviewCmd.AddCommand(reviewRequeueCmd)
+
+	reviewListCmd.Flags().StringVar(&reviewListStatus, "status", "", "Only this status ("+strings.Join(cliapi.ReviewStatuses, ", ")+")")
+	reviewListCmd.Flags().IntVar(&reviewListLimit, "limit", 50, "Maximum results to list")
+
+	for _, c := range []*cobra.Command{reviewPromoteCmd, reviewDuplicateCmd, reviewRejectCmd, reviewRequeueCmd} {
+		c.Flags().StringVar(&reviewBy, "by", os.Getenv("USER"), "Who made the decision")
+		c.Flags().StringVar(&reviewReason, "reason", "", "Why (required)")
+	}
+
+	f := reviewPromoteCmd.Flags()
+	f.StringVar(&reviewFields.Title, "title", "", "RFP title")
+	f.StringVar(&reviewFields.Agency, "agency", "", "Issuing agency")
+	f.StringVar(&reviewFields.City, "city", "", "City")
+	f.StringVar(&reviewFields.State, "state", "", "State (two-letter code)")
+	f.StringVar(&reviewFields.DueDate, "due-date", "", "Due date (YYYY-MM-DD)")
+	f.StringVar(&reviewFields.EstimatedValue, "value", "", "Estimated contract value")
+	f.StringVar(&reviewFields.Category, "category", "", "Category")
+	f.StringVar(&reviewFields.VenueType, "venue-type", "", "Venue type")
+	f.StringVar(&reviewFields.SolicitationNumber, "solicitation", "", "Solicitation number")
+	f.StringVar(&reviewFields.ContactName, "contact-name", "", "Contact name")
+	f.StringVar(&reviewFields.ContactEmail, "contact-email", "", "Contact email")
+	f.StringVar(&reviewFields.Incumbent, "incumbent", "", "Incumbent operator")
+	f.StringVar(&reviewFields.ScopeSummary, "scope", "", "Scope summary")
+}
+
+var uploadBy string
+
+var uploadCmd = &cobra.Command{
+	Use:   "upload [result-id] [file.pdf...]",
+	Short: "Attach solicitation PDFs to a queued result and resume research",
+	Long: `For results behind a portal login (needs_manual_upload), download the solicitation
+yourself and upload it here. The PDFs are stored in R2, research resumes from their
+text, and the result is promoted as usual, keeping login_required on the RFP.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resultID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid result ID: %w", err)
+		}
+
+		var files []cliapi.UploadFile
+		for _, path := range args[1:] {
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			if info.Size() > cliapi.MaxUploadSize {
+				return fmt.Errorf("%s is larger than %d MB", path, cliapi.MaxUploadSize/1024/1024)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			files = append(files, cliapi.UploadFile{Name: filepath.Base(path), Data: data})
+		}
+
+		cfg := config.Load()
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		docs, out, err := cliapi.UploadDocuments(ctx, cfg, database, resultID, files, uploadBy)
+		for _, doc := range docs {
+			fmt.Printf("Stored %s (%d bytes, %d chars of text) as %s\n", doc.Filename, doc.Size, doc.TextChars, doc.R2Key)
+		}
+		if err != nil {
+			return fmt.Errorf("upload failed: %w", err)
+		}
+
+		fmt.Printf("\nResearch run #%d: %s\n", out.RunID, out.Status)
+		if out.Error != "" {
+			fmt.Printf("Error: %s\n", out.Error)
+		}
+		if out.RFPID != nil {
+			fmt.Printf("Promoted to RFP %d\n", *out.RFPID)
+		}
+		if len(out.ReviewFields) > 0 {
+			fmt.Printf("Needs review: %s\n", strings.Join(out.ReviewFields, ", "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	uploadCmd.Flags().StringVar(&uploadBy, "by", os.Getenv("USER"), "Who is uploading the documents")
+}
+
+var gazetteerCmd = &cobra.Command{
+	Use:   "gazetteer",
+	Short: "Manage the offline place gazetteer used for geocoding",
+}
+
+var gazetteerLoadCmd = &cobra.Command{
+	Use:   "load [file]",
+	Short: "Load the place gazetteer",
+	Long: `Replace the place gazetteer used to geocode RFPs. With no file, the bundled major
+cities and state capitals are loaded. For full coverage, pass the Census Bureau's
+Gazetteer places file (2020_Gaz_place_national.txt or later), tab-separated with
+USPS, NAME, INTPTLAT and INTPTLONG columns.
+
+Existing RFPs keep their coordinates; run "discovery geocode --all" to redo them.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var r io.Reader
+		source := "bundled places"
+		if len(args) == 1 {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r, source = f, args[0]
+		}
+
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		n, err := cliapi.LoadGazetteer(ctx, database, r)
+		if err != nil {
+			return fmt.Errorf("load failed: %w", err)
+		}
+		fmt.Printf("Loaded %d places from %s\n", n, source)
+		return nil
+	},
+}
+
+func init() {
+	gazetteerCmd.AddCommand(gazetteerLoadCmd)
+}
+
+var geocodeAll bool
+
+var geocodeCmd = &cobra.Command{
+	Use:   "geocode",
+	Short: "Set coordinates on RFPs from the gazetteer",
+	Long: `Geocode RFPs that have no coordinates yet, such as those promoted before the
+gazetteer was loaded, and retry those only located to their state. RFPs are located
+by city, or by the municipality an agency is named for, falling back to the center
+of their state.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		summary, err := cliapi.GeocodeRFPs(ctx, database, geocodeAll)
+		if summary != nil {
+			fmt.Printf("Checked %d RFPs: %d located to a place, %d to their state, %d unknown\n",
+				summary.Checked, summary.Place, summary.State, summary.Unknown)
+		}
+		if err != nil {
+			return fmt.Errorf("geocode failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	geocodeCmd.Flags().BoolVar(&geocodeAll, "all", false, "Redo RFPs already located to a place")
+}
+
+var agencyCmd = &cobra.Command{
+	Use:   "agency",
+	Short: "Canonical agencies and their solicitations",
+	Long: `RFPs are linked to a canonical agency when they are promoted. Agencies keep the other
+names they appear under as aliases; add an alias or merge two agencies when the same
+agency shows up under different names.`,
+}
+
+var (
+	agencyListState  string
+	agencyListSearch string
+	agencyListLimit  int
+)
+
+var agencyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List agencies, busiest first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		agencies, err := cliapi.ListAgencies(ctx, database, agencyListState, agencyListSearch, agencyListLimit)
+		if err != nil {
+			return err
+		}
+		if len(agencies) == 0 {
+			fmt.Println("No agencies found")
+			return nil
+		}
+
+		fmt.Printf("%-6s %-45s %-5s %-11s %5s %5s  %s\n", "ID", "NAME", "STATE", "TYPE", "RFPS", "OPEN", "LAST POSTED")
+		for _, a := range agencies {
+			last := ""
+			if a.LastPosted != nil {
+				last = a.LastPosted.Format("2006-01-02")
+			}
+			fmt.Printf("%-6d %-45s %-5s %-11s %5d %5d  %s\n",
+				a.ID, truncate(a.Name, 45), a.State, a.Type, a.RFPCount, a.OpenCount, last)
+		}
+		return nil
+	},
+}
+
+var agencyShowCmd = &cobra.Command{
+	Use:   "show [id]",
+	Short: "Show an agency and every solicitation it has put out",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid agency ID: %w", err)
+		}
+
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		a, err := cliapi.GetAgency(ctx, database, id)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Agency #%d: %s\n", a.ID, a.Name)
+		if a.State != "" {
+			fmt.Printf("State:   %s\n", a.State)
+		}
+		if a.Type != "" {
+			fmt.Printf("Type:    %s\n", a.Type)
+		}
+		if a.PortalURL != "" {
+			fmt.Printf("Portal:  %s\n", a.PortalURL)
+		}
+		if len(a.Aliases) > 0 {
+			fmt.Printf("Aliases: %s\n", strings.Join(a.Aliases, "; "))
+		}
+
+		fmt.Printf("\n--- Solicitations (%d) ---\n", len(a.RFPs))
+		for _, r := range a.RFPs {
+			due := "-"
+			if r.DueDate != nil {
+				due = r.DueDate.Format("2006-01-02")
+			}
+			fmt.Printf("  %-6d %-10s %-10s %s\n", r.ID, due, r.LifecycleStatus, truncate(r.Title, 70))
+			if r.Awardee != "" {
+				fmt.Printf("         awarded to %s\n", r.Awardee)
+			}
+		}
+		return nil
+	},
+}
+
+var (
+	agencyUpdateName      string
+	agencyUpdateState     string
+	agencyUpdateType      string
+	agencyUpdatePortalURL string
+)
+
+var agencyUpdateCmd = &cobra.Command{
+	Use:   "update [id]",
+	Short: "Change an agency's name, state, type or portal URL",
+	Long: `Change an agency's details. A renamed agency keeps its old name as an alias.
+Pass an empty --type or --portal-url to clear it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid agency ID: %w", err)
+		}
+
+		var changes cliapi.AgencyChanges
+		if cmd.Flags().Changed("name") {
+			changes.Name = &agencyUpdateName
+		}
+		if cmd.Flags().Changed("state") {
+			changes.State = &agencyUpdateState
+		}
+		if cmd.Flags().Changed("type") {
+			changes.Type = &agencyUpdateType
+		}
+		if cmd.Flags().Changed("portal-url") {
+			changes.PortalURL = &agencyUpdatePortalURL
+		}
+		if changes == (cliapi.AgencyChanges{}) {
+			return fmt.Errorf("nothing to update; pass --name, --state, --type or --portal-url")
+		}
+
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		a, err := cliapi.UpdateAgency(ctx, database, id, changes)
+		if err != nil {
+			return fmt.Errorf("update failed: %w", err)
+		}
+		fmt.Printf("Updated agency %d: %s (%s)\n", a.ID, a.Name, a.State)
+		return nil
+	},
+}
+
+var agencyAliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage the other names an agency goes by",
+}
+
+var agencyAliasAddCmd = &cobra.Command{
+	Use:   "add [id] [name]",
+	Short: "Add an alias, so RFPs under that name link to the agency",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAgencyAlias(args, cliapi.AddAgencyAlias, "Added")
+	},
+}
+
+var agencyAliasRemoveCmd = &cobra.Command{
+	Use:   "remove [id] [name]",
+	Short: "Remove an alias",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAgencyAlias(args, cliapi.RemoveAgencyAlias, "Removed")
+	},
+}
+
+func runAgencyAlias(args []string, fn func(ctx context.Context, database *db.DB, id int, alias string) error, verb string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid agency ID: %w", err)
+	}
+
+	ctx := context.Background()
+	database, err := connectDB(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer database.Close()
+
+	if err := fn(ctx, database, id, args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("%s alias %q on agency %d\n", verb, args[1], id)
+	return nil
+}
+
+var agencyMergeCmd = &cobra.Command{
+	Use:   "merge [keep-id] [drop-id]",
+	Short: "Merge two agencies that are the same",
+	Long: `Fold the dropped agency into the kept one. Its RFPs are re-linked, its name and
+aliases become aliases of the kept agency, and it is deleted.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keepID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid agency ID: %w", err)
+		}
+		dropID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid agency ID: %w", err)
+		}
+
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		a, err := cliapi.MergeAgencies(ctx, database, keepID, dropID)
+		if err != nil {
+			return fmt.Errorf("merge failed: %w", err)
+		}
+		fmt.Printf("Merged agency %d into %d: %s\n", dropID, a.ID, a.Name)
+		if len(a.Aliases) > 0 {
+			fmt.Printf("Aliases: %s\n", strings.Join(a.Aliases, "; "))
+		}
+		return nil
+	},
+}
+
+var agencyLinkAll bool
+
+var agencyLinkCmd = &cobra.Command{
+	Use:   "link",
+	Short: "Link RFPs to their agencies",
+	Long: `Link RFPs that have no agency yet, such as those promoted or imported before agencies
+existed, creating agencies as needed. With --all, every RFP is re-linked, e.g. after
+adding aliases.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		summary, err := cliapi.LinkAgencies(ctx, database, agencyLinkAll)
+		if summary != nil {
+			fmt.Printf("Checked %d RFPs: %d linked, %d new agencies\n", summary.Checked, summary.Linked, summary.Created)
+		}
+		if err != nil {
+			return fmt.Errorf("link failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	agencyCmd.AddCommand(agencyListCmd)
+	agencyCmd.AddCommand(agencyShowCmd)
+	agencyCmd.AddCommand(agencyUpdateCmd)
+	agencyCmd.AddCommand(agencyAliasCmd)
+	agencyCmd.AddCommand(agencyMergeCmd)
+	agencyCmd.AddCommand(agencyLinkCmd)
+	agencyAliasCmd.AddCommand(agencyAliasAddCmd)
+	agencyAliasCmd.AddCommand(agencyAliasRemoveCmd)
+
+	agencyListCmd.Flags().StringVar(&agencyListState, "state", "", "Only agencies in this state")
+	agencyListCmd.Flags().StringVar(&agencyListSearch, "search", "", "Match names and aliases")
+	agencyListCmd.Flags().IntVar(&agencyListLimit, "limit", 50, "Maximum agencies to list")
+
+	agencyUpdateCmd.Flags().StringVar(&agencyUpdateName, "name", "", "Canonical name")
+	agencyUpdateCmd.Flags().StringVar(&agencyUpdateState, "state", "", "Two-letter state code")
+	agencyUpdateCmd.Flags().StringVar(&agencyUpdateType, "type", "", "Agency type: "+strings.Join(cliapi.AgencyTypes, ", "))
+	agencyUpdateCmd.Flags().StringVar(&agencyUpdatePortalURL, "portal-url", "", "Procurement portal URL")
+
+	agencyLinkCmd.Flags().BoolVar(&agencyLinkAll, "all", false, "Re-link RFPs that already have an agency")
+}
+
+// User commands
+
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "User management",
+	Long:  `Commands for managing client application users.`,
+}
+
+var userCreateEmail string
+var userCreateFirstName string
+var userCreateLastName string
+var userCreateRole string
+
+var userCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new user",
+	Long:  `Create a new user with a generated temporary password. Use this to bootstrap the first admin account.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if userCreateEmail == "" {
+			return fmt.Errorf("--email is required")
+		}
+		if userCreateFirstName == "" {
+			return fmt.Errorf("--first-name is required")
+		}
+		if userCreateLastName == "" {
+			return fmt.Errorf("--last-name is required")
+		}
+		if userCreateRole != "admin" && userCreateRole != "user" {
+			return fmt.Errorf("--role must be 'admin' or 'user'")
+		}
+
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		// Generate a temporary password
+		tokenBytes := make([]byte, 32)
+		if _, err := rand.Read(tokenBytes); err != nil {
+			return fmt.Errorf("failed to generate password: %w", err)
+		}
+		tempPassword := base64.URLEncoding.EncodeToString(tokenBytes)[:12]
+
+		passwordHash, err := bcrypt.GenerateFromPassword([]byte(tempPassword), 12)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+
+		var userID int
+		err = database.QueryRow(ctx, `
+			INSERT INTO client.users (email, password_hash, first_name, last_name, role)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, userCreateEmail, string(passwordHash), userCreateFirstName, userCreateLastName, userCreateRole).Scan(&userID)
+		if err != nil {
+			return fmt.Errorf("failed to create user (email may already exist): %w", err)
+		}
+
+		fmt.Println("User created successfully!")
+		fmt.Printf("  ID:       %d\n", userID)
+		fmt.Printf("  Email:    %s\n", userCreateEmail)
+		fmt.Printf("  Name:     %s %s\n", userCreateFirstName, userCreateLastName)
+		fmt.Printf("  Role:     %s\n", userCreateRole)
+		fmt.Printf("  Password: %s\n", tempPassword)
+		fmt.Println()
+		fmt.Println("The user should change their password after first login.")
+
+		return nil
+	},
+}
+
+func init() {
+	userCmd.AddCommand(userCreateCmd)
+	userCreateCmd.Flags().StringVar(&userCreateEmail, "email", "", "User email address")
+	userCreateCmd.Flags().StringVar(&userCreateFirstName, "first-name", "", "First name")
+	userCreateCmd.Flags().StringVar(&userCreateLastName, "last-name", "", "Last name")
+	userCreateCmd.Flags().StringVar(&userCreateRole, "role", "admin", "User role (admin or user)")
+}
+
+// Location commands
+
+var locationCmd = &cobra.Command{
+	Use:   "location",
+	Short: "Company locations",
+	Long:  `Commands for managing the company locations RFPs are scored and filtered by distance from.`,
+}
+
+var locationAddCity string
+var locationAddState string
+var locationAddLat float64
+var locationAddLon float64
+
+var locationAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add a company location",
+	Long: `Add a company location. It is geocoded from --city and --state against the gazetteer
+unless --lat and --lon are given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hasCoords := cmd.Flags().Changed("lat") || cmd.Flags().Changed("lon")
+		if hasCoords && !(cmd.Flags().Changed("lat") && cmd.Flags().Changed("lon")) {
+			return fmt.Errorf("--lat and --lon must be given together")
+		}
+		if !hasCoords && locationAddState == "" {
+			return fmt.Errorf("--state is required unless --lat and --lon are given")
+		}
+
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		lat, lon := locationAddLat, locationAddLon
+		if !hasCoords {
+			p, err := cliapi.Geocode(ctx, database, locationAddCity, locationAddState)
+			if err != nil {
+				return fmt.Errorf("geocode failed: %w", err)
+			}
+			if p == nil {
+				return fmt.Errorf("unknown state %q", locationAddState)
+			}
+			if p.Precision != cliapi.GeoPrecisionPlace {
+				fmt.Printf("Warning: %s not found in the gazetteer, using the center of %s\n",
+					formatLocation(locationAddCity, locationAddState), strings.ToUpper(locationAddState))
+			}
+			lat, lon = p.Latitude, p.Longitude
+		}
+
+		var id int
+		err = database.QueryRow(ctx, `
+			INSERT INTO client.company_locations (name, city, state, latitude, longitude)
+			VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5)
+			RETURNING id
+		`, args[0], locationAddCity, strings.ToUpper(locationAddState), lat, lon).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to add location (name may already exist): %w", err)
+		}
+
+		fmt.Printf("Added location %d: %s (%.4f, %.4f)\n", id, args[0], lat, lon)
+		return nil
+	},
+}
+
+var locationListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List company locations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		rows, err := database.Query(ctx, `
+			SELECT id, name, COALESCE(city, ''), COALESCE(state, ''), latitude, longitude
+			FROM client.company_locations
+			ORDER BY name
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to list locations: %w", err)
+		}
+		defer rows.Close()
+
+		fmt.Printf("%-5s %-25s %-25s %s\n", "ID", "NAME", "LOCATION", "COORDINATES")
+		for rows.Next() {
+			var id int
+			var name, city, state string
+			var lat, lon float64
+			if err := rows.Scan(&id, &name, &city, &state, &lat, &lon); err != nil {
+				return fmt.Errorf("failed to scan location: %w", err)
+			}
+			fmt.Printf("%-5d %-25s %-25s %.4f, %.4f\n", id, truncate(name, 25), truncate(formatLocation(city, state), 25), lat, lon)
+		}
+		return rows.Err()
+	},
+}
+
+var locationRemoveCmd = &cobra.Command{
+	Use:   "remove [id]",
+	Short: "Remove a company location",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid location ID: %w", err)
+		}
+
+		ctx := context.Background()
+		database, err := connectDB(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close()
+
+		tag, err := database.Exec(ctx, `DELETE FROM client.company_locations WHERE id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to remove location: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("location %d not found", id)
+		}
+		fmt.Printf("Removed location %d\n", id)
+		return nil
+	},
+}
+
+func init() {
+	locationCmd.AddCommand(locationAddCmd)
+	locationCmd.AddCommand(locationListCmd)
+	locationCmd.AddCommand(locationRemoveCmd)
+	locationAddCmd.Flags().StringVar(&locationAddCity, "city", "", "City")
+	locationAddCmd.Flags().StringVar(&locationAddState, "state", "", "Two-letter state code")
+	locationAddCmd.Flags().Float64Var(&locationAddLat, "lat", 0, "Latitude, instead of geocoding")
+	locationAddCmd.Flags().Float64Var(&locationAddLon, "lon", 0, "Longitude, instead of geocoding")
 }
@@ -126,6 +126,10 @@ func main() {
 		r.Get("/pipeline", h.Pipeline)
 		r.Post("/pipeline/move", h.PipelineMoveCard)
 
+		// Agencies
+		r.Get("/agencies", h.AgencyList)
+		r.Get("/agencies/{id}", h.AgencyDetail)
+
 		// Settings
 		r.Get("/settings", notImplemented("user settings"))
 		r.Post("/settings", notImplemented("update settings"))
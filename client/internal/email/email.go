@@ -201,7 +201,7 @@ func (s *Service) getNewRFPs(ctx context.Context, within time.Duration) ([]Diges
 		       r.discovered_at
 		FROM discovery.rfps r
 		LEFT JOIN client.rfp_tracking t ON r.id = t.discovery_rfp_id
-		WHERE r.is_active = true AND r.discovered_at >= $1
+		WHERE r.lifecycle_status IN ('open', 'unknown') AND r.discovered_at >= $1
 		ORDER BY COALESCE(t.manual_score, t.auto_score, 3) DESC, r.due_date ASC
 		LIMIT 20
 	`, since)
@@ -176,7 +176,7 @@ func (h *Handlers) getScoreDistribution(ctx context.Context) ([]ScoreDistributio
 			COUNT(*) as count
 		FROM client.rfp_tracking t
 		JOIN discovery.rfps r ON t.discovery_rfp_id = r.id
-		WHERE r.is_active = true
+		WHERE r.lifecycle_status IN ('open', 'unknown')
 		GROUP BY bucket
 		ORDER BY bucket
 	`)
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/zachsouder/rfp/client/internal/middleware"
+	"github.com/zachsouder/rfp/client/internal/templates"
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+// maxAgencies caps the agency list; narrow it with a search or state.
+const maxAgencies = 200
+
+// AgencyListData contains data for the agency list template.
+type AgencyListData struct {
+	Agencies []AgencyListItem
+	Search   string
+	State    string
+	States   []string
+}
+
+// AgencyListItem represents an agency in the list view.
+type AgencyListItem struct {
+	ID         int
+	Name       string
+	State      string
+	Type       string
+	RFPCount   int
+	OpenCount  int
+	LastPosted string
+}
+
+// AgencyDetailData contains data for the agency detail template.
+type AgencyDetailData struct {
+	ID           int
+	Name         string
+	State        string
+	Type         string
+	PortalURL    string
+	Aliases      []string
+	RFPs         []AgencyRFP
+	OpenCount    int
+	AwardedCount int
+}
+
+// AgencyRFP is one of an agency's solicitations.
+type AgencyRFP struct {
+	ID               int
+	Title            string
+	DueDateFormatted string
+	Score            *float64
+	Stage            string
+	StageDisplay     string
+	Lifecycle        string
+	LifecycleDisplay string
+	Awardee          string
+}
+
+// AgencyList renders the agency list page.
+func (h *Handlers) AgencyList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := middleware.GetUser(ctx)
+
+	data := AgencyListData{
+		Search: r.URL.Query().Get("q"),
+		State:  r.URL.Query().Get("state"),
+	}
+
+	// Search by normalized name, as discovery matches agencies, so "Tampa,
+	// City of" finds the City of Tampa
+	rows, err := h.db.Query(ctx, `
+		SELECT a.id, a.name, a.state, COALESCE(a.agency_type, ''),
+		       COUNT(r.id), COUNT(r.id) FILTER (WHERE r.lifecycle_status IN ('open', 'unknown')),
+		       MAX(COALESCE(r.posted_date, r.discovered_at::date))
+		FROM discovery.agencies a
+		LEFT JOIN discovery.rfps r ON r.agency_id = a.id
+		WHERE ($1 = '' OR a.state = $1)
+		  AND ($2 = '' OR a.name_key LIKE '%' || $2 || '%' OR EXISTS (
+			SELECT 1 FROM discovery.agency_aliases al
+			WHERE al.agency_id = a.id AND al.alias_key LIKE '%' || $2 || '%'
+		  ))
+		GROUP BY a.id
+		ORDER BY COUNT(r.id) DESC, a.name
+		LIMIT $3
+	`, models.NormalizeState(data.State), models.AgencyKey(data.Search), maxAgencies)
+	if err != nil {
+		slog.Error("failed to query agencies", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item AgencyListItem
+		var lastPosted *time.Time
+		if err := rows.Scan(&item.ID, &item.Name, &item.State, &item.Type,
+			&item.RFPCount, &item.OpenCount, &lastPosted); err != nil {
+			slog.Error("failed to scan agency", "error", err)
+			continue
+		}
+		if lastPosted != nil {
+			item.LastPosted = lastPosted.Format("Jan 2, 2006")
+		}
+		data.Agencies = append(data.Agencies, item)
+	}
+
+	data.States = h.getDistinctValues(ctx, "state")
+
+	pageData := templates.PageData{
+		Title:     "Agencies",
+		ActiveNav: "agencies",
+		User: &templates.User{
+			ID:        user.ID,
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			IsAdmin:   user.IsAdmin(),
+		},
+		Data: data,
+	}
+
+	if err := h.templates.Render(w, "agencies", pageData); err != nil {
+		slog.Error("failed to render agencies template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// AgencyDetail renders an agency with every solicitation it has put out.
+func (h *Handlers) AgencyDetail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := middleware.GetUser(ctx)
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid agency ID", http.StatusBadRequest)
+		return
+	}
+
+	var data AgencyDetailData
+	err = h.db.QueryRow(ctx, `
+		SELECT id, name, state, COALESCE(agency_type, ''), COALESCE(portal_url, '')
+		FROM discovery.agencies
+		WHERE id = $1
+	`, id).Scan(&data.ID, &data.Name, &data.State, &data.Type, &data.PortalURL)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "Agency not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to fetch agency", "error", err, "id", id)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	aliasRows, err := h.db.Query(ctx, `
+		SELECT alias FROM discovery.agency_aliases WHERE agency_id = $1 ORDER BY alias
+	`, id)
+	if err != nil {
+		slog.Error("failed to fetch agency aliases", "error", err, "id", id)
+	} else {
+		for aliasRows.Next() {
+			var alias string
+			if err := aliasRows.Scan(&alias); err == nil {
+				data.Aliases = append(data.Aliases, alias)
+			}
+		}
+		aliasRows.Close()
+	}
+
+	// Every solicitation, past and present, newest first
+	rows, err := h.db.Query(ctx, `
+		SELECT r.id, r.title, r.due_date, r.lifecycle_status, COALESCE(r.awardee, ''),
+		       COALESCE(t.manual_score, t.auto_score), t.stage
+		FROM discovery.rfps r
+		LEFT JOIN client.rfp_tracking t ON r.id = t.discovery_rfp_id
+		WHERE r.agency_id = $1
+		ORDER BY COALESCE(r.posted_date, r.discovered_at::date) DESC, r.id DESC
+	`, id)
+	if err != nil {
+		slog.Error("failed to query agency RFPs", "error", err, "id", id)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item AgencyRFP
+		var dueDate *time.Time
+		var stage *string
+		if err := rows.Scan(&item.ID, &item.Title, &dueDate, &item.Lifecycle, &item.Awardee,
+			&item.Score, &stage); err != nil {
+			slog.Error("failed to scan agency RFP", "error", err)
+			continue
+		}
+		if dueDate != nil {
+			item.DueDateFormatted = dueDate.Format("Jan 2, 2006")
+		}
+		item.Stage = "new"
+		if stage != nil {
+			item.Stage = *stage
+		}
+		item.StageDisplay = stageDisplayName(item.Stage)
+		item.LifecycleDisplay = lifecycleDisplayName(item.Lifecycle)
+		switch item.Lifecycle {
+		case "open", "unknown":
+			data.OpenCount++
+		case "awarded":
+			data.AwardedCount++
+		}
+		data.RFPs = append(data.RFPs, item)
+	}
+
+	pageData := templates.PageData{
+		Title:     data.Name,
+		ActiveNav: "agencies",
+		User: &templates.User{
+			ID:        user.ID,
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			IsAdmin:   user.IsAdmin(),
+		},
+		Data: data,
+	}
+
+	if err := h.templates.Render(w, "agency_detail", pageData); err != nil {
+		slog.Error("failed to render agency detail template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
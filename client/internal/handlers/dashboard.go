@@ -48,7 +48,7 @@ func (h *Handlers) Dashboard(w http.ResponseWriter, r *http.Request) {
 	// New this week - count RFPs discovered in last 7 days
 	err := h.db.QueryRow(ctx, `
 		SELECT COUNT(*) FROM discovery.rfps
-		WHERE discovered_at > NOW() - INTERVAL '7 days' AND is_active = true
+		WHERE discovered_at > NOW() - INTERVAL '7 days' AND lifecycle_status IN ('open', 'unknown')
 	`).Scan(&data.NewThisWeek)
 	if err != nil {
 		slog.Error("failed to count new RFPs", "error", err)
@@ -77,7 +77,7 @@ func (h *Handlers) Dashboard(w http.ResponseWriter, r *http.Request) {
 
 	// Total active
 	err = h.db.QueryRow(ctx, `
-		SELECT COUNT(*) FROM discovery.rfps WHERE is_active = true
+		SELECT COUNT(*) FROM discovery.rfps WHERE lifecycle_status IN ('open', 'unknown')
 	`).Scan(&data.TotalActive)
 	if err != nil {
 		slog.Error("failed to count total active", "error", err)
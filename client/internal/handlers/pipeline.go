@@ -99,7 +99,9 @@ func (h *Handlers) Pipeline(w http.ResponseWriter, r *http.Request) {
 		FROM discovery.rfps r
 		LEFT JOIN client.rfp_tracking t ON r.id = t.discovery_rfp_id
 		LEFT JOIN client.users u ON t.assigned_to = u.id
-		WHERE r.is_active = true AND (t.is_hidden IS NULL OR t.is_hidden = false)
+		WHERE (t.is_hidden IS NULL OR t.is_hidden = false)
+		  -- Keep RFPs already being worked even after they close
+		  AND (r.lifecycle_status IN ('open', 'unknown') OR t.stage NOT IN ('new', 'passed'))
 	`
 	args := []any{}
 	argIdx := 1
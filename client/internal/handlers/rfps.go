@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,6 +14,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/zachsouder/rfp/client/internal/middleware"
 	"github.com/zachsouder/rfp/client/internal/templates"
+	"github.com/zachsouder/rfp/shared/models"
 )
 
 const (
@@ -25,6 +28,7 @@ type RFPListData struct {
 	Filters     RFPFilters
 	States      []string
 	Categories  []string
+	Locations   []CompanyLocation
 	Page        int
 	TotalPages  int
 	TotalCount  int
@@ -46,6 +50,14 @@ type RFPListItem struct {
 	Score            *float64
 	Stage            string
 	StageDisplay     string
+	Lifecycle        string
+	LifecycleDisplay string
+}
+
+// CompanyLocation is a company location offered in the distance filter.
+type CompanyLocation struct {
+	ID   int
+	Name string
 }
 
 // RFPFilters holds the current filter/sort state.
@@ -53,6 +65,9 @@ type RFPFilters struct {
 	Stage    string
 	State    string
 	Category string
+	Status   string // lifecycle status; empty means active (open or unknown)
+	Within   string // miles from a company location
+	Near     string // company location ID; empty means any
 	Sort     string
 }
 
@@ -68,6 +83,15 @@ func (f RFPFilters) QueryString() string {
 	if f.Category != "" {
 		parts = append(parts, "category="+f.Category)
 	}
+	if f.Status != "" {
+		parts = append(parts, "status="+f.Status)
+	}
+	if f.Within != "" {
+		parts = append(parts, "within="+f.Within)
+	}
+	if f.Near != "" {
+		parts = append(parts, "near="+f.Near)
+	}
 	if f.Sort != "" {
 		parts = append(parts, "sort="+f.Sort)
 	}
@@ -87,6 +111,9 @@ func (h *Handlers) RFPList(w http.ResponseWriter, r *http.Request) {
 		Stage:    r.URL.Query().Get("stage"),
 		State:    r.URL.Query().Get("state"),
 		Category: r.URL.Query().Get("category"),
+		Status:   r.URL.Query().Get("status"),
+		Within:   r.URL.Query().Get("within"),
+		Near:     r.URL.Query().Get("near"),
 		Sort:     r.URL.Query().Get("sort"),
 	}
 	if filters.Sort == "" {
@@ -101,23 +128,34 @@ func (h *Handlers) RFPList(w http.ResponseWriter, r *http.Request) {
 	// Build query
 	baseQuery := `
 		SELECT r.id, r.title, r.agency, r.state, r.city, r.due_date, r.category,
-		       COALESCE(t.manual_score, t.auto_score) as score, t.stage
+		       COALESCE(t.manual_score, t.auto_score) as score, t.stage, r.lifecycle_status
 		FROM discovery.rfps r
 		LEFT JOIN client.rfp_tracking t ON r.id = t.discovery_rfp_id
-		WHERE r.is_active = true AND (t.is_hidden IS NULL OR t.is_hidden = false)
+		WHERE (t.is_hidden IS NULL OR t.is_hidden = false)
 	`
 
 	countQuery := `
 		SELECT COUNT(*)
 		FROM discovery.rfps r
 		LEFT JOIN client.rfp_tracking t ON r.id = t.discovery_rfp_id
-		WHERE r.is_active = true AND (t.is_hidden IS NULL OR t.is_hidden = false)
+		WHERE (t.is_hidden IS NULL OR t.is_hidden = false)
 	`
 
 	var args []any
 	argIdx := 1
 
 	// Apply filters
+	switch filters.Status {
+	case "":
+		baseQuery += " AND r.lifecycle_status IN ('open', 'unknown')"
+		countQuery += " AND r.lifecycle_status IN ('open', 'unknown')"
+	case "all":
+	default:
+		baseQuery += fmt.Sprintf(" AND r.lifecycle_status = $%d", argIdx)
+		countQuery += fmt.Sprintf(" AND r.lifecycle_status = $%d", argIdx)
+		args = append(args, filters.Status)
+		argIdx++
+	}
 	if filters.Stage != "" {
 		baseQuery += fmt.Sprintf(" AND t.stage = $%d", argIdx)
 		countQuery += fmt.Sprintf(" AND t.stage = $%d", argIdx)
@@ -136,6 +174,23 @@ func (h *Handlers) RFPList(w http.ResponseWriter, r *http.Request) {
 		args = append(args, filters.Category)
 		argIdx++
 	}
+	// Straight-line distance; only RFPs located to a place match, since a
+	// state centroid says nothing about how far away the work is
+	if miles, err := strconv.ParseFloat(filters.Within, 64); err == nil && miles > 0 {
+		cond := fmt.Sprintf(` AND r.geo_precision = 'place' AND EXISTS (
+			SELECT 1 FROM client.company_locations l
+			WHERE discovery.distance_miles(r.latitude, r.longitude, l.latitude, l.longitude) <= $%d`, argIdx)
+		args = append(args, miles)
+		argIdx++
+		if nearID, err := strconv.Atoi(filters.Near); err == nil {
+			cond += fmt.Sprintf(" AND l.id = $%d", argIdx)
+			args = append(args, nearID)
+			argIdx++
+		}
+		cond += ")"
+		baseQuery += cond
+		countQuery += cond
+	}
 
 	// Count total
 	var totalCount int
@@ -176,7 +231,7 @@ func (h *Handlers) RFPList(w http.ResponseWriter, r *http.Request) {
 		var item RFPListItem
 		var category, stage *string
 		if err := rows.Scan(&item.ID, &item.Title, &item.Agency, &item.State, &item.City,
-			&item.DueDate, &category, &item.Score, &stage); err != nil {
+			&item.DueDate, &category, &item.Score, &stage, &item.Lifecycle); err != nil {
 			slog.Error("failed to scan RFP", "error", err)
 			continue
 		}
@@ -187,6 +242,7 @@ func (h *Handlers) RFPList(w http.ResponseWriter, r *http.Request) {
 			item.Stage = "new"
 			item.StageDisplay = "New"
 		}
+		item.LifecycleDisplay = lifecycleDisplayName(item.Lifecycle)
 		if item.DueDate != nil {
 			item.DueDateFormatted = item.DueDate.Format("Jan 2, 2006")
 			item.IsUrgent = item.DueDate.Before(weekFromNow)
@@ -197,6 +253,7 @@ func (h *Handlers) RFPList(w http.ResponseWriter, r *http.Request) {
 	// Get distinct states for filter dropdown
 	states := h.getDistinctValues(ctx, "state")
 	categories := h.getDistinctValues(ctx, "category")
+	locations := h.getCompanyLocations(ctx)
 
 	// Calculate pagination info
 	totalPages := (totalCount + defaultPageSize - 1) / defaultPageSize
@@ -222,6 +279,7 @@ func (h *Handlers) RFPList(w http.ResponseWriter, r *http.Request) {
 		Filters:     filters,
 		States:      states,
 		Categories:  categories,
+		Locations:   locations,
 		Page:        page,
 		TotalPages:  totalPages,
 		TotalCount:  totalCount,
@@ -249,6 +307,26 @@ func (h *Handlers) RFPList(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// getCompanyLocations fetches the company locations for the distance filter.
+func (h *Handlers) getCompanyLocations(ctx context.Context) []CompanyLocation {
+	rows, err := h.db.Query(ctx, `SELECT id, name FROM client.company_locations ORDER BY name`)
+	if err != nil {
+		slog.Error("failed to get company locations", "error", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var locations []CompanyLocation
+	for rows.Next() {
+		var l CompanyLocation
+		if err := rows.Scan(&l.ID, &l.Name); err != nil {
+			continue
+		}
+		locations = append(locations, l)
+	}
+	return locations
+}
+
 // getDistinctValues fetches distinct non-null values for a column.
 func (h *Handlers) getDistinctValues(ctx context.Context, column string) []string {
 	query := fmt.Sprintf(`SELECT DISTINCT %s FROM discovery.rfps WHERE %s IS NOT NULL AND %s != '' ORDER BY %s`, column, column, column, column)
@@ -292,6 +370,88 @@ func stageDisplayName(stage string) string {
 	}
 }
 
+// lifecycleDisplayName returns a human-readable lifecycle status.
+func lifecycleDisplayName(status string) string {
+	switch status {
+	case "open":
+		return "Open"
+	case "closed":
+		return "Closed"
+	case "awarded":
+		return "Awarded"
+	case "cancelled":
+		return "Cancelled"
+	case "unknown":
+		return "Unknown"
+	default:
+		return status
+	}
+}
+
+// submissionMethodDisplayName returns a human-readable submission method.
+func submissionMethodDisplayName(method string) string {
+	switch method {
+	case "electronic":
+		return "Electronic (online portal)"
+	case "email":
+		return "Email"
+	case "mail":
+		return "Mail"
+	case "hand_delivery":
+		return "Hand delivery"
+	default:
+		return method
+	}
+}
+
+// fieldDisplayName returns a human-readable label for an extracted field.
+func fieldDisplayName(field string) string {
+	switch field {
+	case "title":
+		return "Title"
+	case "agency":
+		return "Agency"
+	case "state":
+		return "State"
+	case "city":
+		return "City"
+	case "due_date":
+		return "Due Date"
+	case "category":
+		return "Category"
+	case "venue_type":
+		return "Venue Type"
+	case "incumbent":
+		return "Incumbent"
+	case "term_months":
+		return "Contract Term"
+	case "contract_term":
+		return "Contract Term (as written)"
+	case "estimated_value":
+		return "Estimated Value"
+	case "pre_bid_meeting_date":
+		return "Pre-Bid Meeting"
+	case "pre_bid_mandatory":
+		return "Pre-Bid Mandatory"
+	case "questions_deadline":
+		return "Questions Deadline"
+	case "submission_method":
+		return "Submission Method"
+	case "solicitation_number":
+		return "Solicitation #"
+	case "contact_name":
+		return "Contact Name"
+	case "contact_email":
+		return "Contact Email"
+	case "bond_requirements":
+		return "Bonds"
+	case "insurance_requirements":
+		return "Insurance"
+	default:
+		return field
+	}
+}
+
 // RFPDetail renders the RFP detail page.
 func (h *Handlers) RFPDetail(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -306,16 +466,30 @@ func (h *Handlers) RFPDetail(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch RFP details
 	var rfp RFPDetailData
-	var dueDate, postedDate *time.Time
-	var category, venueType, portal, portalID, incumbent, loginNotes, sourceURL *string
-	var termMonths *int
-	var estimatedValue *float64
+	var dueDate, dueAt, postedDate, statusChangedAt, awardDate *time.Time
+	var category, venueType, portal, portalID, incumbent, loginNotes, sourceURL, statusReason *string
+	var awardee, awardSourceURL *string
+	var preBidDate, questionsDeadline *time.Time
+	var preBidMandatory *bool
+	var submissionMethod, solicitationNumber, contactName, contactEmail, bondReqs, insuranceReqs *string
+	var termMonths, baseTermMonths, renewalMonths *int
+	var estimatedValue, estimatedValueMax, awardAmount *float64
+	var rawValues []byte
+	var fieldEvidence []byte
+	var reviewFields []string
 
 	err = h.db.QueryRow(ctx, `
 		SELECT r.id, r.title, r.agency, r.state, r.city, r.due_date, r.posted_date,
 		       r.category, r.venue_type, r.source_url, r.portal, r.portal_id,
 		       r.term_months, r.estimated_value, r.incumbent, r.login_required, r.login_notes,
-		       r.discovered_at, r.is_active
+		       r.discovered_at, r.is_active, r.lifecycle_status, r.status_reason, r.status_changed_at,
+		       r.awardee, r.award_amount, r.award_date, r.award_source_url,
+		       r.pre_bid_date, r.pre_bid_mandatory, r.questions_deadline, r.submission_method,
+		       r.solicitation_number, r.contact_name, r.contact_email,
+		       r.bond_requirements, r.insurance_requirements,
+		       r.field_evidence, r.review_fields,
+		       r.due_at, r.base_term_months, r.renewal_months,
+		       r.estimated_value_max, r.value_not_to_exceed, r.raw_values, r.agency_id
 		FROM discovery.rfps r
 		WHERE r.id = $1
 	`, id).Scan(
@@ -323,6 +497,14 @@ func (h *Handlers) RFPDetail(w http.ResponseWriter, r *http.Request) {
 		&dueDate, &postedDate, &category, &venueType, &sourceURL,
 		&portal, &portalID, &termMonths, &estimatedValue, &incumbent,
 		&rfp.LoginRequired, &loginNotes, &rfp.DiscoveredAt, &rfp.IsActive,
+		&rfp.Lifecycle, &statusReason, &statusChangedAt,
+		&awardee, &awardAmount, &awardDate, &awardSourceURL,
+		&preBidDate, &preBidMandatory, &questionsDeadline, &submissionMethod,
+		&solicitationNumber, &contactName, &contactEmail,
+		&bondReqs, &insuranceReqs,
+		&fieldEvidence, &reviewFields,
+		&dueAt, &baseTermMonths, &renewalMonths,
+		&estimatedValueMax, &rfp.ValueNotToExceed, &rawValues, &rfp.AgencyID,
 	)
 	if err != nil {
 		slog.Error("failed to fetch RFP", "error", err, "id", id)
@@ -333,6 +515,11 @@ func (h *Handlers) RFPDetail(w http.ResponseWriter, r *http.Request) {
 	if dueDate != nil {
 		rfp.DueDate = dueDate.Format("January 2, 2006")
 	}
+	// Deadlines are shown in the agency's local time
+	loc := models.StateLocation(rfp.State)
+	if dueAt != nil {
+		rfp.DueDate = dueAt.In(loc).Format("January 2, 2006 3:04 PM MST")
+	}
 	if postedDate != nil {
 		rfp.PostedDate = postedDate.Format("January 2, 2006")
 	}
@@ -357,12 +544,98 @@ func (h *Handlers) RFPDetail(w http.ResponseWriter, r *http.Request) {
 	if estimatedValue != nil {
 		rfp.EstimatedValue = *estimatedValue
 	}
+	if estimatedValueMax != nil {
+		rfp.EstimatedValueMax = *estimatedValueMax
+	}
+	if baseTermMonths != nil && renewalMonths != nil && *renewalMonths > 0 {
+		rfp.TermBreakdown = fmt.Sprintf("%d base + %d renewal", *baseTermMonths, *renewalMonths)
+	}
 	if incumbent != nil {
 		rfp.Incumbent = *incumbent
 	}
 	if loginNotes != nil {
 		rfp.LoginNotes = *loginNotes
 	}
+	rfp.LifecycleDisplay = lifecycleDisplayName(rfp.Lifecycle)
+	if statusReason != nil {
+		rfp.StatusReason = *statusReason
+	}
+	if statusChangedAt != nil {
+		rfp.StatusChangedAt = statusChangedAt.Format("Jan 2, 2006")
+	}
+	if awardee != nil {
+		rfp.Awardee = *awardee
+	}
+	if awardAmount != nil {
+		rfp.AwardAmount = *awardAmount
+	}
+	if awardDate != nil {
+		rfp.AwardDate = awardDate.Format("January 2, 2006")
+	}
+	if awardSourceURL != nil {
+		rfp.AwardSourceURL = *awardSourceURL
+	}
+	if preBidDate != nil {
+		rfp.PreBidDate = preBidDate.In(loc).Format("January 2, 2006 3:04 PM MST")
+	}
+	if preBidMandatory != nil {
+		rfp.PreBidMandatory = *preBidMandatory
+	}
+	if questionsDeadline != nil {
+		rfp.QuestionsDeadline = questionsDeadline.In(loc).Format("January 2, 2006 3:04 PM MST")
+	}
+	if submissionMethod != nil {
+		rfp.SubmissionMethod = submissionMethodDisplayName(*submissionMethod)
+	}
+	if solicitationNumber != nil {
+		rfp.SolicitationNumber = *solicitationNumber
+	}
+	if contactName != nil {
+		rfp.ContactName = *contactName
+	}
+	if contactEmail != nil {
+		rfp.ContactEmail = *contactEmail
+	}
+	if bondReqs != nil {
+		rfp.BondRequirements = *bondReqs
+	}
+	if insuranceReqs != nil {
+		rfp.InsuranceRequirements = *insuranceReqs
+	}
+
+	// Values the extractor found but couldn't parse
+	if len(rawValues) > 0 {
+		var raw map[string]string
+		if err := json.Unmarshal(rawValues, &raw); err != nil {
+			slog.Warn("failed to parse raw values", "error", err, "id", id)
+		}
+		for field, text := range raw {
+			rfp.RawValues = append(rfp.RawValues, RawValue{Label: fieldDisplayName(field), Text: text})
+		}
+		sort.Slice(rfp.RawValues, func(i, j int) bool { return rfp.RawValues[i].Label < rfp.RawValues[j].Label })
+	}
+
+	// Field provenance
+	needsReview := make(map[string]bool, len(reviewFields))
+	for _, field := range reviewFields {
+		needsReview[field] = true
+		rfp.ReviewFields = append(rfp.ReviewFields, fieldDisplayName(field))
+	}
+	if len(fieldEvidence) > 0 {
+		var evidence []models.FieldEvidence
+		if err := json.Unmarshal(fieldEvidence, &evidence); err != nil {
+			slog.Warn("failed to parse field evidence", "error", err, "id", id)
+		}
+		for _, ev := range evidence {
+			rfp.Evidence = append(rfp.Evidence, FieldEvidenceItem{
+				Label:       fieldDisplayName(ev.Field),
+				Confidence:  int(ev.Confidence*100 + 0.5),
+				Evidence:    ev.Evidence,
+				Verified:    ev.Verified,
+				NeedsReview: needsReview[ev.Field],
+			})
+		}
+	}
 
 	// Fetch tracking info
 	var stage *string
@@ -485,11 +758,27 @@ type Attachment struct {
 	DownloadURL  string
 }
 
+// RawValue is extracted text that couldn't be parsed into a typed field.
+type RawValue struct {
+	Label string
+	Text  string
+}
+
+// FieldEvidenceItem is the source evidence for one extracted field.
+type FieldEvidenceItem struct {
+	Label       string
+	Confidence  int // percent
+	Evidence    string
+	Verified    bool
+	NeedsReview bool
+}
+
 // RFPDetailData contains data for the RFP detail template.
 type RFPDetailData struct {
 	ID             int
 	Title          string
 	Agency         string
+	AgencyID       *int // canonical agency, for the agency page link
 	State          string
 	City           string
 	DueDate        string
@@ -502,11 +791,44 @@ type RFPDetailData struct {
 	TermMonths     int
 	EstimatedValue float64
 	Incumbent      string
+
+	// Normalized values
+	TermBreakdown     string // e.g. "36 base + 24 renewal"
+	EstimatedValueMax float64
+	ValueNotToExceed  bool
+	RawValues         []RawValue
 	LoginRequired  bool
 	LoginNotes     string
 	DiscoveredAt   time.Time
 	IsActive       bool
 
+	// Lifecycle
+	Lifecycle        string
+	LifecycleDisplay string
+	StatusReason     string
+	StatusChangedAt  string
+
+	// Key dates and requirements
+	PreBidDate            string
+	PreBidMandatory       bool
+	QuestionsDeadline     string
+	SubmissionMethod      string
+	SolicitationNumber    string
+	ContactName           string
+	ContactEmail          string
+	BondRequirements      string
+	InsuranceRequirements string
+
+	// Provenance
+	Evidence     []FieldEvidenceItem
+	ReviewFields []string // display names of fields flagged for review
+
+	// Award
+	Awardee        string
+	AwardAmount    float64
+	AwardDate      string
+	AwardSourceURL string
+
 	// Tracking info
 	Stage        string
 	StageDisplay string
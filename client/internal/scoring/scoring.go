@@ -39,6 +39,11 @@ type RFPData struct {
 	TermMonths     *int
 	DueDate        *time.Time
 	EstimatedValue *float64
+
+	// Nearest company location, when both it and the RFP have coordinates
+	NearestLocation *string
+	NearestMiles    *float64
+	GeoPrecision    *string // place, or state when only the state's center is known
 }
 
 // ScoreResult holds the calculated score and breakdown.
@@ -97,10 +102,18 @@ func (s *Service) GetRFPData(ctx context.Context, rfpID int) (*RFPData, error) {
 	data.ID = rfpID
 
 	err := s.db.QueryRow(ctx, `
-		SELECT venue_type, scope_keywords, state, term_months, due_date, estimated_value
-		FROM discovery.rfps
-		WHERE id = $1
-	`, rfpID).Scan(&data.VenueType, &data.ScopeKeywords, &data.State, &data.TermMonths, &data.DueDate, &data.EstimatedValue)
+		SELECT r.venue_type, r.scope_keywords, r.state, r.term_months, r.due_date, r.estimated_value,
+		       l.name, l.miles, r.geo_precision
+		FROM discovery.rfps r
+		LEFT JOIN LATERAL (
+			SELECT name, discovery.distance_miles(r.latitude, r.longitude, latitude, longitude) AS miles
+			FROM client.company_locations
+			ORDER BY miles
+			LIMIT 1
+		) l ON r.latitude IS NOT NULL
+		WHERE r.id = $1
+	`, rfpID).Scan(&data.VenueType, &data.ScopeKeywords, &data.State, &data.TermMonths, &data.DueDate, &data.EstimatedValue,
+		&data.NearestLocation, &data.NearestMiles, &data.GeoPrecision)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get RFP data: %w", err)
 	}
@@ -204,7 +217,8 @@ func evaluateVenueMatch(config json.RawMessage, rfp *RFPData) (float64, string)
 		return 3.0, "No venue type specified"
 	}
 
-	venueLower := strings.ToLower(*rfp.VenueType)
+	// Venue types are stored as identifiers like convention_center
+	venueLower := strings.ReplaceAll(strings.ToLower(*rfp.VenueType), "_", " ")
 
 	// Check negatives first (they override positives)
 	for _, neg := range cfg.Negative {
@@ -273,10 +287,15 @@ func evaluateScopeMatch(config json.RawMessage, rfp *RFPData) (float64, string)
 	return 3.0, "Scope keywords are neutral"
 }
 
-// GeographyConfig for geography rule type.
+// GeographyConfig for geography rule type. Mode "states" (the default)
+// scores preferred and excluded states; mode "distance" scores the
+// straight-line distance to the nearest company location.
 type GeographyConfig struct {
+	Mode            string   `json:"mode"`
 	PreferredStates []string `json:"preferred_states"`
 	ExcludedStates  []string `json:"excluded_states"`
+	IdealMiles      float64  `json:"ideal_miles"`
+	MaxMiles        float64  `json:"max_miles"`
 }
 
 func evaluateGeography(config json.RawMessage, rfp *RFPData) (float64, string) {
@@ -285,6 +304,10 @@ func evaluateGeography(config json.RawMessage, rfp *RFPData) (float64, string) {
 		return 3.0, "Invalid config"
 	}
 
+	if cfg.Mode == "distance" {
+		return evaluateDistance(cfg, rfp)
+	}
+
 	if rfp.State == nil || *rfp.State == "" {
 		return 3.0, "No state specified"
 	}
@@ -313,6 +336,35 @@ func evaluateGeography(config json.RawMessage, rfp *RFPData) (float64, string) {
 	return 3.0, fmt.Sprintf("State '%s' is neutral", *rfp.State)
 }
 
+func evaluateDistance(cfg GeographyConfig, rfp *RFPData) (float64, string) {
+	if cfg.MaxMiles <= cfg.IdealMiles {
+		return 3.0, "Invalid config: max_miles must exceed ideal_miles"
+	}
+
+	if rfp.NearestMiles == nil || rfp.NearestLocation == nil {
+		return 3.0, "Location unknown or no company locations configured"
+	}
+
+	miles := *rfp.NearestMiles
+	where := fmt.Sprintf("%.0f miles from %s", miles, *rfp.NearestLocation)
+	if rfp.GeoPrecision != nil && *rfp.GeoPrecision == "state" {
+		where = "about " + where + " (state center)"
+	}
+
+	if miles <= cfg.IdealMiles {
+		return 5.0, fmt.Sprintf("%s, within %.0f", where, cfg.IdealMiles)
+	}
+
+	if miles >= cfg.MaxMiles {
+		return 1.0, fmt.Sprintf("%s, beyond %.0f", where, cfg.MaxMiles)
+	}
+
+	// Linear interpolation from ideal down to max
+	ratio := (miles - cfg.IdealMiles) / (cfg.MaxMiles - cfg.IdealMiles)
+	score := 5.0 - ratio*4.0
+	return score, where
+}
+
 // TermLengthConfig for term_length rule type.
 type TermLengthConfig struct {
 	MinMonths   int `json:"min_months"`
@@ -455,7 +507,7 @@ func (s *Service) ApplyScore(ctx context.Context, rfpID int) (*ScoreResult, erro
 
 // RefreshAllScores recalculates auto_scores for all active RFPs.
 func (s *Service) RefreshAllScores(ctx context.Context) (int, error) {
-	rows, err := s.db.Query(ctx, `SELECT id FROM discovery.rfps WHERE is_active = true`)
+	rows, err := s.db.Query(ctx, `SELECT id FROM discovery.rfps WHERE lifecycle_status IN ('open', 'unknown')`)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get active RFPs: %w", err)
 	}
@@ -0,0 +1,56 @@
+package cliapi
+
+import (
+	"context"
+
+	"github.com/zachsouder/rfp/discovery/internal/agency"
+	"github.com/zachsouder/rfp/shared/db"
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+// Agency types.
+type (
+	AgencySummary     = agency.Summary     // an agency with solicitation counts
+	AgencyDetail      = agency.Detail      // an agency with its solicitations
+	AgencyChanges     = agency.Changes     // fields to update on an agency
+	AgencyLinkSummary = agency.LinkSummary // counts from linking RFPs
+)
+
+// AgencyTypes are the agency types that may be set.
+var AgencyTypes = agency.Types
+
+// ListAgencies returns agencies with their solicitation counts, busiest
+// first, optionally narrowed by state and a name or alias search.
+func ListAgencies(ctx context.Context, database *db.DB, state, search string, limit int) ([]AgencySummary, error) {
+	return agency.New(database).List(ctx, state, search, limit)
+}
+
+// GetAgency returns an agency with its aliases and every linked RFP.
+func GetAgency(ctx context.Context, database *db.DB, id int) (*AgencyDetail, error) {
+	return agency.New(database).Get(ctx, id)
+}
+
+// UpdateAgency changes an agency's name, state, type or portal URL.
+func UpdateAgency(ctx context.Context, database *db.DB, id int, changes AgencyChanges) (*models.Agency, error) {
+	return agency.New(database).Update(ctx, id, changes)
+}
+
+// AddAgencyAlias records another name an agency goes by.
+func AddAgencyAlias(ctx context.Context, database *db.DB, id int, alias string) error {
+	return agency.New(database).AddAlias(ctx, id, alias)
+}
+
+// RemoveAgencyAlias removes one of an agency's aliases.
+func RemoveAgencyAlias(ctx context.Context, database *db.DB, id int, alias string) error {
+	return agency.New(database).RemoveAlias(ctx, id, alias)
+}
+
+// MergeAgencies folds the dropped agency into the kept one.
+func MergeAgencies(ctx context.Context, database *db.DB, keepID, dropID int) (*models.Agency, error) {
+	return agency.New(database).Merge(ctx, keepID, dropID)
+}
+
+// LinkAgencies links RFPs without an agency, or every RFP when all is set.
+func LinkAgencies(ctx context.Context, database *db.DB, all bool) (*AgencyLinkSummary, error) {
+	return agency.New(database).Link(ctx, all)
+}
@@ -0,0 +1,20 @@
+package cliapi
+
+import "github.com/zachsouder/rfp/discovery/internal/classify"
+
+// Classification is the scope, category and venue derived for an RFP.
+type Classification struct {
+	ScopeKeywords []string `json:"scope_keywords"`
+	Category      string   `json:"category,omitempty"`
+	VenueType     string   `json:"venue_type,omitempty"`
+}
+
+// Classify runs the scope taxonomy over an RFP's title and text.
+func Classify(title string, texts ...string) Classification {
+	res := classify.Classify(title, texts...)
+	return Classification{
+		ScopeKeywords: res.ScopeKeywords,
+		Category:      res.Category,
+		VenueType:     res.VenueType,
+	}
+}
@@ -0,0 +1,103 @@
+package cliapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/zachsouder/rfp/discovery/internal/geo"
+	"github.com/zachsouder/rfp/shared/db"
+)
+
+// GeoPoint is a geocoded location.
+type GeoPoint = geo.Point
+
+// GeoPrecisionPlace marks a point located to its city or town rather than
+// the state's center.
+const GeoPrecisionPlace = geo.PrecisionPlace
+
+// GeocodeSummary counts the RFPs a geocoding backfill looked at.
+type GeocodeSummary struct {
+	Checked int `json:"checked"`
+	Place   int `json:"place"`   // located to the city or town
+	State   int `json:"state"`   // only the state's center
+	Unknown int `json:"unknown"` // no usable state
+}
+
+// LoadGazetteer replaces the place gazetteer with a Census Bureau Gazetteer
+// places file read from r, or with the bundled major cities when r is nil.
+// Returns the number of places loaded.
+func LoadGazetteer(ctx context.Context, database *db.DB, r io.Reader) (int, error) {
+	var places []geo.Place
+	var err error
+	if r == nil {
+		places, err = geo.Bundled()
+	} else {
+		places, err = geo.ParseGazetteer(r)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("parse gazetteer failed: %w", err)
+	}
+	return geo.Load(ctx, database, places)
+}
+
+// Geocode locates a city in a state against the gazetteer. Returns nil when
+// the state is unknown.
+func Geocode(ctx context.Context, database *db.DB, city, state string) (*GeoPoint, error) {
+	return geo.Geocode(ctx, database, city, state, "")
+}
+
+// GeocodeRFPs sets coordinates on RFPs that have none or only their state's,
+// or on every RFP when all is set, e.g. after loading a fuller gazetteer.
+func GeocodeRFPs(ctx context.Context, database *db.DB, all bool) (*GeocodeSummary, error) {
+	query := `SELECT id, COALESCE(city, ''), COALESCE(state, ''), COALESCE(agency, '') FROM discovery.rfps`
+	if !all {
+		query += ` WHERE latitude IS NULL OR geo_precision = 'state'`
+	}
+	rows, err := database.Query(ctx, query+` ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("list rfps failed: %w", err)
+	}
+	type rfpLocation struct {
+		id                  int
+		city, state, agency string
+	}
+	var rfps []rfpLocation
+	for rows.Next() {
+		var r rfpLocation
+		if err := rows.Scan(&r.id, &r.city, &r.state, &r.agency); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan rfp failed: %w", err)
+		}
+		rfps = append(rfps, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list rfps failed: %w", err)
+	}
+
+	summary := &GeocodeSummary{}
+	for _, r := range rfps {
+		summary.Checked++
+		p, err := geo.Geocode(ctx, database, r.city, r.state, r.agency)
+		if err != nil {
+			return summary, fmt.Errorf("geocode rfp %d failed: %w", r.id, err)
+		}
+		if p == nil {
+			summary.Unknown++
+			continue
+		}
+		if p.Precision == geo.PrecisionPlace {
+			summary.Place++
+		} else {
+			summary.State++
+		}
+		_, err = database.Exec(ctx, `
+			UPDATE discovery.rfps SET latitude = $2, longitude = $3, geo_precision = $4 WHERE id = $1
+		`, r.id, p.Latitude, p.Longitude, p.Precision)
+		if err != nil {
+			return summary, fmt.Errorf("update rfp %d failed: %w", r.id, err)
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,53 @@
+package cliapi
+
+import (
+	"context"
+
+	"github.com/zachsouder/rfp/discovery/internal/merge"
+	"github.com/zachsouder/rfp/shared/db"
+)
+
+// MergeResult describes a merge or unmerge of two RFPs.
+type MergeResult struct {
+	MergeID          int      `json:"merge_id"`
+	KeptRFPID        int      `json:"kept_rfp_id"`
+	DroppedRFPID     int      `json:"dropped_rfp_id"`
+	FieldsFilled     []string `json:"fields_filled,omitempty"`
+	SearchResults    int      `json:"search_results"`
+	ReviewDecisions  int      `json:"review_decisions"`
+	NotesMoved       int      `json:"notes_moved"`
+	AttachmentsMoved int      `json:"attachments_moved"`
+	TrackingMoved    bool     `json:"tracking_moved"`
+}
+
+// MergeRFPs folds the dropped RFP into the kept one and records the merge.
+func MergeRFPs(ctx context.Context, database *db.DB, keepID, dropID int, mergedBy string) (*MergeResult, error) {
+	res, err := merge.Merge(ctx, database, keepID, dropID, mergedBy)
+	if err != nil {
+		return nil, err
+	}
+	return toMergeResult(res), nil
+}
+
+// UnmergeRFPs reverses a recorded merge.
+func UnmergeRFPs(ctx context.Context, database *db.DB, mergeID int) (*MergeResult, error) {
+	res, err := merge.Unmerge(ctx, database, mergeID)
+	if err != nil {
+		return nil, err
+	}
+	return toMergeResult(res), nil
+}
+
+func toMergeResult(res *merge.Result) *MergeResult {
+	return &MergeResult{
+		MergeID:          res.MergeID,
+		KeptRFPID:        res.KeptRFPID,
+		DroppedRFPID:     res.DroppedRFPID,
+		FieldsFilled:     res.FieldsFilled,
+		SearchResults:    res.SearchResults,
+		ReviewDecisions:  res.ReviewDecisions,
+		NotesMoved:       res.NotesMoved,
+		AttachmentsMoved: res.AttachmentsMoved,
+		TrackingMoved:    res.TrackingMoved,
+	}
+}
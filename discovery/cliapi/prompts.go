@@ -0,0 +1,114 @@
+package cliapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zachsouder/rfp/discovery/internal/prompts"
+	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/shared/config"
+	"github.com/zachsouder/rfp/shared/db"
+)
+
+// FieldChange is an extracted field that differs between two prompt versions.
+type FieldChange struct {
+	Field     string `json:"field"`
+	Baseline  string `json:"baseline"`
+	Candidate string `json:"candidate"`
+}
+
+// PromptSample is one RFP re-extracted with both prompt versions.
+type PromptSample struct {
+	RFPID   int           `json:"rfp_id"`
+	Title   string        `json:"title"`
+	URL     string        `json:"url"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// PromptComparison is the result of comparing a candidate extraction prompt
+// against a baseline version.
+type PromptComparison struct {
+	Baseline  string         `json:"baseline"`  // e.g. extract_rfp@v1
+	Candidate string         `json:"candidate"` // e.g. extract_rfp@candidate
+	Model     string         `json:"model"`
+	Samples   []PromptSample `json:"samples"`
+}
+
+// CompareExtractionPrompts re-runs extraction over the page text of the
+// most recently discovered RFPs with a candidate prompt and with the
+// baseline version (the current one when against is empty), and reports
+// the fields that differ. candidate is template text in the same form as
+// the embedded extract_rfp templates.
+func CompareExtractionPrompts(ctx context.Context, cfg *config.Config, database *db.DB, candidate, against string, sample int) (*PromptComparison, error) {
+	candidatePrompt, err := prompts.Parse(prompts.ExtractRFP, "candidate", candidate)
+	if err != nil {
+		return nil, err
+	}
+	baselinePrompt := prompts.Current(prompts.ExtractRFP)
+	if against != "" {
+		if baselinePrompt, err = prompts.Get(prompts.ExtractRFP, against); err != nil {
+			return nil, err
+		}
+	}
+
+	extractor, err := newExtractor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.Query(ctx, `
+		SELECT id, title, COALESCE(source_url, ''), raw_content
+		FROM discovery.rfps
+		WHERE raw_content IS NOT NULL AND raw_content <> ''
+		ORDER BY discovered_at DESC
+		LIMIT $1
+	`, sample)
+	if err != nil {
+		return nil, fmt.Errorf("query sample rfps failed: %w", err)
+	}
+	type sampleRFP struct {
+		PromptSample
+		content string
+	}
+	var rfps []sampleRFP
+	for rows.Next() {
+		var r sampleRFP
+		if err := rows.Scan(&r.RFPID, &r.Title, &r.URL, &r.content); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan sample rfp failed: %w", err)
+		}
+		rfps = append(rfps, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query sample rfps failed: %w", err)
+	}
+
+	out := &PromptComparison{
+		Baseline:  baselinePrompt.ID(),
+		Candidate: candidatePrompt.ID(),
+		Model:     extractor.Model(),
+	}
+	for _, r := range rfps {
+		s := r.PromptSample
+		baseline, _, err := extractor.WithRFPPrompt(baselinePrompt).ExtractRFPDetails(ctx, r.URL, r.content)
+		if err != nil {
+			s.Error = fmt.Sprintf("baseline: %v", err)
+			out.Samples = append(out.Samples, s)
+			continue
+		}
+		cand, _, err := extractor.WithRFPPrompt(candidatePrompt).ExtractRFPDetails(ctx, r.URL, r.content)
+		if err != nil {
+			s.Error = fmt.Sprintf("candidate: %v", err)
+			out.Samples = append(out.Samples, s)
+			continue
+		}
+		for _, c := range research.DiffDetails(baseline, cand) {
+			s.Changes = append(s.Changes, FieldChange{Field: c.Field, Baseline: c.Before, Candidate: c.After})
+		}
+		out.Samples = append(out.Samples, s)
+	}
+
+	return out, nil
+}
@@ -4,7 +4,12 @@ package cliapi
 import (
 	"context"
 
+	"github.com/zachsouder/rfp/discovery/internal/llm"
 	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/discovery/internal/scheduler"
+	"github.com/zachsouder/rfp/discovery/internal/validation"
+	"github.com/zachsouder/rfp/shared/config"
+	"github.com/zachsouder/rfp/shared/db"
 	"github.com/zachsouder/rfp/shared/models"
 )
 
@@ -19,6 +24,10 @@ type ResearchResult struct {
 	FoundPDFs        []string          `json:"found_pdfs,omitempty"`
 	Steps            []ResearchStep    `json:"steps"`
 	Error            string            `json:"error,omitempty"`
+	AgentVersion     string            `json:"agent_version"`
+	Model            string            `json:"model"`
+	DurationMs       int64             `json:"duration_ms"`
+	RunID            int               `json:"run_id,omitempty"` // set when the run was saved
 }
 
 // ExtractedDetails contains structured RFP information.
@@ -33,6 +42,23 @@ type ExtractedDetails struct {
 	Incumbent      string `json:"incumbent,omitempty"`
 	Category       string `json:"category,omitempty"`
 	VenueType      string `json:"venue_type,omitempty"`
+
+	// Key dates and requirements
+	PreBidDate            string `json:"pre_bid_meeting_date,omitempty"`
+	PreBidMandatory       *bool  `json:"pre_bid_mandatory,omitempty"`
+	QuestionsDeadline     string `json:"questions_deadline,omitempty"`
+	SubmissionMethod      string `json:"submission_method,omitempty"`
+	SolicitationNumber    string `json:"solicitation_number,omitempty"`
+	ContactName           string `json:"contact_name,omitempty"`
+	ContactEmail          string `json:"contact_email,omitempty"`
+	BondRequirements      string `json:"bond_requirements,omitempty"`
+	InsuranceRequirements string `json:"insurance_requirements,omitempty"`
+	TermMonths            *int   `json:"term_months,omitempty"`
+	ContractTerm          string `json:"contract_term,omitempty"`
+
+	// Per-field provenance
+	Evidence     []models.FieldEvidence `json:"evidence,omitempty"`
+	ReviewFields []string               `json:"review_fields,omitempty"`
 }
 
 // ResearchStep records a single step in the research process.
@@ -45,34 +71,89 @@ type ResearchStep struct {
 	Success       bool   `json:"success"`
 	TokensUsed    int    `json:"tokens_used,omitempty"`
 	DurationMs    int64  `json:"duration_ms"`
+	SnapshotKey   string `json:"snapshot_key,omitempty"`
+	PromptVersion string `json:"prompt_version,omitempty"`
 }
 
 // ResearchURL runs the research agent on a URL and returns the results.
-func ResearchURL(ctx context.Context, apiKey string, url string) (*ResearchResult, error) {
-	agent := research.NewAgent(apiKey)
+// Details are extracted with the configured extraction model.
+func ResearchURL(ctx context.Context, cfg *config.Config, url string) (*ResearchResult, error) {
+	agent, err := newAgent(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create a synthetic search result
 	result := &models.SearchResult{
 		ID:       0,
 		URL:      url,
 		FinalURL: url,
-		Title:    "Manual research",
+		Title:    manualResultTitle,
 	}
 
 	res, err := agent.Research(ctx, result)
 	if err != nil {
 		return nil, err
 	}
+	return toResearchResult(res), nil
+}
 
+// ResearchAndSave runs the research agent on a URL's search result and
+// records the run. The earliest result for the URL is used, and one is
+// created and validated when the URL hasn't come up in a search, so the
+// pipeline researches and promotes it; the result's research status is left
+// to the pipeline.
+func ResearchAndSave(ctx context.Context, cfg *config.Config, database *db.DB, url string) (*ResearchResult, error) {
+	agent, err := newAgent(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	store := scheduler.NewStore(database)
+	result, err := store.FindResultByURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		vr := validation.NewValidator().Validate(ctx, url)
+		id, err := store.SaveManualResult(ctx, url, manualResultTitle, vr)
+		if err != nil {
+			return nil, err
+		}
+		result = &models.SearchResult{ID: id, URL: url, FinalURL: vr.FinalURL, Title: manualResultTitle}
+	}
+
+	res, err := agent.Research(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	runID, err := store.SaveResearchRun(ctx, result.ID, res, scheduler.TriggerManual)
+	if err != nil {
+		return nil, err
+	}
+
+	out := toResearchResult(res)
+	out.RunID = runID
+	return out, nil
+}
+
+// manualResultTitle is the title given to URLs researched by hand.
+const manualResultTitle = "Manual research"
+
+// toResearchResult converts a research result to the public type.
+func toResearchResult(res *research.ResearchResult) *ResearchResult {
 	// Convert to public types
 	out := &ResearchResult{
-		Success:     res.Success,
-		ResultID:    res.ResultID,
-		Status:      string(res.Status),
-		StepsTaken:  res.StepsTaken,
-		TotalTokens: res.TotalTokens,
-		FoundPDFs:   res.FoundPDFs,
-		Error:       res.Error,
+		Success:      res.Success,
+		ResultID:     res.ResultID,
+		Status:       string(res.Status),
+		StepsTaken:   res.StepsTaken,
+		TotalTokens:  res.TotalTokens,
+		FoundPDFs:    res.FoundPDFs,
+		Error:        res.Error,
+		AgentVersion: res.AgentVersion,
+		Model:        res.Model,
+		DurationMs:   res.DurationMs,
 	}
 
 	// Convert extracted details
@@ -88,6 +169,21 @@ func ResearchURL(ctx context.Context, apiKey string, url string) (*ResearchResul
 			Incumbent:      res.ExtractedDetails.Incumbent,
 			Category:       res.ExtractedDetails.Category,
 			VenueType:      res.ExtractedDetails.VenueType,
+
+			PreBidDate:            res.ExtractedDetails.PreBidDate,
+			PreBidMandatory:       res.ExtractedDetails.PreBidMandatory,
+			QuestionsDeadline:     res.ExtractedDetails.QuestionsDeadline,
+			SubmissionMethod:      res.ExtractedDetails.SubmissionMethod,
+			SolicitationNumber:    res.ExtractedDetails.SolicitationNumber,
+			ContactName:           res.ExtractedDetails.ContactName,
+			ContactEmail:          res.ExtractedDetails.ContactEmail,
+			BondRequirements:      res.ExtractedDetails.BondRequirements,
+			InsuranceRequirements: res.ExtractedDetails.InsuranceRequirements,
+			TermMonths:            res.ExtractedDetails.TermMonths,
+			ContractTerm:          res.ExtractedDetails.ContractTerm,
+
+			Evidence:     res.ExtractedDetails.Evidence,
+			ReviewFields: research.ReviewFields(res.ExtractedDetails),
 		}
 	}
 
@@ -102,8 +198,33 @@ func ResearchURL(ctx context.Context, apiKey string, url string) (*ResearchResul
 			Success:       s.Success,
 			TokensUsed:    s.TokensUsed,
 			DurationMs:    s.DurationMs,
+			SnapshotKey:   s.SnapshotKey,
+			PromptVersion: s.PromptVersion,
 		})
 	}
 
-	return out, nil
+	return out
+}
+
+// newAgent creates a research agent that extracts with the configured
+// extraction model.
+func newAgent(cfg *config.Config) (*research.Agent, error) {
+	extractor, err := newExtractor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return research.NewAgent(cfg.GeminiAPIKey).WithExtractor(extractor), nil
+}
+
+// newExtractor creates an extractor for the configured extraction model.
+func newExtractor(cfg *config.Config) (*research.Extractor, error) {
+	provider, err := llm.New(cfg.LLMExtractionModel, llm.Settings{
+		GeminiAPIKey:  cfg.GeminiAPIKey,
+		OpenAIBaseURL: cfg.LLMBaseURL,
+		OpenAIAPIKey:  cfg.LLMAPIKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return research.NewExtractor(provider), nil
 }
@@ -0,0 +1,69 @@
+package cliapi
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/discovery/internal/review"
+	"github.com/zachsouder/rfp/discovery/internal/scheduler"
+	"github.com/zachsouder/rfp/shared/db"
+)
+
+// Review queue types.
+type (
+	ReviewItem     = review.Item     // a result awaiting review
+	ReviewDetail   = review.Detail   // a result with its research trace and decisions
+	ReviewDecision = review.Decision // a reviewer's decision
+)
+
+// ReviewStatuses are the research statuses that put a result in the queue.
+var ReviewStatuses = review.Statuses
+
+// ListReviewQueue returns results awaiting review, oldest first. An empty
+// status lists every review status.
+func ListReviewQueue(ctx context.Context, database *db.DB, status string, limit int) ([]ReviewItem, error) {
+	return newReviewQueue(database).List(ctx, status, limit)
+}
+
+// GetReviewItem returns a result with its latest research trace and the
+// decisions made on it.
+func GetReviewItem(ctx context.Context, database *db.DB, resultID int) (*ReviewDetail, error) {
+	return newReviewQueue(database).Get(ctx, resultID)
+}
+
+// ReviewPromote promotes a queued result to an RFP, with the given fields
+// laid over what research extracted.
+func ReviewPromote(ctx context.Context, database *db.DB, resultID int, fields *ExtractedDetails, reviewer, reason string) (*ReviewDecision, error) {
+	var details *research.ExtractedDetails
+	if fields != nil {
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		details = &research.ExtractedDetails{}
+		if err := json.Unmarshal(data, details); err != nil {
+			return nil, err
+		}
+	}
+	return newReviewQueue(database).Promote(ctx, resultID, details, reviewer, reason)
+}
+
+// ReviewDuplicate marks a queued result as a duplicate of an existing RFP.
+func ReviewDuplicate(ctx context.Context, database *db.DB, resultID, rfpID int, reviewer, reason string) (*ReviewDecision, error) {
+	return newReviewQueue(database).MarkDuplicate(ctx, resultID, rfpID, reviewer, reason)
+}
+
+// ReviewReject rejects a queued result as irrelevant.
+func ReviewReject(ctx context.Context, database *db.DB, resultID int, reviewer, reason string) (*ReviewDecision, error) {
+	return newReviewQueue(database).Reject(ctx, resultID, reviewer, reason)
+}
+
+// ReviewRequeue sends a queued result back for research.
+func ReviewRequeue(ctx context.Context, database *db.DB, resultID int, reviewer, reason string) (*ReviewDecision, error) {
+	return newReviewQueue(database).Requeue(ctx, resultID, reviewer, reason)
+}
+
+func newReviewQueue(database *db.DB) *review.Queue {
+	return review.New(database, scheduler.NewStore(database))
+}
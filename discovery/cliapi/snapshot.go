@@ -0,0 +1,76 @@
+package cliapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zachsouder/rfp/discovery/internal/snapshot"
+	"github.com/zachsouder/rfp/shared/db"
+	"github.com/zachsouder/rfp/shared/r2"
+)
+
+// Snapshot is an archived copy of a page fetched for a search result.
+type Snapshot struct {
+	Key        string      `json:"key"`
+	Source     string      `json:"source"` // validation, or the research step that fetched it
+	URL        string      `json:"url"`
+	FinalURL   string      `json:"final_url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	FetchedAt  time.Time   `json:"fetched_at"`
+	Truncated  bool        `json:"truncated,omitempty"`
+	Body       []byte      `json:"-"`
+}
+
+// GetSnapshot retrieves the archived page for a search result: the last
+// page its research fetched, or the page fetched at validation when
+// research didn't archive one.
+func GetSnapshot(ctx context.Context, database *db.DB, objects *r2.Client, resultID int) (*Snapshot, error) {
+	var key, source string
+	err := database.QueryRow(ctx, `
+		SELECT snapshot_key, 'research step ' || step_number
+		FROM discovery.research_steps
+		WHERE search_result_id = $1 AND snapshot_key IS NOT NULL
+		ORDER BY research_run_id DESC NULLS LAST, step_number DESC
+		LIMIT 1
+	`, resultID).Scan(&key, &source)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var validationKey *string
+		err = database.QueryRow(ctx, `
+			SELECT snapshot_key FROM discovery.search_results WHERE id = $1
+		`, resultID).Scan(&validationKey)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("search result %d not found", resultID)
+		}
+		if err == nil && validationKey == nil {
+			return nil, fmt.Errorf("no snapshot archived for search result %d", resultID)
+		}
+		if validationKey != nil {
+			key, source = *validationKey, "validation"
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("look up snapshot failed: %w", err)
+	}
+
+	env, err := snapshot.New(objects).Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		Key:        key,
+		Source:     source,
+		URL:        env.URL,
+		FinalURL:   env.FinalURL,
+		StatusCode: env.StatusCode,
+		Header:     env.Header,
+		FetchedAt:  env.FetchedAt,
+		Truncated:  env.Truncated,
+		Body:       env.Content(),
+	}, nil
+}
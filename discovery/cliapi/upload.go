@@ -0,0 +1,57 @@
+package cliapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zachsouder/rfp/discovery/internal/scheduler"
+	"github.com/zachsouder/rfp/discovery/internal/upload"
+	"github.com/zachsouder/rfp/shared/config"
+	"github.com/zachsouder/rfp/shared/db"
+	"github.com/zachsouder/rfp/shared/r2"
+)
+
+// Upload types.
+type (
+	UploadedDocument = upload.Document // a stored document
+	UploadOutcome    = upload.Outcome  // research resumed from documents
+)
+
+// UploadFile is a document to attach to a search result.
+type UploadFile struct {
+	Name string
+	Data []byte
+}
+
+// MaxUploadSize is the largest document accepted.
+const MaxUploadSize = upload.MaxSize
+
+// UploadDocuments attaches PDFs to a search result awaiting review, then
+// resumes research from them, promoting the result if the agent finishes.
+// Documents are stored even if research fails.
+func UploadDocuments(ctx context.Context, cfg *config.Config, database *db.DB, resultID int, files []UploadFile, uploadedBy string) ([]UploadedDocument, *UploadOutcome, error) {
+	objects, err := r2.NewClient(cfg.R2AccountID, cfg.R2AccessKeyID, cfg.R2SecretAccessKey, cfg.R2Bucket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("document storage not configured: %w", err)
+	}
+	agent, err := newAgent(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	svc := upload.New(database, scheduler.NewStore(database), agent, objects, cfg.R2AccountID)
+
+	docs := make([]UploadedDocument, 0, len(files))
+	for _, f := range files {
+		doc, err := svc.Attach(ctx, resultID, f.Name, f.Data, uploadedBy)
+		if err != nil {
+			return docs, nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		docs = append(docs, *doc)
+	}
+
+	out, err := svc.Resume(ctx, resultID)
+	if err != nil {
+		return docs, nil, err
+	}
+	return docs, out, nil
+}
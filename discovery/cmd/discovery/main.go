@@ -12,18 +12,25 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/zachsouder/rfp/discovery/internal/awards"
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
+	"github.com/zachsouder/rfp/discovery/internal/llm"
 	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/discovery/internal/review"
 	"github.com/zachsouder/rfp/discovery/internal/scheduler"
 	"github.com/zachsouder/rfp/discovery/internal/search"
+	"github.com/zachsouder/rfp/discovery/internal/snapshot"
+	"github.com/zachsouder/rfp/discovery/internal/upload"
 	"github.com/zachsouder/rfp/discovery/internal/validation"
 	"github.com/zachsouder/rfp/shared/config"
 	"github.com/zachsouder/rfp/shared/db"
+	"github.com/zachsouder/rfp/shared/r2"
 )
 
 func main() {
 	// Parse command line flags
 	runOnce := flag.Bool("run-once", false, "Run discovery once and exit")
-	httpPort := flag.Int("port", 8081, "HTTP port for health checks")
+	httpPort := flag.Int("port", 8081, "HTTP port for health checks and the review API")
 	flag.Parse()
 
 	// Set up structured logging
@@ -59,10 +66,39 @@ func main() {
 	defer database.Close()
 	slog.Info("connected to database")
 
+	// Install the shared fetcher before creating services that fetch pages
+	fetcher, err := newFetcher(cfg, database)
+	if err != nil {
+		slog.Error("failed to configure fetcher", "error", err)
+		os.Exit(1)
+	}
+	fetch.SetDefault(fetcher)
+
+	extractor, err := newExtractor(cfg, cfg.LLMExtractionModel)
+	if err != nil {
+		slog.Error("failed to configure extraction model", "error", err)
+		os.Exit(1)
+	}
+	awardExtractor := extractor
+	if cfg.LLMAwardModel != "" {
+		if awardExtractor, err = newExtractor(cfg, cfg.LLMAwardModel); err != nil {
+			slog.Error("failed to configure award model", "error", err)
+			os.Exit(1)
+		}
+	}
+	slog.Info("language models configured",
+		"extraction", extractor.Model(),
+		"awards", awardExtractor.Model(),
+	)
+
 	// Initialize services
 	searchClient := search.NewClient(cfg.GeminiAPIKey)
+	if cfg.SearchModel != "" {
+		searchClient.WithModel(cfg.SearchModel)
+	}
 	validator := validation.NewValidator()
-	researchAgent := research.NewAgent(cfg.GeminiAPIKey)
+	researchAgent := research.NewAgent(cfg.GeminiAPIKey).WithExtractor(extractor)
+	awardFinder := awards.NewFinder(searchClient, awardExtractor)
 
 	// Create the scheduler
 	sched := scheduler.New(
@@ -71,7 +107,7 @@ func main() {
 		validator,
 		researchAgent,
 		scheduler.WithRunOnStart(!*runOnce), // Don't auto-run if doing run-once
-	)
+	).WithAwardFinder(awardFinder)
 
 	// Handle run-once mode
 	if *runOnce {
@@ -89,7 +125,7 @@ func main() {
 		return
 	}
 
-	// Set up HTTP server for health checks
+	// Set up HTTP server for health checks and the review queue
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /health", healthHandler)
 	mux.HandleFunc("GET /ready", func(w http.ResponseWriter, r *http.Request) {
@@ -104,6 +140,25 @@ func main() {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ready"))
 	})
+	reviewTokens, err := review.ParseTokens(cfg.ReviewAPITokens)
+	if err != nil {
+		slog.Error("invalid REVIEW_API_TOKENS", "error", err)
+		os.Exit(1)
+	}
+	if len(reviewTokens) == 0 {
+		slog.Warn("review API disabled: REVIEW_API_TOKENS is not set")
+	} else {
+		reviewHandler := review.NewHandler(review.New(database, scheduler.NewStore(database)), reviewTokens)
+		mux.Handle("/review", reviewHandler)
+		mux.Handle("/review/", reviewHandler)
+
+		if objects, err := r2.NewClient(cfg.R2AccountID, cfg.R2AccessKeyID, cfg.R2SecretAccessKey, cfg.R2Bucket); err != nil {
+			slog.Warn("document uploads disabled", "error", err)
+		} else {
+			uploads := upload.New(database, scheduler.NewStore(database), researchAgent, objects, cfg.R2AccountID)
+			mux.Handle("POST /review/{id}/documents", upload.NewHandler(uploads, reviewTokens))
+		}
+	}
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", *httpPort),
@@ -114,9 +169,9 @@ func main() {
 
 	// Start HTTP server in background
 	go func() {
-		slog.Info("starting health check server", "addr", server.Addr)
+		slog.Info("starting http server", "addr", server.Addr)
 		if err := server.ListenAndServe(); err != http.ErrServerClosed {
-			slog.Error("health server error", "error", err)
+			slog.Error("http server error", "error", err)
 		}
 	}()
 
@@ -143,6 +198,58 @@ func main() {
 	slog.Info("discovery service stopped")
 }
 
+// newFetcher builds the shared page fetcher from config.
+func newFetcher(cfg *config.Config, database *db.DB) (*fetch.Fetcher, error) {
+	fc := fetch.DefaultConfig()
+	if cfg.FetchUserAgent != "" {
+		fc.UserAgent = cfg.FetchUserAgent
+	}
+	fc.ProxyURL = cfg.FetchProxyURL
+	fc.CAFile = cfg.FetchCAFile
+
+	switch cfg.FetchCache {
+	case "postgres":
+		fc.Cache = fetch.NewPostgresCache(database)
+	case "disk":
+		cache, err := fetch.NewDiskCache(cfg.FetchCacheDir)
+		if err != nil {
+			return nil, err
+		}
+		fc.Cache = cache
+	case "off", "":
+	default:
+		return nil, fmt.Errorf("unknown FETCH_CACHE %q (want postgres, disk, or off)", cfg.FetchCache)
+	}
+
+	switch cfg.FetchSnapshots {
+	case "r2":
+		client, err := r2.NewClient(cfg.R2AccountID, cfg.R2AccessKeyID, cfg.R2SecretAccessKey, cfg.R2Bucket)
+		if err != nil {
+			slog.Warn("page snapshots disabled", "error", err)
+			break
+		}
+		fc.Archiver = snapshot.New(client)
+	case "off", "":
+	default:
+		return nil, fmt.Errorf("unknown FETCH_SNAPSHOTS %q (want r2 or off)", cfg.FetchSnapshots)
+	}
+
+	return fetch.New(fc)
+}
+
+// newExtractor builds an extractor for a provider:model spec.
+func newExtractor(cfg *config.Config, spec string) (*research.Extractor, error) {
+	provider, err := llm.New(spec, llm.Settings{
+		GeminiAPIKey:  cfg.GeminiAPIKey,
+		OpenAIBaseURL: cfg.LLMBaseURL,
+		OpenAIAPIKey:  cfg.LLMAPIKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return research.NewExtractor(provider), nil
+}
+
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("healthy"))
@@ -0,0 +1,513 @@
+// Package agency keeps the canonical agencies RFPs are linked to. An
+// agency is identified by its normalized name and state; the other names
+// it appears under ("Tampa, City of", "Tampa International Airport") are
+// kept as aliases so they resolve to the same agency.
+package agency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/zachsouder/rfp/discovery/internal/dedup"
+	"github.com/zachsouder/rfp/shared/db"
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+var (
+	// ErrNotFound is returned for an unknown agency.
+	ErrNotFound = errors.New("agency not found")
+	// ErrInvalidType is returned when setting a type outside Types.
+	ErrInvalidType = errors.New("not an agency type")
+	// ErrNameTaken is returned when a name or alias already belongs to
+	// another agency in the same state.
+	ErrNameTaken = errors.New("name belongs to another agency")
+)
+
+// Agency types.
+const (
+	TypeCity       = "city"
+	TypeCounty     = "county"
+	TypeUniversity = "university"
+	TypeAuthority  = "authority"
+	TypeAirport    = "airport"
+	TypeState      = "state"
+	TypeOther      = "other"
+)
+
+// Types are the agency types that may be set.
+var Types = []string{TypeCity, TypeCounty, TypeUniversity, TypeAuthority, TypeAirport, TypeState, TypeOther}
+
+// Querier runs queries; *db.DB and pgx.Tx implement it.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Summary is an agency with counts of its solicitations.
+type Summary struct {
+	models.Agency
+	RFPCount   int        `json:"rfp_count"`
+	OpenCount  int        `json:"open_count"`            // lifecycle open or unknown
+	LastPosted *time.Time `json:"last_posted,omitempty"` // latest posting or discovery
+}
+
+// RFP is one of an agency's solicitations.
+type RFP struct {
+	ID              int        `json:"id"`
+	Title           string     `json:"title"`
+	Agency          string     `json:"agency"` // as named on the solicitation
+	DueDate         *time.Time `json:"due_date,omitempty"`
+	LifecycleStatus string     `json:"lifecycle_status"`
+	Awardee         string     `json:"awardee,omitempty"`
+	DiscoveredAt    time.Time  `json:"discovered_at"`
+}
+
+// Detail is an agency with every solicitation linked to it, newest first.
+type Detail struct {
+	models.Agency
+	RFPs []RFP `json:"rfps"`
+}
+
+// Changes are the fields to update on an agency; nil fields are left alone.
+type Changes struct {
+	Name      *string
+	State     *string
+	Type      *string
+	PortalURL *string
+}
+
+// LinkSummary counts the RFPs a link backfill looked at.
+type LinkSummary struct {
+	Checked int `json:"checked"`
+	Linked  int `json:"linked"`
+	Created int `json:"created"` // agencies created along the way
+}
+
+// Key normalizes an agency name for matching. Unlike
+// dedup.NormalizeAgency it keeps the form of government, so the City and
+// County of the same name stay apart: "Tampa, City of" and "The City of
+// Tampa" both become "city of tampa". It's models.AgencyKey, which the
+// client searches with too.
+func Key(name string) string {
+	return models.AgencyKey(name)
+}
+
+// typeWords map words in an agency's name to its type, checked in order so
+// "Airport Authority" is an airport and "County Transit Authority" an
+// authority.
+var typeWords = []struct {
+	typ   string
+	words []string
+}{
+	{TypeAirport, []string{"airport", "aviation"}},
+	{TypeUniversity, []string{"university", "college"}},
+	{TypeAuthority, []string{"authority", "district", "port of", "transit", "commission"}},
+	{TypeCounty, []string{"county", "parish"}},
+	{TypeCity, []string{"city", "town", "village", "borough", "township"}},
+	{TypeState, []string{"state of", "commonwealth of", "department of"}},
+}
+
+// InferType guesses an agency's type from its name, or returns "".
+func InferType(name string) string {
+	key := " " + Key(name) + " "
+	for _, t := range typeWords {
+		for _, w := range t.words {
+			if strings.Contains(key, " "+w+" ") {
+				return t.typ
+			}
+		}
+	}
+	return ""
+}
+
+// IsType reports whether t is one of Types.
+func IsType(t string) bool {
+	for _, v := range Types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the ID of the agency named name in state, matching its
+// canonical name or an alias, and creates the agency if there is none,
+// reporting whether it did. Returns nil when the name is empty.
+func Resolve(ctx context.Context, q Querier, name, state string) (*int, bool, error) {
+	key := Key(name)
+	if key == "" {
+		return nil, false, nil
+	}
+	state = dedup.NormalizeState(state)
+
+	id, err := lookup(ctx, q, key, state, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	if id != 0 {
+		return &id, false, nil
+	}
+
+	// A concurrent promotion may have created it since; keep theirs. xmax
+	// is 0 only on a freshly inserted row.
+	var created bool
+	err = q.QueryRow(ctx, `
+		INSERT INTO discovery.agencies (name, name_key, state, agency_type)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (name_key, state) DO UPDATE SET name_key = EXCLUDED.name_key
+		RETURNING id, xmax = 0
+	`, strings.TrimSpace(name), key, state, InferType(name)).Scan(&id, &created)
+	if err != nil {
+		return nil, false, fmt.Errorf("create agency failed: %w", err)
+	}
+	return &id, created, nil
+}
+
+// lookup finds the agency in state with key as its name or an alias,
+// other than exclude. Returns 0 if there is none.
+func lookup(ctx context.Context, q Querier, key, state string, exclude int) (int, error) {
+	var id int
+	err := q.QueryRow(ctx, `
+		SELECT a.id FROM discovery.agencies a
+		WHERE a.state = $2 AND a.id <> $3 AND (
+			a.name_key = $1 OR EXISTS (
+				SELECT 1 FROM discovery.agency_aliases al
+				WHERE al.agency_id = a.id AND al.alias_key = $1
+			)
+		)
+		ORDER BY (a.name_key = $1) DESC, a.id
+		LIMIT 1
+	`, key, state, exclude).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("look up agency failed: %w", err)
+	}
+	return id, nil
+}
+
+// Directory reads and maintains agencies.
+type Directory struct {
+	db *db.DB
+}
+
+// New creates a Directory.
+func New(database *db.DB) *Directory {
+	return &Directory{db: database}
+}
+
+// List returns agencies with their solicitation counts, busiest first.
+// state and search narrow the list when set; search matches names and
+// aliases.
+func (d *Directory) List(ctx context.Context, state, search string, limit int) ([]Summary, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := d.db.Query(ctx, `
+		SELECT a.id, a.name, a.state, COALESCE(a.agency_type, ''), COALESCE(a.portal_url, ''), a.created_at,
+		       COUNT(r.id), COUNT(r.id) FILTER (WHERE r.lifecycle_status IN ('open', 'unknown')),
+		       MAX(COALESCE(r.posted_date, r.discovered_at::date))
+		FROM discovery.agencies a
+		LEFT JOIN discovery.rfps r ON r.agency_id = a.id
+		WHERE ($1 = '' OR a.state = $1)
+		  AND ($2 = '' OR a.name_key LIKE '%' || $2 || '%' OR EXISTS (
+			SELECT 1 FROM discovery.agency_aliases al
+			WHERE al.agency_id = a.id AND al.alias_key LIKE '%' || $2 || '%'
+		  ))
+		GROUP BY a.id
+		ORDER BY COUNT(r.id) DESC, a.name
+		LIMIT $3
+	`, dedup.NormalizeState(state), Key(search), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list agencies failed: %w", err)
+	}
+	defer rows.Close()
+
+	var agencies []Summary
+	for rows.Next() {
+		var s Summary
+		if err := rows.Scan(&s.ID, &s.Name, &s.State, &s.Type, &s.PortalURL, &s.CreatedAt,
+			&s.RFPCount, &s.OpenCount, &s.LastPosted); err != nil {
+			return nil, fmt.Errorf("scan agency failed: %w", err)
+		}
+		agencies = append(agencies, s)
+	}
+	return agencies, rows.Err()
+}
+
+// Get returns an agency with its aliases and every RFP linked to it.
+func (d *Directory) Get(ctx context.Context, id int) (*Detail, error) {
+	a, err := get(ctx, d.db, id)
+	if err != nil {
+		return nil, err
+	}
+	det := &Detail{Agency: *a, RFPs: []RFP{}}
+
+	rows, err := d.db.Query(ctx, `
+		SELECT id, title, COALESCE(agency, ''), due_date, lifecycle_status, COALESCE(awardee, ''), discovered_at
+		FROM discovery.rfps
+		WHERE agency_id = $1
+		ORDER BY COALESCE(posted_date, discovered_at::date) DESC, id DESC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("list agency rfps failed: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r RFP
+		if err := rows.Scan(&r.ID, &r.Title, &r.Agency, &r.DueDate, &r.LifecycleStatus, &r.Awardee, &r.DiscoveredAt); err != nil {
+			return nil, fmt.Errorf("scan agency rfp failed: %w", err)
+		}
+		det.RFPs = append(det.RFPs, r)
+	}
+	return det, rows.Err()
+}
+
+// get loads an agency and its aliases.
+func get(ctx context.Context, q Querier, id int) (*models.Agency, error) {
+	var a models.Agency
+	err := q.QueryRow(ctx, `
+		SELECT id, name, state, COALESCE(agency_type, ''), COALESCE(portal_url, ''), created_at
+		FROM discovery.agencies
+		WHERE id = $1
+	`, id).Scan(&a.ID, &a.Name, &a.State, &a.Type, &a.PortalURL, &a.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get agency failed: %w", err)
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT alias FROM discovery.agency_aliases WHERE agency_id = $1 ORDER BY alias
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("list aliases failed: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("scan alias failed: %w", err)
+		}
+		a.Aliases = append(a.Aliases, alias)
+	}
+	return &a, rows.Err()
+}
+
+// Update changes an agency's details. A renamed agency keeps its old name
+// as an alias so RFPs still resolve to it.
+func (d *Directory) Update(ctx context.Context, id int, c Changes) (*models.Agency, error) {
+	if c.Type != nil && *c.Type != "" && !IsType(*c.Type) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidType, *c.Type)
+	}
+
+	var a *models.Agency
+	err := d.db.WithTx(ctx, func(tx pgx.Tx) error {
+		cur, err := get(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		name, state := cur.Name, cur.State
+		if c.Name != nil && strings.TrimSpace(*c.Name) != "" {
+			name = strings.TrimSpace(*c.Name)
+		}
+		if c.State != nil {
+			state = dedup.NormalizeState(*c.State)
+		}
+		if Key(name) != Key(cur.Name) || state != cur.State {
+			other, err := lookup(ctx, tx, Key(name), state, id)
+			if err != nil {
+				return err
+			}
+			if other != 0 {
+				return fmt.Errorf("%w: agency %d", ErrNameTaken, other)
+			}
+		}
+
+		typ, portal := cur.Type, cur.PortalURL
+		if c.Type != nil {
+			typ = *c.Type
+		}
+		if c.PortalURL != nil {
+			portal = strings.TrimSpace(*c.PortalURL)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE discovery.agencies
+			SET name = $2, name_key = $3, state = $4, agency_type = NULLIF($5, ''), portal_url = NULLIF($6, ''),
+			    updated_at = NOW()
+			WHERE id = $1
+		`, id, name, Key(name), state, typ, portal)
+		if err != nil {
+			return fmt.Errorf("update agency failed: %w", err)
+		}
+		if Key(name) != Key(cur.Name) {
+			if err := addAlias(ctx, tx, id, cur.Name); err != nil {
+				return err
+			}
+		}
+
+		a, err = get(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// AddAlias records another name an agency goes by.
+func (d *Directory) AddAlias(ctx context.Context, id int, alias string) error {
+	if Key(alias) == "" {
+		return errors.New("alias is empty")
+	}
+	return d.db.WithTx(ctx, func(tx pgx.Tx) error {
+		a, err := get(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		other, err := lookup(ctx, tx, Key(alias), a.State, id)
+		if err != nil {
+			return err
+		}
+		if other != 0 {
+			return fmt.Errorf("%w: agency %d; merge them instead", ErrNameTaken, other)
+		}
+		return addAlias(ctx, tx, id, alias)
+	})
+}
+
+// addAlias records an alias unless the agency already has one with its key.
+func addAlias(ctx context.Context, q Querier, id int, alias string) error {
+	_, err := q.Exec(ctx, `
+		INSERT INTO discovery.agency_aliases (agency_id, alias, alias_key)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (agency_id, alias_key) DO NOTHING
+	`, id, strings.TrimSpace(alias), Key(alias))
+	if err != nil {
+		return fmt.Errorf("add alias failed: %w", err)
+	}
+	return nil
+}
+
+// RemoveAlias removes one of an agency's aliases.
+func (d *Directory) RemoveAlias(ctx context.Context, id int, alias string) error {
+	tag, err := d.db.Exec(ctx, `
+		DELETE FROM discovery.agency_aliases WHERE agency_id = $1 AND alias_key = $2
+	`, id, Key(alias))
+	if err != nil {
+		return fmt.Errorf("remove alias failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("agency %d has no alias %q", id, alias)
+	}
+	return nil
+}
+
+// Merge folds the dropped agency into the kept one: its RFPs are re-linked,
+// its name and aliases become aliases of the kept agency, empty details on
+// the kept agency are filled from it, and it is deleted.
+func (d *Directory) Merge(ctx context.Context, keepID, dropID int) (*models.Agency, error) {
+	if keepID == dropID {
+		return nil, fmt.Errorf("cannot merge agency %d into itself", keepID)
+	}
+
+	var a *models.Agency
+	err := d.db.WithTx(ctx, func(tx pgx.Tx) error {
+		if _, err := get(ctx, tx, keepID); err != nil {
+			return fmt.Errorf("agency %d: %w", keepID, err)
+		}
+		drop, err := get(ctx, tx, dropID)
+		if err != nil {
+			return fmt.Errorf("agency %d: %w", dropID, err)
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE discovery.rfps SET agency_id = $1 WHERE agency_id = $2`, keepID, dropID); err != nil {
+			return fmt.Errorf("re-link rfps failed: %w", err)
+		}
+		for _, alias := range append([]string{drop.Name}, drop.Aliases...) {
+			if err := addAlias(ctx, tx, keepID, alias); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE discovery.agencies
+			SET agency_type = COALESCE(agency_type, NULLIF($2, '')),
+			    portal_url = COALESCE(portal_url, NULLIF($3, '')),
+			    updated_at = NOW()
+			WHERE id = $1
+		`, keepID, drop.Type, drop.PortalURL)
+		if err != nil {
+			return fmt.Errorf("fill agency failed: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM discovery.agencies WHERE id = $1`, dropID); err != nil {
+			return fmt.Errorf("delete merged agency failed: %w", err)
+		}
+
+		a, err = get(ctx, tx, keepID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Link links RFPs that have no agency yet, or every RFP when all is set,
+// creating agencies as needed.
+func (d *Directory) Link(ctx context.Context, all bool) (*LinkSummary, error) {
+	query := `SELECT id, COALESCE(agency, ''), COALESCE(state, '') FROM discovery.rfps`
+	if !all {
+		query += ` WHERE agency_id IS NULL`
+	}
+	rows, err := d.db.Query(ctx, query+` ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("list rfps failed: %w", err)
+	}
+	type rfpAgency struct {
+		id            int
+		agency, state string
+	}
+	var rfps []rfpAgency
+	for rows.Next() {
+		var r rfpAgency
+		if err := rows.Scan(&r.id, &r.agency, &r.state); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan rfp failed: %w", err)
+		}
+		rfps = append(rfps, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list rfps failed: %w", err)
+	}
+
+	summary := &LinkSummary{}
+	for _, r := range rfps {
+		summary.Checked++
+		id, created, err := Resolve(ctx, d.db, r.agency, r.state)
+		if err != nil {
+			return summary, fmt.Errorf("resolve agency for rfp %d failed: %w", r.id, err)
+		}
+		if id == nil {
+			continue
+		}
+		if created {
+			summary.Created++
+		}
+		if _, err := d.db.Exec(ctx, `UPDATE discovery.rfps SET agency_id = $2 WHERE id = $1`, r.id, *id); err != nil {
+			return summary, fmt.Errorf("link rfp %d failed: %w", r.id, err)
+		}
+		summary.Linked++
+	}
+	return summary, nil
+}
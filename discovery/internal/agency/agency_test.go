@@ -0,0 +1,73 @@
+package agency
+
+import "testing"
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"City of Tampa", "city of tampa"},
+		{"Tampa, City of", "city of tampa"},
+		{"The City of Tampa", "city of tampa"},
+		{"  CITY OF TAMPA ", "city of tampa"},
+		{"Hillsborough County", "hillsborough county"},
+		{"Hillsborough, County of", "county of hillsborough"},
+		{"Parks & Recreation Department", "parks and recreation department"},
+		{"Port of Seattle", "port of seattle"},
+		{"University of Texas at Austin", "university of texas at austin"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Key(tt.name); got != tt.want {
+			t.Errorf("Key(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// City and county of the same name stay apart
+	if Key("City of Denver") == Key("County of Denver") {
+		t.Error("city and county of Denver share a key")
+	}
+}
+
+func TestInferType(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"City of Tampa", TypeCity},
+		{"Tampa, City of", TypeCity},
+		{"Town of Cary", TypeCity},
+		{"Hillsborough County", TypeCounty},
+		{"Jefferson Parish", TypeCounty},
+		{"University of Florida", TypeUniversity},
+		{"Miami Dade College", TypeUniversity},
+		{"Hillsborough County Aviation Authority", TypeAirport},
+		{"Tampa International Airport", TypeAirport},
+		{"Port of Seattle", TypeAuthority},
+		{"Capital Metropolitan Transit Authority", TypeAuthority},
+		{"Orange County Transportation Authority", TypeAuthority},
+		{"State of Texas", TypeState},
+		{"Kansas City", TypeCity},
+		{"Acme Parking LLC", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := InferType(tt.name); got != tt.want {
+			t.Errorf("InferType(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsType(t *testing.T) {
+	for _, typ := range Types {
+		if !IsType(typ) {
+			t.Errorf("IsType(%q) = false", typ)
+		}
+	}
+	for _, typ := range []string{"", "City", "school"} {
+		if IsType(typ) {
+			t.Errorf("IsType(%q) = true", typ)
+		}
+	}
+}
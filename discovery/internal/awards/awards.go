@@ -0,0 +1,192 @@
+// Package awards discovers award notices for closed RFPs, recording who won
+// and at what price so the winner can be carried forward as the incumbent on
+// future rebids.
+package awards
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/zachsouder/rfp/discovery/internal/dedup"
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
+	"github.com/zachsouder/rfp/discovery/internal/normalize"
+	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/discovery/internal/search"
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+const (
+	defaultMaxPages  = 3
+	maxBodyReadBytes = 512 * 1024 // 512KB
+)
+
+// Award holds award details found for an RFP.
+type Award struct {
+	Awardee    string     `json:"awardee"`
+	Amount     *float64   `json:"amount,omitempty"`
+	AmountRaw  string     `json:"amount_raw,omitempty"`
+	Date       *time.Time `json:"date,omitempty"`
+	SourceURL  string     `json:"source_url"`
+	TokensUsed int        `json:"tokens_used"`
+}
+
+// Finder searches for and extracts award notices.
+type Finder struct {
+	search   *search.Client
+	extract  *research.Extractor
+	fetcher  *fetch.Fetcher
+	maxPages int
+}
+
+// NewFinder creates a new award finder.
+func NewFinder(searchClient *search.Client, extractor *research.Extractor) *Finder {
+	return &Finder{
+		search:   searchClient,
+		extract:  extractor,
+		fetcher:  fetch.Default(),
+		maxPages: defaultMaxPages,
+	}
+}
+
+// WithMaxPages sets how many search results are examined per RFP.
+func (f *Finder) WithMaxPages(n int) *Finder {
+	f.maxPages = n
+	return f
+}
+
+// BuildQuery constructs the award notice search query for an RFP.
+func BuildQuery(rfp models.RFP) string {
+	var parts []string
+	if rfp.Title != "" {
+		parts = append(parts, fmt.Sprintf("%q", rfp.Title))
+	}
+	if rfp.Agency != "" {
+		parts = append(parts, rfp.Agency)
+	}
+	if rfp.State != "" {
+		parts = append(parts, rfp.State)
+	}
+	parts = append(parts, "contract award")
+	return strings.Join(parts, " ")
+}
+
+// Find searches for an award notice for the RFP. It returns nil without an
+// error when no matching award was found.
+func (f *Finder) Find(ctx context.Context, rfp models.RFP) (*Award, error) {
+	resp, err := f.search.SearchAwardNotices(ctx, BuildQuery(rfp))
+	if err != nil {
+		return nil, fmt.Errorf("award search failed: %w", err)
+	}
+
+	tokens := resp.TokensUsed
+	checked := 0
+	for _, result := range resp.Results {
+		if checked >= f.maxPages {
+			break
+		}
+		if result.URL == rfp.SourceURL {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		content, err := f.fetchText(ctx, result.URL)
+		if err != nil {
+			slog.Debug("failed to fetch award page", "url", result.URL, "error", err)
+			continue
+		}
+		checked++
+
+		details, used, err := f.extract.ExtractAwardDetails(ctx, result.URL, content, rfp.Title, rfp.Agency)
+		tokens += used
+		if err != nil {
+			slog.Debug("award extraction failed", "url", result.URL, "error", err)
+			continue
+		}
+
+		if !details.IsAwardNotice || !details.MatchesSolicitation || strings.TrimSpace(details.Awardee) == "" {
+			continue
+		}
+
+		return &Award{
+			Awardee:    strings.TrimSpace(details.Awardee),
+			Amount:     normalize.ParseAmount(details.AwardAmount),
+			AmountRaw:  details.AwardAmount,
+			Date:       parseDate(details.AwardDate, rfp.State),
+			SourceURL:  result.URL,
+			TokensUsed: tokens,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// fetchText fetches an HTML page and returns its text content.
+func (f *Finder) fetchText(ctx context.Context, pageURL string) (string, error) {
+	resp, err := f.fetcher.Do(ctx, fetch.Request{URL: pageURL, MaxBytes: maxBodyReadBytes})
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	// Bid tabulations are often PDFs, which we can't read yet
+	if strings.Contains(resp.ContentType(), "pdf") {
+		return "", fmt.Errorf("unsupported content type %s", resp.Header.Get("Content-Type"))
+	}
+
+	return research.HTMLToText(string(resp.Body)), nil
+}
+
+// parseDate parses an extracted award date, dropping any time of day.
+func parseDate(text, state string) *time.Time {
+	d := normalize.ParseDate(text, state)
+	if d == nil {
+		return nil
+	}
+	day := time.Date(d.Time.Year(), d.Time.Month(), d.Time.Day(), 0, 0, 0, 0, time.UTC)
+	return &day
+}
+
+// IsRebid reports whether candidate looks like a later solicitation for the
+// same work as the awarded RFP: same state, matching agency, the same
+// category when both are known, and a due or posted date after the award.
+// Without dates to compare it's not a rebid, since it may be the awarded
+// solicitation itself found again.
+func IsRebid(awarded, candidate models.RFP) bool {
+	if candidate.ID == awarded.ID {
+		return false
+	}
+	if dedup.NormalizeState(candidate.State) != dedup.NormalizeState(awarded.State) {
+		return false
+	}
+	if !dedup.AgencyMatches(dedup.NormalizeAgency(candidate.Agency), dedup.NormalizeAgency(awarded.Agency)) {
+		return false
+	}
+	if candidate.Category != "" && awarded.Category != "" && candidate.Category != awarded.Category {
+		return false
+	}
+
+	// The award date when known, otherwise the awarded RFP's due date
+	since := awarded.AwardDate
+	if since == nil {
+		since = awarded.DueDate
+	}
+	if since == nil {
+		return false
+	}
+	return after(candidate.DueDate, *since) || after(candidate.PostedDate, *since)
+}
+
+func after(t *time.Time, since time.Time) bool {
+	return t != nil && t.After(since)
+}
@@ -0,0 +1,111 @@
+package awards
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+func TestBuildQuery(t *testing.T) {
+	rfp := models.RFP{Title: "Parking Management Services", Agency: "City of Austin", State: "TX"}
+	query := BuildQuery(rfp)
+
+	for _, want := range []string{`"Parking Management Services"`, "City of Austin", "TX", "award"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("BuildQuery() = %q, should contain %q", query, want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	if got := parseDate("2024-03-15", "TX"); got == nil || got.Format("2006-01-02") != "2024-03-15" {
+		t.Errorf("parseDate(2024-03-15) = %v", got)
+	}
+	if got := parseDate("March 15, 2024 at 2:00 PM", "TX"); got == nil || got.Format("2006-01-02") != "2024-03-15" {
+		t.Errorf("parseDate(March 15, 2024) = %v", got)
+	}
+	if got := parseDate("sometime in spring", "TX"); got != nil {
+		t.Errorf("parseDate(garbage) = %v, want nil", got)
+	}
+}
+
+func TestIsRebid(t *testing.T) {
+	due := func(s string) *time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return &d
+	}
+
+	awarded := models.RFP{ID: 1, Agency: "City of Austin", State: "TX", Category: "parking", DueDate: due("2022-01-15")}
+
+	tests := []struct {
+		name      string
+		candidate models.RFP
+		want      bool
+	}{
+		{"later rebid", models.RFP{ID: 2, Agency: "Austin", State: "TX", Category: "parking", DueDate: due("2025-01-15")}, true},
+		{"unknown category", models.RFP{ID: 2, Agency: "City of Austin", State: "Texas", DueDate: due("2025-01-15")}, true},
+		{"no dates", models.RFP{ID: 2, Agency: "City of Austin", State: "TX", Category: "parking"}, false},
+		{"posted after award", models.RFP{ID: 2, Agency: "City of Austin", State: "TX", Category: "parking", PostedDate: due("2024-11-01")}, true},
+		{"same rfp", models.RFP{ID: 1, Agency: "City of Austin", State: "TX", Category: "parking", DueDate: due("2025-01-15")}, false},
+		{"earlier solicitation", models.RFP{ID: 2, Agency: "City of Austin", State: "TX", Category: "parking", DueDate: due("2019-01-15")}, false},
+		{"different category", models.RFP{ID: 2, Agency: "City of Austin", State: "TX", Category: "shuttle", DueDate: due("2025-01-15")}, false},
+		{"different agency", models.RFP{ID: 2, Agency: "Travis County", State: "TX", Category: "parking", DueDate: due("2025-01-15")}, false},
+		{"different state", models.RFP{ID: 2, Agency: "City of Austin", State: "MN", Category: "parking", DueDate: due("2025-01-15")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRebid(awarded, tt.candidate); got != tt.want {
+				t.Errorf("IsRebid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The award date, when known, is what the rebid must come after
+	awarded.AwardDate = due("2022-06-01")
+	if IsRebid(awarded, models.RFP{ID: 3, Agency: "City of Austin", State: "TX", DueDate: due("2022-03-01")}) {
+		t.Error("solicitation due before the award counted as a rebid")
+	}
+	if IsRebid(models.RFP{ID: 1, Agency: "City of Austin", State: "TX"}, models.RFP{ID: 3, Agency: "City of Austin", State: "TX", DueDate: due("2025-01-15")}) {
+		t.Error("rebid found for an award with no dates")
+	}
+}
+
+func TestFinder_FetchText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/notice":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body><h1>Notice of Award</h1><p>Awarded to ABC Parking</p></body></html>"))
+		case "/tab.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	f := NewFinder(nil, nil)
+
+	text, err := f.fetchText(context.Background(), server.URL+"/notice")
+	if err != nil {
+		t.Fatalf("fetchText() error = %v", err)
+	}
+	if !strings.Contains(text, "Awarded to ABC Parking") {
+		t.Errorf("fetchText() = %q, should contain page text", text)
+	}
+
+	if _, err := f.fetchText(context.Background(), server.URL+"/tab.pdf"); err == nil {
+		t.Error("Expected error for PDF content")
+	}
+
+	if _, err := f.fetchText(context.Background(), server.URL+"/missing"); err == nil {
+		t.Error("Expected error for 404")
+	}
+}
@@ -0,0 +1,159 @@
+// Package classify derives scope keywords, a category and a venue type for
+// discovered RFPs from their page and document text.
+package classify
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// titleWeight is how much more a phrase in the title counts than one in the
+// body text.
+const titleWeight = 5
+
+// Result is the classification of one RFP.
+type Result struct {
+	ScopeKeywords []string // sorted by relevance
+	Category      string   // empty if no scope terms matched
+	VenueType     string   // empty if no venue terms matched
+}
+
+// compiled taxonomies, built once at init
+var (
+	scopePatterns = compileScope()
+	venuePatterns = compileVenues()
+)
+
+type scopePattern struct {
+	term    scopeTerm
+	pattern *regexp.Regexp
+}
+
+type venuePattern struct {
+	venueType string
+	pattern   *regexp.Regexp
+}
+
+// Classify scores the taxonomy against an RFP's title and body texts (page
+// content, document text, scope summary). Title matches are weighted more
+// heavily than body matches.
+func Classify(title string, texts ...string) Result {
+	title = strings.ToLower(title)
+	body := strings.ToLower(strings.Join(texts, "\n"))
+
+	// Scope keywords and the categories they count toward
+	type hit struct {
+		keyword string
+		score   int
+		order   int
+	}
+	var hits []hit
+	categoryScores := make(map[string]int)
+	for i, sp := range scopePatterns {
+		score := titleWeight*len(sp.pattern.FindAllStringIndex(title, -1)) +
+			len(sp.pattern.FindAllStringIndex(body, -1))
+		if score == 0 {
+			continue
+		}
+		hits = append(hits, hit{sp.term.Keyword, score, i})
+		categoryScores[sp.term.Category] += score
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].order < hits[j].order
+	})
+
+	var res Result
+	for _, h := range hits {
+		res.ScopeKeywords = append(res.ScopeKeywords, h.keyword)
+	}
+	res.Category = bestCategory(categoryScores)
+
+	// Venue type: highest score, taxonomy order breaks ties
+	bestScore := 0
+	for _, vp := range venuePatterns {
+		score := titleWeight*len(vp.pattern.FindAllStringIndex(title, -1)) +
+			len(vp.pattern.FindAllStringIndex(body, -1))
+		if score > bestScore {
+			bestScore = score
+			res.VenueType = vp.venueType
+		}
+	}
+
+	return res
+}
+
+// NormalizeCategory maps a free-text category label, such as the one the
+// extraction model returns, to the fixed vocabulary. Returns "" if unknown.
+func NormalizeCategory(label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if category, ok := categoryAliases[label]; ok {
+		return category
+	}
+	return categoryAliases[strings.ReplaceAll(label, "_", " ")]
+}
+
+// NormalizeVenueType maps a free-text venue label to the fixed vocabulary.
+// Returns "" if unknown.
+func NormalizeVenueType(label string) string {
+	label = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(label, "_", " ")))
+	if label == "" {
+		return ""
+	}
+	for _, vp := range venuePatterns {
+		if vp.pattern.MatchString(label) {
+			return vp.venueType
+		}
+	}
+	return ""
+}
+
+// bestCategory returns the highest-scoring category, breaking ties by the
+// order categories first appear in the taxonomy.
+func bestCategory(scores map[string]int) string {
+	best, bestScore := "", 0
+	seen := make(map[string]bool)
+	for _, term := range scopeTaxonomy {
+		if seen[term.Category] {
+			continue
+		}
+		seen[term.Category] = true
+		if scores[term.Category] > bestScore {
+			best, bestScore = term.Category, scores[term.Category]
+		}
+	}
+	return best
+}
+
+func compileScope() []scopePattern {
+	patterns := make([]scopePattern, len(scopeTaxonomy))
+	for i, term := range scopeTaxonomy {
+		patterns[i] = scopePattern{term: term, pattern: phrasePattern(term.Phrases)}
+	}
+	return patterns
+}
+
+func compileVenues() []venuePattern {
+	patterns := make([]venuePattern, len(venueTaxonomy))
+	for i, term := range venueTaxonomy {
+		patterns[i] = venuePattern{venueType: term.VenueType, pattern: phrasePattern(term.Phrases)}
+	}
+	return patterns
+}
+
+// phrasePattern builds a word-bounded alternation of phrases, longest first
+// so "parking enforcement" is counted once rather than also as "enforcement".
+func phrasePattern(phrases []string) *regexp.Regexp {
+	sorted := append([]string(nil), phrases...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	quoted := make([]string, len(sorted))
+	for i, p := range sorted {
+		quoted[i] = strings.ReplaceAll(regexp.QuoteMeta(strings.ToLower(p)), " ", `[\s-]+`)
+	}
+	return regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
@@ -0,0 +1,113 @@
+package classify
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name         string
+		title        string
+		text         string
+		wantKeywords []string
+		wantCategory string
+		wantVenue    string
+	}{
+		{
+			name:         "arena event parking",
+			title:        "RFP for Event Parking and Traffic Control Services",
+			text:         "The Arena seeks a contractor to provide parking attendants, traffic control and shuttle service for all home games and concerts at the arena.",
+			wantKeywords: []string{"traffic control", "event parking", "attendants", "gameday", "shuttle"},
+			wantCategory: CategoryEventOps,
+			wantVenue:    VenueArena,
+		},
+		{
+			name:         "municipal enforcement",
+			title:        "Parking Enforcement Services",
+			text:         "The City requires on-street parking enforcement, citation processing and adjudication. Booting of scofflaw vehicles.",
+			wantKeywords: []string{"enforcement", "citation", "booting"},
+			wantCategory: CategoryEnforcement,
+			wantVenue:    VenueMunicipal,
+		},
+		{
+			name:         "airport PARCS",
+			title:        "Parking Access and Revenue Control System Replacement",
+			text:         "Airport parking PARCS hardware including gate arms and license plate recognition (LPR) cameras.",
+			wantKeywords: []string{"parcs", "lpr", "hardware"},
+			wantCategory: CategoryEquipment,
+			wantVenue:    VenueAirport,
+		},
+		{
+			name:         "hospital valet",
+			title:        "Valet Parking Services",
+			text:         "Medical Center patient and visitor valet services at the main entrance.",
+			wantKeywords: []string{"valet"},
+			wantCategory: CategoryValet,
+			wantVenue:    VenueHospital,
+		},
+		{
+			name:  "law enforcement",
+			title: "Event Security Services",
+			text:  "The contractor shall coordinate with law enforcement and provide enforcement services for venue rules.",
+		},
+		{
+			name:  "unrelated",
+			title: "Janitorial Services",
+			text:  "Custodial cleaning of administrative offices.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.title, tt.text)
+			if !reflect.DeepEqual(got.ScopeKeywords, tt.wantKeywords) {
+				t.Errorf("ScopeKeywords = %v, want %v", got.ScopeKeywords, tt.wantKeywords)
+			}
+			if got.Category != tt.wantCategory {
+				t.Errorf("Category = %q, want %q", got.Category, tt.wantCategory)
+			}
+			if got.VenueType != tt.wantVenue {
+				t.Errorf("VenueType = %q, want %q", got.VenueType, tt.wantVenue)
+			}
+		})
+	}
+}
+
+func TestClassify_LongestPhraseCountsOnce(t *testing.T) {
+	got := Classify("", "parking enforcement")
+	if !reflect.DeepEqual(got.ScopeKeywords, []string{"enforcement"}) {
+		t.Errorf("ScopeKeywords = %v, want [enforcement]", got.ScopeKeywords)
+	}
+}
+
+func TestNormalizeCategory(t *testing.T) {
+	tests := map[string]string{
+		"Parking":          CategoryParking,
+		"event_ops":        CategoryEventOps,
+		"Event Operations": CategoryEventOps,
+		"PARCS":            CategoryEquipment,
+		"janitorial":       "",
+		"":                 "",
+	}
+	for input, want := range tests {
+		if got := NormalizeCategory(input); got != want {
+			t.Errorf("NormalizeCategory(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestNormalizeVenueType(t *testing.T) {
+	tests := map[string]string{
+		"convention_center":      VenueConventionCenter,
+		"Convention Center":      VenueConventionCenter,
+		"Performing Arts Center": VenuePerformingArts,
+		"municipal":              VenueMunicipal,
+		"office building":        "",
+	}
+	for input, want := range tests {
+		if got := NormalizeVenueType(input); got != want {
+			t.Errorf("NormalizeVenueType(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package classify
+
+// Categories are the fixed values of rfps.category.
+const (
+	CategoryParking     = "parking"     // parking management and operations
+	CategoryValet       = "valet"       // valet services
+	CategoryEventOps    = "event_ops"   // event day parking, traffic and crowd operations
+	CategoryTransit     = "transit"     // shuttle and transportation services
+	CategoryEnforcement = "enforcement" // citation issuance, collections, booting
+	CategoryEquipment   = "equipment"   // PARCS, meters, LPR and other hardware
+)
+
+// Venue types are the fixed values of rfps.venue_type.
+const (
+	VenueArena            = "arena"
+	VenueStadium          = "stadium"
+	VenueConventionCenter = "convention_center"
+	VenueAmphitheater     = "amphitheater"
+	VenuePerformingArts   = "performing_arts"
+	VenueFairgrounds      = "fairgrounds"
+	VenueAirport          = "airport"
+	VenueHospital         = "hospital"
+	VenueUniversity       = "university"
+	VenueMunicipal        = "municipal"
+)
+
+// scopeTerm is a normalized scope keyword, the phrases that indicate it, and
+// the category it counts toward.
+type scopeTerm struct {
+	Keyword  string
+	Category string
+	Phrases  []string
+}
+
+// scopeTaxonomy is the maintained list of scope keywords. Keywords are what
+// the client's scope_match rules are written against, so renaming one here
+// means updating those rules too. Phrases are matched case-insensitively on
+// word boundaries.
+var scopeTaxonomy = []scopeTerm{
+	// Parking operations
+	{"parking management", CategoryParking, []string{"parking management", "parking operations", "parking operator", "management of parking", "operation of parking"}},
+	{"garage operations", CategoryParking, []string{"parking garage", "parking structure", "parking deck", "garage operations", "garage management"}},
+	{"surface lots", CategoryParking, []string{"surface lot", "surface parking", "parking lots"}},
+	{"cashiering", CategoryParking, []string{"cashier", "cashiering", "revenue collection", "fee collection"}},
+	{"attendants", CategoryParking, []string{"attendant", "attendants", "lot attendant", "parking staff"}},
+	{"monthly permits", CategoryParking, []string{"monthly parking", "parking permit", "permit parking", "monthly permit"}},
+
+	// Valet
+	{"valet", CategoryValet, []string{"valet", "valet parking", "valet services"}},
+	{"vip valet", CategoryValet, []string{"vip valet", "premium valet", "vip parking"}},
+
+	// Event operations
+	{"event parking", CategoryEventOps, []string{"event parking", "special event parking", "event day parking", "event-day parking"}},
+	{"gameday", CategoryEventOps, []string{"gameday", "game day", "game-day", "home games"}},
+	{"traffic control", CategoryEventOps, []string{"traffic control", "traffic management", "traffic direction", "flaggers", "flagging", "traffic flow"}},
+	{"crowd management", CategoryEventOps, []string{"crowd management", "crowd control", "ticket taking", "ushers", "event staffing"}},
+	{"tailgating", CategoryEventOps, []string{"tailgating", "tailgate"}},
+
+	// Transit
+	{"shuttle", CategoryTransit, []string{"shuttle", "shuttles", "shuttle bus", "shuttle service", "park and ride", "park-and-ride"}},
+	{"transportation", CategoryTransit, []string{"transportation services", "transit services", "bus service", "circulator"}},
+
+	// Enforcement
+	// Only parking and meter enforcement; "law enforcement" is everywhere in boilerplate
+	{"enforcement", CategoryEnforcement, []string{"parking enforcement", "enforcement of parking", "meter enforcement", "citation enforcement", "on-street enforcement"}},
+	{"citation", CategoryEnforcement, []string{"citation", "citations", "parking tickets", "ticket processing", "adjudication"}},
+	{"booting", CategoryEnforcement, []string{"booting", "boot and tow", "immobilization", "towing"}},
+
+	// Equipment
+	{"parcs", CategoryEquipment, []string{"parcs", "parking access and revenue control", "parking access & revenue control", "revenue control system", "gate arms"}},
+	{"pay stations", CategoryEquipment, []string{"pay station", "pay stations", "parking meters", "multi-space meters", "pay-by-plate", "pay by phone", "mobile payment"}},
+	{"lpr", CategoryEquipment, []string{"license plate recognition", "lpr", "alpr"}},
+	{"hardware", CategoryEquipment, []string{"hardware", "equipment installation", "equipment procurement"}},
+	{"parking guidance", CategoryEquipment, []string{"parking guidance", "space counting", "occupancy sensors", "wayfinding signage"}},
+}
+
+// venueTerm is a venue type and the phrases that indicate it.
+type venueTerm struct {
+	VenueType string
+	Phrases   []string
+}
+
+// venueTaxonomy lists venue types in priority order: when a document
+// mentions several, the most specific event venue wins ties.
+var venueTaxonomy = []venueTerm{
+	{VenueStadium, []string{"stadium", "ballpark", "football field", "speedway", "raceway"}},
+	{VenueArena, []string{"arena", "coliseum", "fieldhouse", "events center", "event center"}},
+	{VenueConventionCenter, []string{"convention center", "convention centre", "exhibition center", "expo center", "civic center"}},
+	{VenueAmphitheater, []string{"amphitheater", "amphitheatre", "bowl", "pavilion"}},
+	{VenuePerformingArts, []string{"performing arts", "theater", "theatre", "opera house", "concert hall", "symphony"}},
+	{VenueFairgrounds, []string{"fairgrounds", "fair grounds", "expo grounds", "state fair", "county fair", "rodeo"}},
+	{VenueAirport, []string{"airport", "aviation", "terminal parking", "airport authority"}},
+	{VenueHospital, []string{"hospital", "medical center", "health system", "healthcare campus"}},
+	{VenueUniversity, []string{"university", "college", "campus", "school district"}},
+	{VenueMunicipal, []string{"downtown", "on-street", "municipal", "city-owned", "city owned", "county-owned", "public parking"}},
+}
+
+// categoryAliases maps free-text category labels to the fixed vocabulary.
+var categoryAliases = map[string]string{
+	"parking":             CategoryParking,
+	"parking management":  CategoryParking,
+	"parking operations":  CategoryParking,
+	"valet":               CategoryValet,
+	"valet parking":       CategoryValet,
+	"event_ops":           CategoryEventOps,
+	"event ops":           CategoryEventOps,
+	"event operations":    CategoryEventOps,
+	"event parking":       CategoryEventOps,
+	"traffic control":     CategoryEventOps,
+	"transit":             CategoryTransit,
+	"shuttle":             CategoryTransit,
+	"transportation":      CategoryTransit,
+	"enforcement":         CategoryEnforcement,
+	"parking enforcement": CategoryEnforcement,
+	"equipment":           CategoryEquipment,
+	"parcs":               CategoryEquipment,
+	"technology":          CategoryEquipment,
+}
@@ -2,6 +2,8 @@
 package dedup
 
 import (
+	"context"
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
@@ -18,6 +20,15 @@ const (
 
 	// DateToleranceDays for date matching.
 	DateToleranceDays = 3
+
+	// TitleConflictThreshold is the title similarity below which two titles
+	// are treated as different solicitations, even from the same agency.
+	TitleConflictThreshold = 0.5
+
+	// TitleMatchThreshold is the title similarity at which a title can stand
+	// in for an agency match, e.g. for a portal mirror that spells the agency
+	// differently.
+	TitleMatchThreshold = 0.8
 )
 
 // Weights for match score calculation.
@@ -25,61 +36,167 @@ var weights = struct {
 	Agency float64
 	State  float64
 	Date   float64
+	Title  float64
 }{
-	Agency: 0.5,
-	State:  0.2,
-	Date:   0.3,
+	Agency: 0.35,
+	State:  0.15,
+	Date:   0.2,
+	Title:  0.3,
+}
+
+// Signal names used in MatchResult.Signals.
+const (
+	SignalURL          = "canonical_url"
+	SignalPortalID     = "portal_id"
+	SignalSolicitation = "solicitation_number"
+	SignalAgency       = "agency"
+	SignalState        = "state"
+	SignalDate         = "due_date"
+	SignalTitle        = "title"
+)
+
+// Signal explains how one piece of evidence contributed to a match score.
+type Signal struct {
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`            // contribution to the match score
+	Detail string  `json:"detail,omitempty"` // what was compared
 }
 
 // MatchResult contains the result of a deduplication check.
 type MatchResult struct {
-	FoundMatch        bool    `json:"found_match"`
-	MatchedRFPID      int     `json:"rfp_id,omitempty"`
-	MatchedRFPTitle   string  `json:"rfp_title,omitempty"`
-	MatchScore        float64 `json:"match_score,omitempty"`
-	Reason            string  `json:"reason,omitempty"`
-	CandidatesChecked int     `json:"candidates_checked,omitempty"`
+	FoundMatch        bool     `json:"found_match"`
+	MatchedRFPID      int      `json:"rfp_id,omitempty"`
+	MatchedRFPTitle   string   `json:"rfp_title,omitempty"`
+	MatchScore        float64  `json:"match_score,omitempty"`
+	Reason            string   `json:"reason,omitempty"`
+	Signals           []Signal `json:"signals,omitempty"`
+	CandidatesChecked int      `json:"candidates_checked,omitempty"`
+}
+
+// Candidate describes a search result or RFP being checked for duplicates.
+// Every field is optional; more fields give more signals.
+type Candidate struct {
+	Title              string
+	Agency             string
+	State              string
+	DueDate            string
+	URL                string
+	Portal             string
+	PortalID           string
+	SolicitationNumber string
+}
+
+// CandidateFromRFP builds a Candidate from a stored RFP.
+func CandidateFromRFP(rfp *models.RFP) Candidate {
+	c := Candidate{
+		Title:              rfp.Title,
+		Agency:             rfp.Agency,
+		State:              rfp.State,
+		URL:                rfp.SourceURL,
+		Portal:             rfp.Portal,
+		PortalID:           rfp.PortalID,
+		SolicitationNumber: rfp.SolicitationNumber,
+	}
+	if rfp.DueDate != nil {
+		c.DueDate = rfp.DueDate.Format("2006-01-02")
+	}
+	return c
+}
+
+// normalized holds a Candidate's comparison keys.
+type normalized struct {
+	title        string
+	agency       string
+	state        string
+	date         string
+	url          string
+	portal       string
+	portalID     string
+	solicitation []string
+}
+
+func normalizeCandidate(c Candidate) normalized {
+	return normalized{
+		title:        c.Title,
+		agency:       NormalizeAgency(c.Agency),
+		state:        NormalizeState(c.State),
+		date:         NormalizeDate(c.DueDate),
+		url:          CanonicalURL(c.URL),
+		portal:       strings.ToLower(strings.TrimSpace(c.Portal)),
+		portalID:     strings.TrimSpace(c.PortalID),
+		solicitation: ExtractSolicitationNumbers(c.SolicitationNumber, c.Title, c.URL),
+	}
 }
 
 // Matcher handles deduplication matching.
 type Matcher struct {
-	existingRFPs []models.RFP
+	source CandidateSource
 }
 
-// NewMatcher creates a new deduplication matcher.
+// NewMatcher creates a new deduplication matcher over an in-memory list of
+// RFPs.
 func NewMatcher(existingRFPs []models.RFP) *Matcher {
+	return NewSourceMatcher(SliceSource(existingRFPs))
+}
+
+// NewSourceMatcher creates a deduplication matcher that retrieves
+// candidates from source, e.g. a PostgresSource.
+func NewSourceMatcher(source CandidateSource) *Matcher {
 	return &Matcher{
-		existingRFPs: existingRFPs,
+		source: source,
 	}
 }
 
 // CheckDuplicate checks if the given hints match an existing RFP.
 func (m *Matcher) CheckDuplicate(agency, state, dueDate string) *MatchResult {
-	// Need at least agency to do matching
-	if agency == "" {
+	return m.Check(Candidate{Agency: agency, State: state, DueDate: dueDate})
+}
+
+// Check checks if a candidate matches an existing RFP. Candidate retrieval
+// errors are reported as no match; use CheckContext to handle them.
+func (m *Matcher) Check(c Candidate) *MatchResult {
+	result, err := m.CheckContext(context.Background(), c)
+	if err != nil {
 		return &MatchResult{
 			FoundMatch: false,
-			Reason:     "No agency hint available",
+			Reason:     err.Error(),
 		}
 	}
+	return result
+}
 
-	// Normalize the hints
-	normalizedAgency := NormalizeAgency(agency)
-	normalizedState := NormalizeState(state)
-	normalizedDate := NormalizeDate(dueDate)
+// CheckContext checks if a candidate matches an existing RFP. A shared
+// canonical URL, portal ID or solicitation number is decisive. Otherwise
+// agency, state, due date and title similarity are scored, and titles that
+// clearly differ rule out a match even when everything else lines up.
+func (m *Matcher) CheckContext(ctx context.Context, c Candidate) (*MatchResult, error) {
+	n := normalizeCandidate(c)
+
+	// Need something to match on
+	if n.agency == "" && n.url == "" && n.portalID == "" && len(n.solicitation) == 0 && len(TitleTokens(n.title)) == 0 {
+		return &MatchResult{
+			FoundMatch: false,
+			Reason:     "No agency hint available",
+		}, nil
+	}
 
 	// Find candidates
-	candidates := m.findCandidates(normalizedAgency, normalizedState)
+	candidates, err := m.findCandidates(ctx, c, n)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check each candidate for match
 	var bestMatch *models.RFP
+	var bestSignals []Signal
 	bestScore := 0.0
 
 	for i := range candidates {
-		score := m.calculateMatchScore(&candidates[i], normalizedAgency, normalizedState, normalizedDate)
+		score, signals := scoreCandidate(&candidates[i], n)
 		if score > bestScore && score >= MatchThreshold {
 			bestScore = score
 			bestMatch = &candidates[i]
+			bestSignals = signals
 		}
 	}
 
@@ -89,74 +206,174 @@ func (m *Matcher) CheckDuplicate(agency, state, dueDate string) *MatchResult {
 			MatchedRFPID:      bestMatch.ID,
 			MatchedRFPTitle:   bestMatch.Title,
 			MatchScore:        bestScore,
+			Reason:            describeSignals(bestSignals),
+			Signals:           bestSignals,
 			CandidatesChecked: len(candidates),
-		}
+		}, nil
 	}
 
 	return &MatchResult{
 		FoundMatch:        false,
 		Reason:            "No matching RFP found",
 		CandidatesChecked: len(candidates),
-	}
+	}, nil
 }
 
 // findCandidates finds RFPs that might match.
-func (m *Matcher) findCandidates(normalizedAgency string, state string) []models.RFP {
-	var candidates []models.RFP
-
-	for _, rfp := range m.existingRFPs {
-		// Filter by state if available
-		if state != "" && rfp.State != "" && rfp.State != state {
-			continue
-		}
+func (m *Matcher) findCandidates(ctx context.Context, c Candidate, n normalized) ([]models.RFP, error) {
+	existing, err := m.source.Candidates(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("candidate retrieval failed: %w", err)
+	}
 
-		// Check agency similarity
-		rfpAgency := NormalizeAgency(rfp.Agency)
-		if AgencyMatches(normalizedAgency, rfpAgency) {
+	var candidates []models.RFP
+	for _, rfp := range existing {
+		if isCandidate(&rfp, n) {
 			candidates = append(candidates, rfp)
 		}
 	}
 
-	return candidates
+	return candidates, nil
 }
 
-// calculateMatchScore calculates the match score between an RFP and hints.
-func (m *Matcher) calculateMatchScore(rfp *models.RFP, agency, state, date string) float64 {
+// isCandidate is the cheap prefilter applied before scoring.
+func isCandidate(rfp *models.RFP, n normalized) bool {
+	if decisiveSignal(rfp, n) != nil {
+		return true
+	}
+
+	// Filter by state if available
+	if n.state != "" && rfp.State != "" && NormalizeState(rfp.State) != n.state {
+		return false
+	}
+
+	// Check agency similarity, or a near-identical title for mirrors that
+	// spell the agency differently
+	if n.agency != "" && AgencyMatches(n.agency, NormalizeAgency(rfp.Agency)) {
+		return true
+	}
+	return n.title != "" && TitleSimilarity(n.title, rfp.Title) >= TitleMatchThreshold
+}
+
+// decisiveSignal returns the identifier signal that settles a match on its
+// own, or nil if there isn't one.
+func decisiveSignal(rfp *models.RFP, n normalized) *Signal {
+	if n.url != "" && n.url == CanonicalURL(rfp.SourceURL) {
+		return &Signal{Name: SignalURL, Score: 1, Detail: n.url}
+	}
+	if n.portal != "" && n.portalID != "" &&
+		n.portal == strings.ToLower(rfp.Portal) && n.portalID == rfp.PortalID {
+		return &Signal{Name: SignalPortalID, Score: 1, Detail: n.portal + " " + n.portalID}
+	}
+	if len(n.solicitation) > 0 {
+		theirs := ExtractSolicitationNumbers(rfp.SolicitationNumber, rfp.Title, rfp.SourceURL)
+		if sharesAny(n.solicitation, theirs) {
+			// Numbers like 24-001 recur across agencies, so require the
+			// state to agree when both are known
+			if n.state == "" || rfp.State == "" || NormalizeState(rfp.State) == n.state {
+				return &Signal{Name: SignalSolicitation, Score: 1, Detail: strings.Join(n.solicitation, ", ")}
+			}
+		}
+	}
+	return nil
+}
+
+// scoreCandidate calculates the match score between an RFP and a candidate,
+// with the signals that produced it.
+func scoreCandidate(rfp *models.RFP, n normalized) (float64, []Signal) {
+	if s := decisiveSignal(rfp, n); s != nil {
+		return 1, []Signal{*s}
+	}
+
+	// Conflicting identifiers mean different solicitations
+	if n.portal != "" && n.portalID != "" && n.portal == strings.ToLower(rfp.Portal) && rfp.PortalID != "" {
+		return 0, nil // same portal, different IDs
+	}
+	if len(n.solicitation) > 0 {
+		if theirs := ExtractSolicitationNumbers(rfp.SolicitationNumber, rfp.Title, rfp.SourceURL); len(theirs) > 0 {
+			return 0, nil // both numbered, no number in common
+		}
+	}
+
+	var signals []Signal
 	score := 0.0
 
-	// Agency match (required)
+	// Title similarity; clearly different titles rule out a match
+	titleSim := -1.0
+	if len(TitleTokens(n.title)) > 0 && len(TitleTokens(rfp.Title)) > 0 {
+		titleSim = TitleSimilarity(n.title, rfp.Title)
+		if titleSim < TitleConflictThreshold {
+			return 0, nil
+		}
+		score += weights.Title * titleSim
+		signals = append(signals, Signal{Name: SignalTitle, Score: weights.Title * titleSim, Detail: rfp.Title})
+	} else {
+		// No title to compare - give partial credit
+		score += weights.Title * 0.5
+		signals = append(signals, Signal{Name: SignalTitle, Score: weights.Title * 0.5, Detail: "no title to compare"})
+	}
+
+	// Agency match (required unless the titles are near-identical)
 	rfpAgency := NormalizeAgency(rfp.Agency)
-	if rfpAgency == agency {
+	switch {
+	case n.agency != "" && rfpAgency == n.agency:
 		score += weights.Agency
-	} else if AgencyMatches(rfpAgency, agency) {
+		signals = append(signals, Signal{Name: SignalAgency, Score: weights.Agency, Detail: rfpAgency})
+	case n.agency != "" && AgencyMatches(rfpAgency, n.agency):
 		score += weights.Agency * 0.8 // Partial match
-	} else {
-		return 0 // Agency must match
+		signals = append(signals, Signal{Name: SignalAgency, Score: weights.Agency * 0.8, Detail: n.agency + " ~ " + rfpAgency})
+	case titleSim >= TitleMatchThreshold:
+		score += weights.Agency * 0.5
+		signals = append(signals, Signal{Name: SignalAgency, Score: weights.Agency * 0.5, Detail: "agency differs, title matches"})
+	default:
+		return 0, nil // Agency must match
 	}
 
 	// State match
-	if state != "" {
-		rfpState := NormalizeState(rfp.State)
-		if rfpState == state {
+	if n.state != "" {
+		if NormalizeState(rfp.State) == n.state {
 			score += weights.State
+			signals = append(signals, Signal{Name: SignalState, Score: weights.State, Detail: n.state})
 		}
 	} else {
 		// No state hint - give partial credit
 		score += weights.State * 0.5
+		signals = append(signals, Signal{Name: SignalState, Score: weights.State * 0.5, Detail: "no state hint"})
 	}
 
 	// Date match
-	if date != "" && rfp.DueDate != nil {
+	if n.date != "" && rfp.DueDate != nil {
 		rfpDate := rfp.DueDate.Format("2006-01-02")
-		if DatesMatch(date, rfpDate) {
+		if DatesMatch(n.date, rfpDate) {
 			score += weights.Date
+			signals = append(signals, Signal{Name: SignalDate, Score: weights.Date, Detail: rfpDate})
 		}
-	} else if date == "" {
-		// No date hint - give partial credit
+	} else if n.date == "" && stillOpen(rfp, time.Now()) {
+		// No date hint - give partial credit, unless the RFP is over: an
+		// undated result matching a closed or past-due RFP may be its rebid
 		score += weights.Date * 0.5
+		signals = append(signals, Signal{Name: SignalDate, Score: weights.Date * 0.5, Detail: "no date hint"})
+	}
+
+	return score, signals
+}
+
+// stillOpen reports whether an RFP may still be taking submissions: its
+// lifecycle is active or unrecorded and its due date hasn't passed.
+func stillOpen(rfp *models.RFP, now time.Time) bool {
+	if rfp.LifecycleStatus != "" && !models.IsLifecycleActive(rfp.LifecycleStatus) {
+		return false
 	}
+	return rfp.DueDate == nil || !rfp.DueDate.AddDate(0, 0, 1).Before(now)
+}
 
-	return score
+// describeSignals summarizes the signals behind a match.
+func describeSignals(signals []Signal) string {
+	parts := make([]string, len(signals))
+	for i, s := range signals {
+		parts[i] = fmt.Sprintf("%s %.2f", s.Name, s.Score)
+	}
+	return "Matched on " + strings.Join(parts, ", ")
 }
 
 // Common agency name prefixes to remove.
@@ -192,41 +409,27 @@ func NormalizeAgency(agency string) string {
 }
 
 // stateNames maps full state names to 2-letter codes.
-var stateNames = map[string]string{
-	"ALABAMA": "AL", "ALASKA": "AK", "ARIZONA": "AZ", "ARKANSAS": "AR",
-	"CALIFORNIA": "CA", "COLORADO": "CO", "CONNECTICUT": "CT", "DELAWARE": "DE",
-	"FLORIDA": "FL", "GEORGIA": "GA", "HAWAII": "HI", "IDAHO": "ID",
-	"ILLINOIS": "IL", "INDIANA": "IN", "IOWA": "IA", "KANSAS": "KS",
-	"KENTUCKY": "KY", "LOUISIANA": "LA", "MAINE": "ME", "MARYLAND": "MD",
-	"MASSACHUSETTS": "MA", "MICHIGAN": "MI", "MINNESOTA": "MN", "MISSISSIPPI": "MS",
-	"MISSOURI": "MO", "MONTANA": "MT", "NEBRASKA": "NE", "NEVADA": "NV",
-	"NEW HAMPSHIRE": "NH", "NEW JERSEY": "NJ", "NEW MEXICO": "NM", "NEW YORK": "NY",
-	"NORTH CAROLINA": "NC", "NORTH DAKOTA": "ND", "OHIO": "OH", "OKLAHOMA": "OK",
-	"OREGON": "OR", "PENNSYLVANIA": "PA", "RHODE ISLAND": "RI", "SOUTH CAROLINA": "SC",
-	"SOUTH DAKOTA": "SD", "TENNESSEE": "TN", "TEXAS": "TX", "UTAH": "UT",
-	"VERMONT": "VT", "VIRGINIA": "VA", "WASHINGTON": "WA", "WEST VIRGINIA": "WV",
-	"WISCONSIN": "WI", "WYOMING": "WY", "DISTRICT OF COLUMBIA": "DC",
-}
+var stateNames = models.StateNames
 
 // NormalizeState normalizes a state to 2-letter code.
 func NormalizeState(state string) string {
-	if state == "" {
-		return ""
-	}
-
-	state = strings.ToUpper(strings.TrimSpace(state))
+	return models.NormalizeState(state)
+}
 
-	// If already 2 letters, return as is
-	if len(state) == 2 && regexp.MustCompile(`^[A-Z]{2}$`).MatchString(state) {
-		return state
+// StateSpellings returns the upper-cased ways a state may be stored: its
+// code and its full name. Returns nil if the state isn't recognized.
+func StateSpellings(state string) []string {
+	code := NormalizeState(state)
+	if code == "" {
+		return nil
 	}
-
-	// Look up full name
-	if code, ok := stateNames[state]; ok {
-		return code
+	spellings := []string{code}
+	for name, c := range stateNames {
+		if c == code {
+			spellings = append(spellings, name)
+		}
 	}
-
-	return ""
+	return spellings
 }
 
 // Common date formats to try.
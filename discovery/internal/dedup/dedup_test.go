@@ -1,6 +1,9 @@
 package dedup
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
 	"time"
 
@@ -60,6 +63,16 @@ func TestNormalizeState(t *testing.T) {
 	}
 }
 
+func TestStateSpellings(t *testing.T) {
+	got := StateSpellings("texas")
+	if len(got) != 2 || got[0] != "TX" || got[1] != "TEXAS" {
+		t.Errorf("StateSpellings(texas) = %v, want [TX TEXAS]", got)
+	}
+	if got := StateSpellings("Invalid"); got != nil {
+		t.Errorf("StateSpellings(Invalid) = %v, want nil", got)
+	}
+}
+
 func TestNormalizeDate(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -252,3 +265,348 @@ func TestMatcher_EmptyRFPs(t *testing.T) {
 		t.Errorf("Expected 0 candidates, got %d", result.CandidatesChecked)
 	}
 }
+
+func TestMatcher_Check(t *testing.T) {
+	dueDate := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	nextWeek := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+
+	existingRFPs := []models.RFP{
+		{
+			ID:        1,
+			Title:     "Parking Management Services RFP #2024-015",
+			Agency:    "City of Springfield",
+			State:     "IL",
+			DueDate:   &dueDate,
+			SourceURL: "https://www.springfield.gov/bids/2024-015/",
+		},
+		{
+			ID:        2,
+			Title:     "Valet Services for Convention Center",
+			Agency:    "City of Springfield",
+			State:     "IL",
+			DueDate:   &nextWeek,
+			SourceURL: "https://springfield.gov/bids/valet",
+		},
+		{
+			ID:       3,
+			Title:    "Event Parking Operations",
+			Agency:   "Metro Sports Authority",
+			State:    "TX",
+			Portal:   "bonfire",
+			PortalID: "88123",
+		},
+	}
+
+	matcher := NewMatcher(existingRFPs)
+
+	tests := []struct {
+		name        string
+		candidate   Candidate
+		expectMatch bool
+		matchID     int
+		signal      string
+	}{
+		{
+			name:        "canonical URL",
+			candidate:   Candidate{URL: "http://springfield.gov/bids/2024-015?utm_source=google"},
+			expectMatch: true,
+			matchID:     1,
+			signal:      SignalURL,
+		},
+		{
+			name:        "portal ID",
+			candidate:   Candidate{Title: "Parking RFP", Portal: "Bonfire", PortalID: "88123"},
+			expectMatch: true,
+			matchID:     3,
+			signal:      SignalPortalID,
+		},
+		{
+			name: "solicitation number on a mirror with a different agency spelling",
+			candidate: Candidate{
+				Title:  "RFP 2024-015 Parking Mgmt",
+				Agency: "Springfield Public Works",
+				State:  "IL",
+				URL:    "https://springfield.bonfirehub.com/opportunities/5521",
+			},
+			expectMatch: true,
+			matchID:     1,
+			signal:      SignalSolicitation,
+		},
+		{
+			name: "same agency, same week, different title",
+			candidate: Candidate{
+				Title:   "Valet Parking at the Convention Center",
+				Agency:  "City of Springfield",
+				State:   "IL",
+				DueDate: "2024-03-16",
+			},
+			expectMatch: true,
+			matchID:     2,
+			signal:      SignalTitle,
+		},
+		{
+			name: "same agency, same week, unrelated title",
+			candidate: Candidate{
+				Title:   "Parking Enforcement Officers",
+				Agency:  "City of Springfield",
+				State:   "IL",
+				DueDate: "2024-03-15",
+			},
+			expectMatch: false,
+		},
+		{
+			name: "conflicting solicitation numbers",
+			candidate: Candidate{
+				Title:   "Parking Management Services RFP #2024-031",
+				Agency:  "City of Springfield",
+				State:   "IL",
+				DueDate: "2024-03-15",
+			},
+			expectMatch: false,
+		},
+		{
+			name: "near-identical title with a different agency spelling",
+			candidate: Candidate{
+				Title:  "Event Parking Operations",
+				Agency: "MSA",
+				State:  "TX",
+			},
+			expectMatch: true,
+			matchID:     3,
+			signal:      SignalAgency,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := matcher.Check(tt.candidate)
+			if result.FoundMatch != tt.expectMatch {
+				t.Fatalf("Check() FoundMatch = %v, want %v (reason: %s)", result.FoundMatch, tt.expectMatch, result.Reason)
+			}
+			if !tt.expectMatch {
+				return
+			}
+			if result.MatchedRFPID != tt.matchID {
+				t.Errorf("Check() MatchedRFPID = %d, want %d", result.MatchedRFPID, tt.matchID)
+			}
+			found := false
+			for _, s := range result.Signals {
+				if s.Name == tt.signal {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Check() Signals = %+v, want a %s signal", result.Signals, tt.signal)
+			}
+		})
+	}
+}
+
+func TestExtractSolicitationNumbers(t *testing.T) {
+	tests := []struct {
+		input    []string
+		expected []string
+	}{
+		{[]string{"RFP #2024-015 Parking Services"}, []string{"2024015"}},
+		{[]string{"Bid No. 24-117"}, []string{"24117"}},
+		{[]string{"", "Solicitation: PW-2291"}, []string{"PW2291"}},
+		{[]string{"https://agency.gov/bids/rfp-2024-015/details"}, []string{"2024015"}},
+		{[]string{"Parking Management RFP 2024"}, nil},
+		{[]string{"Request for Proposals"}, nil},
+		{[]string{"Bid #40117 Parking Garage"}, []string{"40117"}},
+
+		// Counts and addresses after a label are not solicitation numbers
+		{[]string{"RFP 1000 parking spaces"}, nil},
+		{[]string{"Project 1234 Main St garage"}, nil},
+		{[]string{"Contract 2500 meters"}, nil},
+
+		// Year ranges and dates are not solicitation numbers
+		{[]string{"Parking Services FY 2024-2025"}, nil},
+		{[]string{"RFP for FY 2024-2025 Parking Services"}, nil},
+		{[]string{"Proposals due 2024-03-15"}, nil},
+		{[]string{"Pre-bid meeting 03-15-2024"}, nil},
+		{[]string{"RFP 2024-03-15"}, nil},
+
+		// Bare numbers need letters or a nearby label
+		{[]string{"Parking Management 2024-015"}, nil},
+		{[]string{"Parking Management 24-PK117"}, []string{"24PK117"}},
+		{[]string{"Parking RFP for garages, 2024-015"}, []string{"2024015"}},
+	}
+
+	for _, tt := range tests {
+		result := ExtractSolicitationNumbers(tt.input...)
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("ExtractSolicitationNumbers(%q) = %v, want %v", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://www.Example.gov/bids/123/", "example.gov/bids/123"},
+		{"http://example.gov/bids/123#details", "example.gov/bids/123"},
+		{"https://example.gov/bid?id=5&utm_source=x&fbclid=y", "example.gov/bid?id=5"},
+		{"https://example.gov/bid?b=2&a=1", "example.gov/bid?a=1&b=2"},
+		{"not a url", ""},
+	}
+
+	for _, tt := range tests {
+		if result := CanonicalURL(tt.input); result != tt.expected {
+			t.Errorf("CanonicalURL(%q) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"Parking Management Services", "RFP - Parking Management 2024", 1, 1},
+		{"Parking Management Services", "Parking Enforcement Services", 0.3, 0.4},
+		{"Valet Services", "Janitorial Services", 0, 0},
+		{"", "Parking", 0, 0},
+	}
+
+	for _, tt := range tests {
+		result := TitleSimilarity(tt.a, tt.b)
+		if result < tt.min || result > tt.max {
+			t.Errorf("TitleSimilarity(%q, %q) = %.2f, want between %.2f and %.2f", tt.a, tt.b, result, tt.min, tt.max)
+		}
+	}
+}
+
+// failingSource is a CandidateSource that always errors.
+type failingSource struct{}
+
+func (failingSource) Candidates(ctx context.Context, c Candidate) ([]models.RFP, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestMatcher_SourceError(t *testing.T) {
+	matcher := NewSourceMatcher(failingSource{})
+
+	if _, err := matcher.CheckContext(context.Background(), Candidate{Agency: "City of Springfield"}); err == nil {
+		t.Error("CheckContext() should return the source error")
+	}
+	if result := matcher.Check(Candidate{Agency: "City of Springfield"}); result.FoundMatch {
+		t.Error("Check() should report no match when the source fails")
+	}
+}
+
+func TestCandidateQueryArgs(t *testing.T) {
+	args := candidateQueryArgs(Candidate{
+		Title:   "RFP #2024-015 Parking",
+		Agency:  "City of Springfield",
+		State:   "Illinois",
+		DueDate: "March 15, 2024",
+		Portal:  " Bonfire ",
+	})
+
+	if args[1] != "bonfire" {
+		t.Errorf("portal arg = %v, want bonfire", args[1])
+	}
+	if !reflect.DeepEqual(args[3], []string{"2024015"}) {
+		t.Errorf("solicitation arg = %v, want [2024015]", args[3])
+	}
+	if states := args[4].([]string); len(states) != 2 || states[0] != "IL" || states[1] != "ILLINOIS" {
+		t.Errorf("state arg = %v, want [IL ILLINOIS]", args[4])
+	}
+	if args[5] != "2024-03-15" {
+		t.Errorf("due date arg = %v, want 2024-03-15", args[5])
+	}
+
+	// Missing hints must not filter
+	empty := candidateQueryArgs(Candidate{})
+	if empty[5] != nil {
+		t.Errorf("due date arg = %v, want nil", empty[5])
+	}
+	if empty[3] == nil {
+		t.Error("solicitation arg should be an empty array, not nil")
+	}
+	if states, ok := empty[4].([]string); !ok || states == nil || len(states) != 0 {
+		t.Errorf("state arg = %v, want an empty array", empty[4])
+	}
+}
+
+func TestExtractHints(t *testing.T) {
+	tests := []struct {
+		name       string
+		title      string
+		snippet    string
+		url        string
+		wantAgency string
+		wantState  string
+		wantDue    string
+	}{
+		{
+			name:       "city of in title",
+			title:      "City of Austin Parking Management Services RFP",
+			snippet:    "Proposals due March 15, 2024 at 2:00 PM CST.",
+			url:        "https://www.austintexas.gov/bids/123",
+			wantAgency: "City of Austin",
+			wantDue:    "2024-03-15",
+		},
+		{
+			name:       "county suffix and state code",
+			title:      "RFP 24-117 Valet Services - Travis County, TX",
+			wantAgency: "Travis County",
+			wantState:  "TX",
+		},
+		{
+			name:      "state code beats city name",
+			title:     "Event Parking Operations - Kansas City, MO",
+			wantState: "MO",
+		},
+		{
+			name:       "state name in snippet",
+			title:      "Stadium Parking Operator",
+			snippet:    "The Harris County Sports & Convention Corporation in Texas seeks proposals. Deadline: 4/1/2024",
+			wantAgency: "Harris County",
+			wantState:  "TX",
+			wantDue:    "2024-04-01",
+		},
+		{
+			name:      "state from government host",
+			title:     "Parking Enforcement Services",
+			url:       "https://purchasing.ci.springfield.il.us/rfp/42",
+			wantState: "IL",
+		},
+		{
+			name:    "posting date is not a due date",
+			title:   "Shuttle Services",
+			snippet: "Posted January 5, 2024.",
+		},
+		{
+			name:       "university",
+			title:      "University of Florida Gameday Parking RFP",
+			snippet:    "Responses due by Friday, February 9th, 2024",
+			wantAgency: "University of Florida",
+			wantState:  "FL",
+			wantDue:    "2024-02-09",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := ExtractHints(tt.title, tt.snippet, tt.url)
+			if h.Agency != tt.wantAgency {
+				t.Errorf("Agency = %q, want %q", h.Agency, tt.wantAgency)
+			}
+			if h.State != tt.wantState {
+				t.Errorf("State = %q, want %q", h.State, tt.wantState)
+			}
+			gotDue := ""
+			if h.DueDate != nil {
+				gotDue = h.DueDate.Format("2006-01-02")
+			}
+			if gotDue != tt.wantDue {
+				t.Errorf("DueDate = %q, want %q", gotDue, tt.wantDue)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package dedup
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zachsouder/rfp/discovery/internal/normalize"
+)
+
+// Hints are the agency, state and due date guessed from a search result's
+// title, snippet and URL before any research is done. Every field may be
+// empty.
+type Hints struct {
+	Agency  string
+	State   string
+	DueDate *time.Time
+}
+
+var (
+	// "City of Austin", "County of Los Angeles", "University of Florida"
+	agencyOfPattern = regexp.MustCompile(`\b(?:City|Town|Village|County|Borough|State|Port|University|Commonwealth) of(?: (?:[A-Z][A-Za-z.'&-]*|the))+`)
+
+	// "Travis County", "Capital Metropolitan Transportation Authority"
+	agencySuffixPattern = regexp.MustCompile(`(?:\b[A-Z][A-Za-z.'&-]* )+(?:County|Parish|Authority|University|College|School District|Airport|District|Commission)\b`)
+
+	// Title words that sit next to an agency name but aren't part of it
+	agencyStopwords = map[string]bool{
+		"rfp": true, "rfq": true, "rfb": true, "rfi": true, "ifb": true, "itb": true,
+		"request": true, "bid": true, "bids": true, "invitation": true, "solicitation": true,
+		"notice": true, "proposal": true, "proposals": true, "for": true, "the": true,
+		"parking": true, "valet": true, "event": true, "shuttle": true, "enforcement": true,
+		"services": true, "management": true, "operations": true, "department": true,
+		"gameday": true, "garage": true, "lot": true, "lots": true, "transportation": true,
+		"towing": true, "citation": true, "meters": true, "purchasing": true, "procurement": true,
+	}
+
+	// ", TX" or ", Texas" after a place name
+	stateCodePattern = regexp.MustCompile(`,\s*([A-Z]{2})\b`)
+	stateNamePattern = compileStateNames()
+
+	// "ci.austin.tx.us", "dot.state.ny.us"
+	stateHostPattern = regexp.MustCompile(`\.([a-z]{2})\.us$`)
+
+	// A due date keyword followed closely by a date
+	dueDatePattern = regexp.MustCompile(`(?i)\b(?:due(?: date)?|deadline|closes|closing(?: date)?|close date|opening date|submit(?:ted)? by|responses? due|bids? due|proposals? due)\b[^0-9A-Za-z]{0,5}(?:(?:on|by|is|date)\b[^0-9A-Za-z]{0,3})?` +
+		`((?:(?:mon|tue|tues|wed|thu|thur|thurs|fri|sat|sun)[a-z]*\.?,?\s+)?` +
+		`(?:[A-Za-z]{3,9}\.?\s+\d{1,2}(?:st|nd|rd|th)?,?\s+\d{4}|\d{1,2}/\d{1,2}/\d{2,4}|\d{4}-\d{2}-\d{2}))`)
+)
+
+// stateCodes is the set of valid 2-letter state codes.
+var stateCodes = func() map[string]bool {
+	codes := make(map[string]bool, len(stateNames))
+	for _, code := range stateNames {
+		codes[code] = true
+	}
+	return codes
+}()
+
+// ExtractHints guesses an agency, state and due date from a search result
+// without calling a model. The title is preferred over the snippet, and the
+// URL is only used for the state. Due dates are only taken when a keyword
+// like "due" or "closes" precedes them, since snippets also carry posting
+// dates.
+func ExtractHints(title, snippet, rawURL string) Hints {
+	var h Hints
+
+	for _, text := range []string{title, snippet} {
+		if h.Agency == "" {
+			h.Agency = extractAgency(text)
+		}
+		if h.State == "" {
+			h.State = extractState(text)
+		}
+	}
+	if h.State == "" {
+		h.State = stateFromURL(rawURL)
+	}
+
+	for _, text := range []string{title, snippet} {
+		if m := dueDatePattern.FindStringSubmatch(text); m != nil {
+			if d := normalize.ParseDate(m[1], h.State); d != nil {
+				day := time.Date(d.Time.Year(), d.Time.Month(), d.Time.Day(), 0, 0, 0, 0, time.UTC)
+				h.DueDate = &day
+				break
+			}
+		}
+	}
+
+	return h
+}
+
+// extractAgency returns the first agency name found in text.
+func extractAgency(text string) string {
+	for _, pattern := range []*regexp.Regexp{agencyOfPattern, agencySuffixPattern} {
+		for _, m := range pattern.FindAllString(text, -1) {
+			if agency := trimAgency(m, pattern == agencyOfPattern); agency != "" {
+				return agency
+			}
+		}
+	}
+	return ""
+}
+
+// trimAgency cuts stopwords from an agency match: trailing ones for "City of
+// X" matches, leading ones for "X County" matches.
+func trimAgency(match string, trailing bool) string {
+	words := strings.Fields(match)
+	if trailing {
+		// Keep "City of"; stop at the first stopword after it
+		for i := 2; i < len(words); i++ {
+			if w := strings.ToLower(words[i]); agencyStopwords[w] && w != "the" {
+				words = words[:i]
+				break
+			}
+		}
+		if len(words) < 3 {
+			return ""
+		}
+	} else {
+		for len(words) > 1 && (agencyStopwords[strings.ToLower(words[0])] || strings.ToUpper(words[0]) == words[0] && len(words[0]) <= 4) {
+			words = words[1:]
+		}
+		if len(words) < 2 {
+			return ""
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// extractState returns the 2-letter code of the first state named in text.
+func extractState(text string) string {
+	// ", MO" is checked first so "Kansas City, MO" isn't read as Kansas
+	for _, m := range stateCodePattern.FindAllStringSubmatch(text, -1) {
+		if stateCodes[m[1]] {
+			return m[1]
+		}
+	}
+	if m := stateNamePattern.FindString(text); m != "" {
+		return NormalizeState(m)
+	}
+	return ""
+}
+
+// stateFromURL reads a state from government hostnames such as
+// "austin.tx.us" or "texas.gov".
+func stateFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+
+	if m := stateHostPattern.FindStringSubmatch(host); m != nil {
+		if code := strings.ToUpper(m[1]); stateCodes[code] {
+			return code
+		}
+	}
+
+	// A state's own domain, e.g. "www.texas.gov"
+	labels := strings.Split(strings.TrimPrefix(host, "www."), ".")
+	if len(labels) == 2 && labels[1] == "gov" {
+		for name, code := range stateNames {
+			if labels[0] == strings.ToLower(strings.ReplaceAll(name, " ", "")) {
+				return code
+			}
+		}
+	}
+	return ""
+}
+
+// compileStateNames builds a pattern matching any full state name, longest
+// first so "West Virginia" isn't read as "Virginia".
+func compileStateNames() *regexp.Regexp {
+	names := make([]string, 0, len(stateNames))
+	for name := range stateNames {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	for i, name := range names {
+		// Title case only, since state names are proper nouns in titles
+		words := strings.Fields(strings.ToLower(name))
+		for j, w := range words {
+			if w != "of" {
+				words[j] = strings.ToUpper(w[:1]) + w[1:]
+			}
+		}
+		names[i] = regexp.QuoteMeta(strings.Join(words, " "))
+	}
+	return regexp.MustCompile(`\b(?:` + strings.Join(names, "|") + `)\b`)
+}
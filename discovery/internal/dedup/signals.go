@@ -0,0 +1,233 @@
+package dedup
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// titleStopwords are words too common in RFP titles to say anything about
+// which solicitation a title refers to.
+var titleStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "for": true, "to": true,
+	"in": true, "at": true, "on": true, "by": true, "with": true, "or": true,
+	"rfp": true, "rfq": true, "rfb": true, "ifb": true, "itb": true, "bid": true, "bids": true,
+	"request": true, "requests": true, "proposal": true, "proposals": true, "qualifications": true,
+	"invitation": true, "solicitation": true, "notice": true, "services": true, "service": true,
+	"city": true, "county": true, "town": true, "village": true, "no": true,
+}
+
+var (
+	titleTokenPattern = regexp.MustCompile(`[a-z0-9]+`)
+
+	// Solicitation numbers written after a label, e.g. "RFP #2024-015" or "Bid No. 24-117"
+	labeledNumberPattern = regexp.MustCompile(`(?i)\b(?:rfp|rfq|rfb|rfi|ifb|itb|bid|solicitation|sol|project|contract)\b\s*(no\.?|number|num|#)?\s*([:#]?)\s*([a-z0-9][a-z0-9._-]*\d[a-z0-9._-]*)`)
+
+	// Identifiers mix letters and digits or separate digit groups, e.g.
+	// "PW2291" or "2024-015", unlike counts and street numbers
+	identifierShapePattern = regexp.MustCompile(`(?i)[a-z]|[a-z0-9][._-]+[a-z0-9]`)
+
+	// Bare numbers shaped like solicitation numbers, e.g. "2024-015" or "24-117"
+	bareNumberPattern = regexp.MustCompile(`\b(\d{2,4}-[a-z]{0,4}\d{2,5}(?:-[a-z0-9]+)?)\b`)
+
+	// Context that marks a nearby bare number as a solicitation number
+	numberContextPattern = regexp.MustCompile(`(?i)\b(?:rfp|rfq|rfb|rfi|ifb|itb|bid|solicitation|sol|contract)\b|\bno\.|#`)
+
+	// Shapes that are dates or fiscal years rather than solicitation
+	// numbers, e.g. "2024-03-15" or "FY 2024-2025"
+	dateShapePattern      = regexp.MustCompile(`^(?:\d{4}-\d{1,2}-\d{1,2}|\d{1,2}-\d{1,2}-\d{4})$`)
+	yearRangeShapePattern = regexp.MustCompile(`^(?:19|20)\d{2}-(?:19|20)\d{2}$`)
+
+	yearPattern = regexp.MustCompile(`^(19|20)\d{2}$`)
+
+	// Query parameters that don't change which page a URL points to
+	trackingParams = regexp.MustCompile(`^(utm_.*|fbclid|gclid|msclkid|mc_cid|mc_eid|ref|source|srsltid)$`)
+)
+
+// TitleTokens returns the distinguishing words of a title, lowercased and
+// without stopwords or solicitation numbers.
+func TitleTokens(title string) []string {
+	title = labeledNumberPattern.ReplaceAllString(title, " ")
+	title = bareNumberPattern.ReplaceAllString(strings.ToLower(title), " ")
+
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, tok := range titleTokenPattern.FindAllString(title, -1) {
+		if titleStopwords[tok] || seen[tok] {
+			continue
+		}
+		// Years say when, not what
+		if len(tok) == 4 && (strings.HasPrefix(tok, "19") || strings.HasPrefix(tok, "20")) {
+			continue
+		}
+		seen[tok] = true
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// TitleSimilarity returns the Jaccard similarity of two titles' tokens, from
+// 0 (nothing in common) to 1 (same words).
+func TitleSimilarity(a, b string) float64 {
+	ta, tb := TitleTokens(a), TitleTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(ta))
+	for _, t := range ta {
+		set[t] = true
+	}
+	shared := 0
+	for _, t := range tb {
+		if set[t] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// ExtractSolicitationNumbers finds solicitation or bid numbers in titles,
+// URLs and explicit number fields. Numbers are normalized to uppercase
+// alphanumerics so "RFP #2024-015" and "rfp-2024015" compare equal.
+func ExtractSolicitationNumbers(texts ...string) []string {
+	seen := make(map[string]bool)
+	var numbers []string
+
+	add := func(raw string) {
+		n := NormalizeSolicitationNumber(raw)
+		if n != "" && !seen[n] {
+			seen[n] = true
+			numbers = append(numbers, n)
+		}
+	}
+
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		// URLs separate words with slashes and dashes; decode escapes first
+		if decoded, err := url.PathUnescape(text); err == nil {
+			text = decoded
+		}
+		for _, m := range labeledNumberPattern.FindAllStringSubmatch(text, -1) {
+			if labeledNumber(m[1]+m[2], m[3]) {
+				add(m[3])
+			}
+		}
+		lower := strings.ToLower(text)
+		for _, m := range bareNumberPattern.FindAllStringSubmatchIndex(lower, -1) {
+			if bareNumber(lower, m[2], m[3]) {
+				add(lower[m[2]:m[3]])
+			}
+		}
+	}
+
+	sort.Strings(numbers)
+	return numbers
+}
+
+// numberContextWindow is how far before a bare number to look for a label
+// such as "RFP" or "Bid No.".
+const numberContextWindow = 24
+
+// bareNumber reports whether the unlabeled match text[start:end] is likely
+// a solicitation number: not a date or year range, and either containing
+// letters ("PW-2291") or preceded closely by a solicitation label.
+// Solicitation numbers are a decisive duplicate signal, so a bare "2024-2025"
+// shared by unrelated RFPs must not count.
+func bareNumber(text string, start, end int) bool {
+	number := text[start:end]
+	if dateOrYearRange(number) {
+		return false
+	}
+	if strings.IndexFunc(number, func(r rune) bool { return r >= 'a' && r <= 'z' }) >= 0 {
+		return true
+	}
+	from := max(0, start-numberContextWindow)
+	return numberContextPattern.MatchString(text[from:start])
+}
+
+// labeledNumber reports whether a number written after a label is likely a
+// solicitation number: not a date or year range, and shaped like an
+// identifier. Plain digits only count after an explicit "No." or "#", so
+// "RFP 1000 parking spaces" and "Project 1234 Main St" don't match.
+func labeledNumber(marker, number string) bool {
+	number = strings.TrimRight(number, "._-")
+	if dateOrYearRange(number) {
+		return false
+	}
+	return identifierShapePattern.MatchString(number) || marker != ""
+}
+
+// dateOrYearRange reports whether a number is shaped like a date or a span
+// of years.
+func dateOrYearRange(number string) bool {
+	return dateShapePattern.MatchString(number) || yearRangeShapePattern.MatchString(number)
+}
+
+// NormalizeSolicitationNumber uppercases a solicitation number and strips
+// punctuation. Returns "" for values too short or without digits to be
+// meaningful.
+func NormalizeSolicitationNumber(number string) string {
+	var b strings.Builder
+	hasDigit := false
+	for _, r := range strings.ToUpper(number) {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+			b.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+		}
+	}
+	n := b.String()
+	if !hasDigit || len(n) < 4 || yearPattern.MatchString(n) {
+		return ""
+	}
+	return n
+}
+
+// CanonicalURL normalizes a URL for equality checks: lowercase host without
+// "www.", no scheme, fragment, trailing slash or tracking parameters, and
+// sorted query parameters. Returns "" for unparseable URLs.
+func CanonicalURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := strings.TrimRight(u.EscapedPath(), "/")
+
+	query := u.Query()
+	for key := range query {
+		if trackingParams.MatchString(strings.ToLower(key)) {
+			query.Del(key)
+		}
+	}
+
+	canonical := host + path
+	if encoded := query.Encode(); encoded != "" { // Encode sorts by key
+		canonical += "?" + encoded
+	}
+	return canonical
+}
+
+// sharesAny reports whether two sorted string slices have a value in common.
+func sharesAny(a, b []string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			return true
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return false
+}
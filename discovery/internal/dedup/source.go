@@ -0,0 +1,140 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/zachsouder/rfp/shared/db"
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+// candidateLimit caps how many rows a source returns for one check.
+const candidateLimit = 200
+
+// CandidateSource returns the existing RFPs that could plausibly match a
+// candidate. Sources may over-return; the Matcher does the exact scoring.
+type CandidateSource interface {
+	Candidates(ctx context.Context, c Candidate) ([]models.RFP, error)
+}
+
+// SliceSource is an in-memory CandidateSource that returns every RFP.
+type SliceSource []models.RFP
+
+// Candidates returns all RFPs in the slice.
+func (s SliceSource) Candidates(ctx context.Context, c Candidate) ([]models.RFP, error) {
+	return s, nil
+}
+
+// PostgresSource retrieves candidates from discovery.rfps, prefiltering on
+// the indexed keys added in migration 009: exact URL, portal ID and
+// solicitation number keys, or the same state within the due date window
+// with a trigram-similar agency or title. Exact identifier matches come
+// first, since url_key ignores query strings and a busy portal can share
+// one key across many listings.
+type PostgresSource struct {
+	db db.Querier
+}
+
+// NewPostgresSource creates a CandidateSource backed by discovery.rfps.
+func NewPostgresSource(q db.Querier) *PostgresSource {
+	return &PostgresSource{db: q}
+}
+
+// Candidates queries for RFPs that share an identifier with the candidate
+// or are close enough on agency, title, state and due date to be scored.
+func (s *PostgresSource) Candidates(ctx context.Context, c Candidate) ([]models.RFP, error) {
+	args := candidateQueryArgs(c)
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, title, agency, state, due_date, source_url, portal, portal_id, solicitation_number,
+		       lifecycle_status
+		FROM discovery.rfps
+		WHERE (url_key = discovery.url_key(NULLIF($1, '')))
+		   OR (portal = NULLIF($2, '') AND portal_id = NULLIF($3, ''))
+		   OR (solicitation_key = ANY($4))
+		   OR (
+				(cardinality($5::text[]) = 0 OR state IS NULL OR UPPER(TRIM(state)) = ANY($5))
+				AND ($6::date IS NULL OR due_date IS NULL
+					OR due_date BETWEEN $6::date - $7::int AND $6::date + $7::int)
+				AND (
+					agency_key = discovery.normalize_agency(NULLIF($8, ''))
+					OR agency_key % discovery.normalize_agency(NULLIF($8, ''))
+					OR lower(title) % lower(NULLIF($9, ''))
+				)
+		   )
+		ORDER BY CASE
+				WHEN source_url = NULLIF($1, '')
+				  OR (portal = NULLIF($2, '') AND portal_id = NULLIF($3, ''))
+				  OR solicitation_key = ANY($4) THEN 0
+				WHEN url_key = discovery.url_key(NULLIF($1, '')) THEN 1
+				ELSE 2
+			END,
+			discovered_at DESC
+		LIMIT $10
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query dedup candidates failed: %w", err)
+	}
+	defer rows.Close()
+
+	var rfps []models.RFP
+	for rows.Next() {
+		var r models.RFP
+		var agency, state, sourceURL, portal, portalID, solicitation *string
+		if err := rows.Scan(&r.ID, &r.Title, &agency, &state, &r.DueDate, &sourceURL, &portal, &portalID, &solicitation,
+			&r.LifecycleStatus); err != nil {
+			return nil, fmt.Errorf("scan dedup candidate failed: %w", err)
+		}
+		r.Agency = deref(agency)
+		r.State = deref(state)
+		r.SourceURL = deref(sourceURL)
+		r.Portal = deref(portal)
+		r.PortalID = deref(portalID)
+		r.SolicitationNumber = deref(solicitation)
+		rfps = append(rfps, r)
+	}
+
+	return rfps, rows.Err()
+}
+
+// candidateQueryArgs builds the arguments for the candidate query.
+func candidateQueryArgs(c Candidate) []any {
+	// nil rather than "" so the date comparisons are skipped
+	var dueDate any
+	if d := NormalizeDate(c.DueDate); d != "" {
+		dueDate = d
+	}
+
+	numbers := ExtractSolicitationNumbers(c.SolicitationNumber, c.Title, c.URL)
+	if numbers == nil {
+		numbers = []string{}
+	}
+
+	// Stored states may be codes or full names
+	states := StateSpellings(c.State)
+	if states == nil {
+		states = []string{}
+	}
+
+	return []any{
+		c.URL,
+		strings.ToLower(strings.TrimSpace(c.Portal)),
+		strings.TrimSpace(c.PortalID),
+		numbers,
+		states,
+		dueDate,
+		DateToleranceDays,
+		c.Agency,
+		c.Title,
+		candidateLimit,
+	}
+}
+
+// deref returns the string value of a nullable column.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,179 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zachsouder/rfp/shared/db"
+)
+
+// cachedHeaders are the response headers kept with a cache entry.
+var cachedHeaders = []string{"Content-Type", "ETag", "Last-Modified"}
+
+// Entry is a cached response.
+type Entry struct {
+	URL        string      `json:"url"`
+	FinalURL   string      `json:"final_url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	FetchedAt  time.Time   `json:"fetched_at"` // last fetched or revalidated
+}
+
+// Cache stores responses keyed by requested URL.
+type Cache interface {
+	// Get returns the entry for a URL, or nil if there isn't one.
+	Get(ctx context.Context, url string) (*Entry, error)
+	Put(ctx context.Context, e *Entry) error
+}
+
+// entryFor builds a cache entry from a response.
+func entryFor(r *Response) *Entry {
+	header := make(http.Header)
+	for _, key := range cachedHeaders {
+		if v := r.Header.Get(key); v != "" {
+			header.Set(key, v)
+		}
+	}
+	return &Entry{
+		URL:        r.URL,
+		FinalURL:   r.FinalURL,
+		StatusCode: r.StatusCode,
+		Header:     header,
+		Body:       r.Body,
+		FetchedAt:  time.Now(),
+	}
+}
+
+// DiskCache stores entries as JSON files under a directory.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a cache in dir, creating the directory if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache dir failed: %w", err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get reads the entry for a URL.
+func (c *DiskCache) Get(ctx context.Context, url string) (*Entry, error) {
+	data, err := os.ReadFile(c.path(url))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("parse cache entry failed: %w", err)
+	}
+	if e.URL != url {
+		return nil, nil // hash collision
+	}
+	return &e, nil
+}
+
+// Put writes an entry, replacing any existing one atomically.
+func (c *DiskCache) Put(ctx context.Context, e *Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal cache entry failed: %w", err)
+	}
+
+	path := c.path(e.URL)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// path returns the file for a URL, fanned out by hash prefix.
+func (c *DiskCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, name[:2], name+".json")
+}
+
+// PostgresCache stores entries in discovery.fetch_cache.
+type PostgresCache struct {
+	db db.Querier
+}
+
+// NewPostgresCache creates a cache backed by discovery.fetch_cache.
+func NewPostgresCache(q db.Querier) *PostgresCache {
+	return &PostgresCache{db: q}
+}
+
+// Get reads the entry for a URL.
+func (c *PostgresCache) Get(ctx context.Context, url string) (*Entry, error) {
+	e := Entry{URL: url, Header: make(http.Header)}
+	var contentType, etag, lastModified *string
+
+	err := c.db.QueryRow(ctx, `
+		SELECT final_url, status_code, content_type, etag, last_modified, body, fetched_at
+		FROM discovery.fetch_cache
+		WHERE url = $1
+	`, url).Scan(&e.FinalURL, &e.StatusCode, &contentType, &etag, &lastModified, &e.Body, &e.FetchedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query fetch cache failed: %w", err)
+	}
+
+	for key, v := range map[string]*string{"Content-Type": contentType, "ETag": etag, "Last-Modified": lastModified} {
+		if v != nil && *v != "" {
+			e.Header.Set(key, *v)
+		}
+	}
+	return &e, nil
+}
+
+// Put upserts an entry.
+func (c *PostgresCache) Put(ctx context.Context, e *Entry) error {
+	_, err := c.db.Exec(ctx, `
+		INSERT INTO discovery.fetch_cache (url, final_url, status_code, content_type, etag, last_modified, body, fetched_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, $8)
+		ON CONFLICT (url) DO UPDATE SET
+			final_url = EXCLUDED.final_url,
+			status_code = EXCLUDED.status_code,
+			content_type = EXCLUDED.content_type,
+			etag = EXCLUDED.etag,
+			last_modified = EXCLUDED.last_modified,
+			body = EXCLUDED.body,
+			fetched_at = EXCLUDED.fetched_at
+	`, e.URL, e.FinalURL, e.StatusCode, e.Header.Get("Content-Type"), e.Header.Get("ETag"),
+		e.Header.Get("Last-Modified"), e.Body, e.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("upsert fetch cache failed: %w", err)
+	}
+	return nil
+}
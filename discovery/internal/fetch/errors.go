@@ -0,0 +1,117 @@
+package fetch
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// Kind classifies the outcome of a fetch. The values match the
+// validation.Status values they map to.
+type Kind string
+
+const (
+	KindOK                Kind = "ok"
+	KindInvalidURL        Kind = "invalid_url"
+	KindNotFound          Kind = "not_found"
+	KindClientError       Kind = "client_error"
+	KindServerError       Kind = "server_error"
+	KindConnectionFailed  Kind = "connection_failed"
+	KindConnectionRefused Kind = "connection_refused"
+	KindDNSError          Kind = "dns_error"
+	KindTimeout           Kind = "timeout"
+	KindSSLError          Kind = "ssl_error"
+	KindTooManyRedirects  Kind = "too_many_redirects"
+	KindDisallowed        Kind = "robots_disallowed"
+)
+
+// errTooManyRedirects stops a redirect chain longer than the limit.
+var errTooManyRedirects = errors.New("too many redirects")
+
+// Error is a failed fetch: the request never got an HTTP response.
+type Error struct {
+	Kind Kind
+	URL  string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("fetch %s: %s: %v", e.URL, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf classifies an error returned by a Fetcher or an http.Client.
+func KindOf(err error) Kind {
+	if err == nil {
+		return KindOK
+	}
+
+	var fe *Error
+	if errors.As(err, &fe) {
+		return fe.Kind
+	}
+
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, ErrDisallowed):
+		return KindDisallowed
+	case errors.Is(err, errTooManyRedirects):
+		return KindTooManyRedirects
+	case errors.As(err, &dnsErr):
+		return KindDNSError
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return KindConnectionRefused
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidCert), errors.As(err, &recordErr):
+		return KindSSLError
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return KindTimeout
+	}
+
+	// Fall back to the message for errors wrapped without their type
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "no such host"):
+		return KindDNSError
+	case strings.Contains(msg, "connection refused"):
+		return KindConnectionRefused
+	case strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded"):
+		return KindTimeout
+	case strings.Contains(msg, "certificate") || strings.Contains(msg, "tls") || strings.Contains(msg, "x509"):
+		return KindSSLError
+	case strings.Contains(msg, "redirect"):
+		return KindTooManyRedirects
+	}
+	return KindConnectionFailed
+}
+
+// KindForStatus classifies an HTTP status code.
+func KindForStatus(code int) Kind {
+	switch {
+	case code >= 200 && code < 300:
+		return KindOK
+	case code == http.StatusNotFound || code == http.StatusGone:
+		return KindNotFound
+	case code >= 500:
+		return KindServerError
+	default:
+		// 4xx, and 3xx responses that weren't followed
+		return KindClientError
+	}
+}
@@ -0,0 +1,618 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const robotsFile = `
+# Example robots.txt
+User-agent: *
+Disallow: /private/
+Allow: /private/bids/
+Disallow: /*.aspx$
+Crawl-delay: 2
+
+User-agent: BadBot
+User-agent: OtherBot
+Disallow: /
+`
+
+func TestParseRobots(t *testing.T) {
+	r := parseRobots(robotsFile, RobotsAgent)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/bids/123", true},
+		{"/private/hr", false},
+		{"/private/bids/42", true}, // longer Allow wins
+		{"/search.aspx", false},
+		{"/search.aspx?id=1", true}, // $ anchors the end
+	}
+	for _, tt := range tests {
+		if got := r.allowed(tt.path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if r.crawlDelay != 2*time.Second {
+		t.Errorf("crawlDelay = %v, want 2s", r.crawlDelay)
+	}
+}
+
+func TestParseRobots_SpecificAgent(t *testing.T) {
+	body := `
+User-agent: *
+Disallow: /
+
+User-agent: RFPBot
+Disallow: /admin
+`
+	r := parseRobots(body, RobotsAgent)
+	if !r.allowed("/bids") {
+		t.Error("RFPBot group should replace the * group")
+	}
+	if r.allowed("/admin/users") {
+		t.Error("/admin should be disallowed for RFPBot")
+	}
+
+	// A named group with no rules allows everything
+	r = parseRobots("User-agent: *\nDisallow: /\n\nUser-agent: rfpbot\nDisallow:\n", RobotsAgent)
+	if !r.allowed("/anything") {
+		t.Error("empty Disallow for rfpbot should allow everything")
+	}
+
+	// Groups for other bots don't apply
+	r = parseRobots("User-agent: BadBot\nDisallow: /\n", RobotsAgent)
+	if !r.allowed("/") {
+		t.Error("rules for other agents should not apply")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"Fri, 15 Mar 2024 12:01:00 GMT", time.Minute},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// testLimiter returns a limiter that applies to httptest servers.
+func testLimiter(opts ...Option) *Limiter {
+	l := NewLimiter(opts...)
+	l.exemptLoopback = false
+	return l
+}
+
+func get(t *testing.T, client *http.Client, url string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.Do(req)
+}
+
+func TestLimiter_RobotsDisallowed(t *testing.T) {
+	var pageHits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		}
+		pageHits.Add(1)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: testLimiter(WithMinInterval(0)).Transport(nil)}
+
+	_, err := get(t, client, server.URL+"/private/page")
+	if !errors.Is(err, ErrDisallowed) {
+		t.Errorf("expected ErrDisallowed, got %v", err)
+	}
+
+	resp, err := get(t, client, server.URL+"/public")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if n := pageHits.Load(); n != 1 {
+		t.Errorf("expected 1 page request, got %d", n)
+	}
+}
+
+func TestLimiter_Spacing(t *testing.T) {
+	var mu sync.Mutex
+	var starts []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	interval := 100 * time.Millisecond
+	client := &http.Client{Transport: testLimiter(WithMinInterval(interval), WithMaxPerHost(3)).Transport(nil)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := get(t, client, server.URL+"/page")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if len(starts) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(starts))
+	}
+	first, last := starts[0], starts[0]
+	for _, s := range starts {
+		if s.Before(first) {
+			first = s
+		}
+		if s.After(last) {
+			last = s
+		}
+	}
+	// Allow some scheduling slack below 2 intervals
+	if gap := last.Sub(first); gap < 2*interval-20*time.Millisecond {
+		t.Errorf("3 requests spanned %v, want at least %v", gap, 2*interval)
+	}
+}
+
+func TestLimiter_MaxPerHost(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		inFlight.Add(-1)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: testLimiter(WithMinInterval(0), WithMaxPerHost(2)).Transport(nil)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := get(t, client, server.URL+"/page")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", p)
+	}
+}
+
+func TestLimiter_RetryAfter(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Retry-After: 0 falls back to exponential backoff, capped here so the
+	// test doesn't wait out the real minimum
+	client := &http.Client{Transport: testLimiter(WithMinInterval(0), WithMaxBackoff(50*time.Millisecond)).Transport(nil)}
+
+	start := time.Now()
+	resp, err := get(t, client, server.URL+"/page")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected retried request to succeed, got %d", resp.StatusCode)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", hits.Load())
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("retry did not back off, took %v", elapsed)
+	}
+}
+
+func TestLimiter_GivesUpAfterMaxRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: testLimiter(WithMinInterval(0), WithMaxRetries(1), WithMaxBackoff(10*time.Millisecond)).Transport(nil)}
+
+	resp, err := get(t, client, server.URL+"/page")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 after retries, got %d", resp.StatusCode)
+	}
+}
+
+func TestLimiter_ExemptsLoopback(t *testing.T) {
+	var robotsHits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsHits.Add(1)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewLimiter().Transport(nil)}
+	resp, err := get(t, client, server.URL+"/page")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if robotsHits.Load() != 0 {
+		t.Error("loopback hosts should not be checked against robots.txt")
+	}
+}
+
+// testFetcher returns a fetcher with no politeness delays.
+func testFetcher(t *testing.T, cfg Config) *Fetcher {
+	t.Helper()
+	if cfg.Limiter == nil {
+		cfg.Limiter = NewLimiter(WithMinInterval(0))
+	}
+	f, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return f
+}
+
+func TestFetcher_RevalidatesCachedPage(t *testing.T) {
+	var hits, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<p>Request for Proposals</p>"))
+	}))
+	defer server.Close()
+
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := testFetcher(t, Config{Cache: cache})
+	ctx := context.Background()
+
+	first, err := f.Get(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.FromCache {
+		t.Error("first fetch should not come from the cache")
+	}
+
+	second, err := f.Get(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !second.FromCache {
+		t.Error("revalidated fetch should come from the cache")
+	}
+	if second.StatusCode != http.StatusOK || string(second.Body) != string(first.Body) {
+		t.Errorf("cached response = %d %q, want 200 %q", second.StatusCode, second.Body, first.Body)
+	}
+	if second.ContentType() != "text/html" {
+		t.Errorf("ContentType() = %q, want text/html", second.ContentType())
+	}
+	if hits.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("hits = %d, 304s = %d, want 2 and 1", hits.Load(), notModified.Load())
+	}
+
+	// NoCache skips the conditional headers
+	third, err := f.Do(ctx, Request{URL: server.URL, NoCache: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if third.FromCache || notModified.Load() != 1 {
+		t.Error("NoCache request should not be revalidated")
+	}
+}
+
+func TestFetcher_DoesNotCacheWithoutValidator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("no validators"))
+	}))
+	defer server.Close()
+
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := testFetcher(t, Config{Cache: cache})
+
+	if _, err := f.Get(context.Background(), server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e, err := cache.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != nil {
+		t.Error("response without ETag or Last-Modified should not be cached")
+	}
+}
+
+func TestFetcher_Truncates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	f := testFetcher(t, Config{})
+	resp, err := f.Do(context.Background(), Request{URL: server.URL, MaxBytes: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.Body) != "0123" || !resp.Truncated {
+		t.Errorf("body = %q truncated = %v, want \"0123\" true", resp.Body, resp.Truncated)
+	}
+}
+
+func TestFetcher_Redirects(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, server.URL+"/b", http.StatusFound)
+		case "/b":
+			http.Redirect(w, r, server.URL+"/c", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, server.URL+"/loop", http.StatusFound)
+		default:
+			w.Write([]byte("done"))
+		}
+	}))
+	defer server.Close()
+
+	f := testFetcher(t, Config{MaxRedirects: 3})
+	ctx := context.Background()
+
+	resp, err := f.Get(ctx, server.URL+"/a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Redirects != 2 || resp.FinalURL != server.URL+"/c" {
+		t.Errorf("redirects = %d final = %s, want 2 and /c", resp.Redirects, resp.FinalURL)
+	}
+
+	_, err = f.Get(ctx, server.URL+"/loop")
+	if KindOf(err) != KindTooManyRedirects {
+		t.Errorf("KindOf(%v) = %s, want %s", err, KindOf(err), KindTooManyRedirects)
+	}
+}
+
+// recordingArchiver records archived responses and can be set to fail or
+// to hang until its context ends.
+type recordingArchiver struct {
+	mu       sync.Mutex
+	archived []string
+	err      error
+	hang     bool
+}
+
+func (a *recordingArchiver) Archive(ctx context.Context, res *Response) (string, error) {
+	if a.hang {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	if a.err != nil {
+		return "", a.err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.archived = append(a.archived, string(res.Body))
+	return fmt.Sprintf("snap-%d", len(a.archived)), nil
+}
+
+func TestFetcher_Archives(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+
+	archiver := &recordingArchiver{}
+	f := testFetcher(t, Config{Archiver: archiver})
+	resp, err := f.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.SnapshotKey != "snap-1" || len(archiver.archived) != 1 || archiver.archived[0] != "page" {
+		t.Errorf("key = %q archived = %v, want snap-1 [page]", resp.SnapshotKey, archiver.archived)
+	}
+
+	// An archive failure doesn't fail the fetch
+	archiver.err = errors.New("storage unavailable")
+	resp, err = f.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.SnapshotKey != "" {
+		t.Errorf("key = %q after failed archive, want empty", resp.SnapshotKey)
+	}
+}
+
+func TestFetcher_ArchiveTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+
+	// Stalled storage delays the page by at most ArchiveTimeout
+	f := testFetcher(t, Config{Archiver: &recordingArchiver{hang: true}, ArchiveTimeout: 50 * time.Millisecond})
+	start := time.Now()
+	resp, err := f.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.Body) != "page" || resp.SnapshotKey != "" {
+		t.Errorf("body = %q key = %q, want page without a snapshot", resp.Body, resp.SnapshotKey)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("fetch took %v waiting on the archiver", elapsed)
+	}
+}
+
+func TestFetcher_InvalidURL(t *testing.T) {
+	f := testFetcher(t, Config{})
+	for _, u := range []string{"not-a-url", "ftp://example.com/file", "://bad"} {
+		_, err := f.Get(context.Background(), u)
+		if KindOf(err) != KindInvalidURL {
+			t.Errorf("Get(%q): KindOf = %s, want %s", u, KindOf(err), KindInvalidURL)
+		}
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Kind
+	}{
+		{nil, KindOK},
+		{&Error{Kind: KindDNSError, Err: errors.New("x")}, KindDNSError},
+		{fmt.Errorf("get: %w", ErrDisallowed), KindDisallowed},
+		{context.DeadlineExceeded, KindTimeout},
+		{&net.DNSError{Err: "no such host", Name: "nope.invalid"}, KindDNSError},
+		{errors.New("dial tcp: connect: connection refused"), KindConnectionRefused},
+		{errors.New("x509: certificate signed by unknown authority"), KindSSLError},
+		{errors.New("connection reset by peer"), KindConnectionFailed},
+	}
+	for _, tt := range tests {
+		if got := KindOf(tt.err); got != tt.want {
+			t.Errorf("KindOf(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestKindForStatus(t *testing.T) {
+	tests := []struct {
+		code int
+		want Kind
+	}{
+		{200, KindOK},
+		{204, KindOK},
+		{304, KindClientError},
+		{403, KindClientError},
+		{404, KindNotFound},
+		{410, KindNotFound},
+		{500, KindServerError},
+		{503, KindServerError},
+	}
+	for _, tt := range tests {
+		if got := KindForStatus(tt.code); got != tt.want {
+			t.Errorf("KindForStatus(%d) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestDiskCache_RoundTrip(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	e, err := cache.Get(ctx, "https://example.gov/bids")
+	if err != nil || e != nil {
+		t.Fatalf("Get on empty cache = %v, %v; want nil, nil", e, err)
+	}
+
+	want := &Entry{
+		URL:        "https://example.gov/bids",
+		FinalURL:   "https://example.gov/bids/",
+		StatusCode: 200,
+		Header:     http.Header{"Etag": []string{`"abc"`}},
+		Body:       []byte("<html>bids</html>"),
+		FetchedAt:  time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC),
+	}
+	if err := cache.Put(ctx, want); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, err := cache.Get(ctx, want.URL)
+	if err != nil || got == nil {
+		t.Fatalf("Get = %v, %v", got, err)
+	}
+	if got.FinalURL != want.FinalURL || string(got.Body) != string(want.Body) ||
+		got.Header.Get("ETag") != `"abc"` || !got.FetchedAt.Equal(want.FetchedAt) {
+		t.Errorf("Get = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,416 @@
+package fetch
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUserAgent identifies our crawler to agency websites.
+const DefaultUserAgent = "Mozilla/5.0 (compatible; RFPBot/1.0)"
+
+// Accept headers for the kinds of documents we fetch.
+const (
+	AcceptHTML = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	AcceptPDF  = "application/pdf,*/*"
+)
+
+// Config holds the settings shared by every fetch.
+type Config struct {
+	// UserAgent is sent with every request. Default: DefaultUserAgent.
+	UserAgent string
+
+	// ProxyURL routes requests through an HTTP proxy. Empty uses the
+	// standard HTTP_PROXY/HTTPS_PROXY environment variables.
+	ProxyURL string
+
+	// CAFile adds PEM root certificates, for agency sites whose chains
+	// aren't in the system pool.
+	CAFile string
+
+	// MinTLSVersion is the lowest TLS version accepted. Default: TLS 1.2.
+	MinTLSVersion uint16
+
+	// Timeout bounds a whole request, including reading the body.
+	// Default: 15 seconds.
+	Timeout time.Duration
+
+	// ConnectTimeout bounds dialing. Default: 10 seconds.
+	ConnectTimeout time.Duration
+
+	// MaxRedirects is the longest redirect chain followed. Default: 10.
+	MaxRedirects int
+
+	// MaxBodyBytes caps how much of a body Get reads. Default: 512KB.
+	MaxBodyBytes int64
+
+	// Cache stores responses for conditional revalidation. Nil disables
+	// caching.
+	Cache Cache
+
+	// Limiter applies robots.txt and per-host limits. Default:
+	// DefaultLimiter().
+	Limiter *Limiter
+
+	// Archiver keeps a snapshot of every page read with Do. Nil disables
+	// archiving.
+	Archiver Archiver
+
+	// ArchiveTimeout bounds how long Do waits on the archiver before
+	// returning the page without a snapshot. Default: 10 seconds.
+	ArchiveTimeout time.Duration
+}
+
+// Archiver stores a copy of a fetched page and returns the key it can be
+// retrieved by.
+type Archiver interface {
+	Archive(ctx context.Context, res *Response) (key string, err error)
+}
+
+// DefaultConfig returns a Config with sensible defaults and no cache.
+func DefaultConfig() Config {
+	return Config{
+		UserAgent:      DefaultUserAgent,
+		MinTLSVersion:  tls.VersionTLS12,
+		Timeout:        15 * time.Second,
+		ConnectTimeout: 10 * time.Second,
+		MaxRedirects:   10,
+		MaxBodyBytes:   512 * 1024,
+		ArchiveTimeout: 10 * time.Second,
+	}
+}
+
+// Fetcher performs polite, cached HTTP GETs with consistent error
+// classification. It's safe for concurrent use.
+type Fetcher struct {
+	cfg    Config
+	client *http.Client
+}
+
+// New creates a Fetcher. Zero fields in cfg take their defaults.
+func New(cfg Config) (*Fetcher, error) {
+	def := DefaultConfig()
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = def.UserAgent
+	}
+	if cfg.MinTLSVersion == 0 {
+		cfg.MinTLSVersion = def.MinTLSVersion
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = def.Timeout
+	}
+	if cfg.ConnectTimeout == 0 {
+		cfg.ConnectTimeout = def.ConnectTimeout
+	}
+	if cfg.MaxRedirects == 0 {
+		cfg.MaxRedirects = def.MaxRedirects
+	}
+	if cfg.MaxBodyBytes == 0 {
+		cfg.MaxBodyBytes = def.MaxBodyBytes
+	}
+	if cfg.ArchiveTimeout == 0 {
+		cfg.ArchiveTimeout = def.ArchiveTimeout
+	}
+	if cfg.Limiter == nil {
+		cfg.Limiter = DefaultLimiter()
+	}
+
+	tlsConfig := &tls.Config{MinVersion: cfg.MinTLSVersion}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file failed: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	transport := &http.Transport{
+		Proxy:           proxy,
+		TLSClientConfig: tlsConfig,
+		DialContext: (&net.Dialer{
+			Timeout: cfg.ConnectTimeout,
+		}).DialContext,
+		MaxIdleConns:          50,
+		MaxIdleConnsPerHost:   defaultMaxPerHost,
+		IdleConnTimeout:       30 * time.Second,
+		TLSHandshakeTimeout:   cfg.ConnectTimeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+	}
+
+	return &Fetcher{
+		cfg: cfg,
+		client: &http.Client{
+			Transport: cfg.Limiter.Transport(transport),
+			Timeout:   cfg.Timeout,
+		},
+	}, nil
+}
+
+var (
+	defaultFetcher   *Fetcher
+	defaultFetcherMu sync.Mutex
+)
+
+// Default returns the process-wide Fetcher, created with DefaultConfig
+// unless SetDefault installed another.
+func Default() *Fetcher {
+	defaultFetcherMu.Lock()
+	defer defaultFetcherMu.Unlock()
+
+	if defaultFetcher == nil {
+		f, err := New(DefaultConfig())
+		if err != nil {
+			// DefaultConfig has no files or URLs to fail on
+			panic(err)
+		}
+		defaultFetcher = f
+	}
+	return defaultFetcher
+}
+
+// SetDefault replaces the process-wide Fetcher. Call it at startup, before
+// creating the validator, research agent and other fetching services.
+func SetDefault(f *Fetcher) {
+	defaultFetcherMu.Lock()
+	defaultFetcher = f
+	defaultFetcherMu.Unlock()
+}
+
+// Request describes one fetch. Zero fields take the Fetcher's defaults.
+type Request struct {
+	URL      string
+	Accept   string        // default: AcceptHTML
+	MaxBytes int64         // body cap for Get
+	Timeout  time.Duration // whole-request timeout
+	NoCache  bool          // bypass the response cache
+}
+
+// Response is a fetched page.
+type Response struct {
+	URL        string // as requested
+	FinalURL   string // after redirects
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Truncated  bool // body was cut at MaxBytes
+	Redirects  int
+	FromCache  bool // body came from the cache after a 304
+	Duration   time.Duration
+
+	// SnapshotKey locates the archived copy of the page, when an archiver
+	// is configured and archiving succeeded.
+	SnapshotKey string
+}
+
+// Kind classifies the response's status code.
+func (r *Response) Kind() Kind {
+	return KindForStatus(r.StatusCode)
+}
+
+// ContentType returns the response's media type, lowercased and without
+// parameters.
+func (r *Response) ContentType() string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Type")))
+	}
+	return mediaType
+}
+
+// Get fetches a URL with default settings.
+func (f *Fetcher) Get(ctx context.Context, rawURL string) (*Response, error) {
+	return f.Do(ctx, Request{URL: rawURL})
+}
+
+// Do fetches a page and reads up to MaxBytes of its body. Cached pages are
+// revalidated with If-None-Match / If-Modified-Since, and a 304 returns the
+// cached body. HTTP error statuses are returned as responses; the error is
+// only set, as an *Error, when no response was received.
+func (f *Fetcher) Do(ctx context.Context, r Request) (*Response, error) {
+	start := time.Now()
+	maxBytes := r.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = f.cfg.MaxBodyBytes
+	}
+
+	var cached *Entry
+	if f.cfg.Cache != nil && !r.NoCache {
+		var err error
+		if cached, err = f.cfg.Cache.Get(ctx, r.URL); err != nil {
+			slog.Warn("fetch cache read failed", "url", r.URL, "error", err)
+			cached = nil
+		}
+	}
+
+	resp, redirects, err := f.send(ctx, r, cached)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	res := &Response{
+		URL:        r.URL,
+		FinalURL:   resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Redirects:  redirects,
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		res.StatusCode = cached.StatusCode
+		res.FinalURL = cached.FinalURL
+		res.Header = cached.Header.Clone()
+		res.Body = cached.Body
+		res.FromCache = true
+		if int64(len(res.Body)) > maxBytes {
+			res.Body, res.Truncated = res.Body[:maxBytes], true
+		}
+		res.Duration = time.Since(start)
+
+		cached.FetchedAt = time.Now()
+		f.store(ctx, cached)
+		f.archive(ctx, res)
+		return res, nil
+	}
+
+	// Read one byte past the cap to tell a full body from a truncated one
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, &Error{Kind: KindOf(err), URL: r.URL, Err: err}
+	}
+	if int64(len(body)) > maxBytes {
+		body, res.Truncated = body[:maxBytes], true
+	}
+	res.Body = body
+	res.Duration = time.Since(start)
+
+	if f.cfg.Cache != nil && !r.NoCache && cacheable(res) {
+		f.store(ctx, entryFor(res))
+	}
+	f.archive(ctx, res)
+
+	return res, nil
+}
+
+// archive snapshots a response when an archiver is configured, giving up
+// after ArchiveTimeout so slow storage can't hold up the fetch. Failures
+// are logged and don't fail the fetch.
+func (f *Fetcher) archive(ctx context.Context, res *Response) {
+	if f.cfg.Archiver == nil || len(res.Body) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.ArchiveTimeout)
+	defer cancel()
+	key, err := f.cfg.Archiver.Archive(ctx, res)
+	if err != nil {
+		slog.Warn("page snapshot failed", "url", res.URL, "error", err)
+		return
+	}
+	res.SnapshotKey = key
+}
+
+// Open starts a GET and returns the response with its body unread, for
+// large downloads. Responses aren't cached. The caller must close the body.
+func (f *Fetcher) Open(ctx context.Context, r Request) (*http.Response, error) {
+	resp, _, err := f.send(ctx, r, nil)
+	return resp, err
+}
+
+// send performs the request, adding conditional headers for a cached entry.
+func (f *Fetcher) send(ctx context.Context, r Request, cached *Entry) (*http.Response, int, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		if err == nil {
+			err = fmt.Errorf("not an absolute http(s) URL")
+		}
+		return nil, 0, &Error{Kind: KindInvalidURL, URL: r.URL, Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return nil, 0, &Error{Kind: KindInvalidURL, URL: r.URL, Err: err}
+	}
+
+	accept := r.Accept
+	if accept == "" {
+		accept = AcceptHTML
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	req.Header.Set("Accept", accept)
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+
+	if cached != nil {
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lm := cached.Header.Get("Last-Modified"); lm != "" {
+			req.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	// Per-request copy so redirects can be counted and the timeout varied
+	redirects := 0
+	client := *f.client
+	if r.Timeout > 0 {
+		client.Timeout = r.Timeout
+	}
+	client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if len(via) > f.cfg.MaxRedirects {
+			return errTooManyRedirects
+		}
+		redirects = len(via)
+		return nil
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, redirects, &Error{Kind: KindOf(err), URL: r.URL, Err: err}
+	}
+	return resp, redirects, nil
+}
+
+// store writes an entry to the cache, logging failures.
+func (f *Fetcher) store(ctx context.Context, e *Entry) {
+	if err := f.cfg.Cache.Put(ctx, e); err != nil {
+		slog.Warn("fetch cache write failed", "url", e.URL, "error", err)
+	}
+}
+
+// cacheable reports whether a response is worth caching: a complete 200
+// with a validator to revalidate against.
+func cacheable(r *Response) bool {
+	if r.StatusCode != http.StatusOK || r.Truncated {
+		return false
+	}
+	if strings.Contains(strings.ToLower(r.Header.Get("Cache-Control")), "no-store") {
+		return false
+	}
+	return r.Header.Get("ETag") != "" || r.Header.Get("Last-Modified") != ""
+}
@@ -0,0 +1,416 @@
+// Package fetch is the shared HTTP layer for validation, research, lifecycle
+// checks and PDF downloads: one client configuration, robots.txt and
+// per-host rate limits, a revalidating response cache, and consistent error
+// classification.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// RobotsAgent is the user-agent token matched against robots.txt groups.
+	RobotsAgent = "rfpbot"
+
+	defaultMaxPerHost  = 2
+	defaultMinInterval = 1 * time.Second
+	defaultRobotsTTL   = 24 * time.Hour
+	defaultMaxRetries  = 2
+	defaultMaxBackoff  = 5 * time.Minute
+
+	minBackoff     = 5 * time.Second
+	maxCrawlDelay  = 30 * time.Second
+	robotsErrorTTL = 1 * time.Hour
+	robotsTimeout  = 10 * time.Second
+	maxRobotsBytes = 512 * 1024 // 512KB
+	maxDrainBytes  = 64 * 1024  // read before closing a response that's retried
+)
+
+// ErrDisallowed is returned for requests that robots.txt doesn't allow.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// Limiter enforces per-host politeness: robots.txt, a cap on concurrent
+// requests, a minimum gap between request starts, and backoff after 429 and
+// 503 responses. It's safe for concurrent use and meant to be shared.
+type Limiter struct {
+	maxPerHost  int
+	minInterval time.Duration
+	robotsTTL   time.Duration
+	maxRetries  int
+	maxBackoff  time.Duration
+
+	// Loopback hosts (local services, test servers) aren't limited
+	exemptLoopback bool
+
+	robotsClient *http.Client
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithMaxPerHost sets how many requests may be in flight to one host.
+func WithMaxPerHost(n int) Option {
+	return func(l *Limiter) {
+		l.maxPerHost = n
+	}
+}
+
+// WithMinInterval sets the minimum time between request starts to one host.
+// A longer robots.txt Crawl-delay takes precedence.
+func WithMinInterval(d time.Duration) Option {
+	return func(l *Limiter) {
+		l.minInterval = d
+	}
+}
+
+// WithRobotsTTL sets how long a host's robots.txt is cached.
+func WithRobotsTTL(d time.Duration) Option {
+	return func(l *Limiter) {
+		l.robotsTTL = d
+	}
+}
+
+// WithMaxRetries sets how many times a request is retried after a 429 or
+// 503 response.
+func WithMaxRetries(n int) Option {
+	return func(l *Limiter) {
+		l.maxRetries = n
+	}
+}
+
+// WithMaxBackoff caps how long a host is paused after a 429 or 503,
+// including waits requested by Retry-After.
+func WithMaxBackoff(d time.Duration) Option {
+	return func(l *Limiter) {
+		l.maxBackoff = d
+	}
+}
+
+// NewLimiter creates a Limiter. Most callers should use DefaultLimiter so
+// limits are shared across the process.
+func NewLimiter(opts ...Option) *Limiter {
+	l := &Limiter{
+		maxPerHost:     defaultMaxPerHost,
+		minInterval:    defaultMinInterval,
+		robotsTTL:      defaultRobotsTTL,
+		maxRetries:     defaultMaxRetries,
+		maxBackoff:     defaultMaxBackoff,
+		exemptLoopback: true,
+		robotsClient:   &http.Client{Timeout: robotsTimeout},
+		hosts:          make(map[string]*host),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.maxPerHost < 1 {
+		l.maxPerHost = 1
+	}
+	return l
+}
+
+var (
+	defaultLimiter     *Limiter
+	defaultLimiterOnce sync.Once
+)
+
+// DefaultLimiter returns the process-wide Limiter.
+func DefaultLimiter() *Limiter {
+	defaultLimiterOnce.Do(func() {
+		defaultLimiter = NewLimiter()
+	})
+	return defaultLimiter
+}
+
+// Transport wraps base so every request it sends, including redirects, goes
+// through the limiter. A nil base uses http.DefaultTransport.
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{limiter: l, base: base}
+}
+
+type transport struct {
+	limiter *Limiter
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := t.limiter
+	if l.exempt(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	h := l.host(req.URL.Scheme, req.URL.Host)
+
+	if !l.allowed(ctx, h, req.URL.RequestURI()) {
+		return nil, fmt.Errorf("%w: %s", ErrDisallowed, req.URL.Redacted())
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := h.acquire(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			h.release()
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+			h.succeeded()
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: h.release}
+			return resp, nil
+		}
+
+		wait := h.backOff(resp.Header.Get("Retry-After"), l.maxBackoff)
+		if attempt >= l.maxRetries || !canRetry(req, wait) {
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: h.release}
+			return resp, nil
+		}
+
+		// Free the connection and slot; acquire waits out the backoff
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+		resp.Body.Close()
+		h.release()
+	}
+}
+
+// exempt reports whether a request skips the limiter.
+func (l *Limiter) exempt(req *http.Request) bool {
+	if !l.exemptLoopback {
+		return false
+	}
+	hostname := req.URL.Hostname()
+	if hostname == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
+
+// host returns the state for a scheme and host, creating it on first use.
+func (l *Limiter) host(scheme, hostport string) *host {
+	key := strings.ToLower(scheme + "://" + hostport)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h, ok := l.hosts[key]
+	if !ok {
+		h = &host{
+			base:     key,
+			slots:    make(chan struct{}, l.maxPerHost),
+			interval: l.minInterval,
+		}
+		l.hosts[key] = h
+	}
+	return h
+}
+
+// allowed checks a path against the host's robots.txt, fetching it if the
+// cached copy is missing or stale.
+func (l *Limiter) allowed(ctx context.Context, h *host, path string) bool {
+	h.robotsMu.Lock()
+	defer h.robotsMu.Unlock()
+
+	if h.robots == nil || time.Now().After(h.robotsExpires) {
+		rules, ttl := l.fetchRobots(ctx, h.base)
+		h.robots = rules
+		h.robotsExpires = time.Now().Add(ttl)
+		h.setCrawlDelay(rules.crawlDelay, l.minInterval)
+	}
+
+	return h.robots.allowed(path)
+}
+
+// fetchRobots downloads and parses a host's robots.txt, returning the rules
+// and how long to cache them. Missing files allow everything. Errors also
+// allow everything but are cached briefly so they're retried soon. These
+// requests aren't counted against the host's limits; there's one a day.
+func (l *Limiter) fetchRobots(ctx context.Context, base string) (*robots, time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, robotsTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/robots.txt", nil)
+	if err != nil {
+		return &robots{}, robotsErrorTTL
+	}
+	req.Header.Set("User-Agent", DefaultUserAgent)
+
+	resp, err := l.robotsClient.Do(req)
+	if err != nil {
+		return &robots{}, robotsErrorTTL
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsBytes))
+		if err != nil {
+			return &robots{}, robotsErrorTTL
+		}
+		return parseRobots(string(body), RobotsAgent), l.robotsTTL
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests:
+		return &robots{}, l.robotsTTL
+	default:
+		return &robots{}, robotsErrorTTL
+	}
+}
+
+// host is the politeness state for one scheme and host.
+type host struct {
+	base  string        // scheme://host[:port]
+	slots chan struct{} // one token per in-flight request
+
+	mu       sync.Mutex
+	next     time.Time     // earliest start for the next request
+	interval time.Duration // minimum gap between request starts
+	backoff  time.Duration // current 429/503 backoff, 0 when healthy
+
+	robotsMu      sync.Mutex
+	robots        *robots
+	robotsExpires time.Time
+}
+
+// acquire waits for a free slot and the host's next start time.
+func (h *host) acquire(ctx context.Context) error {
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	h.mu.Lock()
+	start := h.next
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+	h.next = start.Add(h.interval)
+	h.mu.Unlock()
+
+	wait := time.Until(start)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		h.release()
+		return ctx.Err()
+	}
+}
+
+func (h *host) release() {
+	<-h.slots
+}
+
+// succeeded resets the backoff after a normal response.
+func (h *host) succeeded() {
+	h.mu.Lock()
+	h.backoff = 0
+	h.mu.Unlock()
+}
+
+// backOff pauses the host after a 429 or 503, for the Retry-After duration
+// if one was given or an exponential backoff otherwise, capped at max.
+// Returns the pause.
+func (h *host) backOff(retryAfter string, max time.Duration) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	wait := parseRetryAfter(retryAfter, time.Now())
+	if wait <= 0 {
+		if h.backoff == 0 {
+			h.backoff = minBackoff
+		} else {
+			h.backoff *= 2
+		}
+		wait = h.backoff
+	}
+	if wait > max {
+		wait = max
+	}
+	if h.backoff > max {
+		h.backoff = max
+	}
+
+	if until := time.Now().Add(wait); until.After(h.next) {
+		h.next = until
+	}
+	return wait
+}
+
+// setCrawlDelay raises the host's interval to a robots.txt Crawl-delay.
+func (h *host) setCrawlDelay(delay, min time.Duration) {
+	if delay > maxCrawlDelay {
+		delay = maxCrawlDelay
+	}
+	h.mu.Lock()
+	h.interval = min
+	if delay > min {
+		h.interval = delay
+	}
+	h.mu.Unlock()
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date.
+// Returns 0 if it's missing or unparseable.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// canRetry reports whether a request can be resent after waiting: it must
+// have no body to replay, and the wait must end before its deadline.
+func canRetry(req *http.Request, wait time.Duration) bool {
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	if deadline, ok := req.Context().Deadline(); ok && time.Now().Add(wait).After(deadline) {
+		return false
+	}
+	return true
+}
+
+// releasingBody frees the host slot when the response body is closed, so
+// slow downloads count against the host's concurrency.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,137 @@
+package fetch
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robots is the part of a robots.txt file that applies to us.
+type robots struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	length  int // pattern length; the longest matching rule wins
+	pattern *regexp.Regexp
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// parseRobots parses a robots.txt file and keeps the groups for agent,
+// falling back to the "*" groups if none name it.
+func parseRobots(body, agent string) *robots {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	inAgents := false // consecutive User-agent lines share a group
+
+	for _, line := range strings.Split(body, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			continue
+		case "allow", "disallow":
+			// An empty Disallow allows everything, which is the default
+			if current != nil && value != "" {
+				current.rules = append(current.rules, robotsRule{
+					allow:   key == "allow",
+					length:  len(value),
+					pattern: robotsPattern(value),
+				})
+			}
+		case "crawl-delay":
+			if current != nil {
+				if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+					current.crawlDelay = time.Duration(secs * float64(time.Second))
+				}
+			}
+		}
+		inAgents = false
+	}
+
+	r := &robots{}
+	for _, wildcard := range []bool{false, true} {
+		matched := false
+		for _, g := range groups {
+			if g.matches(agent, wildcard) {
+				matched = true
+				r.rules = append(r.rules, g.rules...)
+				if g.crawlDelay > r.crawlDelay {
+					r.crawlDelay = g.crawlDelay
+				}
+			}
+		}
+		if matched {
+			break
+		}
+	}
+	return r
+}
+
+// matches reports whether a group names agent, or is a "*" group when
+// wildcard is set.
+func (g *robotsGroup) matches(agent string, wildcard bool) bool {
+	for _, a := range g.agents {
+		if wildcard && a == "*" {
+			return true
+		}
+		if !wildcard && a != "*" && a != "" && strings.HasPrefix(agent, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether a path (with query) may be fetched. The longest
+// matching rule wins, and Allow wins ties.
+func (r *robots) allowed(path string) bool {
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > best || (rule.length == best && rule.allow) {
+			best, allow = rule.length, rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsPattern compiles a robots.txt path pattern, where "*" matches any
+// sequence and a trailing "$" anchors the end.
+func robotsPattern(p string) *regexp.Regexp {
+	anchored := strings.HasSuffix(p, "$")
+	p = strings.TrimSuffix(p, "$")
+
+	parts := strings.Split(p, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
@@ -0,0 +1,283 @@
+// Package geo geocodes RFP locations offline against a US place gazetteer
+// kept in discovery.places. The gazetteer is loaded from the Census Bureau's
+// Gazetteer places file, or from the small bundled set of major cities and
+// state capitals; places that aren't found fall back to the state's
+// geographic center.
+package geo
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zachsouder/rfp/discovery/internal/dedup"
+	"github.com/zachsouder/rfp/shared/db"
+)
+
+// Precision says how closely coordinates locate an RFP.
+const (
+	PrecisionPlace = "place" // the city or town
+	PrecisionState = "state" // the state's geographic center
+)
+
+// earthRadiusMiles is the mean radius of the Earth.
+const earthRadiusMiles = 3958.8
+
+//go:embed data/places.tsv
+var bundled string
+
+// Place is a gazetteer entry.
+type Place struct {
+	State     string
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// Point is a geocoded location.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+	Precision string
+}
+
+// Querier runs a single-row query; *db.DB and pgx.Tx implement it.
+type Querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Bundled returns the places that ship with the binary.
+func Bundled() ([]Place, error) {
+	return ParseGazetteer(strings.NewReader(bundled))
+}
+
+// ParseGazetteer reads a tab-separated gazetteer with a header row naming
+// USPS, NAME, INTPTLAT and INTPTLONG columns, as in the Census Bureau's
+// Gazetteer files. Other columns are ignored.
+func ParseGazetteer(r io.Reader) ([]Place, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("gazetteer is empty")
+	}
+	cols := make(map[string]int)
+	for i, h := range strings.Split(sc.Text(), "\t") {
+		cols[strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	for _, want := range []string{"USPS", "NAME", "INTPTLAT", "INTPTLONG"} {
+		if _, ok := cols[want]; !ok {
+			return nil, fmt.Errorf("gazetteer header has no %s column", want)
+		}
+	}
+
+	var places []Place
+	line := 1
+	for sc.Scan() {
+		line++
+		fields := strings.Split(sc.Text(), "\t")
+		if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+		get := func(col string) string {
+			if i := cols[col]; i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+		lat, err := strconv.ParseFloat(get("INTPTLAT"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude: %w", line, err)
+		}
+		lon, err := strconv.ParseFloat(get("INTPTLONG"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude: %w", line, err)
+		}
+		places = append(places, Place{
+			State:     strings.ToUpper(get("USPS")),
+			Name:      get("NAME"),
+			Latitude:  lat,
+			Longitude: lon,
+		})
+	}
+	return places, sc.Err()
+}
+
+// Load replaces the gazetteer with places. Returns the number loaded.
+func Load(ctx context.Context, database *db.DB, places []Place) (int, error) {
+	var n int64
+	err := database.WithTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM discovery.places`); err != nil {
+			return fmt.Errorf("clear places failed: %w", err)
+		}
+		var err error
+		n, err = tx.CopyFrom(ctx,
+			pgx.Identifier{"discovery", "places"},
+			[]string{"state", "name", "name_key", "latitude", "longitude"},
+			pgx.CopyFromSlice(len(places), func(i int) ([]any, error) {
+				p := places[i]
+				return []any{p.State, p.Name, Key(p.Name), p.Latitude, p.Longitude}, nil
+			}),
+		)
+		if err != nil {
+			return fmt.Errorf("copy places failed: %w", err)
+		}
+		return nil
+	})
+	return int(n), err
+}
+
+// Geocode locates a city in a state, using the agency's name when the city
+// is empty ("City of Tempe"). The state may be a code or a full name. It
+// falls back to the state's center and returns nil when the state is
+// unknown.
+func Geocode(ctx context.Context, q Querier, city, state, agency string) (*Point, error) {
+	state = dedup.NormalizeState(state)
+	if state == "" {
+		return nil, nil
+	}
+
+	name := city
+	if strings.TrimSpace(name) == "" {
+		name = PlaceFromAgency(agency)
+	}
+	if key := Key(name); key != "" {
+		var p Point
+		err := q.QueryRow(ctx, `
+			SELECT latitude, longitude FROM discovery.places
+			WHERE state = $1 AND name_key = $2
+			ORDER BY id
+			LIMIT 1
+		`, state, key).Scan(&p.Latitude, &p.Longitude)
+		if err == nil {
+			p.Precision = PrecisionPlace
+			return &p, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("look up place failed: %w", err)
+		}
+	}
+
+	if c, ok := stateCenters[state]; ok {
+		return &Point{Latitude: c[0], Longitude: c[1], Precision: PrecisionState}, nil
+	}
+	return nil, nil
+}
+
+// Distance returns the great-circle distance in miles between two points.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMiles * math.Asin(math.Sqrt(a))
+}
+
+// lsadSuffixes are the legal/statistical area descriptions the Census
+// appends to place names, longest first.
+var lsadSuffixes = []string{
+	" consolidated government (balance)",
+	" metropolitan government (balance)",
+	" unified government (balance)",
+	" metro government (balance)",
+	" city and borough",
+	" urban county",
+	" (balance)",
+	" municipality",
+	" township",
+	" borough",
+	" village",
+	" city",
+	" town",
+	" cdp",
+}
+
+// placePrefixes are the forms of government agencies put before a place name.
+var placePrefixes = []string{"city of ", "town of ", "village of ", "borough of ", "township of "}
+
+// abbreviations expands common abbreviations at the start of place names.
+var abbreviations = map[string]string{"st": "saint", "ste": "sainte", "ft": "fort", "mt": "mount"}
+
+// Key normalizes a place name for matching: "St. Paul city" and
+// "Saint Paul" both become "saint paul". Every "city" suffix is dropped, so
+// "Oklahoma City city" and "Oklahoma City" match too.
+func Key(name string) string {
+	s := strings.ToLower(strings.TrimSpace(name))
+
+	// Consolidated city-counties are named for both, as in
+	// "Nashville-Davidson metropolitan government (balance)"; keep the city
+	consolidated := strings.HasSuffix(s, "(balance)") || strings.HasSuffix(s, " urban county")
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, suffix := range lsadSuffixes {
+			if strings.HasSuffix(s, suffix) && len(s) > len(suffix) {
+				s = strings.TrimSuffix(s, suffix)
+				trimmed = true
+			}
+		}
+	}
+	for _, prefix := range placePrefixes {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	if i := strings.IndexAny(s, "/"); i > 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexAny(s, "-"); consolidated && i > 0 {
+		s = s[:i]
+	}
+	s = strings.NewReplacer(".", " ", "'", "", "-", " ", ",", " ").Replace(s)
+
+	words := strings.Fields(s)
+	if len(words) > 1 {
+		if full, ok := abbreviations[words[0]]; ok {
+			words[0] = full
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// agencyPlace matches agencies named for their municipality.
+var agencyPlace = regexp.MustCompile(`(?i)^(?:the\s+)?(?:city|town|village|borough|township)\s+of\s+([a-z .'-]+?)(?:\s*[,(]|\s+-|$)`)
+
+// PlaceFromAgency returns the municipality an agency is named for, such as
+// "Tempe" for "City of Tempe, Arizona", or "" if it isn't.
+func PlaceFromAgency(agency string) string {
+	m := agencyPlace.FindStringSubmatch(strings.TrimSpace(agency))
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(m[1])
+}
+
+// stateCenters are the geographic centers of the states and DC.
+var stateCenters = map[string][2]float64{
+	"AL": {32.7794, -86.8287}, "AK": {64.0685, -152.2782}, "AZ": {34.2744, -111.6602},
+	"AR": {34.8938, -92.4426}, "CA": {37.1841, -119.4696}, "CO": {38.9972, -105.5478},
+	"CT": {41.6219, -72.7273}, "DE": {38.9896, -75.5050}, "DC": {38.9101, -77.0147},
+	"FL": {28.6305, -82.4497}, "GA": {32.6415, -83.4426}, "HI": {20.2927, -156.3737},
+	"ID": {44.3509, -114.6130}, "IL": {40.0417, -89.1965}, "IN": {39.8942, -86.2816},
+	"IA": {42.0751, -93.4960}, "KS": {38.4937, -98.3804}, "KY": {37.5347, -85.3021},
+	"LA": {31.0689, -91.9968}, "ME": {45.3695, -69.2428}, "MD": {39.0550, -76.7909},
+	"MA": {42.2596, -71.8083}, "MI": {44.3467, -85.4102}, "MN": {46.2807, -94.3053},
+	"MS": {32.7364, -89.6678}, "MO": {38.3566, -92.4580}, "MT": {47.0527, -109.6333},
+	"NE": {41.5378, -99.7951}, "NV": {39.3289, -116.6312}, "NH": {43.6805, -71.5811},
+	"NJ": {40.1907, -74.6728}, "NM": {34.4071, -106.1126}, "NY": {42.9538, -75.5268},
+	"NC": {35.5557, -79.3877}, "ND": {47.4501, -100.4659}, "OH": {40.2862, -82.7937},
+	"OK": {35.5889, -97.4943}, "OR": {43.9336, -120.5583}, "PA": {40.8781, -77.7996},
+	"RI": {41.6762, -71.5562}, "SC": {33.9169, -80.8964}, "SD": {44.4443, -100.2263},
+	"TN": {35.8580, -86.3505}, "TX": {31.4757, -99.3312}, "UT": {39.3055, -111.6703},
+	"VT": {44.0687, -72.6658}, "VA": {37.5215, -78.8537}, "WA": {47.3826, -120.4472},
+	"WV": {38.6409, -80.6227}, "WI": {44.6243, -89.9941}, "WY": {42.9957, -107.5512},
+}
@@ -0,0 +1,111 @@
+package geo
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Austin city", "austin"},
+		{"Austin", "austin"},
+		{"St. Paul city", "saint paul"},
+		{"Saint Paul", "saint paul"},
+		{"Oklahoma City city", "oklahoma"},
+		{"Oklahoma City", "oklahoma"},
+		{"Boise City city", "boise"},
+		{"Boise", "boise"},
+		{"Winston-Salem city", "winston salem"},
+		{"Nashville-Davidson metropolitan government (balance)", "nashville"},
+		{"Louisville/Jefferson County metro government (balance)", "louisville"},
+		{"Lexington-Fayette urban county", "lexington"},
+		{"Indianapolis city (balance)", "indianapolis"},
+		{"Honolulu CDP", "honolulu"},
+		{"City of Tempe", "tempe"},
+		{"Ft. Collins", "fort collins"},
+		{"Coeur d'Alene city", "coeur dalene"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Key(tt.name); got != tt.want {
+			t.Errorf("Key(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlaceFromAgency(t *testing.T) {
+	tests := []struct {
+		agency string
+		want   string
+	}{
+		{"City of Tempe", "Tempe"},
+		{"City of Tempe, Arizona", "Tempe"},
+		{"The City of San Diego (Purchasing)", "San Diego"},
+		{"Town of Cary - Procurement", "Cary"},
+		{"Denver International Airport", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := PlaceFromAgency(tt.agency); got != tt.want {
+			t.Errorf("PlaceFromAgency(%q) = %q, want %q", tt.agency, got, tt.want)
+		}
+	}
+}
+
+func TestParseGazetteer(t *testing.T) {
+	// Census files have extra columns and a padded last header
+	data := "USPS\tGEOID\tANSICODE\tNAME\tLSAD\tFUNCSTAT\tALAND\tAWATER\tALAND_SQMI\tAWATER_SQMI\tINTPTLAT\tINTPTLONG                                                                                                               \n" +
+		"TX\t4805000\t02409761\tAustin city\t25\tA\t830000000\t20000000\t320.4\t7.7\t30.306936\t-97.752922\n" +
+		"\n"
+	places, err := ParseGazetteer(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseGazetteer() error = %v", err)
+	}
+	if len(places) != 1 || places[0].State != "TX" || places[0].Name != "Austin city" || places[0].Longitude != -97.752922 {
+		t.Errorf("ParseGazetteer() = %+v", places)
+	}
+
+	if _, err := ParseGazetteer(strings.NewReader("STATE\tCITY\n")); err == nil {
+		t.Error("ParseGazetteer() without required columns should fail")
+	}
+}
+
+func TestBundled(t *testing.T) {
+	places, err := Bundled()
+	if err != nil {
+		t.Fatalf("Bundled() error = %v", err)
+	}
+	if len(places) < 100 {
+		t.Errorf("Bundled() has %d places", len(places))
+	}
+	capitals := 0
+	for _, p := range places {
+		if _, ok := stateCenters[p.State]; !ok {
+			t.Errorf("bundled place %q has unknown state %q", p.Name, p.State)
+		}
+		if c := stateCenters[p.State]; Distance(p.Latitude, p.Longitude, c[0], c[1]) > 1500 {
+			t.Errorf("bundled place %s, %s is far from its state", p.Name, p.State)
+		}
+		if p.State == "TX" && Key(p.Name) == "austin" {
+			capitals++
+		}
+	}
+	if capitals != 1 {
+		t.Errorf("found Austin, TX %d times", capitals)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// Austin to Dallas is about 182 miles as the crow flies
+	d := Distance(30.2672, -97.7431, 32.7767, -96.7970)
+	if math.Abs(d-182) > 5 {
+		t.Errorf("Distance(Austin, Dallas) = %.1f, want about 182", d)
+	}
+	if d := Distance(30.2672, -97.7431, 30.2672, -97.7431); d != 0 {
+		t.Errorf("Distance to itself = %v", d)
+	}
+}
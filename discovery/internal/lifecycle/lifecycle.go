@@ -0,0 +1,127 @@
+// Package lifecycle tracks whether discovered RFPs are still open by
+// rechecking their source pages for closure, cancellation, and award wording.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
+	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+const maxBodyReadBytes = 512 * 1024 // 512KB
+
+// CheckResult contains the outcome of rechecking an RFP source page.
+type CheckResult struct {
+	// Status is the detected lifecycle status, or empty if the page gave no
+	// signal and the current status should be kept.
+	Status   string `json:"status,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	HTTPCode int    `json:"http_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Changed reports whether the check produced a new lifecycle status.
+func (r *CheckResult) Changed(current string) bool {
+	return r.Status != "" && r.Status != current
+}
+
+// Checker rechecks RFP source pages.
+type Checker struct {
+	fetcher *fetch.Fetcher
+}
+
+// NewChecker creates a new lifecycle checker using the shared fetcher.
+func NewChecker() *Checker {
+	return &Checker{
+		fetcher: fetch.Default(),
+	}
+}
+
+// Check fetches the source page and looks for lifecycle wording.
+// Network errors leave Status empty so a flaky host does not close an RFP.
+func (c *Checker) Check(ctx context.Context, sourceURL string) *CheckResult {
+	resp, err := c.fetcher.Do(ctx, fetch.Request{URL: sourceURL, MaxBytes: maxBodyReadBytes})
+	if err != nil {
+		return &CheckResult{Error: err.Error()}
+	}
+
+	result := &CheckResult{HTTPCode: resp.StatusCode}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		// The page was removed; we can't tell whether the RFP closed or moved
+		result.Status = models.LifecycleUnknown
+		result.Reason = fmt.Sprintf("Source page removed (HTTP %d)", resp.StatusCode)
+		return result
+	case resp.StatusCode >= 400:
+		result.Error = fmt.Sprintf("HTTP error %d", resp.StatusCode)
+		return result
+	}
+
+	status, phrase := DetectStatus(research.HTMLToText(string(resp.Body)))
+	if status != "" {
+		result.Status = status
+		result.Reason = fmt.Sprintf("Source page says %q", phrase)
+	} else {
+		result.Status = models.LifecycleOpen
+	}
+
+	return result
+}
+
+// Lifecycle wording patterns. They are anchored on procurement nouns (optionally
+// followed by a solicitation number) or status labels so that boilerplate like
+// "the contract may be cancelled for convenience" does not trigger a change.
+var (
+	cancelledPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(solicitation|rfp|rfq|rfb|ifb|bid|opportunity|procurement|project)(\s+(no\.\s*)?#?[\w-]*\d[\w-]*)?\s+(has\s+been\s+|was\s+|is\s+)?(cancell?ed|rescinded|withdrawn)\b`),
+		regexp.MustCompile(`(?i)\bstatus\s*:?\s*(cancell?ed|rescinded|withdrawn)\b`),
+		regexp.MustCompile(`(?i)\bnotice\s+of\s+cancell?ation\b`),
+	}
+
+	awardedPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bstatus\s*:?\s*awarded\b`),
+		// A notice of award only counts once it has gone out; solicitations
+		// routinely describe the one they will issue
+		regexp.MustCompile(`(?i)\b(notice\s+of|intent\s+to)\s+award\s+(has\s+been\s+|was\s+)?(issued|posted|published)\b`),
+		regexp.MustCompile(`(?i)\b(has|have)\s+(issued|posted|published)\s+(a|an|its|the)?\s*(notice\s+of\s+)?(intent\s+to\s+)?award\b`),
+		regexp.MustCompile(`(?i)\b(contract|solicitation|rfp|rfq|bid)\s+(has\s+been\s+|was\s+)?awarded\s+to\b`),
+	}
+
+	closedPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bstatus\s*:?\s*closed\b`),
+		regexp.MustCompile(`(?i)\b(solicitation|rfp|rfq|rfb|ifb|bid|opportunity)\s+(has\s+|is\s+)?(now\s+)?closed\b`),
+		regexp.MustCompile(`(?i)\bno\s+longer\s+accepting\s+(bids|proposals|submissions|responses|quotes)\b`),
+		regexp.MustCompile(`(?i)\b(submission|response|bid)\s+(deadline|period)\s+has\s+(passed|ended|expired)\b`),
+	}
+)
+
+// DetectStatus looks for closure, cancellation, or award wording in page text.
+// It returns the detected status and the matched phrase, or empty strings if
+// the page gives no signal. Cancellation wins over award, and award over closure.
+func DetectStatus(text string) (string, string) {
+	checks := []struct {
+		status   string
+		patterns []*regexp.Regexp
+	}{
+		{models.LifecycleCancelled, cancelledPatterns},
+		{models.LifecycleAwarded, awardedPatterns},
+		{models.LifecycleClosed, closedPatterns},
+	}
+
+	for _, check := range checks {
+		for _, pattern := range check.patterns {
+			if match := pattern.FindString(text); match != "" {
+				return check.status, strings.Join(strings.Fields(match), " ")
+			}
+		}
+	}
+
+	return "", ""
+}
@@ -0,0 +1,109 @@
+package lifecycle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+func TestDetectStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		status string
+	}{
+		{"open page", "Request for Proposals: Valet Parking Services. Proposals due March 15.", ""},
+		{"cancelled solicitation", "NOTICE: This solicitation has been cancelled.", models.LifecycleCancelled},
+		{"canceled spelling", "RFP 2024-15 was canceled by the agency", models.LifecycleCancelled},
+		{"status cancelled", "Status: Cancelled", models.LifecycleCancelled},
+		{"notice of cancellation", "Notice of Cancellation - Parking Management", models.LifecycleCancelled},
+		{"termination boilerplate", "The contract may be cancelled for convenience by the City.", ""},
+		{"status awarded", "Bid Status: Awarded", models.LifecycleAwarded},
+		{"intent to award", "Notice of Intent to Award issued 4/2/2024", models.LifecycleAwarded},
+		{"notice of award posted", "Notice of Award has been posted for RFP 24-117.", models.LifecycleAwarded},
+		{"has issued intent to award", "The City has issued a notice of intent to award to ABC Parking.", models.LifecycleAwarded},
+		{"award boilerplate", "A Notice of Award will be issued to the successful proposer.", ""},
+		{"intent boilerplate", "The City intends to issue a Notice of Intent to Award within 30 days.", ""},
+		{"awarded to", "The contract was awarded to ABC Parking LLC.", models.LifecycleAwarded},
+		{"status closed", "Status: Closed", models.LifecycleClosed},
+		{"bid closed", "This bid is now closed.", models.LifecycleClosed},
+		{"no longer accepting", "We are no longer accepting proposals for this project.", models.LifecycleClosed},
+		{"deadline passed", "The submission deadline has passed.", models.LifecycleClosed},
+		{"cancelled beats closed", "Status: Closed. This solicitation has been cancelled.", models.LifecycleCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, phrase := DetectStatus(tt.text)
+			if status != tt.status {
+				t.Errorf("DetectStatus(%q) = %q, want %q", tt.text, status, tt.status)
+			}
+			if status != "" && phrase == "" {
+				t.Errorf("DetectStatus(%q) returned status without phrase", tt.text)
+			}
+		})
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantStatus string
+		wantError  bool
+	}{
+		{"open page", http.StatusOK, "<html><body><h1>RFP: Parking Operations</h1><p>Due May 1</p></body></html>", models.LifecycleOpen, false},
+		{"cancelled page", http.StatusOK, "<html><body><p>This RFP has been cancelled.</p></body></html>", models.LifecycleCancelled, false},
+		{"removed page", http.StatusNotFound, "", models.LifecycleUnknown, false},
+		{"gone page", http.StatusGone, "", models.LifecycleUnknown, false},
+		{"server error", http.StatusInternalServerError, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			result := NewChecker().Check(context.Background(), server.URL)
+
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", result.Status, tt.wantStatus)
+			}
+			if (result.Error != "") != tt.wantError {
+				t.Errorf("Error = %q, wantError %v", result.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestChecker_Check_ConnectionError(t *testing.T) {
+	result := NewChecker().Check(context.Background(), "http://127.0.0.1:1/rfp")
+
+	if result.Status != "" {
+		t.Errorf("Expected no status change on connection error, got %q", result.Status)
+	}
+	if result.Error == "" {
+		t.Error("Expected error to be set")
+	}
+}
+
+func TestCheckResult_Changed(t *testing.T) {
+	r := &CheckResult{Status: models.LifecycleOpen}
+	if r.Changed(models.LifecycleOpen) {
+		t.Error("Expected no change for same status")
+	}
+	if !r.Changed(models.LifecycleUnknown) {
+		t.Error("Expected change from unknown to open")
+	}
+	if (&CheckResult{}).Changed(models.LifecycleOpen) {
+		t.Error("Expected no change for empty status")
+	}
+}
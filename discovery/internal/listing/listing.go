@@ -0,0 +1,224 @@
+// Package listing enumerates the opportunities linked from a portal listing
+// page, such as an agency's page of open bids, so each can be validated and
+// researched on its own.
+package listing
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zachsouder/rfp/discovery/internal/classify"
+	"github.com/zachsouder/rfp/discovery/internal/dedup"
+	"github.com/zachsouder/rfp/discovery/internal/portal"
+	"golang.org/x/net/html"
+)
+
+const (
+	maxContextLength = 500 // characters of row text kept per entry
+	maxTitleLength   = 200
+)
+
+// Entry is one opportunity linked from a listing page.
+type Entry struct {
+	URL         string
+	Title       string
+	Context     string     // text of the table row or list item holding the link
+	ClosingDate *time.Time // from the row text, when visible
+}
+
+// genericLinkText is anchor text that says nothing about the opportunity;
+// the row text is used as the title instead.
+var genericLinkText = map[string]bool{
+	"view": true, "details": true, "view details": true, "more": true, "more info": true,
+	"click here": true, "open": true, "bid details": true, "view bid": true, "view opportunity": true,
+	"view solicitation": true, "read more": true, "learn more": true,
+}
+
+// Links on non-portal pages that look like a single solicitation: a
+// procurement word in the path plus a number, or an ID query parameter.
+var (
+	detailPathPattern = regexp.MustCompile(`(?i)/(bids?|rfps?|rfqs?|solicitations?|opportunit(y|ies)|procurements?|projects?|tenders?)[/_-].*\d`)
+	detailIDParams    = []string{"bidid", "bid_id", "solicitationid", "rfpid", "opportunityid", "projectid", "sourceeventid"}
+)
+
+// cardClassPattern matches the classes of div-based listing rows.
+var cardClassPattern = regexp.MustCompile(`(?i)(row|item|card|result|listing|opportunity)`)
+
+// Extract finds the opportunity links on a listing page. baseURL is the
+// page's final URL, used to resolve relative links. Links are returned in
+// page order without duplicates.
+func Extract(baseURL string, body []byte) ([]Entry, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	seen := map[string]bool{stripFragment(base): true}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			if e, ok := entryFor(base, n); ok && !seen[e.URL] {
+				seen[e.URL] = true
+				entries = append(entries, e)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return entries, nil
+}
+
+// Filter keeps the entries whose title or row text matches the scope
+// taxonomy, i.e. the parking-related ones.
+func Filter(entries []Entry) []Entry {
+	var kept []Entry
+	for _, e := range entries {
+		if classify.Classify(e.Title, e.Context).Category != "" {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// entryFor builds an entry from a link, if it points to an opportunity.
+func entryFor(base *url.URL, a *html.Node) (Entry, bool) {
+	href := strings.TrimSpace(attr(a, "href"))
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") ||
+		strings.HasPrefix(strings.ToLower(href), "mailto:") {
+		return Entry{}, false
+	}
+	ref, err := base.Parse(href)
+	if err != nil || (ref.Scheme != "http" && ref.Scheme != "https") {
+		return Entry{}, false
+	}
+	if !isOpportunity(base, ref) {
+		return Entry{}, false
+	}
+
+	context := truncate(text(container(a)), maxContextLength)
+	title := text(a)
+	if title == "" {
+		title = attr(a, "title")
+	}
+	if len(title) < 4 || genericLinkText[strings.ToLower(strings.Trim(title, " .:>»"))] {
+		title = context
+	}
+
+	e := Entry{
+		URL:     stripFragment(ref),
+		Title:   truncate(title, maxTitleLength),
+		Context: context,
+	}
+	e.ClosingDate = dedup.ExtractHints("", context, "").DueDate
+	return e, true
+}
+
+// isOpportunity reports whether a link points to a single opportunity:
+// a portal opportunity URL, or a same-site link shaped like a detail page.
+func isOpportunity(base, ref *url.URL) bool {
+	if m := portal.Parse(ref.String()); m != nil {
+		return m.Page == portal.PageOpportunity
+	}
+	if !strings.EqualFold(ref.Hostname(), base.Hostname()) {
+		return false
+	}
+	if strings.HasSuffix(strings.ToLower(ref.Path), ".pdf") {
+		return false
+	}
+	if detailPathPattern.MatchString(ref.Path) {
+		return true
+	}
+	for key := range ref.Query() {
+		for _, param := range detailIDParams {
+			if strings.EqualFold(key, param) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// container returns the table row, list item or card holding a link,
+// falling back to its parent element.
+func container(a *html.Node) *html.Node {
+	for n := a.Parent; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch n.Data {
+		case "tr", "li", "article", "dd":
+			return n
+		case "div":
+			if cardClassPattern.MatchString(attr(n, "class")) {
+				return n
+			}
+		case "table", "ul", "ol", "body":
+			// Left the listing structure without finding a row
+			if a.Parent != nil {
+				return a.Parent
+			}
+			return a
+		}
+	}
+	return a
+}
+
+// text returns the collapsed text content of a node.
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			b.WriteByte(' ')
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style"):
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func stripFragment(u *url.URL) string {
+	c := *u
+	c.Fragment = ""
+	c.RawFragment = ""
+	return c.String()
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := strings.LastIndex(s[:n], " ")
+	if cut < n/2 {
+		cut = n
+	}
+	return strings.TrimSpace(s[:cut]) + "..."
+}
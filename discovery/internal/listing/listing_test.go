@@ -0,0 +1,102 @@
+package listing
+
+import (
+	"testing"
+)
+
+const bidTable = `<html><body>
+<h1>Open Bids</h1>
+<table>
+  <tr><th>Title</th><th>Closes</th><th></th></tr>
+  <tr>
+    <td>RFP 24-031 Parking Management Services</td>
+    <td>Closing Date: 03/15/2024</td>
+    <td><a href="/opportunities/123456">View</a></td>
+  </tr>
+  <tr>
+    <td><a href="https://cityofaustin.bonfirehub.com/opportunities/123457#docs">Janitorial Services for City Hall</a></td>
+    <td>Closing Date: 03/20/2024</td>
+  </tr>
+  <tr>
+    <td><a href="/opportunities/123456">RFP 24-031 Parking Management Services</a></td>
+  </tr>
+</table>
+<a href="/portal/?tab=pastOpportunities">Past opportunities</a>
+<a href="mailto:purchasing@austintexas.gov">Contact</a>
+</body></html>`
+
+func TestExtract_Portal(t *testing.T) {
+	entries, err := Extract("https://cityofaustin.bonfirehub.com/portal/?tab=openOpportunities", []byte(bidTable))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+
+	first := entries[0]
+	if first.URL != "https://cityofaustin.bonfirehub.com/opportunities/123456" {
+		t.Errorf("URL = %q", first.URL)
+	}
+	if first.Title != "RFP 24-031 Parking Management Services Closing Date: 03/15/2024 View" {
+		t.Errorf("generic link text should fall back to the row, got title %q", first.Title)
+	}
+	if first.ClosingDate == nil || first.ClosingDate.Format("2006-01-02") != "2024-03-15" {
+		t.Errorf("ClosingDate = %v, want 2024-03-15", first.ClosingDate)
+	}
+
+	second := entries[1]
+	if second.URL != "https://cityofaustin.bonfirehub.com/opportunities/123457" {
+		t.Errorf("fragment should be stripped, got %q", second.URL)
+	}
+	if second.Title != "Janitorial Services for City Hall" {
+		t.Errorf("Title = %q", second.Title)
+	}
+}
+
+func TestExtract_AgencySite(t *testing.T) {
+	body := `<ul>
+  <li class="bid"><a href="bids/2024-017-parking-garage-operations">2024-017 Parking Garage Operations</a> Due: April 2, 2024</li>
+  <li><a href="/bids/archive">Archived bids</a></li>
+  <li><a href="/bids/2024-017.pdf">Specifications (PDF)</a></li>
+  <li><a href="https://other.gov/bids/2024-1">Partner agency bid</a></li>
+  <li><a href="/procurement/view.aspx?BidID=88">Shuttle Service RFQ</a></li>
+</ul>`
+	entries, err := Extract("https://www.springfield.gov/purchasing/", []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"https://www.springfield.gov/purchasing/bids/2024-017-parking-garage-operations",
+		"https://www.springfield.gov/procurement/view.aspx?BidID=88",
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, u := range want {
+		if entries[i].URL != u {
+			t.Errorf("entries[%d].URL = %q, want %q", i, entries[i].URL, u)
+		}
+	}
+	if entries[0].ClosingDate == nil || entries[0].ClosingDate.Format("2006-01-02") != "2024-04-02" {
+		t.Errorf("ClosingDate = %v, want 2024-04-02", entries[0].ClosingDate)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	entries := []Entry{
+		{Title: "RFP 24-031 Parking Management Services"},
+		{Title: "Janitorial Services for City Hall"},
+		{Title: "RFQ 24-040", Context: "RFQ 24-040 Valet services for the convention center"},
+		{Title: "Roof Replacement", Context: "Roof replacement at the parking authority offices"},
+	}
+
+	kept := Filter(entries)
+	if len(kept) != 2 {
+		t.Fatalf("kept %d entries, want 2: %+v", len(kept), kept)
+	}
+	if kept[0].Title != "RFP 24-031 Parking Management Services" || kept[1].Title != "RFQ 24-040" {
+		t.Errorf("kept = %+v", kept)
+	}
+}
@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	geminiTimeout = 30 * time.Second
+)
+
+// Gemini calls the Gemini API with structured JSON output.
+type Gemini struct {
+	apiKey     string
+	model      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewGemini creates a Gemini provider for a model.
+func NewGemini(apiKey, model string) *Gemini {
+	return &Gemini{
+		apiKey:  apiKey,
+		model:   model,
+		baseURL: geminiBaseURL,
+		httpClient: &http.Client{
+			Timeout: geminiTimeout,
+		},
+	}
+}
+
+// WithBaseURL points the provider at a different API endpoint.
+func (g *Gemini) WithBaseURL(baseURL string) *Gemini {
+	g.baseURL = baseURL
+	return g
+}
+
+// Name returns the model spec.
+func (g *Gemini) Name() string {
+	return ProviderGemini + ":" + g.model
+}
+
+type geminiRequest struct {
+	Contents         []geminiContent        `json:"contents"`
+	GenerationConfig geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiContent struct {
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature      float64         `json:"temperature"`
+	ResponseMimeType string          `json:"responseMimeType,omitempty"`
+	ResponseSchema   json.RawMessage `json:"responseSchema,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates    []geminiCandidate    `json:"candidates"`
+	UsageMetadata *geminiUsageMetadata `json:"usageMetadata,omitempty"`
+}
+
+type geminiCandidate struct {
+	Content *geminiContent `json:"content,omitempty"`
+}
+
+type geminiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+}
+
+// GenerateJSON makes a Gemini API call with structured JSON output.
+func (g *Gemini) GenerateJSON(ctx context.Context, prompt string, schema json.RawMessage) (string, int, error) {
+	endpoint := fmt.Sprintf("%s/models/%s:generateContent?key=%s", g.baseURL, g.model, g.apiKey)
+
+	reqBody := geminiRequest{
+		Contents: []geminiContent{
+			{Parts: []geminiPart{{Text: prompt}}},
+		},
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:      0.1,
+			ResponseMimeType: "application/json",
+			ResponseSchema:   schema,
+		},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("http request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("API error (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+
+	var geminiResp geminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		return "", 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	tokens := 0
+	if geminiResp.UsageMetadata != nil {
+		tokens = geminiResp.UsageMetadata.PromptTokenCount + geminiResp.UsageMetadata.CandidatesTokenCount
+	}
+
+	if len(geminiResp.Candidates) == 0 || geminiResp.Candidates[0].Content == nil {
+		return "{}", tokens, nil
+	}
+
+	var text string
+	for _, part := range geminiResp.Candidates[0].Content.Parts {
+		text += part.Text
+	}
+
+	return text, tokens, nil
+}
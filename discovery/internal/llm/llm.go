@@ -0,0 +1,81 @@
+// Package llm provides the language model backends used for structured
+// extraction: Gemini, and any server with an OpenAI-compatible
+// chat-completions API, such as llama.cpp or Ollama for offline work.
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Provider names used in model specs.
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+)
+
+// DefaultModel is the model used when a spec is empty.
+const DefaultModel = ProviderGemini + ":gemini-3-flash-preview"
+
+// Provider generates JSON from a prompt.
+type Provider interface {
+	// GenerateJSON returns the model's JSON answer to a prompt, constrained
+	// to schema where the backend supports it, and the tokens used.
+	GenerateJSON(ctx context.Context, prompt string, schema json.RawMessage) (string, int, error)
+
+	// Name identifies the backend and model, e.g. "gemini:gemini-3-flash-preview".
+	Name() string
+}
+
+// Settings holds the credentials and endpoints backends are created with.
+type Settings struct {
+	GeminiAPIKey  string
+	OpenAIBaseURL string // Default: DefaultOpenAIBaseURL
+	OpenAIAPIKey  string // optional for local servers
+}
+
+// New creates a provider from a model spec of the form "provider:model",
+// e.g. "gemini:gemini-3-flash-preview" or "openai:qwen2.5:14b". A spec
+// without a known provider prefix is a Gemini model name.
+func New(spec string, s Settings) (Provider, error) {
+	provider, model := ParseSpec(spec)
+	switch provider {
+	case ProviderGemini:
+		if s.GeminiAPIKey == "" {
+			return nil, fmt.Errorf("GEMINI_API_KEY not set (needed for model %s)", spec)
+		}
+		return NewGemini(s.GeminiAPIKey, model), nil
+	case ProviderOpenAI:
+		return NewOpenAI(s.OpenAIBaseURL, s.OpenAIAPIKey, model), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (want gemini or openai)", provider)
+	}
+}
+
+// ParseSpec splits a model spec into provider and model.
+func ParseSpec(spec string) (provider, model string) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = DefaultModel
+	}
+	if p, m, ok := strings.Cut(spec, ":"); ok && (p == ProviderGemini || p == ProviderOpenAI) {
+		return p, m
+	}
+	return ProviderGemini, spec
+}
+
+// stripCodeFence removes a markdown code fence around a JSON answer, which
+// local models add even when asked for JSON only.
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	text = strings.TrimPrefix(text, "```")
+	if nl := strings.IndexByte(text, '\n'); nl != -1 {
+		text = text[nl+1:] // drop the language tag line
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+}
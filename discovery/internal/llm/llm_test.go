@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testSchema = json.RawMessage(`{"type": "object", "properties": {"title": {"type": "string"}}}`)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		spec, provider, model string
+	}{
+		{"", ProviderGemini, "gemini-3-flash-preview"},
+		{"gemini:gemini-2.5-pro", ProviderGemini, "gemini-2.5-pro"},
+		{"openai:qwen2.5:14b", ProviderOpenAI, "qwen2.5:14b"},
+		{"gemini-2.5-flash", ProviderGemini, "gemini-2.5-flash"},
+	}
+	for _, tt := range tests {
+		provider, model := ParseSpec(tt.spec)
+		if provider != tt.provider || model != tt.model {
+			t.Errorf("ParseSpec(%q) = %q, %q, want %q, %q", tt.spec, provider, model, tt.provider, tt.model)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("gemini:gemini-2.5-pro", Settings{}); err == nil {
+		t.Error("New(gemini) without an API key: want error")
+	}
+
+	p, err := New("openai:llama3.1", Settings{})
+	if err != nil {
+		t.Fatalf("New(openai): %v", err)
+	}
+	if p.Name() != "openai:llama3.1" {
+		t.Errorf("Name() = %q, want openai:llama3.1", p.Name())
+	}
+	if o := p.(*OpenAI); o.baseURL != DefaultOpenAIBaseURL {
+		t.Errorf("baseURL = %q, want %q", o.baseURL, DefaultOpenAIBaseURL)
+	}
+}
+
+func TestGemini_GenerateJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-test:generateContent" || r.URL.Query().Get("key") != "k" {
+			t.Errorf("request to %s", r.URL)
+		}
+		var req geminiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if req.GenerationConfig.ResponseMimeType != "application/json" || len(req.GenerationConfig.ResponseSchema) == 0 {
+			t.Errorf("generation config = %+v, want JSON with schema", req.GenerationConfig)
+		}
+		w.Write([]byte(`{
+			"candidates": [{"content": {"parts": [{"text": "{\"title\":"}, {"text": " \"Parking\"}"}]}}],
+			"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 5}
+		}`))
+	}))
+	defer server.Close()
+
+	g := NewGemini("k", "gemini-test").WithBaseURL(server.URL)
+	text, tokens, err := g.GenerateJSON(context.Background(), "prompt", testSchema)
+	if err != nil {
+		t.Fatalf("GenerateJSON: %v", err)
+	}
+	if text != `{"title": "Parking"}` || tokens != 15 {
+		t.Errorf("GenerateJSON() = %q, %d, want the joined parts and 15 tokens", text, tokens)
+	}
+}
+
+func TestOpenAI_GenerateJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("request to %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if req.Model != "qwen2.5:14b" || req.ResponseFormat == nil || req.ResponseFormat.Type != "json_schema" {
+			t.Errorf("request = %+v, want model and json_schema format", req)
+		}
+		if last := req.Messages[len(req.Messages)-1]; last.Role != "user" || last.Content != "prompt" {
+			t.Errorf("last message = %+v", last)
+		}
+		w.Write([]byte(`{
+			"choices": [{"message": {"role": "assistant", "content": "` + "```json\\n{\\\"title\\\": \\\"Parking\\\"}\\n```" + `"}}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 7}
+		}`))
+	}))
+	defer server.Close()
+
+	o := NewOpenAI(server.URL+"/v1/", "secret", "qwen2.5:14b")
+	text, tokens, err := o.GenerateJSON(context.Background(), "prompt", testSchema)
+	if err != nil {
+		t.Fatalf("GenerateJSON: %v", err)
+	}
+	if text != `{"title": "Parking"}` || tokens != 27 {
+		t.Errorf("GenerateJSON() = %q, %d, want fenced JSON unwrapped and 27 tokens", text, tokens)
+	}
+}
+
+func TestOpenAI_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, _, err := NewOpenAI(server.URL, "", "missing").GenerateJSON(context.Background(), "prompt", testSchema)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Errorf("err = %v, want HTTP 404 error", err)
+	}
+}
+
+func TestStripCodeFence(t *testing.T) {
+	tests := map[string]string{
+		`{"a": 1}`:                 `{"a": 1}`,
+		"```json\n{\"a\": 1}\n```": `{"a": 1}`,
+		"```\n{\"a\": 1}\n```\n":   `{"a": 1}`,
+		"  \n{\"a\": 1}\n":         `{"a": 1}`,
+	}
+	for in, want := range tests {
+		if got := stripCodeFence(in); got != want {
+			t.Errorf("stripCodeFence(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
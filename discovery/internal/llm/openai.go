@@ -0,0 +1,145 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultOpenAIBaseURL is Ollama's OpenAI-compatible endpoint.
+const DefaultOpenAIBaseURL = "http://localhost:11434/v1"
+
+// Local models are slower than hosted ones, especially on CPU.
+const openAITimeout = 120 * time.Second
+
+// OpenAI calls an OpenAI-compatible chat-completions endpoint. It works
+// with llama.cpp's server, Ollama, vLLM and the OpenAI API itself.
+type OpenAI struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAI creates a provider for a chat-completions endpoint. baseURL is
+// the API root, e.g. "http://localhost:8080/v1"; apiKey may be empty for
+// local servers.
+func NewOpenAI(baseURL, apiKey, model string) *OpenAI {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	return &OpenAI{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: openAITimeout,
+		},
+	}
+}
+
+// Name returns the model spec.
+func (o *OpenAI) Name() string {
+	return ProviderOpenAI + ":" + o.model
+}
+
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
+}
+
+// GenerateJSON makes a chat-completions call constrained to the schema.
+func (o *OpenAI) GenerateJSON(ctx context.Context, prompt string, schema json.RawMessage) (string, int, error) {
+	reqBody := chatRequest{
+		Model: o.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: "Respond with a single JSON object only, with no other text."},
+			{Role: "user", Content: prompt},
+		},
+		Temperature: 0.1,
+	}
+	if len(schema) > 0 {
+		reqBody.ResponseFormat = &responseFormat{
+			Type:       "json_schema",
+			JSONSchema: &jsonSchema{Name: "response", Schema: schema},
+		}
+	} else {
+		reqBody.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("http request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("API error (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+
+	var chatResp chatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	tokens := 0
+	if chatResp.Usage != nil {
+		tokens = chatResp.Usage.PromptTokens + chatResp.Usage.CompletionTokens
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return "{}", tokens, nil
+	}
+	return stripCodeFence(chatResp.Choices[0].Message.Content), tokens, nil
+}
@@ -0,0 +1,510 @@
+// Package merge combines duplicate RFPs across the discovery and client
+// schemas, and undoes merges from their recorded history.
+package merge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zachsouder/rfp/shared/db"
+)
+
+var (
+	// ErrAlreadyUnmerged is returned when undoing a merge that was already undone.
+	ErrAlreadyUnmerged = errors.New("merge was already undone")
+	// ErrKeptRFPChanged is returned when undoing a merge whose kept RFP has
+	// since been deleted or taken part in another merge.
+	ErrKeptRFPChanged = errors.New("kept RFP was deleted or merged again; undo the later merge first")
+)
+
+// Result describes a completed merge.
+type Result struct {
+	MergeID          int
+	KeptRFPID        int
+	DroppedRFPID     int
+	FieldsFilled     []string
+	SearchResults    int  // search results re-pointed to the kept RFP
+	ReviewDecisions  int  // review decisions re-pointed to the kept RFP
+	NotesMoved       int  // client notes moved to the kept RFP's tracking
+	AttachmentsMoved int  // client attachments moved to the kept RFP's tracking
+	TrackingMoved    bool // the dropped RFP's tracking became the kept RFP's
+}
+
+// snapshot records the state before a merge, enough to reverse it.
+type snapshot struct {
+	KeptRFP         json.RawMessage `json:"kept_rfp"`
+	DroppedRFP      json.RawMessage `json:"dropped_rfp"`
+	PromotedFrom    []int           `json:"promoted_from,omitempty"`    // search_results.promoted_rfp_id
+	DuplicateOf     []int           `json:"duplicate_of,omitempty"`     // search_results.duplicate_of_id
+	Decisions       []int           `json:"decisions,omitempty"`        // review_decisions.rfp_id
+	KeptTracking    json.RawMessage `json:"kept_tracking,omitempty"`    // before fields were filled
+	DroppedTracking json.RawMessage `json:"dropped_tracking,omitempty"` // deleted after notes were moved
+	TrackingMoved   bool            `json:"tracking_moved,omitempty"`
+	NoteIDs         []int           `json:"note_ids,omitempty"`
+	AttachmentIDs   []int           `json:"attachment_ids,omitempty"`
+}
+
+// unfilledColumns are discovery.rfps columns that merging never copies from
+// the dropped RFP: identity, the kept RFP's own status, and columns merged
+// by other rules.
+var unfilledColumns = map[string]bool{
+	"id":                true,
+	"discovered_at":     true,
+	"last_checked":      true,
+	"is_active":         true,
+	"lifecycle_status":  true,
+	"status_changed_at": true,
+	"status_reason":     true,
+	"login_required":    true,
+	"pdf_urls":          true, // unioned
+	"review_fields":     true, // describe the kept RFP's own extraction
+	"field_evidence":    true,
+	"raw_values":        true,
+}
+
+// columnGroup is a set of columns describing one thing. They're filled from
+// the dropped RFP together, when take holds, so the kept RFP never ends up
+// with half of each RFP's values.
+type columnGroup struct {
+	columns []string
+	take    string // SQL condition for using the dropped RFP's values
+}
+
+var columnGroups = []columnGroup{
+	// Location: when the kept RFP has none, or only knows its state and the
+	// dropped one was placed in a city
+	{[]string{"latitude", "longitude", "geo_precision"},
+		"d.latitude IS NOT NULL AND (k.latitude IS NULL OR (COALESCE(k.geo_precision, '') <> 'place' AND d.geo_precision = 'place'))"},
+	// Value: a not-to-exceed flag and range belong to their amount
+	{[]string{"estimated_value", "estimated_value_max", "value_not_to_exceed"},
+		"k.estimated_value IS NULL AND d.estimated_value IS NOT NULL"},
+	// Award: who won, for how much and when all come from one notice
+	{[]string{"awardee", "award_amount", "award_date", "award_source_url"},
+		"COALESCE(k.awardee, '') = '' AND COALESCE(d.awardee, '') <> ''"},
+}
+
+// groupOf returns the group a column is filled with, or nil.
+func groupOf(name string) *columnGroup {
+	for i := range columnGroups {
+		for _, c := range columnGroups[i].columns {
+			if c == name {
+				return &columnGroups[i]
+			}
+		}
+	}
+	return nil
+}
+
+// groupFillExpression returns the SQL for a column in group g.
+func groupFillExpression(g *columnGroup, c column) string {
+	return fmt.Sprintf("CASE WHEN %[1]s THEN d.%[2]s ELSE k.%[2]s END", g.take, c.Name)
+}
+
+// Merge folds the dropped RFP into the kept one. Empty fields on the kept
+// RFP are filled from the dropped one, PDF URLs are combined, search
+// results, review decisions and client tracking are re-pointed, notes and
+// attachments are moved, and the dropped RFP is deleted. Everything needed
+// to undo it is recorded in discovery.rfp_merges.
+func Merge(ctx context.Context, database *db.DB, keepID, dropID int, mergedBy string) (*Result, error) {
+	if keepID == dropID {
+		return nil, fmt.Errorf("cannot merge RFP %d into itself", keepID)
+	}
+
+	res := &Result{KeptRFPID: keepID, DroppedRFPID: dropID}
+
+	err := database.WithTx(ctx, func(tx pgx.Tx) error {
+		var snap snapshot
+		var err error
+
+		// Lock both rows so a concurrent merge can't interleave
+		if snap.KeptRFP, err = rowJSON(ctx, tx, "discovery.rfps", keepID); err != nil {
+			return fmt.Errorf("load kept RFP %d failed: %w", keepID, err)
+		}
+		if snap.DroppedRFP, err = rowJSON(ctx, tx, "discovery.rfps", dropID); err != nil {
+			return fmt.Errorf("load dropped RFP %d failed: %w", dropID, err)
+		}
+
+		// Fill empty fields and combine PDFs
+		if res.FieldsFilled, err = fillRFP(ctx, tx, keepID, dropID, snap.KeptRFP); err != nil {
+			return err
+		}
+
+		// Re-point search results and review decisions
+		if snap.PromotedFrom, err = repoint(ctx, tx, "discovery.search_results", "promoted_rfp_id", keepID, dropID); err != nil {
+			return err
+		}
+		if snap.DuplicateOf, err = repoint(ctx, tx, "discovery.search_results", "duplicate_of_id", keepID, dropID); err != nil {
+			return err
+		}
+		res.SearchResults = len(snap.PromotedFrom) + len(snap.DuplicateOf)
+		if snap.Decisions, err = repoint(ctx, tx, "discovery.review_decisions", "rfp_id", keepID, dropID); err != nil {
+			return err
+		}
+		res.ReviewDecisions = len(snap.Decisions)
+
+		// Client tracking, notes and attachments
+		if err := mergeTracking(ctx, tx, keepID, dropID, &snap); err != nil {
+			return err
+		}
+		res.TrackingMoved = snap.TrackingMoved
+		res.NotesMoved = len(snap.NoteIDs)
+		res.AttachmentsMoved = len(snap.AttachmentIDs)
+
+		if _, err := tx.Exec(ctx, `DELETE FROM discovery.rfps WHERE id = $1`, dropID); err != nil {
+			return fmt.Errorf("delete dropped RFP failed: %w", err)
+		}
+
+		data, err := json.Marshal(snap)
+		if err != nil {
+			return fmt.Errorf("marshal merge snapshot failed: %w", err)
+		}
+		err = tx.QueryRow(ctx, `
+			INSERT INTO discovery.rfp_merges (kept_rfp_id, dropped_rfp_id, merged_by, snapshot)
+			VALUES ($1, $2, NULLIF($3, ''), $4)
+			RETURNING id
+		`, keepID, dropID, mergedBy, data).Scan(&res.MergeID)
+		if err != nil {
+			return fmt.Errorf("record merge failed: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Unmerge reverses a recorded merge: the dropped RFP is restored with its
+// original ID, the kept RFP's fields and tracking are reset to their
+// pre-merge values, and everything re-pointed is pointed back. Changes made
+// to the kept RFP after the merge are lost. It returns ErrKeptRFPChanged if
+// the kept RFP is gone or a later merge involving it hasn't been undone.
+func Unmerge(ctx context.Context, database *db.DB, mergeID int) (*Result, error) {
+	res := &Result{MergeID: mergeID}
+
+	err := database.WithTx(ctx, func(tx pgx.Tx) error {
+		var data []byte
+		var unmerged bool
+		err := tx.QueryRow(ctx, `
+			SELECT kept_rfp_id, dropped_rfp_id, snapshot, unmerged_at IS NOT NULL
+			FROM discovery.rfp_merges
+			WHERE id = $1
+			FOR UPDATE
+		`, mergeID).Scan(&res.KeptRFPID, &res.DroppedRFPID, &data, &unmerged)
+		if err != nil {
+			return fmt.Errorf("load merge %d failed: %w", mergeID, err)
+		}
+		if unmerged {
+			return ErrAlreadyUnmerged
+		}
+
+		// Undoing this merge under a later one would corrupt both
+		var later bool
+		err = tx.QueryRow(ctx, `
+			SELECT NOT EXISTS (SELECT 1 FROM discovery.rfps WHERE id = $2)
+			    OR EXISTS (
+					SELECT 1 FROM discovery.rfp_merges
+					WHERE id > $1 AND unmerged_at IS NULL
+					  AND (kept_rfp_id = $2 OR dropped_rfp_id = $2)
+				)
+		`, mergeID, res.KeptRFPID).Scan(&later)
+		if err != nil {
+			return fmt.Errorf("check later merges failed: %w", err)
+		}
+		if later {
+			return ErrKeptRFPChanged
+		}
+
+		var snap snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return fmt.Errorf("parse merge snapshot failed: %w", err)
+		}
+
+		// Restore both RFPs
+		if err := restoreRow(ctx, tx, "discovery.rfps", snap.DroppedRFP, true); err != nil {
+			return fmt.Errorf("restore dropped RFP failed: %w", err)
+		}
+		if err := restoreRow(ctx, tx, "discovery.rfps", snap.KeptRFP, false); err != nil {
+			return fmt.Errorf("restore kept RFP failed: %w", err)
+		}
+
+		// Point search results back
+		if _, err := tx.Exec(ctx, `UPDATE discovery.search_results SET promoted_rfp_id = $1 WHERE id = ANY($2)`,
+			res.DroppedRFPID, nonNil(snap.PromotedFrom)); err != nil {
+			return fmt.Errorf("restore promoted search results failed: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE discovery.search_results SET duplicate_of_id = $1 WHERE id = ANY($2)`,
+			res.DroppedRFPID, nonNil(snap.DuplicateOf)); err != nil {
+			return fmt.Errorf("restore duplicate search results failed: %w", err)
+		}
+		res.SearchResults = len(snap.PromotedFrom) + len(snap.DuplicateOf)
+		if _, err := tx.Exec(ctx, `UPDATE discovery.review_decisions SET rfp_id = $1 WHERE id = ANY($2)`,
+			res.DroppedRFPID, nonNil(snap.Decisions)); err != nil {
+			return fmt.Errorf("restore review decisions failed: %w", err)
+		}
+		res.ReviewDecisions = len(snap.Decisions)
+
+		if err := unmergeTracking(ctx, tx, res.DroppedRFPID, &snap); err != nil {
+			return err
+		}
+		res.TrackingMoved = snap.TrackingMoved
+		res.NotesMoved = len(snap.NoteIDs)
+		res.AttachmentsMoved = len(snap.AttachmentIDs)
+
+		if _, err := tx.Exec(ctx, `UPDATE discovery.rfp_merges SET unmerged_at = NOW() WHERE id = $1`, mergeID); err != nil {
+			return fmt.Errorf("record unmerge failed: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// fillRFP copies the dropped RFP's values into the kept RFP's empty columns,
+// including its agency and location, and unions their PDF URLs. Returns the
+// names of the filled columns.
+func fillRFP(ctx context.Context, tx pgx.Tx, keepID, dropID int, kept json.RawMessage) ([]string, error) {
+	columns, err := tableColumns(ctx, tx, "discovery", "rfps")
+	if err != nil {
+		return nil, err
+	}
+
+	var sets, candidates []string
+	for _, col := range columns {
+		switch g := groupOf(col.Name); {
+		case g != nil:
+			sets = append(sets, fmt.Sprintf("%s = %s", col.Name, groupFillExpression(g, col)))
+		case unfilledColumns[col.Name]:
+			continue
+		default:
+			sets = append(sets, fmt.Sprintf("%s = %s", col.Name, fillExpression(col)))
+		}
+		candidates = append(candidates, col.Name)
+	}
+	sets = append(sets, "pdf_urls = ARRAY(SELECT DISTINCT unnest(COALESCE(k.pdf_urls, '{}') || COALESCE(d.pdf_urls, '{}')))")
+
+	_, err = tx.Exec(ctx, `
+		UPDATE discovery.rfps k
+		SET `+strings.Join(sets, ",\n\t\t\t")+`
+		FROM discovery.rfps d
+		WHERE k.id = $1 AND d.id = $2
+	`, keepID, dropID)
+	if err != nil {
+		return nil, fmt.Errorf("fill kept RFP failed: %w", err)
+	}
+
+	// Report the columns that changed
+	row, err := rowJSON(ctx, tx, "discovery.rfps", keepID)
+	if err != nil {
+		return nil, err
+	}
+	var before, after map[string]any
+	if err := json.Unmarshal(kept, &before); err != nil {
+		return nil, fmt.Errorf("parse kept RFP failed: %w", err)
+	}
+	if err := json.Unmarshal(row, &after); err != nil {
+		return nil, fmt.Errorf("parse filled RFP failed: %w", err)
+	}
+	var filled []string
+	for _, name := range candidates {
+		if !reflect.DeepEqual(before[name], after[name]) {
+			filled = append(filled, name)
+		}
+	}
+
+	return filled, nil
+}
+
+// repoint moves a table's reference from the dropped to the kept RFP and
+// returns the IDs of the rows it changed.
+func repoint(ctx context.Context, tx pgx.Tx, table, column string, keepID, dropID int) ([]int, error) {
+	rows, err := tx.Query(ctx, `
+		UPDATE `+table+` SET `+column+` = $1
+		WHERE `+column+` = $2
+		RETURNING id
+	`, keepID, dropID)
+	if err != nil {
+		return nil, fmt.Errorf("re-point %s.%s failed: %w", table, column, err)
+	}
+	return collectIDs(rows)
+}
+
+// mergeTracking combines the client tracking of both RFPs. If only the
+// dropped RFP is tracked, its tracking moves to the kept RFP. If both are,
+// the kept tracking absorbs the dropped one's notes, attachments, and any
+// stage, score or assignment it lacks.
+func mergeTracking(ctx context.Context, tx pgx.Tx, keepID, dropID int, snap *snapshot) error {
+	keptTrackingID, err := trackingID(ctx, tx, keepID)
+	if err != nil {
+		return err
+	}
+	droppedTrackingID, err := trackingID(ctx, tx, dropID)
+	if err != nil {
+		return err
+	}
+
+	if droppedTrackingID == 0 {
+		return nil
+	}
+
+	if keptTrackingID == 0 {
+		if _, err := tx.Exec(ctx, `
+			UPDATE client.rfp_tracking SET discovery_rfp_id = $1, updated_at = NOW() WHERE id = $2
+		`, keepID, droppedTrackingID); err != nil {
+			return fmt.Errorf("move tracking failed: %w", err)
+		}
+		snap.TrackingMoved = true
+		return nil
+	}
+
+	if snap.KeptTracking, err = rowJSON(ctx, tx, "client.rfp_tracking", keptTrackingID); err != nil {
+		return fmt.Errorf("load kept tracking failed: %w", err)
+	}
+	if snap.DroppedTracking, err = rowJSON(ctx, tx, "client.rfp_tracking", droppedTrackingID); err != nil {
+		return fmt.Errorf("load dropped tracking failed: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE client.notes SET rfp_tracking_id = $1 WHERE rfp_tracking_id = $2 RETURNING id
+	`, keptTrackingID, droppedTrackingID)
+	if err != nil {
+		return fmt.Errorf("move notes failed: %w", err)
+	}
+	if snap.NoteIDs, err = collectIDs(rows); err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(ctx, `
+		UPDATE client.attachments SET rfp_tracking_id = $1 WHERE rfp_tracking_id = $2 RETURNING id
+	`, keptTrackingID, droppedTrackingID)
+	if err != nil {
+		return fmt.Errorf("move attachments failed: %w", err)
+	}
+	if snap.AttachmentIDs, err = collectIDs(rows); err != nil {
+		return err
+	}
+
+	// Work done on the dropped copy shouldn't be lost: take its stage if the
+	// kept copy was never triaged, and fill anything unset
+	_, err = tx.Exec(ctx, `
+		UPDATE client.rfp_tracking k SET
+			stage = CASE WHEN COALESCE(k.stage, 'new') = 'new' THEN d.stage ELSE k.stage END,
+			stage_changed_at = CASE WHEN COALESCE(k.stage, 'new') = 'new' THEN d.stage_changed_at ELSE k.stage_changed_at END,
+			stage_changed_by = CASE WHEN COALESCE(k.stage, 'new') = 'new' THEN d.stage_changed_by ELSE k.stage_changed_by END,
+			manual_score = COALESCE(k.manual_score, d.manual_score),
+			assigned_to = COALESCE(k.assigned_to, d.assigned_to),
+			priority = CASE WHEN COALESCE(k.priority, 'normal') = 'normal' THEN d.priority ELSE k.priority END,
+			decision_date = COALESCE(k.decision_date, d.decision_date),
+			is_hidden = k.is_hidden AND d.is_hidden,
+			updated_at = NOW()
+		FROM client.rfp_tracking d
+		WHERE k.id = $1 AND d.id = $2
+	`, keptTrackingID, droppedTrackingID)
+	if err != nil {
+		return fmt.Errorf("merge tracking failed: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM client.rfp_tracking WHERE id = $1`, droppedTrackingID); err != nil {
+		return fmt.Errorf("delete dropped tracking failed: %w", err)
+	}
+
+	return nil
+}
+
+// unmergeTracking reverses mergeTracking.
+func unmergeTracking(ctx context.Context, tx pgx.Tx, dropID int, snap *snapshot) error {
+	if snap.TrackingMoved {
+		var keptID int
+		if err := json.Unmarshal(snap.KeptRFP, &struct {
+			ID *int `json:"id"`
+		}{&keptID}); err != nil {
+			return fmt.Errorf("parse kept RFP failed: %w", err)
+		}
+		tag, err := tx.Exec(ctx, `
+			UPDATE client.rfp_tracking SET discovery_rfp_id = $1, updated_at = NOW() WHERE discovery_rfp_id = $2
+		`, dropID, keptID)
+		if err != nil {
+			return fmt.Errorf("move tracking back failed: %w", err)
+		}
+		if tag.RowsAffected() != 1 {
+			return fmt.Errorf("move tracking back failed: kept RFP %d has no tracking", keptID)
+		}
+		return nil
+	}
+
+	if snap.DroppedTracking == nil {
+		return nil
+	}
+
+	if err := restoreRow(ctx, tx, "client.rfp_tracking", snap.DroppedTracking, true); err != nil {
+		return fmt.Errorf("restore dropped tracking failed: %w", err)
+	}
+	if err := restoreRow(ctx, tx, "client.rfp_tracking", snap.KeptTracking, false); err != nil {
+		return fmt.Errorf("restore kept tracking failed: %w", err)
+	}
+
+	var droppedTrackingID int
+	if err := json.Unmarshal(snap.DroppedTracking, &struct {
+		ID *int `json:"id"`
+	}{&droppedTrackingID}); err != nil {
+		return fmt.Errorf("parse dropped tracking failed: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE client.notes SET rfp_tracking_id = $1 WHERE id = ANY($2)`,
+		droppedTrackingID, nonNil(snap.NoteIDs)); err != nil {
+		return fmt.Errorf("move notes back failed: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE client.attachments SET rfp_tracking_id = $1 WHERE id = ANY($2)`,
+		droppedTrackingID, nonNil(snap.AttachmentIDs)); err != nil {
+		return fmt.Errorf("move attachments back failed: %w", err)
+	}
+
+	return nil
+}
+
+// trackingID returns the client tracking ID for an RFP, or 0 if untracked.
+func trackingID(ctx context.Context, tx pgx.Tx, rfpID int) (int, error) {
+	var id int
+	err := tx.QueryRow(ctx, `
+		SELECT id FROM client.rfp_tracking WHERE discovery_rfp_id = $1 FOR UPDATE
+	`, rfpID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("load tracking for RFP %d failed: %w", rfpID, err)
+	}
+	return id, nil
+}
+
+func collectIDs(rows pgx.Rows) ([]int, error) {
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan id failed: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// nonNil returns an empty slice for nil so ANY($n) gets an empty array.
+func nonNil(ids []int) []int {
+	if ids == nil {
+		return []int{}
+	}
+	return ids
+}
@@ -0,0 +1,169 @@
+package merge
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/zachsouder/rfp/shared/db"
+)
+
+func TestFillExpression(t *testing.T) {
+	tests := []struct {
+		col  column
+		want string
+	}{
+		{column{"agency", "text"}, "COALESCE(NULLIF(k.agency, ''), d.agency)"},
+		{column{"contact_email", "character varying"}, "COALESCE(NULLIF(k.contact_email, ''), d.contact_email)"},
+		{column{"scope_keywords", "ARRAY"}, "CASE WHEN k.scope_keywords IS NULL OR cardinality(k.scope_keywords) = 0 THEN d.scope_keywords ELSE k.scope_keywords END"},
+		{column{"due_date", "date"}, "COALESCE(k.due_date, d.due_date)"},
+		{column{"estimated_value", "numeric"}, "COALESCE(k.estimated_value, d.estimated_value)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.col.Name, func(t *testing.T) {
+			if got := fillExpression(tt.col); got != tt.want {
+				t.Errorf("fillExpression(%v) = %q, want %q", tt.col, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnfilledColumns(t *testing.T) {
+	// The kept RFP's identity and status must never come from the dropped one
+	for _, col := range []string{"id", "discovered_at", "lifecycle_status", "is_active", "pdf_urls"} {
+		if !unfilledColumns[col] {
+			t.Errorf("%s should not be filled from the dropped RFP", col)
+		}
+	}
+}
+
+func TestColumnGroups(t *testing.T) {
+	// A location moves as a whole, and a city beats a state centroid
+	want := "CASE WHEN d.latitude IS NOT NULL AND (k.latitude IS NULL OR (COALESCE(k.geo_precision, '') <> 'place' AND d.geo_precision = 'place')) THEN d.longitude ELSE k.longitude END"
+	if got := groupFillExpression(groupOf("longitude"), column{"longitude", "double precision"}); got != want {
+		t.Errorf("groupFillExpression() = %q, want %q", got, want)
+	}
+
+	// A not-to-exceed flag travels with its amount, and an award with its awardee
+	for _, pair := range [][2]string{
+		{"value_not_to_exceed", "estimated_value"},
+		{"estimated_value_max", "estimated_value"},
+		{"award_date", "awardee"},
+		{"award_amount", "awardee"},
+		{"award_source_url", "awardee"},
+	} {
+		if g := groupOf(pair[0]); g == nil || g != groupOf(pair[1]) {
+			t.Errorf("%s should be filled together with %s", pair[0], pair[1])
+		}
+	}
+
+	for _, g := range columnGroups {
+		for _, col := range g.columns {
+			if unfilledColumns[col] {
+				t.Errorf("%s is grouped but listed as unfilled", col)
+			}
+		}
+	}
+	if groupOf("agency_id") != nil || unfilledColumns["agency_id"] {
+		t.Error("agency_id should be filled from the dropped RFP on its own")
+	}
+}
+
+// TestMergeRoundTrip merges and unmerges two RFPs in a migrated database
+// given by TEST_DATABASE_URL, and checks both come back unchanged.
+func TestMergeRoundTrip(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	database, err := db.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer database.Close()
+
+	insert := func(title string, value any, nte bool, awardee any) int {
+		var id int
+		err := database.QueryRow(ctx, `
+			INSERT INTO discovery.rfps (title, state, estimated_value, value_not_to_exceed, awardee, award_amount, pdf_urls)
+			VALUES ($1, 'TX', $2, $3, $4, CASE WHEN $4::text IS NULL THEN NULL ELSE 125000 END, ARRAY[$1 || '.pdf'])
+			RETURNING id
+		`, title, value, nte, awardee).Scan(&id)
+		if err != nil {
+			t.Fatalf("insert %s failed: %v", title, err)
+		}
+		t.Cleanup(func() {
+			database.Exec(ctx, `DELETE FROM discovery.rfp_merges WHERE kept_rfp_id = $1 OR dropped_rfp_id = $1`, id)
+			database.Exec(ctx, `DELETE FROM discovery.rfps WHERE id = $1`, id)
+		})
+		return id
+	}
+	row := func(id int) string {
+		var s string
+		err := database.QueryRow(ctx, `SELECT to_jsonb(r)::text FROM discovery.rfps r WHERE id = $1`, id).Scan(&s)
+		if err != nil {
+			t.Fatalf("load RFP %d failed: %v", id, err)
+		}
+		return s
+	}
+
+	keepID := insert("Kept valet RFP", nil, false, nil)
+	dropID := insert("Dropped valet RFP", 500000, true, "Towne Park")
+	keptBefore, droppedBefore := row(keepID), row(dropID)
+
+	res, err := Merge(ctx, database, keepID, dropID, "test")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	var value *float64
+	var nte bool
+	var awardee *string
+	var amount *float64
+	var pdfs []string
+	err = database.QueryRow(ctx, `
+		SELECT estimated_value::float8, value_not_to_exceed, awardee, award_amount::float8, pdf_urls
+		FROM discovery.rfps WHERE id = $1
+	`, keepID).Scan(&value, &nte, &awardee, &amount, &pdfs)
+	if err != nil {
+		t.Fatalf("load merged RFP failed: %v", err)
+	}
+	if value == nil || *value != 500000 || !nte {
+		t.Errorf("merged value = %v, not to exceed %v; want 500000, true", value, nte)
+	}
+	if awardee == nil || *awardee != "Towne Park" || amount == nil || *amount != 125000 {
+		t.Errorf("merged award = %v, %v; want Towne Park, 125000", awardee, amount)
+	}
+	if len(pdfs) != 2 {
+		t.Errorf("merged pdf_urls = %v, want both RFPs' PDFs", pdfs)
+	}
+
+	if _, err := Unmerge(ctx, database, res.MergeID); err != nil {
+		t.Fatalf("Unmerge() error = %v", err)
+	}
+	if got := row(keepID); got != keptBefore {
+		t.Errorf("kept RFP after unmerge = %s, want %s", got, keptBefore)
+	}
+	if got := row(dropID); got != droppedBefore {
+		t.Errorf("dropped RFP after unmerge = %s, want %s", got, droppedBefore)
+	}
+	if _, err := Unmerge(ctx, database, res.MergeID); !errors.Is(err, ErrAlreadyUnmerged) {
+		t.Errorf("second Unmerge() error = %v, want ErrAlreadyUnmerged", err)
+	}
+
+	// Undoing a merge whose kept RFP was merged away again must be refused
+	first, err := Merge(ctx, database, keepID, dropID, "test")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	otherID := insert("Other valet RFP", nil, false, nil)
+	if _, err := Merge(ctx, database, otherID, keepID, "test"); err != nil {
+		t.Fatalf("second Merge() error = %v", err)
+	}
+	if _, err := Unmerge(ctx, database, first.MergeID); !errors.Is(err, ErrKeptRFPChanged) {
+		t.Errorf("Unmerge() under a later merge error = %v, want ErrKeptRFPChanged", err)
+	}
+}
@@ -0,0 +1,101 @@
+package merge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// column is a writable table column.
+type column struct {
+	Name     string
+	DataType string // information_schema data_type, e.g. text, ARRAY, integer
+}
+
+// tableColumns lists a table's writable columns. Generated columns are left
+// out since they can't be assigned.
+func tableColumns(ctx context.Context, tx pgx.Tx, schema, table string) ([]column, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT column_name, data_type
+		FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2 AND is_generated = 'NEVER'
+		ORDER BY ordinal_position
+	`, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("list columns of %s.%s failed: %w", schema, table, err)
+	}
+	defer rows.Close()
+
+	var columns []column
+	for rows.Next() {
+		var c column
+		if err := rows.Scan(&c.Name, &c.DataType); err != nil {
+			return nil, fmt.Errorf("scan column failed: %w", err)
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// fillExpression returns the SQL that keeps the kept row's (k) value for a
+// column unless it's empty, in which case the dropped row's (d) is used.
+func fillExpression(c column) string {
+	switch c.DataType {
+	case "text", "character varying":
+		return fmt.Sprintf("COALESCE(NULLIF(k.%[1]s, ''), d.%[1]s)", c.Name)
+	case "ARRAY":
+		return fmt.Sprintf("CASE WHEN k.%[1]s IS NULL OR cardinality(k.%[1]s) = 0 THEN d.%[1]s ELSE k.%[1]s END", c.Name)
+	default:
+		return fmt.Sprintf("COALESCE(k.%[1]s, d.%[1]s)", c.Name)
+	}
+}
+
+// rowJSON returns a row as JSON, locking it for the rest of the transaction.
+func rowJSON(ctx context.Context, tx pgx.Tx, table string, id int) (json.RawMessage, error) {
+	var data []byte
+	err := tx.QueryRow(ctx, `SELECT to_jsonb(t) FROM `+table+` t WHERE id = $1 FOR UPDATE`, id).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// restoreRow writes a row saved by rowJSON back to its table, either
+// re-inserting it with its original ID or overwriting the existing row,
+// which must still be there.
+func restoreRow(ctx context.Context, tx pgx.Tx, table string, row json.RawMessage, insert bool) error {
+	schema, name, _ := strings.Cut(table, ".")
+	columns, err := tableColumns(ctx, tx, schema, name)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	list := strings.Join(names, ", ")
+
+	var sql string
+	if insert {
+		sql = `INSERT INTO ` + table + ` (` + list + `)
+			SELECT ` + list + ` FROM jsonb_populate_record(NULL::` + table + `, $1)`
+	} else {
+		sql = `UPDATE ` + table + ` SET (` + list + `) = (
+				SELECT ` + list + ` FROM jsonb_populate_record(NULL::` + table + `, $1)
+			)
+			WHERE id = ($1::jsonb->>'id')::int`
+	}
+
+	tag, err := tx.Exec(ctx, sql, []byte(row))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("%s row no longer exists", table)
+	}
+	return nil
+}
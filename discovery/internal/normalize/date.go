@@ -0,0 +1,157 @@
+// Package normalize parses free-text values extracted from RFP documents
+// into typed fields.
+package normalize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+// Date is a parsed date, with a time of day when one was given.
+type Date struct {
+	Time    time.Time
+	HasTime bool
+}
+
+var (
+	rfc3339Pattern  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T`)
+	weekdayPattern  = regexp.MustCompile(`^(mon|tues?|wed(nes)?|thu(rs)?|fri|sat(ur)?|sun)(day)?\.?,?\s+`)
+	clockPattern    = regexp.MustCompile(`\b(\d{1,2})(?::(\d{2}))?(?::\d{2})?\s*([ap])\.?\s*m\b\.?`)
+	clock24Pattern  = regexp.MustCompile(`\b([01]?\d|2[0-3]):([0-5]\d)(?::\d{2})?\b`)
+	noonPattern     = regexp.MustCompile(`\b(12\s*)?noon\b`)
+	zonePattern     = regexp.MustCompile(`\b(eastern|central|mountain|pacific|alaska|hawaii|[ecmp][sd]?t|ak[sd]?t|h[sd]?t)\b(\s+(standard|daylight))?(\s+time)?`)
+	ordinalPattern  = regexp.MustCompile(`\b(\d{1,2})(st|nd|rd|th)\b`)
+	monthDotPattern = regexp.MustCompile(`\b(jan|feb|mar|apr|jun|jul|aug|sep|sept|oct|nov|dec)\.`)
+	fillerPattern   = regexp.MustCompile(`\b(at|by|before|on|local|prevailing|time)\b|@`)
+)
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006/1/2",
+	"1/2/2006",
+	"1/2/06",
+	"1-2-2006",
+	"1.2.2006",
+	"January 2, 2006",
+	"January 2 2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"2-Jan-2006",
+	"2-Jan-06",
+}
+
+// localISOLayouts are ISO datetimes written without an offset.
+var localISOLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// zoneNames maps US time zone names and abbreviations to IANA locations.
+// Standard and daylight abbreviations map to the same location so that
+// "EST" written in July still resolves to the correct offset.
+var zoneNames = map[string]string{
+	"eastern": "America/New_York", "et": "America/New_York", "est": "America/New_York", "edt": "America/New_York",
+	"central": "America/Chicago", "ct": "America/Chicago", "cst": "America/Chicago", "cdt": "America/Chicago",
+	"mountain": "America/Denver", "mt": "America/Denver", "mst": "America/Denver", "mdt": "America/Denver",
+	"pacific": "America/Los_Angeles", "pt": "America/Los_Angeles", "pst": "America/Los_Angeles", "pdt": "America/Los_Angeles",
+	"alaska": "America/Anchorage", "akt": "America/Anchorage", "akst": "America/Anchorage", "akdt": "America/Anchorage",
+	"hawaii": "Pacific/Honolulu", "ht": "Pacific/Honolulu", "hst": "Pacific/Honolulu", "hdt": "Pacific/Honolulu",
+}
+
+// ParseDate parses a date such as "Friday, March 15th, 2024 at 2:00 p.m. EST"
+// or "3/15/24". A time of day is interpreted in the time zone written next to
+// it, falling back to the zone of the agency's state, as are ISO datetimes
+// without an offset. Dates without a time are returned at midnight UTC.
+// Returns nil if the text isn't recognized.
+func ParseDate(text, state string) *Date {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	if rfc3339Pattern.MatchString(text) {
+		if t, err := time.Parse(time.RFC3339, text); err == nil {
+			return &Date{Time: t, HasTime: true}
+		}
+		for _, layout := range localISOLayouts {
+			if t, err := time.ParseInLocation(layout, text, models.StateLocation(state)); err == nil {
+				return &Date{Time: t, HasTime: true}
+			}
+		}
+	}
+
+	s := strings.ToLower(text)
+	s = weekdayPattern.ReplaceAllString(s, "")
+
+	// Pull out the time of day and zone, leaving only the date
+	hour, minute, hasTime := -1, 0, false
+	if m := clockPattern.FindStringSubmatch(s); m != nil {
+		hour, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			minute, _ = strconv.Atoi(m[2])
+		}
+		if hour > 12 {
+			return nil
+		}
+		hour %= 12
+		if m[3] == "p" {
+			hour += 12
+		}
+		hasTime = true
+		s = strings.Replace(s, m[0], " ", 1)
+	} else if noonPattern.MatchString(s) {
+		hour, hasTime = 12, true
+		s = noonPattern.ReplaceAllString(s, " ")
+	} else if m := clock24Pattern.FindStringSubmatch(s); m != nil {
+		hour, _ = strconv.Atoi(m[1])
+		minute, _ = strconv.Atoi(m[2])
+		hasTime = true
+		s = strings.Replace(s, m[0], " ", 1)
+	}
+
+	loc := models.StateLocation(state)
+	if m := zonePattern.FindStringSubmatch(s); m != nil {
+		if name, ok := zoneNames[m[1]]; ok {
+			if l, err := time.LoadLocation(name); err == nil {
+				loc = l
+			}
+		}
+		s = strings.Replace(s, m[0], " ", 1)
+	}
+
+	day, ok := parseDay(s)
+	if !ok {
+		return nil
+	}
+
+	if !hasTime {
+		return &Date{Time: day}
+	}
+
+	return &Date{
+		Time:    time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc),
+		HasTime: true,
+	}
+}
+
+// parseDay parses what's left of a date string once the time and zone have
+// been removed.
+func parseDay(s string) (time.Time, bool) {
+	s = fillerPattern.ReplaceAllString(s, " ")
+	s = ordinalPattern.ReplaceAllString(s, "$1")
+	s = monthDotPattern.ReplaceAllString(s, "$1")
+	s = strings.ReplaceAll(s, "sept ", "sep ")
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.Trim(s, " ,-")
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,142 @@
+package normalize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money is a parsed dollar value. A range such as "$1M - $1.5M" sets both
+// Amount (the low end) and Max.
+type Money struct {
+	Amount      float64
+	Max         *float64
+	NotToExceed bool
+}
+
+var (
+	amountPattern = regexp.MustCompile(`(\$?)\s*([0-9][0-9,]*(?:\.[0-9]+)?)\s*(billion|bn|b|million|mil|mm|m|thousand|k)?\b`)
+	rangePattern  = regexp.MustCompile(`^\s*(-|–|—|to|and)\s*$`)
+	barePattern   = regexp.MustCompile(`^\s*[0-9][0-9,]*(?:\.[0-9]+)?\s*$`)
+	ntePattern    = regexp.MustCompile(`not[\s-]+to[\s-]+exceed|\bnte\b|\bup to\b|\bmaximum\b|\bceiling\b|\bcap(ped)? (at|of)\b`)
+)
+
+// amountMatch is one number found in a money string.
+type amountMatch struct {
+	value     float64
+	hasDollar bool
+	hasScale  bool
+	start     int
+	end       int
+}
+
+// ParseAmount extracts a dollar amount from text such as "$1,234,567.00" or
+// "$1.2 million", preferring amounts written with a dollar sign. Returns nil
+// if none is found.
+func ParseAmount(text string) *float64 {
+	m := ParseMoney(text)
+	if m == nil {
+		return nil
+	}
+	return &m.Amount
+}
+
+// ParseMoney parses a dollar value such as "$1.2M", "$500,000 - $750,000",
+// "between $1 and $2 million" or "not to exceed $250K". An amount needs a
+// dollar sign or a scale word unless the text is just a number, so counts
+// like "3 years" aren't read as dollars. Returns nil if no amount is found.
+func ParseMoney(text string) *Money {
+	s := strings.ToLower(text)
+	matches := findAmounts(s)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	// Prefer the first amount written with a dollar sign, then one with a
+	// scale word
+	first := -1
+	for i, m := range matches {
+		if m.hasDollar {
+			first = i
+			break
+		}
+		if m.hasScale && first < 0 {
+			first = i
+		}
+	}
+	if first < 0 {
+		if !barePattern.MatchString(s) {
+			return nil
+		}
+		first = 0
+	}
+
+	money := &Money{
+		Amount:      matches[first].value,
+		NotToExceed: ntePattern.MatchString(s),
+	}
+
+	// A second amount joined by a dash, "to" or "and" makes a range
+	if first+1 < len(matches) {
+		lo, hi := matches[first], matches[first+1]
+		if rangePattern.MatchString(s[lo.end:hi.start]) {
+			// "$1 - 2 million" scales both ends
+			if hi.hasScale && !lo.hasScale {
+				scaled := applyScale(lo.value, scaleOf(s[hi.start:hi.end]))
+				if scaled <= hi.value {
+					lo.value = scaled
+				}
+			}
+			if hi.value > lo.value {
+				money.Amount = lo.value
+				money.Max = &hi.value
+			}
+		}
+	}
+
+	return money
+}
+
+// findAmounts returns every number in s with its scale applied.
+func findAmounts(s string) []amountMatch {
+	var matches []amountMatch
+	for _, idx := range amountPattern.FindAllStringSubmatchIndex(s, -1) {
+		value, err := strconv.ParseFloat(strings.ReplaceAll(s[idx[4]:idx[5]], ",", ""), 64)
+		if err != nil {
+			continue
+		}
+		m := amountMatch{
+			hasDollar: idx[3] > idx[2],
+			start:     idx[0],
+			end:       idx[1],
+		}
+		if idx[6] >= 0 {
+			m.hasScale = true
+			value = applyScale(value, s[idx[6]:idx[7]])
+		}
+		m.value = value
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// scaleOf returns the scale word at the end of a matched amount.
+func scaleOf(s string) string {
+	m := amountPattern.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return m[3]
+}
+
+func applyScale(value float64, scale string) float64 {
+	switch scale {
+	case "billion", "bn", "b":
+		return value * 1_000_000_000
+	case "million", "mil", "mm", "m":
+		return value * 1_000_000
+	case "thousand", "k":
+		return value * 1_000
+	}
+	return value
+}
@@ -0,0 +1,162 @@
+package normalize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		input   string
+		state   string
+		want    string // RFC3339, or YYYY-MM-DD for date-only
+		hasTime bool
+	}{
+		{"2024-03-15", "", "2024-03-15", false},
+		{"03/15/2024", "", "2024-03-15", false},
+		{"3/15/24", "", "2024-03-15", false},
+		{"March 15, 2024", "", "2024-03-15", false},
+		{"Mar. 15th, 2024", "", "2024-03-15", false},
+		{"15 March 2024", "", "2024-03-15", false},
+		{"Friday, March 15, 2024", "", "2024-03-15", false},
+		{"Sept. 3, 2024", "", "2024-09-03", false},
+		{"March 15, 2024 at 2:00 PM", "TX", "2024-03-15T14:00:00-05:00", true},
+		{"March 15, 2024 2:00 p.m. EST", "CA", "2024-03-15T14:00:00-04:00", true},
+		{"January 10, 2024 at 10 a.m. local time", "CA", "2024-01-10T10:00:00-08:00", true},
+		{"01/10/2024 14:30", "NY", "2024-01-10T14:30:00-05:00", true},
+		{"Noon on April 1, 2024", "AZ", "2024-04-01T12:00:00-07:00", true},
+		{"2024-03-15T14:00:00-06:00", "", "2024-03-15T14:00:00-06:00", true},
+		{"2024-03-15T14:00:00", "CA", "2024-03-15T14:00:00-07:00", true},
+		{"2024-01-10T09:30", "NY", "2024-01-10T09:30:00-05:00", true},
+		{"12:00 AM, June 1, 2024", "", "2024-06-01T00:00:00Z", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ParseDate(tt.input, tt.state)
+			if got == nil {
+				t.Fatalf("ParseDate(%q) = nil", tt.input)
+			}
+			if got.HasTime != tt.hasTime {
+				t.Errorf("HasTime = %v, want %v", got.HasTime, tt.hasTime)
+			}
+			var formatted string
+			if tt.hasTime {
+				formatted = got.Time.Format(time.RFC3339)
+			} else {
+				formatted = got.Time.Format("2006-01-02")
+			}
+			if formatted != tt.want {
+				t.Errorf("ParseDate(%q) = %s, want %s", tt.input, formatted, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate_Unrecognized(t *testing.T) {
+	for _, input := range []string{"", "sometime in spring", "TBD", "March 2024", "25:00 on 3/15/2024 pm"} {
+		if got := ParseDate(input, "TX"); got != nil {
+			t.Errorf("ParseDate(%q) = %+v, want nil", input, got)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+		max   float64
+		nte   bool
+	}{
+		{"$1,234,567.00", 1234567, 0, false},
+		{"$1.2M", 1200000, 0, false},
+		{"$1.2 million", 1200000, 0, false},
+		{"$450K annually", 450000, 0, false},
+		{"$2.5B", 2500000000, 0, false},
+		{"Three-year term, $250,000 per year", 250000, 0, false},
+		{"$500,000 - $750,000", 500000, 750000, false},
+		{"$1 to $1.5 million", 1000000, 1500000, false},
+		{"between $1 and 2 million", 1000000, 2000000, false},
+		{"Not to exceed $250,000", 250000, 0, true},
+		{"NTE $75K", 75000, 0, true},
+		{"up to $3M over 5 years", 3000000, 0, true},
+		{"125000", 125000, 0, false},
+		{"3 years at $40,000 per year", 40000, 0, false},
+		{"5 year contract worth 2 million", 2000000, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ParseMoney(tt.input)
+			if got == nil {
+				t.Fatalf("ParseMoney(%q) = nil", tt.input)
+			}
+			if got.Amount != tt.want {
+				t.Errorf("Amount = %v, want %v", got.Amount, tt.want)
+			}
+			if tt.max == 0 && got.Max != nil {
+				t.Errorf("Max = %v, want nil", *got.Max)
+			}
+			if tt.max != 0 && (got.Max == nil || *got.Max != tt.max) {
+				t.Errorf("Max = %v, want %v", got.Max, tt.max)
+			}
+			if got.NotToExceed != tt.nte {
+				t.Errorf("NotToExceed = %v, want %v", got.NotToExceed, tt.nte)
+			}
+		})
+	}
+}
+
+func TestParseMoney_Unrecognized(t *testing.T) {
+	for _, input := range []string{"", "not disclosed", "TBD", "3 years", "Lot 12, 400 spaces"} {
+		if got := ParseMoney(input); got != nil {
+			t.Errorf("ParseMoney(%q) = %+v, want nil", input, got)
+		}
+		if got := ParseAmount(input); got != nil {
+			t.Errorf("ParseAmount(%q) = %v, want nil", input, *got)
+		}
+	}
+}
+
+func TestParseTerm(t *testing.T) {
+	tests := []struct {
+		input    string
+		base     int
+		renewal  int
+		renewals int
+	}{
+		{"36 months", 36, 0, 0},
+		{"three (3) years with two one-year renewals", 36, 24, 2},
+		{"Three (3) years plus two (2) one (1) year renewal options", 36, 24, 2},
+		{"Initial term of five years with an option to renew for an additional five years", 60, 60, 1},
+		{"1 year with 4 optional 1-year extensions", 12, 48, 4},
+		{"2 years, renewable for 1 additional year", 24, 12, 1},
+		{"3+1+1 years", 36, 24, 2},
+		{"18 mos", 18, 0, 0},
+		{"3 year base plus 2 option years", 36, 24, 2},
+		{"Two years plus three option years", 24, 36, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ParseTerm(tt.input)
+			if got == nil {
+				t.Fatalf("ParseTerm(%q) = nil", tt.input)
+			}
+			if got.BaseMonths != tt.base || got.RenewalMonths != tt.renewal || got.Renewals != tt.renewals {
+				t.Errorf("ParseTerm(%q) = %+v, want base %d renewal %d x%d", tt.input, *got, tt.base, tt.renewal, tt.renewals)
+			}
+			if got.TotalMonths() != tt.base+tt.renewal {
+				t.Errorf("TotalMonths() = %d", got.TotalMonths())
+			}
+		})
+	}
+}
+
+func TestParseTerm_Unrecognized(t *testing.T) {
+	for _, input := range []string{"", "TBD", "renewable annually"} {
+		if got := ParseTerm(input); got != nil {
+			t.Errorf("ParseTerm(%q) = %+v, want nil", input, got)
+		}
+	}
+}
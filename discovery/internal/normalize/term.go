@@ -0,0 +1,144 @@
+package normalize
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Term is a parsed contract term.
+type Term struct {
+	BaseMonths    int
+	RenewalMonths int // all renewal options combined
+	Renewals      int
+}
+
+// TotalMonths returns the term including every renewal option.
+func (t Term) TotalMonths() int {
+	return t.BaseMonths + t.RenewalMonths
+}
+
+var numberWords = map[string]string{
+	"one": "1", "two": "2", "three": "3", "four": "4", "five": "5",
+	"six": "6", "seven": "7", "eight": "8", "nine": "9", "ten": "10",
+	"eleven": "11", "twelve": "12", "eighteen": "18", "twenty-four": "24",
+	"thirty-six": "36", "sixty": "60", "a single": "1", "an": "1", "a": "1",
+	"single": "1", "twice": "2",
+}
+
+var (
+	numberWordPattern = regexp.MustCompile(`\b(twenty-four|thirty-six|a single|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve|eighteen|sixty|single|an|a)\b`)
+	echoPattern       = regexp.MustCompile(`(\d+)\s*\(\s*(\d+)\s*\)`)
+	durationPattern   = regexp.MustCompile(`(\d+(?:\.\d+)?)[\s-]*(?:additional\s+|optional\s+)?(years?|yrs?|months?|mos?)\b`)
+	countedPattern    = regexp.MustCompile(`(\d+)\s+(?:additional\s+|optional\s+|successive\s+|consecutive\s+|separate\s+|more\s+)*(\d+(?:\.\d+)?)[\s-]*(years?|yrs?|months?|mos?)\b`)
+	optionYearPattern = regexp.MustCompile(`(\d+)\s+(?:additional\s+)?(?:option(?:al)?|renewal|extension)\s+(years?|yrs?|months?|mos?)\b`)
+	plusPattern       = regexp.MustCompile(`^(\d+)((?:\s*\+\s*\d+)+)\s*(years?|yrs?|months?|mos?)\b`)
+	renewalPattern    = regexp.MustCompile(`renew|extension|extend|option|additional`)
+)
+
+// ParseTerm parses a contract term such as "three (3) years with two one-year
+// renewals", "3 year base plus 2 option years", "36 months" or "3+1+1 years"
+// into base and renewal months.
+// Returns nil if no duration is found.
+func ParseTerm(text string) *Term {
+	s := strings.ToLower(strings.TrimSpace(text))
+	if s == "" {
+		return nil
+	}
+
+	s = numberWordPattern.ReplaceAllStringFunc(s, func(w string) string { return numberWords[w] })
+	// "three (3) years" becomes "3 (3) years"; drop the echoed number
+	s = echoPattern.ReplaceAllString(s, "$1")
+
+	// Shorthand like "3+1+1 years"
+	if m := plusPattern.FindStringSubmatch(s); m != nil {
+		base, _ := strconv.Atoi(m[1])
+		term := &Term{BaseMonths: toMonths(float64(base), m[3])}
+		for _, part := range strings.Split(m[2], "+")[1:] {
+			n, _ := strconv.Atoi(strings.TrimSpace(part))
+			term.RenewalMonths += toMonths(float64(n), m[3])
+			term.Renewals++
+		}
+		return term
+	}
+
+	basePart, renewalPart := s, ""
+	if loc := renewalPattern.FindStringIndex(s); loc != nil {
+		basePart, renewalPart = s[:loc[0]], s[loc[0]:]
+	}
+
+	term := &Term{}
+	if m := durationPattern.FindStringSubmatch(basePart); m != nil {
+		term.BaseMonths = durationMonths(m[1], m[2])
+	} else if renewalPart != "" {
+		// "Renewable annually" style text with no base term stated
+		return nil
+	}
+
+	if renewalPart != "" {
+		// Count the duration before the keyword too: "two one-year renewals"
+		tail := lastWords(basePart, 3) + renewalPart
+		if m := countedPattern.FindStringSubmatch(tail); m != nil {
+			count, _ := strconv.Atoi(m[1])
+			term.Renewals = count
+			term.RenewalMonths = count * durationMonths(m[2], m[3])
+		} else if m := optionYearPattern.FindStringSubmatch(tail); m != nil {
+			// "2 option years" is two renewals of one year each
+			count, _ := strconv.Atoi(m[1])
+			term.Renewals = count
+			term.RenewalMonths = count * durationMonths("1", m[2])
+		} else if m := durationPattern.FindStringSubmatch(trailingNumber(basePart) + renewalPart); m != nil {
+			term.Renewals = 1
+			term.RenewalMonths = durationMonths(m[1], m[2])
+		}
+	}
+
+	if term.BaseMonths == 0 && term.RenewalMonths == 0 {
+		return nil
+	}
+
+	return term
+}
+
+// lastWords returns the last n words of s followed by a space, so a count
+// written just before the renewal keyword stays attached to it.
+func lastWords(s string, n int) string {
+	words := strings.Fields(s)
+	if len(words) > n {
+		words = words[len(words)-n:]
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.Join(words, " ") + " "
+}
+
+// trailingNumber returns the last word of s followed by a space if it is a
+// number, so "for 1 additional year" keeps its count.
+func trailingNumber(s string) string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return ""
+	}
+	last := words[len(words)-1]
+	if _, err := strconv.Atoi(last); err != nil {
+		return ""
+	}
+	return last + " "
+}
+
+func durationMonths(number, unit string) int {
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0
+	}
+	return toMonths(n, unit)
+}
+
+func toMonths(n float64, unit string) int {
+	if strings.HasPrefix(unit, "y") {
+		n *= 12
+	}
+	return int(math.Round(n))
+}
@@ -0,0 +1,140 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// maxCMapRange caps how many codes one bfrange entry may map.
+const maxCMapRange = 0x10000
+
+// cmap is a font's ToUnicode map from the character codes in its strings
+// to text. Fonts embedded as subsets, which is what Word and browsers
+// write, number their glyphs arbitrarily and can only be read through it.
+type cmap struct {
+	codeLen int // bytes per character code
+	codes   map[uint32]string
+}
+
+// parseCMap reads the code space and bfchar/bfrange mappings of a ToUnicode
+// CMap. It returns nil if there are no mappings.
+func parseCMap(data []byte) *cmap {
+	cm := &cmap{codeLen: 1, codes: make(map[uint32]string)}
+	var operands []any
+	lex := &lexer{data: data}
+
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		op, isOp := tok.(operator)
+		if !isOp {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch op {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if lo, ok := operands[0].(rawString); ok && len(lo) >= 1 && len(lo) <= 4 {
+					cm.codeLen = len(lo)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(rawString)
+				dst, ok2 := operands[i+1].(rawString)
+				if ok1 && ok2 && len(src) <= 4 {
+					cm.codes[code(src)] = utf16Text(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(rawString)
+				hi, ok2 := operands[i+1].(rawString)
+				if !ok1 || !ok2 || len(lo) > 4 || len(hi) > 4 {
+					continue
+				}
+				from, to := code(lo), code(hi)
+				if to < from || to-from >= maxCMapRange {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case rawString:
+					for c := from; c <= to; c++ {
+						cm.codes[c] = utf16Text(addCode(dst, c-from))
+					}
+				case []any:
+					for j, el := range dst {
+						if s, ok := el.(rawString); ok && from+uint32(j) <= to {
+							cm.codes[from+uint32(j)] = utf16Text(s)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+
+	if len(cm.codes) == 0 {
+		return nil
+	}
+	return cm
+}
+
+// decode maps a string's character codes to text. It also returns how many
+// codes had no mapping; one-byte codes without one are read as Latin-1.
+func (cm *cmap) decode(s rawString) (string, int) {
+	var sb strings.Builder
+	missing := 0
+	for i := 0; i+cm.codeLen <= len(s); i += cm.codeLen {
+		c := code(s[i : i+cm.codeLen])
+		if text, ok := cm.codes[c]; ok {
+			sb.WriteString(text)
+			continue
+		}
+		if cm.codeLen == 1 && c >= 0x20 {
+			sb.WriteRune(rune(c))
+			continue
+		}
+		missing++
+	}
+	return sb.String(), missing
+}
+
+// code reads a character code as a big-endian number.
+func code(b []byte) uint32 {
+	var c uint32
+	for _, x := range b {
+		c = c<<8 | uint32(x)
+	}
+	return c
+}
+
+// addCode returns b, read as a big-endian number, plus n.
+func addCode(b []byte, n uint32) []byte {
+	out := append([]byte(nil), b...)
+	for i := len(out) - 1; i >= 0 && n > 0; i-- {
+		sum := uint32(out[i]) + n
+		out[i] = byte(sum)
+		n = sum >> 8
+	}
+	return out
+}
+
+// utf16Text decodes a CMap destination, which is UTF-16BE, dropping control
+// characters.
+func utf16Text(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	var sb strings.Builder
+	for _, r := range utf16.Decode(u) {
+		if r >= 0x20 {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,383 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// maxObjectSize caps how far an object's dictionary is parsed, so a
+	// malformed one can't make every object read to the end of the file.
+	maxObjectSize = 1024 * 1024
+	// maxPages caps how many pages are read.
+	maxPages = 2000
+	// maxFormDepth caps how deeply form XObjects drawing form XObjects are
+	// followed.
+	maxFormDepth = 4
+)
+
+// objHeader matches the start of an indirect object, "12 0 obj".
+var objHeader = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+
+// ref is a reference to an indirect object by number.
+type ref int
+
+// dict is a PDF dictionary keyed by name, slash included.
+type dict map[string]any
+
+// object is an indirect object's value and, for streams, its undecoded data.
+type object struct {
+	value  any
+	stream []byte
+}
+
+// document is the object graph of a PDF, as far as text extraction needs it.
+type document struct {
+	objects map[int]*object
+	root    int // the catalog's object number, 0 if none was found
+	budget  *int
+	cmaps   map[int]*cmap
+}
+
+// parseDocument reads the indirect objects of a PDF, including those
+// packed in object streams. It doesn't use the cross-reference table; when
+// an object appears more than once the last copy wins, as it does for
+// incremental updates.
+func parseDocument(data []byte, budget *int) *document {
+	d := &document{objects: make(map[int]*object), budget: budget, cmaps: make(map[int]*cmap)}
+
+	for pos := 0; pos < len(data); {
+		m := objHeader.FindSubmatchIndex(data[pos:])
+		if m == nil {
+			break
+		}
+		num, err := strconv.Atoi(string(data[pos+m[2] : pos+m[3]]))
+		pos += m[1]
+		if err != nil {
+			continue
+		}
+		obj, n := parseObject(data[pos:])
+		pos += n
+		d.add(num, obj, true)
+	}
+
+	var packed []*object
+	for _, obj := range d.objects {
+		if v, ok := obj.value.(dict); ok && v["/Type"] == name("/ObjStm") {
+			packed = append(packed, obj)
+		}
+	}
+	for _, obj := range packed {
+		d.unpack(obj)
+	}
+	return d
+}
+
+func (d *document) add(num int, obj *object, replace bool) {
+	if _, exists := d.objects[num]; exists && !replace {
+		return
+	}
+	d.objects[num] = obj
+	if v, ok := obj.value.(dict); ok && v["/Type"] == name("/Catalog") {
+		d.root = num
+	}
+}
+
+// unpack adds the objects in an object stream. Objects written directly in
+// the file take precedence.
+func (d *document) unpack(stm *object) {
+	v := stm.value.(dict)
+	n, _ := v["/N"].(float64)
+	first, _ := v["/First"].(float64)
+	data := d.decode(stm)
+	if n <= 0 || first <= 0 || int(first) > len(data) {
+		return
+	}
+
+	lex := &lexer{data: data[:int(first)]}
+	for i := 0; i < int(n); i++ {
+		numTok, ok1 := lex.next()
+		offTok, ok2 := lex.next()
+		num, isNum := numTok.(float64)
+		off, isOff := offTok.(float64)
+		if !ok1 || !ok2 || !isNum || !isOff {
+			return
+		}
+		start := int(first) + int(off)
+		if start < 0 || start >= len(data) {
+			continue
+		}
+		p := &parser{lex: &lexer{data: window(data[start:]), flat: true}}
+		d.add(int(num), &object{value: p.value()}, false)
+	}
+}
+
+// parseObject reads an object's value and stream, if it has one, from just
+// after its "obj" keyword. It returns the object and how many bytes it
+// spans.
+func parseObject(data []byte) (*object, int) {
+	lex := &lexer{data: window(data), flat: true}
+	p := &parser{lex: lex}
+	obj := &object{value: p.value()}
+	v, ok := obj.value.(dict)
+	if !ok {
+		return obj, lex.pos
+	}
+
+	rest := bytes.TrimLeft(data[lex.pos:], " \t\r\n\f")
+	if !bytes.HasPrefix(rest, []byte("stream")) {
+		return obj, lex.pos
+	}
+	start := len(data) - len(rest) + len("stream")
+	if bytes.HasPrefix(data[start:], []byte("\r\n")) {
+		start += 2
+	} else if start < len(data) && (data[start] == '\n' || data[start] == '\r') {
+		start++
+	}
+
+	// Trust /Length when it's direct and lands on endstream
+	if n, ok := v["/Length"].(float64); ok && n >= 0 && start+int(n) <= len(data) {
+		end := start + int(n)
+		if bytes.HasPrefix(bytes.TrimLeft(data[end:], " \t\r\n\f"), []byte("endstream")) {
+			obj.stream = data[start:end]
+			return obj, end
+		}
+	}
+	end := bytes.Index(data[start:], []byte("endstream"))
+	if end < 0 {
+		obj.stream = data[start:]
+		return obj, len(data)
+	}
+	obj.stream = bytes.TrimRight(data[start:start+end], "\r\n")
+	return obj, start + end + len("endstream")
+}
+
+func window(data []byte) []byte {
+	if len(data) > maxObjectSize {
+		return data[:maxObjectSize]
+	}
+	return data
+}
+
+// resolve follows references to the value they point at.
+func (d *document) resolve(v any) any {
+	for i := 0; i < 8; i++ {
+		r, ok := v.(ref)
+		if !ok {
+			return v
+		}
+		obj := d.objects[int(r)]
+		if obj == nil {
+			return nil
+		}
+		v = obj.value
+	}
+	return nil
+}
+
+func (d *document) dict(v any) dict {
+	dd, _ := d.resolve(v).(dict)
+	return dd
+}
+
+// stream returns the decoded data of the stream v refers to, or nil.
+func (d *document) stream(v any) (dict, []byte) {
+	r, ok := v.(ref)
+	if !ok {
+		return nil, nil
+	}
+	obj := d.objects[int(r)]
+	if obj == nil || obj.stream == nil {
+		return nil, nil
+	}
+	dd, _ := obj.value.(dict)
+	return dd, d.decode(obj)
+}
+
+// decode returns a stream's data with its filter undone, or nil for
+// filters other than Flate.
+func (d *document) decode(obj *object) []byte {
+	v, _ := obj.value.(dict)
+	filter := d.resolve(v["/Filter"])
+	if arr, ok := filter.([]any); ok && len(arr) == 1 {
+		filter = arr[0]
+	}
+	switch filter {
+	case nil:
+		return obj.stream
+	case name("/FlateDecode"):
+		inflated, _ := inflate(obj.stream, d.budget)
+		return inflated
+	}
+	return nil
+}
+
+// pages returns each page's contents and resources, in order, following
+// the page tree from the catalog. Resources are inherited from parent
+// nodes as the spec says.
+func (d *document) pages() []dict {
+	root := d.dict(ref(d.root))
+	if root == nil {
+		return nil
+	}
+
+	var pages []dict
+	seen := make(map[ref]bool)
+	var walk func(node any, resources any, depth int)
+	walk = func(node any, resources any, depth int) {
+		if r, ok := node.(ref); ok {
+			if seen[r] {
+				return
+			}
+			seen[r] = true
+		}
+		n := d.dict(node)
+		if n == nil || depth > 32 || len(pages) >= maxPages {
+			return
+		}
+		if res, ok := n["/Resources"]; ok {
+			resources = res
+		}
+		if kids, ok := d.resolve(n["/Kids"]).([]any); ok {
+			for _, kid := range kids {
+				walk(kid, resources, depth+1)
+			}
+			return
+		}
+		pages = append(pages, dict{"/Contents": n["/Contents"], "/Resources": resources})
+	}
+	walk(root["/Pages"], nil, 0)
+	return pages
+}
+
+// pageText writes the text of every page to w. It returns false if the
+// document has no page tree to follow.
+func (d *document) pageText(w *textWriter) bool {
+	pages := d.pages()
+	for _, page := range pages {
+		contents := []any{page["/Contents"]}
+		if arr, ok := d.resolve(page["/Contents"]).([]any); ok {
+			contents = arr
+		}
+		var content []byte
+		for _, c := range contents {
+			_, data := d.stream(c)
+			content = append(content, data...)
+			content = append(content, '\n')
+		}
+		d.run(content, d.dict(page["/Resources"]), w, 0)
+		w.write("\n")
+		if w.full {
+			break
+		}
+	}
+	return len(pages) > 0
+}
+
+// run writes the text a content stream shows, decoding it with the fonts
+// in resources and following the form XObjects it draws.
+func (d *document) run(content []byte, resources dict, w *textWriter, depth int) {
+	contentText(content, d.fonts(resources), w, func(n name) {
+		if depth >= maxFormDepth {
+			return
+		}
+		form, data := d.stream(d.dict(resources["/XObject"])[string(n)])
+		if form == nil || form["/Subtype"] != name("/Form") {
+			return
+		}
+		res := d.dict(form["/Resources"])
+		if res == nil {
+			res = resources
+		}
+		d.run(data, res, w, depth+1)
+	})
+}
+
+// fonts returns the ToUnicode maps of the fonts in resources that have one.
+func (d *document) fonts(resources dict) map[name]*cmap {
+	fonts := make(map[name]*cmap)
+	for key, v := range d.dict(resources["/Font"]) {
+		r, ok := d.dict(v)["/ToUnicode"].(ref)
+		if !ok {
+			continue
+		}
+		cm, cached := d.cmaps[int(r)]
+		if !cached {
+			_, data := d.stream(r)
+			cm = parseCMap(data)
+			d.cmaps[int(r)] = cm
+		}
+		if cm != nil {
+			fonts[name(key)] = cm
+		}
+	}
+	return fonts
+}
+
+// parser reads PDF objects from a lexer in flat mode.
+type parser struct {
+	lex   *lexer
+	depth int
+}
+
+// value reads one object: a dictionary, an array, or a single token.
+func (p *parser) value() any {
+	tok, ok := p.lex.next()
+	if !ok {
+		return nil
+	}
+	switch tok {
+	case operator("<<"):
+		return p.dict()
+	case operator("["):
+		return p.array()
+	}
+	return tok
+}
+
+func (p *parser) dict() dict {
+	items := p.items(">>")
+	d := make(dict, len(items)/2)
+	for i := 0; i+1 < len(items); i += 2 {
+		if k, ok := items[i].(name); ok {
+			d[string(k)] = items[i+1]
+		}
+	}
+	return d
+}
+
+func (p *parser) array() []any {
+	items := p.items("]")
+	if items == nil {
+		items = []any{}
+	}
+	return items
+}
+
+// items reads values up to end, folding "12 0 R" into references.
+func (p *parser) items(end operator) []any {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > 32 {
+		return nil
+	}
+
+	var items []any
+	for p.lex.pos < len(p.lex.data) {
+		v := p.value()
+		if v == nil || v == end {
+			break
+		}
+		if v == operator("R") && len(items) >= 2 {
+			num, ok1 := items[len(items)-2].(float64)
+			_, ok2 := items[len(items)-1].(float64)
+			if ok1 && ok2 {
+				items = append(items[:len(items)-2], ref(num))
+				continue
+			}
+		}
+		items = append(items, v)
+	}
+	return items
+}
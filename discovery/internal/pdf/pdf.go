@@ -12,18 +12,19 @@ import (
 	"strings"
 	"time"
 
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
 	"github.com/zachsouder/rfp/shared/r2"
 )
 
 const (
-	downloadTimeout  = 60 * time.Second
-	maxPDFSize       = 100 * 1024 * 1024 // 100MB
-	userAgent        = "Mozilla/5.0 (compatible; RFPBot/1.0)"
+	downloadTimeout = 60 * time.Second
+	maxPDFSize      = 100 * 1024 * 1024 // 100MB
 )
 
 // Downloader handles PDF download and storage.
 type Downloader struct {
 	r2Client  *r2.Client
+	fetcher   *fetch.Fetcher
 	accountID string
 }
 
@@ -31,6 +32,7 @@ type Downloader struct {
 func NewDownloader(r2Client *r2.Client, accountID string) *Downloader {
 	return &Downloader{
 		r2Client:  r2Client,
+		fetcher:   fetch.Default(),
 		accountID: accountID,
 	}
 }
@@ -126,25 +128,11 @@ func (d *Downloader) generateKey(pdfURL string, rfpID int) string {
 
 // downloadPDF fetches a PDF from a URL.
 func (d *Downloader) downloadPDF(ctx context.Context, pdfURL string) (io.ReadCloser, int64, error) {
-	client := &http.Client{
+	resp, err := d.fetcher.Open(ctx, fetch.Request{
+		URL:     pdfURL,
+		Accept:  fetch.AcceptPDF,
 		Timeout: downloadTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 10 {
-				return http.ErrUseLastResponse
-			}
-			return nil
-		},
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pdfURL, nil)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Accept", "application/pdf,*/*")
-
-	resp, err := client.Do(req)
+	})
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to download PDF: %w", err)
 	}
@@ -0,0 +1,583 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+var (
+	// ErrNotPDF is returned for data that doesn't start with a PDF header.
+	ErrNotPDF = errors.New("not a PDF")
+	// ErrEncrypted is returned for encrypted PDFs, whose streams can't be read.
+	ErrEncrypted = errors.New("PDF is encrypted")
+	// ErrNoText is returned when a PDF has no extractable text, usually
+	// because it is a scan.
+	ErrNoText = errors.New("PDF has no extractable text")
+	// ErrGarbled is returned when a PDF's text can't be decoded, because its
+	// fonts use custom encodings without a ToUnicode map.
+	ErrGarbled = errors.New("PDF text can't be decoded")
+)
+
+const (
+	// maxStreamSize caps how much a single stream may inflate to.
+	maxStreamSize = 20 * 1024 * 1024
+	// maxInflated caps how much all of a PDF's streams may inflate to
+	// together, so a small file of compressed zeros can't exhaust memory.
+	maxInflated = 100 * 1024 * 1024
+	// maxTextSize caps the extracted text; anything past it is dropped.
+	maxTextSize = 2 * 1024 * 1024
+)
+
+// errInflateLimit is returned once a PDF's streams have inflated to
+// maxInflated.
+var errInflateLimit = errors.New("PDF streams inflate past the size limit")
+
+// ExtractText returns the text shown by a PDF's pages, one line per text
+// line where the layout makes that clear. It follows the page tree and
+// decodes text through the fonts' ToUnicode maps, which is how Word and
+// browsers write embedded fonts; when there is no page tree it reads every
+// content stream in file order instead. Only uncompressed and
+// Flate-compressed streams are read. Text that mostly can't be decoded is
+// rejected with ErrGarbled rather than returned as noise.
+func ExtractText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return "", ErrNotPDF
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", ErrEncrypted
+	}
+
+	budget := maxInflated
+	w := &textWriter{}
+	if !parseDocument(data, &budget).pageText(w) || strings.TrimSpace(w.String()) == "" {
+		w = &textWriter{}
+		scanText(data, &budget, w)
+	}
+
+	text := cleanLines(w.String())
+	if text == "" {
+		return "", ErrNoText
+	}
+	if w.garbled(text) {
+		return "", ErrGarbled
+	}
+	return text, nil
+}
+
+// scanText writes the text of every stream that could be page content, in
+// file order, for PDFs without a page tree to follow.
+func scanText(data []byte, budget *int, w *textWriter) {
+	rest := data
+	for {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+		// Skip "endstream" and the dictionary's own mentions of the word
+		if start >= 3 && string(rest[start-3:start]) == "end" {
+			rest = rest[start+len("stream"):]
+			continue
+		}
+		dict := streamDict(rest[:start])
+		body := rest[start+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		rest = body[end+len("endstream"):]
+
+		if !isContentStream(dict) {
+			continue
+		}
+		content := body[:end]
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			inflated, err := inflate(content, budget)
+			if err != nil && len(inflated) == 0 {
+				continue
+			}
+			content = inflated
+		}
+		contentText(content, nil, w, nil)
+		w.write("\n")
+		if w.full {
+			return
+		}
+	}
+}
+
+// streamDict returns the dictionary of the stream whose "stream" keyword
+// follows before.
+func streamDict(before []byte) []byte {
+	if i := bytes.LastIndex(before, []byte("obj")); i >= 0 {
+		return before[i:]
+	}
+	return before
+}
+
+// isContentStream reports whether a stream dictionary could be a page
+// content stream rather than an image, font, or cross-reference data.
+func isContentStream(dict []byte) bool {
+	for _, skip := range []string{"/Image", "/Length1", "/Length2", "/FontFile", "/XRef", "/ObjStm", "/Metadata", "/CMap", "/Type1C", "/CIDFontType0C", "/OpenType", "/Alternate"} {
+		if bytes.Contains(dict, []byte(skip)) {
+			return false
+		}
+	}
+	for _, filter := range []string{"/DCTDecode", "/JPXDecode", "/CCITTFaxDecode", "/JBIG2Decode", "/LZWDecode", "/ASCII85Decode"} {
+		if bytes.Contains(dict, []byte(filter)) {
+			return false
+		}
+	}
+	return true
+}
+
+// inflate decompresses a Flate stream, returning what it could read if the
+// stream is truncated. What it reads is taken from budget, the bytes left
+// for the whole PDF.
+func inflate(data []byte, budget *int) ([]byte, error) {
+	limit := min(maxStreamSize, *budget)
+	if limit <= 0 {
+		return nil, errInflateLimit
+	}
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	out, err := io.ReadAll(io.LimitReader(r, int64(limit)))
+	*budget -= len(out)
+	return out, err
+}
+
+// contentText runs the text operators of a content stream, writing the text
+// they show to w. Strings are decoded through the ToUnicode map of the font
+// selected with Tf when fonts has one. do, if set, is called with the name
+// of each XObject drawn.
+func contentText(content []byte, fonts map[name]*cmap, w *textWriter, do func(name)) {
+	var operands []any
+	var font *cmap
+	lex := &lexer{data: content}
+
+	show := func(i int) {
+		if i >= 0 && i < len(operands) {
+			if s, ok := operands[i].(rawString); ok {
+				w.write(w.decode(s, font))
+			}
+		}
+	}
+
+	for !w.full {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		op, isOp := tok.(operator)
+		if !isOp {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch op {
+		case "Tf":
+			font = nil
+			if len(operands) >= 2 {
+				if n, ok := operands[len(operands)-2].(name); ok {
+					font = fonts[n]
+				}
+			}
+		case "Tj":
+			show(len(operands) - 1)
+		case "'":
+			w.write("\n")
+			show(len(operands) - 1)
+		case "\"":
+			w.write("\n")
+			show(len(operands) - 1)
+		case "TJ":
+			if len(operands) > 0 {
+				if arr, ok := operands[len(operands)-1].([]any); ok {
+					for _, el := range arr {
+						switch v := el.(type) {
+						case rawString:
+							w.write(w.decode(v, font))
+						case float64:
+							// Large negative kerning is a word gap
+							if v < -200 {
+								w.write(" ")
+							}
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if ty, ok := operands[len(operands)-1].(float64); ok && ty != 0 {
+					w.write("\n")
+				} else {
+					w.write(" ")
+				}
+			}
+		case "T*", "Tm", "ET":
+			w.write("\n")
+		case "Do":
+			if do != nil && len(operands) > 0 {
+				if n, ok := operands[len(operands)-1].(name); ok {
+					do(n)
+				}
+			}
+		case "ID":
+			lex.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+// textWriter collects extracted text up to maxTextSize and counts the
+// character codes that couldn't be decoded.
+type textWriter struct {
+	strings.Builder
+	full      bool
+	codes     int // character codes read
+	undecoded int // codes that were control characters or had no mapping
+}
+
+func (w *textWriter) write(s string) {
+	if w.full {
+		return
+	}
+	if w.Len()+len(s) > maxTextSize {
+		w.full = true
+		return
+	}
+	w.WriteString(s)
+}
+
+// decode decodes a shown string through font's ToUnicode map, or as
+// UTF-16 or Latin-1 without one.
+func (w *textWriter) decode(s rawString, font *cmap) string {
+	if font != nil {
+		text, missing := font.decode(s)
+		w.codes += len(s) / font.codeLen
+		w.undecoded += missing
+		return text
+	}
+	if len(s) < 2 || s[0] != 0xFE || s[1] != 0xFF {
+		// Two-byte glyph IDs read as Latin-1 are mostly control characters
+		w.codes += len(s)
+		for _, c := range s {
+			if c < 0x20 && c != '\t' && c != '\n' && c != '\r' {
+				w.undecoded++
+			}
+		}
+	}
+	return decodeString(s)
+}
+
+// garbled reports whether too much of the text couldn't be decoded for it
+// to be worth reading: a fifth of its character codes, or a fifth of the
+// text being unprintable.
+func (w *textWriter) garbled(text string) bool {
+	if w.undecoded*5 > w.codes {
+		return true
+	}
+	var bad, total int
+	for _, r := range text {
+		total++
+		if r == unicode.ReplacementChar || unicode.Is(unicode.Co, r) || (!unicode.IsPrint(r) && !unicode.IsSpace(r)) {
+			bad++
+		}
+	}
+	return bad*5 > total
+}
+
+// cleanLines collapses runs of whitespace within lines and drops blank ones.
+func cleanLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// operator is a content stream operator such as Tj.
+type operator string
+
+// rawString is a string operand as written, before any font decodes it.
+type rawString []byte
+
+// lexer tokenizes a content stream into operators and operands: strings,
+// numbers, names, and arrays of those. In flat mode, used for reading
+// objects, brackets come back as "[" and "]" operators instead of arrays.
+type lexer struct {
+	data []byte
+	pos  int
+	flat bool
+}
+
+func (l *lexer) next() (any, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, false
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		return l.literal(), true
+	case c == '<' && l.peek(1) == '<':
+		l.pos += 2
+		return operator("<<"), true
+	case c == '>' && l.peek(1) == '>':
+		l.pos += 2
+		return operator(">>"), true
+	case c == '<':
+		return l.hex(), true
+	case c == '[' && l.flat:
+		l.pos++
+		return operator("["), true
+	case c == ']' && l.flat:
+		l.pos++
+		return operator("]"), true
+	case c == '[':
+		l.pos++
+		var arr []any
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return arr, true
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				return arr, true
+			}
+			tok, ok := l.next()
+			if !ok {
+				return arr, true
+			}
+			arr = append(arr, tok)
+		}
+	case c == ']' || c == '{' || c == '}' || c == ')' || c == '>':
+		l.pos++
+		return l.next()
+	case c == '/':
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.data) && !isDelimiter(l.data[l.pos]) && !isSpace(l.data[l.pos]) {
+			l.pos++
+		}
+		return name(l.data[start:l.pos]), true
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.number(), true
+	default:
+		start := l.pos
+		for l.pos < len(l.data) && !isDelimiter(l.data[l.pos]) && !isSpace(l.data[l.pos]) {
+			l.pos++
+		}
+		if l.pos == start {
+			l.pos++
+		}
+		return operator(l.data[start:l.pos]), true
+	}
+}
+
+// name is a content stream name operand such as /F1.
+type name string
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.data) {
+		return l.data[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) number() float64 {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c != '.' && (c < '0' || c > '9') {
+			break
+		}
+		l.pos++
+	}
+	var n, frac float64
+	neg := false
+	scale := 0.0
+	for _, c := range l.data[start:l.pos] {
+		switch {
+		case c == '-':
+			neg = true
+		case c == '.':
+			scale = 1
+		case scale > 0:
+			scale *= 10
+			frac += float64(c-'0') / scale
+		case c >= '0' && c <= '9':
+			n = n*10 + float64(c-'0')
+		}
+	}
+	if neg {
+		return -(n + frac)
+	}
+	return n + frac
+}
+
+// literal reads a (string), handling nested parentheses and escapes.
+func (l *lexer) literal() rawString {
+	l.pos++ // (
+	var buf []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			buf = append(buf, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return buf
+			}
+			buf = append(buf, c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				break
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				buf = append(buf, '\n')
+			case 'r':
+				buf = append(buf, '\r')
+			case 't':
+				buf = append(buf, '\t')
+			case 'b', 'f':
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					buf = append(buf, byte(v))
+				} else {
+					buf = append(buf, e)
+				}
+			}
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// hex reads a <hex string>.
+func (l *lexer) hex() rawString {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; isHexDigit(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	buf := make([]byte, len(digits)/2)
+	for i := range buf {
+		buf[i] = hexValue(digits[2*i])<<4 | hexValue(digits[2*i+1])
+	}
+	return buf
+}
+
+// skipInlineImage skips the binary data of an inline image up to EI.
+func (l *lexer) skipInlineImage() {
+	for l.pos+2 < len(l.data) {
+		if isSpace(l.data[l.pos]) && l.data[l.pos+1] == 'E' && l.data[l.pos+2] == 'I' &&
+			(l.pos+3 == len(l.data) || isSpace(l.data[l.pos+3])) {
+			l.pos += 3
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
+
+// decodeString decodes a PDF string as UTF-16 when it has a byte order mark
+// and as Latin-1 otherwise, dropping control characters.
+func decodeString(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+	var sb strings.Builder
+	for _, c := range b {
+		switch {
+		case c == '\t' || c == '\n' || c == '\r':
+			sb.WriteByte(' ')
+		case c < 0x20:
+		default:
+			sb.WriteRune(rune(c))
+		}
+	}
+	return sb.String()
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
@@ -0,0 +1,219 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF assembles a minimal PDF around the given content streams,
+// compressing those marked to be.
+func buildPDF(t *testing.T, streams map[string]bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	buf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	n := 3
+	for content, compress := range streams {
+		data := []byte(content)
+		filter := ""
+		if compress {
+			var z bytes.Buffer
+			w := zlib.NewWriter(&z)
+			w.Write(data)
+			w.Close()
+			data = z.Bytes()
+			filter = " /Filter /FlateDecode"
+		}
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Length %d%s >>\nstream\n", n, len(data), filter)
+		buf.Write(data)
+		buf.WriteString("\nendstream\nendobj\n")
+		n++
+	}
+	// An image stream whose bytes must not be read as text
+	buf.WriteString("9 0 obj\n<< /Type /XObject /Subtype /Image /Length 14 >>\nstream\n(junk) Tj BT\nendstream\nendobj\n")
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func TestExtractText(t *testing.T) {
+	data := buildPDF(t, map[string]bool{
+		"BT /F1 12 Tf 72 720 Td (Parking Management Services) Tj 0 -14 Td [(Due ) -50 (March 15, 2026) ] TJ ET":           true,
+		"BT /F1 10 Tf 72 600 Td (Contact: J. Smith \\(Purchasing\\)) Tj T* <5265666572656e6365> Tj ( RFP-2026-014) Tj ET": false,
+	})
+
+	got, err := ExtractText(data)
+	if err != nil {
+		t.Fatalf("ExtractText() error = %v", err)
+	}
+	for _, want := range []string{
+		"Parking Management Services\n",
+		"Due March 15, 2026",
+		"Contact: J. Smith (Purchasing)\nReference RFP-2026-014",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ExtractText() = %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "junk") {
+		t.Errorf("ExtractText() read an image stream: %q", got)
+	}
+}
+
+func TestExtractText_Errors(t *testing.T) {
+	if _, err := ExtractText([]byte("<html>not a pdf</html>")); !errors.Is(err, ErrNotPDF) {
+		t.Errorf("html: error = %v, want ErrNotPDF", err)
+	}
+
+	scan := buildPDF(t, map[string]bool{"q 612 0 0 792 0 0 cm /Im1 Do Q": true})
+	if _, err := ExtractText(scan); !errors.Is(err, ErrNoText) {
+		t.Errorf("scan: error = %v, want ErrNoText", err)
+	}
+
+	encrypted := append(buildPDF(t, nil), []byte("trailer << /Encrypt 5 0 R >>")...)
+	if _, err := ExtractText(encrypted); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("encrypted: error = %v, want ErrEncrypted", err)
+	}
+}
+
+func TestDecodeString(t *testing.T) {
+	if got := decodeString([]byte{0xFE, 0xFF, 0x00, 'O', 0x00, 'K'}); got != "OK" {
+		t.Errorf("utf-16: got %q", got)
+	}
+	if got := decodeString([]byte("caf\xe9")); got != "café" {
+		t.Errorf("latin-1: got %q", got)
+	}
+}
+
+// buildPagedPDF assembles a PDF with a page tree: objects are numbered from
+// 1 in order, and 1 must be the catalog.
+func buildPagedPDF(objs ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	for i, obj := range objs {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func streamObject(content string, compress bool, extra string) string {
+	data := []byte(content)
+	if compress {
+		var z bytes.Buffer
+		w := zlib.NewWriter(&z)
+		w.Write(data)
+		w.Close()
+		data = z.Bytes()
+		extra += " /Filter /FlateDecode"
+	}
+	return fmt.Sprintf("<< /Length %d%s >>\nstream\n%s\nendstream", len(data), extra, data)
+}
+
+// glyphs encodes text as the two-byte glyph IDs of a subset TrueType font
+// written with Identity-H, where glyphs sit 29 below their ASCII codes.
+func glyphs(text string) string {
+	var sb strings.Builder
+	sb.WriteString("<")
+	for _, r := range text {
+		if r == ' ' {
+			sb.WriteString("0003")
+		} else {
+			fmt.Fprintf(&sb, "%04X", r-29)
+		}
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
+
+const toUnicode = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+1 beginbfchar
+<0003> <0020>
+endbfchar
+1 beginbfrange
+<0024> <005D> <0041>
+endbfrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end`
+
+func TestExtractText_ToUnicode(t *testing.T) {
+	font := "<< /Type /Font /Subtype /Type0 /BaseFont /AAAAAA+Arial /Encoding /Identity-H /ToUnicode 8 0 R >>"
+	data := buildPagedPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [4 0 R 3 0 R] /Count 2 /Resources << /Font << /F4 7 0 R >> /XObject << /X1 9 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [5 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>",
+		streamObject("BT /F4 12 Tf 72 720 Td "+glyphs("Parking Enforcement")+" Tj ET", true, ""),
+		streamObject("BT /F4 12 Tf 72 720 Td "+glyphs("Request for Proposals")+" Tj ET q /X1 Do Q", true, ""),
+		font,
+		streamObject(toUnicode, true, ""),
+		streamObject("BT /F4 9 Tf 72 40 Td ["+glyphs("Due")+" -300 "+glyphs("May")+"] TJ ET", false, " /Type /XObject /Subtype /Form /BBox [0 0 612 792]"),
+	)
+
+	got, err := ExtractText(data)
+	if err != nil {
+		t.Fatalf("ExtractText() error = %v", err)
+	}
+	want := "Request for Proposals\nDue May\nParking Enforcement"
+	if got != want {
+		t.Errorf("ExtractText() = %q, want %q", got, want)
+	}
+
+	// The same glyph IDs without a ToUnicode map can't be read
+	noMap := strings.Replace(string(data), " /ToUnicode 8 0 R", "", 1)
+	if _, err := ExtractText([]byte(noMap)); !errors.Is(err, ErrGarbled) {
+		t.Errorf("without ToUnicode: error = %v, want ErrGarbled", err)
+	}
+}
+
+func TestParseCMap(t *testing.T) {
+	cm := parseCMap([]byte(`1 begincodespacerange <00> <FF> endcodespacerange
+2 beginbfchar <01> <0066006C> <02> <00E9> endbfchar
+1 beginbfrange <41> <43> [<0058> <0059> <005A>] endbfrange`))
+	if cm == nil {
+		t.Fatal("parseCMap() = nil")
+	}
+	got, missing := cm.decode(rawString("\x01\x41\x43\x02!\x05"))
+	if got != "flXZé!" || missing != 1 {
+		t.Errorf("decode() = %q, %d; want %q, 1", got, missing, "flXZé!")
+	}
+	if parseCMap([]byte("begincmap endcmap")) != nil {
+		t.Error("parseCMap() without mappings should be nil")
+	}
+}
+
+func TestInflate_Budget(t *testing.T) {
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	w.Write(make([]byte, 1<<20))
+	w.Close()
+
+	budget := 1000
+	out, _ := inflate(z.Bytes(), &budget)
+	if len(out) != 1000 || budget != 0 {
+		t.Errorf("inflate() = %d bytes, budget %d; want 1000, 0", len(out), budget)
+	}
+	if _, err := inflate(z.Bytes(), &budget); !errors.Is(err, errInflateLimit) {
+		t.Errorf("inflate() past the budget: error = %v, want errInflateLimit", err)
+	}
+}
+
+func TestTextWriter_Limit(t *testing.T) {
+	w := &textWriter{}
+	w.write(strings.Repeat("a", maxTextSize-1))
+	w.write("bc")
+	w.write("d")
+	if !w.full || w.Len() != maxTextSize-1 {
+		t.Errorf("full = %v, len = %d; want true, %d", w.full, w.Len(), maxTextSize-1)
+	}
+}
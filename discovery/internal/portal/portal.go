@@ -0,0 +1,197 @@
+// Package portal recognizes procurement portal URLs, extracting the portal,
+// the agency's slug on it, and the opportunity ID, and telling single
+// opportunity pages from listings.
+package portal
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Page is the kind of portal page a URL points to.
+type Page string
+
+const (
+	PageOpportunity Page = "opportunity" // a single solicitation
+	PageListing     Page = "listing"     // an agency's bid list, search, or other portal page
+)
+
+// Match is a parsed portal URL.
+type Match struct {
+	Portal string // registry name, e.g. "bonfire"
+	Agency string // agency slug on the portal, if the URL has one
+	ID     string // opportunity ID, set for PageOpportunity
+	Page   Page
+}
+
+// Portal describes a known procurement portal.
+type Portal struct {
+	Name    string   // stored in portal columns
+	Domains []string // registrable domains; subdomains match too
+	rules   []rule
+}
+
+// rule matches one URL shape on a portal. The pattern runs against the host
+// and path, and may capture agency and id groups.
+type rule struct {
+	page    Page
+	pattern *regexp.Regexp
+	idParam string // query parameter holding the ID, when it's not in the path
+}
+
+// pattern compiles a case-insensitive rule pattern.
+func pattern(expr string) *regexp.Regexp {
+	return regexp.MustCompile("(?i)" + expr)
+}
+
+// genericSubdomains are portal subdomains that aren't agency slugs.
+var genericSubdomains = map[string]bool{
+	"www": true, "app": true, "vendor": true, "vendors": true, "go": true,
+	"help": true, "support": true, "secure": true, "procurement": true,
+}
+
+// registry lists the portals we know, most specific rules first. Portals
+// with no rules are recognized by domain only and always parse as listings.
+var registry = []Portal{
+	{
+		Name: "bonfire", Domains: []string{"bonfirehub.com", "bonfirehub.ca"},
+		rules: []rule{
+			{page: PageOpportunity, pattern: pattern(`^(?P<agency>[a-z0-9-]+)\.bonfirehub\.(com|ca)/opportunities/(?P<id>\d+)`)},
+			{page: PageListing, pattern: pattern(`^(?P<agency>[a-z0-9-]+)\.bonfirehub\.(com|ca)`)},
+		},
+	},
+	{
+		Name: "opengov", Domains: []string{"opengov.com", "procurenow.com"},
+		rules: []rule{
+			{page: PageOpportunity, pattern: pattern(`^[a-z.]*(opengov|procurenow)\.com/portal/(?P<agency>[^/]+)/projects/(?P<id>\d+)`)},
+			{page: PageListing, pattern: pattern(`^[a-z.]*(opengov|procurenow)\.com/portal/(?P<agency>[^/]+)`)},
+		},
+	},
+	{
+		Name: "planetbids", Domains: []string{"planetbids.com"},
+		rules: []rule{
+			{page: PageOpportunity, pattern: pattern(`^[a-z.]*planetbids\.com/portal/(?P<agency>\d+)/bo/bo-detail/(?P<id>\d+)`)},
+			{page: PageListing, pattern: pattern(`^[a-z.]*planetbids\.com/portal/(?P<agency>\d+)`)},
+		},
+	},
+	{
+		Name: "bidnet", Domains: []string{"bidnetdirect.com", "bidnet.com"},
+		rules: []rule{
+			{page: PageOpportunity, pattern: pattern(`^[a-z.]*bidnetdirect\.com/[^/]+/(?P<agency>[^/]+)/solicitations/(open|closed)-bids/[^/]+/(?P<id>\d+)`)},
+			{page: PageListing, pattern: pattern(`^[a-z.]*bidnetdirect\.com/[^/]+/(?P<agency>[^/]+)/solicitations`)},
+		},
+	},
+	{
+		Name: "ionwave", Domains: []string{"ionwave.net"},
+		rules: []rule{
+			{page: PageOpportunity, pattern: pattern(`^(?P<agency>[a-z0-9-]+)\.ionwave\.net/sourcingevents?\.aspx`), idParam: "SourceEventID"},
+			{page: PageListing, pattern: pattern(`^(?P<agency>[a-z0-9-]+)\.ionwave\.net`)},
+		},
+	},
+	{
+		Name: "publicpurchase", Domains: []string{"publicpurchase.com"},
+		rules: []rule{
+			{page: PageOpportunity, pattern: pattern(`^[a-z.]*publicpurchase\.com/gems/bid/bidview`), idParam: "bidId"},
+			{page: PageListing, pattern: pattern(`^[a-z.]*publicpurchase\.com/gems/(?P<agency>[^/,]+)(,[a-z]{2})?/buyer/`)},
+		},
+	},
+	{
+		Name: "demandstar", Domains: []string{"demandstar.com"},
+		rules: []rule{
+			{page: PageOpportunity, pattern: pattern(`^[a-z.]*demandstar\.com/app/(limited/)?bids/(?P<id>\d+)`)},
+			{page: PageListing, pattern: pattern(`^[a-z.]*demandstar\.com/app/agencies/[^/]+/(?P<agency>[^/]+)`)},
+		},
+	},
+	{
+		Name: "bidsync", Domains: []string{"bidsync.com", "periscopeholdings.com"},
+		rules: []rule{
+			{page: PageOpportunity, pattern: pattern(`^[a-z.]*bidsync\.com/.*biddetail`), idParam: "bidid"},
+		},
+	},
+	{
+		Name: "sam", Domains: []string{"sam.gov"},
+		rules: []rule{
+			{page: PageOpportunity, pattern: pattern(`^[a-z.]*sam\.gov/(workspace/contract/)?opp/(?P<id>[0-9a-f]{32})`)},
+		},
+	},
+	{Name: "vendorregistry", Domains: []string{"vendorregistry.com"}},
+	{Name: "negometrix", Domains: []string{"negometrix.com"}},
+	{Name: "procurato", Domains: []string{"procurato.com"}},
+}
+
+// Parse recognizes a portal URL. It returns nil for URLs that aren't on a
+// known portal.
+func Parse(rawURL string) *Match {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	p := lookupHost(host)
+	if p == nil {
+		return nil
+	}
+
+	target := host + u.Path
+	for _, r := range p.rules {
+		groups := r.match(target)
+		if groups == nil {
+			continue
+		}
+		id := groups["id"]
+		if r.idParam != "" {
+			id = queryValue(u.Query(), r.idParam)
+		}
+		if r.page == PageOpportunity && id == "" {
+			continue
+		}
+
+		m := &Match{Portal: p.Name, Page: r.page, ID: id}
+		if agency := strings.ToLower(groups["agency"]); !genericSubdomains[agency] {
+			m.Agency = agency
+		}
+		return m
+	}
+
+	return &Match{Portal: p.Name, Page: PageListing}
+}
+
+// lookupHost returns the portal serving a host, or nil.
+func lookupHost(host string) *Portal {
+	for i := range registry {
+		for _, domain := range registry[i].Domains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return &registry[i]
+			}
+		}
+	}
+	return nil
+}
+
+// match returns the rule's named groups for a target, or nil if it doesn't
+// match.
+func (r rule) match(target string) map[string]string {
+	sub := r.pattern.FindStringSubmatch(target)
+	if sub == nil {
+		return nil
+	}
+	groups := make(map[string]string)
+	for i, name := range r.pattern.SubexpNames() {
+		if name != "" {
+			groups[name] = sub[i]
+		}
+	}
+	return groups
+}
+
+// queryValue reads a query parameter, ignoring the key's case.
+func queryValue(q url.Values, key string) string {
+	for k, v := range q {
+		if strings.EqualFold(k, key) && len(v) > 0 {
+			return strings.TrimSpace(v[0])
+		}
+	}
+	return ""
+}
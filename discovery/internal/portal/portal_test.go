@@ -0,0 +1,69 @@
+package portal
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		url  string
+		want *Match
+	}{
+		{"https://cityofaustin.bonfirehub.com/opportunities/123456",
+			&Match{Portal: "bonfire", Agency: "cityofaustin", ID: "123456", Page: PageOpportunity}},
+		{"https://cityofaustin.bonfirehub.com/portal/?tab=openOpportunities",
+			&Match{Portal: "bonfire", Agency: "cityofaustin", Page: PageListing}},
+		{"https://app.bonfirehub.com/portal/rfp/123",
+			&Match{Portal: "bonfire", Page: PageListing}},
+		{"https://procurement.opengov.com/portal/sandiego/projects/98765",
+			&Match{Portal: "opengov", Agency: "sandiego", ID: "98765", Page: PageOpportunity}},
+		{"https://secure.procurenow.com/portal/sandiego",
+			&Match{Portal: "opengov", Agency: "sandiego", Page: PageListing}},
+		{"https://vendors.planetbids.com/portal/14319/bo/bo-detail/112233",
+			&Match{Portal: "planetbids", Agency: "14319", ID: "112233", Page: PageOpportunity}},
+		{"https://pbsystem.planetbids.com/portal/14319/bo/bo-search",
+			&Match{Portal: "planetbids", Agency: "14319", Page: PageListing}},
+		{"https://www.bidnetdirect.com/california/cityoffresno/solicitations/open-bids/Parking-Management-Services/0000298765",
+			&Match{Portal: "bidnet", Agency: "cityoffresno", ID: "0000298765", Page: PageOpportunity}},
+		{"https://www.bidnetdirect.com/california/cityoffresno/solicitations/open-bids",
+			&Match{Portal: "bidnet", Agency: "cityoffresno", Page: PageListing}},
+		{"https://harriscounty.ionwave.net/SourcingEvents.aspx?SourceEventID=20240117",
+			&Match{Portal: "ionwave", Agency: "harriscounty", ID: "20240117", Page: PageOpportunity}},
+		{"https://harriscounty.ionwave.net/CurrentSourcingEvents.aspx",
+			&Match{Portal: "ionwave", Agency: "harriscounty", Page: PageListing}},
+		{"https://www.publicpurchase.com/gems/bid/bidView?bidId=55511",
+			&Match{Portal: "publicpurchase", ID: "55511", Page: PageOpportunity}},
+		{"https://www.publicpurchase.com/gems/boisestate,id/buyer/public/publicInfo",
+			&Match{Portal: "publicpurchase", Agency: "boisestate", Page: PageListing}},
+		{"https://www.demandstar.com/app/limited/bids/401122/details",
+			&Match{Portal: "demandstar", ID: "401122", Page: PageOpportunity}},
+		{"https://sam.gov/opp/0123456789abcdef0123456789abcdef/view",
+			&Match{Portal: "sam", ID: "0123456789abcdef0123456789abcdef", Page: PageOpportunity}},
+		{"https://www.opengov.com/solicitation/456", &Match{Portal: "opengov", Page: PageListing}},
+		{"https://www.negometrix.com/tender/1", &Match{Portal: "negometrix", Page: PageListing}},
+
+		// An opportunity rule without its ID falls through to the listing
+		{"https://harriscounty.ionwave.net/SourcingEvents.aspx",
+			&Match{Portal: "ionwave", Agency: "harriscounty", Page: PageListing}},
+
+		{"https://www.austintexas.gov/department/purchasing", nil},
+		{"https://notbonfirehub.com/opportunities/1", nil},
+		{"not a url", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got := Parse(tt.url)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("Parse() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("Parse() = nil, want %+v", tt.want)
+			}
+			if *got != *tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
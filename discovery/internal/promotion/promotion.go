@@ -0,0 +1,134 @@
+// Package promotion turns researched search results into RFP records.
+package promotion
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zachsouder/rfp/discovery/internal/classify"
+	"github.com/zachsouder/rfp/discovery/internal/dedup"
+	"github.com/zachsouder/rfp/discovery/internal/normalize"
+	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+// BuildRFP builds the RFP record for a researched search result. Fields the
+// agent could not extract fall back to the search result's title and hints.
+// Free-text dates, money and terms are parsed into typed fields; values that
+// can't be parsed are kept in RawValues. Returns nil if the research produced
+// no details.
+func BuildRFP(sr *models.SearchResult, res *research.ResearchResult) *models.RFP {
+	d := res.ExtractedDetails
+	if d == nil {
+		return nil
+	}
+
+	rfp := &models.RFP{
+		Title:     firstNonEmpty(d.Title, sr.Title),
+		Agency:    firstNonEmpty(d.Agency, sr.HintAgency),
+		State:     dedup.NormalizeState(firstNonEmpty(d.State, sr.HintState)),
+		City:      strings.TrimSpace(d.City),
+		SourceURL: firstNonEmpty(sr.FinalURL, sr.URL),
+		Incumbent: strings.TrimSpace(d.Incumbent),
+		PDFURLs:   res.FoundPDFs,
+
+		Portal:       sr.Portal,
+		PortalID:     sr.PortalID,
+		PortalAgency: sr.PortalAgency,
+
+		RawContent: res.SourceText,
+
+		PreBidMandatory:       d.PreBidMandatory,
+		SubmissionMethod:      d.SubmissionMethod,
+		SolicitationNumber:    strings.TrimSpace(d.SolicitationNumber),
+		ContactName:           strings.TrimSpace(d.ContactName),
+		ContactEmail:          strings.TrimSpace(d.ContactEmail),
+		BondRequirements:      d.BondRequirements,
+		InsuranceRequirements: d.InsuranceRequirements,
+		TermMonths:            d.TermMonths,
+
+		FieldEvidence:   d.Evidence,
+		ReviewFields:    research.ReviewFields(d),
+		LifecycleStatus: models.LifecycleOpen,
+		IsActive:        true,
+	}
+
+	// The taxonomy decides scope and vocabulary, from the page and the PDFs it
+	// links to; the model's own labels are a fallback when the text doesn't
+	// match any taxonomy terms
+	cls := classify.Classify(rfp.Title, res.SourceText, res.DocumentText, d.ScopeSummary)
+	rfp.ScopeKeywords = cls.ScopeKeywords
+	rfp.Category = firstNonEmpty(cls.Category, classify.NormalizeCategory(d.Category))
+	rfp.VenueType = firstNonEmpty(cls.VenueType, classify.NormalizeVenueType(d.VenueType))
+
+	raw := make(map[string]string)
+
+	if due := normalize.ParseDate(d.DueDate, rfp.State); due != nil {
+		rfp.DueDate = dateOnly(due)
+		if due.HasTime {
+			rfp.DueAt = &due.Time
+		}
+	} else {
+		keepRaw(raw, "due_date", d.DueDate)
+		rfp.DueDate = sr.HintDueDate
+	}
+
+	if preBid := normalize.ParseDate(d.PreBidDate, rfp.State); preBid != nil {
+		rfp.PreBidDate = &preBid.Time
+	} else {
+		keepRaw(raw, "pre_bid_meeting_date", d.PreBidDate)
+	}
+
+	if questions := normalize.ParseDate(d.QuestionsDeadline, rfp.State); questions != nil {
+		rfp.QuestionsDeadline = &questions.Time
+	} else {
+		keepRaw(raw, "questions_deadline", d.QuestionsDeadline)
+	}
+
+	if money := normalize.ParseMoney(d.EstimatedValue); money != nil {
+		rfp.EstimatedValue = &money.Amount
+		rfp.EstimatedValueMax = money.Max
+		rfp.ValueNotToExceed = money.NotToExceed
+	} else {
+		keepRaw(raw, "estimated_value", d.EstimatedValue)
+	}
+
+	// The written term beats the model's own arithmetic
+	if term := normalize.ParseTerm(d.ContractTerm); term != nil {
+		base, renewal, total := term.BaseMonths, term.RenewalMonths, term.TotalMonths()
+		rfp.BaseTermMonths = &base
+		rfp.RenewalMonths = &renewal
+		rfp.TermMonths = &total
+	} else {
+		keepRaw(raw, "contract_term", d.ContractTerm)
+	}
+
+	if len(raw) > 0 {
+		rfp.RawValues = raw
+	}
+
+	return rfp
+}
+
+// dateOnly returns the calendar date of a parsed date in its own time zone.
+func dateOnly(d *normalize.Date) *time.Time {
+	day := time.Date(d.Time.Year(), d.Time.Month(), d.Time.Day(), 0, 0, 0, 0, time.UTC)
+	return &day
+}
+
+// keepRaw records the text of a value that couldn't be parsed.
+func keepRaw(raw map[string]string, field, text string) {
+	if text = strings.TrimSpace(text); text != "" {
+		raw[field] = text
+	}
+}
+
+// firstNonEmpty returns the first non-blank value.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package promotion
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+func TestBuildRFP(t *testing.T) {
+	months := 36
+	sr := &models.SearchResult{
+		ID:         7,
+		URL:        "https://example.gov/bids/123",
+		FinalURL:   "https://example.gov/bids/123?view=1",
+		Title:      "Search title",
+		HintAgency: "City of Springfield",
+		HintState:  "Illinois",
+	}
+	res := &research.ResearchResult{
+		Status:     research.StatusResearched,
+		FoundPDFs:  []string{"https://example.gov/bids/123/rfp.pdf"},
+		SourceText: "The Civic Center seeks a vendor for event parking, attendants and traffic control.",
+		// Only the linked PDF mentions the shuttle
+		DocumentText: "Scope of work: shuttle service between the remote lots and the venue.",
+		ExtractedDetails: &research.ExtractedDetails{
+			Title:          "Parking Management Services",
+			DueDate:        "March 15, 2024 at 2:00 PM",
+			EstimatedValue: "$1,200,000 - $1,500,000",
+			TermMonths:     &months,
+			ContractTerm:   "three (3) years with two one-year renewals",
+			Evidence: []models.FieldEvidence{
+				{Field: "title", Confidence: 0.95, Evidence: "Parking Management Services", Verified: true},
+				{Field: "due_date", Confidence: 0.5, Evidence: "due March 15", Verified: true},
+			},
+		},
+	}
+
+	rfp := BuildRFP(sr, res)
+	if rfp == nil {
+		t.Fatal("BuildRFP() returned nil")
+	}
+
+	if rfp.Title != "Parking Management Services" {
+		t.Errorf("Title = %q", rfp.Title)
+	}
+	if rfp.Agency != "City of Springfield" {
+		t.Errorf("Agency = %q, want hint fallback", rfp.Agency)
+	}
+	if rfp.State != "IL" {
+		t.Errorf("State = %q, want IL", rfp.State)
+	}
+	if rfp.SourceURL != sr.FinalURL {
+		t.Errorf("SourceURL = %q, want final URL", rfp.SourceURL)
+	}
+	if rfp.DueDate == nil || rfp.DueDate.Format("2006-01-02") != "2024-03-15" {
+		t.Errorf("DueDate = %v", rfp.DueDate)
+	}
+	if rfp.DueAt == nil || rfp.DueAt.Format(time.RFC3339) != "2024-03-15T14:00:00-05:00" {
+		t.Errorf("DueAt = %v, want 2pm Central", rfp.DueAt)
+	}
+	if rfp.EstimatedValue == nil || *rfp.EstimatedValue != 1200000 {
+		t.Errorf("EstimatedValue = %v", rfp.EstimatedValue)
+	}
+	if rfp.EstimatedValueMax == nil || *rfp.EstimatedValueMax != 1500000 {
+		t.Errorf("EstimatedValueMax = %v", rfp.EstimatedValueMax)
+	}
+	if rfp.TermMonths == nil || *rfp.TermMonths != 60 {
+		t.Errorf("TermMonths = %v, want 60 including renewals", rfp.TermMonths)
+	}
+	if rfp.BaseTermMonths == nil || *rfp.BaseTermMonths != 36 {
+		t.Errorf("BaseTermMonths = %v", rfp.BaseTermMonths)
+	}
+	if rfp.RenewalMonths == nil || *rfp.RenewalMonths != 24 {
+		t.Errorf("RenewalMonths = %v", rfp.RenewalMonths)
+	}
+	if rfp.RawValues != nil {
+		t.Errorf("RawValues = %v, want nil", rfp.RawValues)
+	}
+	if len(rfp.FieldEvidence) != 2 {
+		t.Errorf("FieldEvidence = %v, want 2 entries", rfp.FieldEvidence)
+	}
+	wantReview := []string{"contract_term", "due_date", "estimated_value", "term_months"}
+	if !reflect.DeepEqual(rfp.ReviewFields, wantReview) {
+		t.Errorf("ReviewFields = %v, want %v", rfp.ReviewFields, wantReview)
+	}
+	if !reflect.DeepEqual(rfp.ScopeKeywords, []string{"parking management", "attendants", "event parking", "traffic control", "shuttle"}) {
+		t.Errorf("ScopeKeywords = %v", rfp.ScopeKeywords)
+	}
+	if rfp.Category != "parking" || rfp.VenueType != "convention_center" {
+		t.Errorf("Category = %q, VenueType = %q", rfp.Category, rfp.VenueType)
+	}
+	if rfp.LifecycleStatus != models.LifecycleOpen {
+		t.Errorf("LifecycleStatus = %q", rfp.LifecycleStatus)
+	}
+}
+
+func TestBuildRFP_Fallbacks(t *testing.T) {
+	hintDue := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	sr := &models.SearchResult{
+		URL:         "https://example.gov/rfp",
+		Title:       "Valet Services RFP",
+		HintDueDate: &hintDue,
+	}
+	res := &research.ResearchResult{
+		ExtractedDetails: &research.ExtractedDetails{
+			DueDate:        "sometime soon",
+			EstimatedValue: "TBD",
+			Category:       "Valet Parking",
+			VenueType:      "Stadium",
+		},
+	}
+
+	rfp := BuildRFP(sr, res)
+
+	if rfp.Title != "Valet Services RFP" {
+		t.Errorf("Title = %q, want search result title", rfp.Title)
+	}
+	if rfp.SourceURL != sr.URL {
+		t.Errorf("SourceURL = %q, want original URL", rfp.SourceURL)
+	}
+	if rfp.DueDate == nil || !rfp.DueDate.Equal(hintDue) {
+		t.Errorf("DueDate = %v, want hint due date", rfp.DueDate)
+	}
+	if rfp.Category != "valet" || rfp.VenueType != "stadium" {
+		t.Errorf("Category = %q, VenueType = %q, want normalized model labels", rfp.Category, rfp.VenueType)
+	}
+	if rfp.RawValues["due_date"] != "sometime soon" || rfp.RawValues["estimated_value"] != "TBD" {
+		t.Errorf("RawValues = %v, want unparsed text kept", rfp.RawValues)
+	}
+}
+
+func TestBuildRFP_NoDetails(t *testing.T) {
+	if rfp := BuildRFP(&models.SearchResult{}, &research.ResearchResult{}); rfp != nil {
+		t.Errorf("BuildRFP() = %+v, want nil", rfp)
+	}
+}
+
+func TestBuildRFP_Portal(t *testing.T) {
+	sr := &models.SearchResult{
+		URL:          "https://cityofaustin.bonfirehub.com/opportunities/123456",
+		Title:        "Parking Enforcement Services",
+		Portal:       "bonfire",
+		PortalAgency: "cityofaustin",
+		PortalID:     "123456",
+		PortalPage:   "opportunity",
+	}
+	res := &research.ResearchResult{
+		Status:           research.StatusResearched,
+		ExtractedDetails: &research.ExtractedDetails{Title: "Parking Enforcement Services"},
+	}
+
+	rfp := BuildRFP(sr, res)
+	if rfp.Portal != "bonfire" || rfp.PortalID != "123456" || rfp.PortalAgency != "cityofaustin" {
+		t.Errorf("portal = %q %q %q, want bonfire 123456 cityofaustin", rfp.Portal, rfp.PortalID, rfp.PortalAgency)
+	}
+}
@@ -0,0 +1,117 @@
+// Package prompts holds the versioned prompt templates sent to language
+// models. Templates are embedded from templates/<name>.<version>.tmpl, and
+// the version each prompt runs with is recorded alongside its output so
+// results can be traced to the wording that produced them.
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Prompt names.
+const (
+	Search       = "search"        // grounded search for RFP listings
+	AwardSearch  = "award_search"  // grounded search for award notices
+	ExtractRFP   = "extract_rfp"   // RFP details from page text
+	ExtractAward = "extract_award" // award details from page text
+)
+
+// current is the version of each prompt used in production. Add a new
+// template file and bump its entry here to change a prompt.
+var current = map[string]string{
+	Search:       "v1",
+	AwardSearch:  "v1",
+	ExtractRFP:   "v1",
+	ExtractAward: "v1",
+}
+
+//go:embed templates/*.tmpl
+var files embed.FS
+
+// Prompt is one version of a prompt template.
+type Prompt struct {
+	Name    string
+	Version string
+	tmpl    *template.Template
+}
+
+// ID identifies the prompt version, e.g. "extract_rfp@v1". It's what's
+// stored with search queries and research steps.
+func (p *Prompt) ID() string {
+	return p.Name + "@" + p.Version
+}
+
+// Render fills in the template.
+func (p *Prompt) Render(data any) (string, error) {
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render prompt %s failed: %w", p.ID(), err)
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// Parse builds a prompt from template text, e.g. a candidate version being
+// evaluated before it's added to the templates.
+func Parse(name, version, text string) (*Prompt, error) {
+	tmpl, err := template.New(name + "@" + version).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse prompt %s@%s failed: %w", name, version, err)
+	}
+	return &Prompt{Name: name, Version: version, tmpl: tmpl}, nil
+}
+
+// Get returns an embedded prompt version.
+func Get(name, version string) (*Prompt, error) {
+	text, err := files.ReadFile(path.Join("templates", name+"."+version+".tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("prompt %s@%s not found", name, version)
+	}
+	return Parse(name, version, string(text))
+}
+
+// Current returns the production version of a prompt. It panics for an
+// unknown name, since prompt names are constants.
+func Current(name string) *Prompt {
+	p, ok := loaded[name]
+	if !ok {
+		panic("prompts: unknown prompt " + name)
+	}
+	return p
+}
+
+// loaded holds the parsed current version of each prompt.
+var loaded = func() map[string]*Prompt {
+	m := make(map[string]*Prompt, len(current))
+	for name, version := range current {
+		p, err := Get(name, version)
+		if err != nil {
+			panic("prompts: " + err.Error())
+		}
+		m[name] = p
+	}
+	return m
+}()
+
+// Versions lists the embedded versions of a prompt, oldest first.
+func Versions(name string) []string {
+	matches, _ := fs.Glob(files, "templates/"+name+".*.tmpl")
+	var versions []string
+	for _, m := range matches {
+		v := strings.TrimSuffix(strings.TrimPrefix(path.Base(m), name+"."), ".tmpl")
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if len(versions[i]) != len(versions[j]) {
+			return len(versions[i]) < len(versions[j]) // v2 before v10
+		}
+		return versions[i] < versions[j]
+	})
+	return versions
+}
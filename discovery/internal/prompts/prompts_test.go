@@ -0,0 +1,86 @@
+package prompts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCurrent(t *testing.T) {
+	for name, version := range current {
+		p := Current(name)
+		if p.Name != name || p.Version != version {
+			t.Errorf("Current(%q) = %s, want %s@%s", name, p.ID(), name, version)
+		}
+	}
+	if got := Current(ExtractRFP).ID(); got != "extract_rfp@v1" {
+		t.Errorf("ID() = %q, want extract_rfp@v1", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	got, err := Current(Search).Render(struct{ Query string }{"parking RFP site:example.gov"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.HasPrefix(got, "Search the web for: parking RFP site:example.gov\n") {
+		t.Errorf("Render() = %q, want the query filled in", got)
+	}
+	if strings.HasSuffix(got, "\n") {
+		t.Error("Render() should trim the trailing newline")
+	}
+
+	got, err = Current(ExtractAward).Render(struct{ Title, Agency, URL, Content string }{
+		"Parking Management", "City of Austin", "https://austin.gov/award", "Awarded to SP+",
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, want := range []string{"Solicitation: Parking Management", "Issuing agency: City of Austin", "https://austin.gov/award", "Awarded to SP+"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("award prompt missing %q", want)
+		}
+	}
+}
+
+func TestRender_MissingField(t *testing.T) {
+	if _, err := Current(ExtractRFP).Render(map[string]string{"URL": "https://example.gov"}); err == nil {
+		t.Error("Render() without Content: want error")
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse(ExtractRFP, "candidate", "Page {{.URL}}:\n{{.Content}}\n")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if p.ID() != "extract_rfp@candidate" {
+		t.Errorf("ID() = %q", p.ID())
+	}
+	got, err := p.Render(struct{ URL, Content string }{"u", "c"})
+	if err != nil || got != "Page u:\nc" {
+		t.Errorf("Render() = %q, %v", got, err)
+	}
+
+	if _, err := Parse(ExtractRFP, "bad", "{{.URL"); err == nil {
+		t.Error("Parse(unterminated action): want error")
+	}
+}
+
+func TestGet(t *testing.T) {
+	if _, err := Get(Search, "v1"); err != nil {
+		t.Errorf("Get(search, v1): %v", err)
+	}
+	if _, err := Get(Search, "v999"); err == nil {
+		t.Error("Get(search, v999): want error")
+	}
+}
+
+func TestVersions(t *testing.T) {
+	if got := Versions(Search); !reflect.DeepEqual(got, []string{"v1"}) {
+		t.Errorf("Versions(search) = %v, want [v1]", got)
+	}
+	if got := Versions("missing"); len(got) != 0 {
+		t.Errorf("Versions(missing) = %v, want none", got)
+	}
+}
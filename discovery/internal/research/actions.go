@@ -3,51 +3,36 @@ package research
 import (
 	"context"
 	"fmt"
-	"io"
-	"net/http"
+	"log/slog"
+	"net/url"
 	"regexp"
 	"strings"
-	"time"
+	"unicode/utf8"
 
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
+	"github.com/zachsouder/rfp/discovery/internal/pdf"
 	"golang.org/x/net/html"
 )
 
 const (
-	fetchTimeout     = 15 * time.Second
 	maxContentLength = 512 * 1024 // 512KB
 	maxTextLength    = 15000      // Truncate for API calls
-	userAgent        = "Mozilla/5.0 (compatible; RFPBot/1.0)"
+
+	maxPDFs         = 3                // PDFs read per result
+	maxPDFSize      = 10 * 1024 * 1024 // 10MB
+	maxDocumentText = 256 * 1024       // PDF text kept for classification
 )
 
 // actionFetchPage fetches and processes the page content.
 func (a *Agent) actionFetchPage(ctx context.Context, rc *ResearchContext) error {
-	client := &http.Client{
-		Timeout: fetchTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 5 {
-				return http.ErrUseLastResponse
-			}
-			return nil
-		},
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rc.CurrentURL, nil)
+	rc.snapshotKey = ""
+	resp, err := a.fetcher.Do(ctx, fetch.Request{URL: rc.CurrentURL, MaxBytes: maxContentLength})
 	if err != nil {
 		rc.fetchFailed = true
 		rc.fetchError = err.Error()
 		return err
 	}
-
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		rc.fetchFailed = true
-		rc.fetchError = err.Error()
-		return err
-	}
-	defer resp.Body.Close()
+	rc.snapshotKey = resp.SnapshotKey
 
 	if resp.StatusCode >= 400 {
 		rc.fetchFailed = true
@@ -56,60 +41,110 @@ func (a *Agent) actionFetchPage(ctx context.Context, rc *ResearchContext) error
 	}
 
 	// Update current URL after redirects
-	rc.CurrentURL = resp.Request.URL.String()
-
-	// Read body
-	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxContentLength))
-	if err != nil {
-		rc.fetchFailed = true
-		rc.fetchError = err.Error()
-		return err
-	}
+	rc.CurrentURL = resp.FinalURL
 
-	// Convert HTML to text
-	rc.PageContent = htmlToText(string(bodyBytes))
+	// Convert HTML to text, keeping the markup for its links
+	rc.pageHTML = string(resp.Body)
+	rc.PageContent = HTMLToText(rc.pageHTML)
 
 	return nil
 }
 
-// actionExtractDetails uses Gemini to extract structured RFP details.
+// actionExtractDetails uses the extractor to pull structured RFP details.
 func (a *Agent) actionExtractDetails(ctx context.Context, rc *ResearchContext) (int, error) {
-	details, tokens, err := a.geminiClient.ExtractRFPDetails(ctx, rc.CurrentURL, rc.PageContent)
+	details, tokens, err := a.extractor.ExtractRFPDetails(ctx, rc.CurrentURL, rc.PageContent)
 	if err != nil {
 		return tokens, err
 	}
 
+	VerifyEvidence(details, rc.PageContent)
 	rc.ExtractedDetails = details
 	return tokens, nil
 }
 
-// actionDiscoverPDFs finds PDF links in the page content.
+// actionDiscoverPDFs finds PDF links in the fetched page.
 func (a *Agent) actionDiscoverPDFs(rc *ResearchContext) {
 	rc.pdfSearchDone = true
 
+	// Links are in the markup; the text conversion drops them
+	content := rc.pageHTML
+	if content == "" {
+		content = rc.PageContent
+	}
+	base, _ := url.Parse(rc.CurrentURL)
+
 	// Pattern to match PDF URLs
 	pdfPattern := regexp.MustCompile(`(?i)href\s*=\s*["']([^"']*\.pdf[^"']*)["']`)
-	matches := pdfPattern.FindAllStringSubmatch(rc.PageContent, -1)
+	matches := pdfPattern.FindAllStringSubmatch(content, -1)
 
 	seen := make(map[string]bool)
 	for _, match := range matches {
 		if len(match) > 1 {
-			url := match[1]
-			// Normalize URL
-			if !strings.HasPrefix(url, "http") {
-				// Relative URL - would need base URL handling
-				continue
+			link := html.UnescapeString(match[1])
+			// Resolve relative links against the page
+			if !strings.HasPrefix(link, "http") {
+				ref, err := url.Parse(link)
+				if base == nil || err != nil {
+					continue
+				}
+				link = base.ResolveReference(ref).String()
+				if !strings.HasPrefix(link, "http") {
+					continue
+				}
 			}
-			if !seen[url] {
-				seen[url] = true
-				rc.FoundPDFs = append(rc.FoundPDFs, url)
+			if !seen[link] {
+				seen[link] = true
+				rc.FoundPDFs = append(rc.FoundPDFs, link)
 			}
 		}
 	}
 }
 
-// htmlToText converts HTML to plain text.
-func htmlToText(htmlContent string) string {
+// actionReadPDFs fetches the first few PDFs found and keeps their text. PDFs
+// that can't be fetched or read are skipped. Returns how many were read.
+func (a *Agent) actionReadPDFs(ctx context.Context, rc *ResearchContext) int {
+	rc.pdfReadDone = true
+
+	var texts []string
+	size := 0
+	for i, link := range rc.FoundPDFs {
+		if i >= maxPDFs || size >= maxDocumentText {
+			break
+		}
+		resp, err := a.fetcher.Do(ctx, fetch.Request{URL: link, Accept: fetch.AcceptPDF, MaxBytes: maxPDFSize})
+		if err != nil || resp.StatusCode >= 400 || resp.Truncated {
+			slog.Debug("skipping PDF", "url", link, "error", err)
+			continue
+		}
+		text, err := pdf.ExtractText(resp.Body)
+		if err != nil {
+			slog.Debug("failed to read PDF", "url", link, "error", err)
+			continue
+		}
+		if size+len(text) > maxDocumentText {
+			text = truncateRunes(text, maxDocumentText-size)
+		}
+		size += len(text)
+		texts = append(texts, text)
+	}
+	rc.documentText = strings.Join(texts, "\n\n")
+	return len(texts)
+}
+
+// truncateRunes cuts s to at most n bytes without splitting a UTF-8
+// character.
+func truncateRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// HTMLToText converts HTML to plain text, truncated for API calls.
+func HTMLToText(htmlContent string) string {
 	// Remove scripts and styles
 	scriptPattern := regexp.MustCompile(`(?is)<script[^>]*>.*?</script>`)
 	stylePattern := regexp.MustCompile(`(?is)<style[^>]*>.*?</style>`)
@@ -7,14 +7,19 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
+	"github.com/zachsouder/rfp/discovery/internal/llm"
 	"github.com/zachsouder/rfp/shared/models"
 )
 
 const (
 	defaultMaxSteps = 5
-	defaultModel    = "gemini-3-flash-preview"
 )
 
+// AgentVersion identifies the agent's decision logic in research run
+// records. Bump it when the actions or the order they're taken in change.
+const AgentVersion = "2"
+
 // Status represents the research outcome status.
 type Status string
 
@@ -25,22 +30,33 @@ const (
 	StatusNeedsManualUpload Status = "needs_manual_upload"
 	StatusExhausted        Status = "research_exhausted"
 	StatusFailed           Status = "failed"
+	StatusSkipped          Status = "skipped" // duplicate of an existing RFP, not researched
 )
 
 // Agent is a multi-step research agent that investigates search results.
 type Agent struct {
-	geminiClient *GeminiClient
-	maxSteps     int
+	extractor *Extractor
+	fetcher   *fetch.Fetcher
+	maxSteps  int
 }
 
-// NewAgent creates a new research agent.
+// NewAgent creates a new research agent that extracts with the default
+// Gemini model.
 func NewAgent(apiKey string) *Agent {
+	_, model := llm.ParseSpec(llm.DefaultModel)
 	return &Agent{
-		geminiClient: NewGeminiClient(apiKey),
-		maxSteps:     defaultMaxSteps,
+		extractor: NewExtractor(llm.NewGemini(apiKey, model)),
+		fetcher:   fetch.Default(),
+		maxSteps:  defaultMaxSteps,
 	}
 }
 
+// WithExtractor sets the extractor, and so the model, used for details.
+func (a *Agent) WithExtractor(e *Extractor) *Agent {
+	a.extractor = e
+	return a
+}
+
 // WithMaxSteps sets the maximum number of research steps.
 func (a *Agent) WithMaxSteps(n int) *Agent {
 	a.maxSteps = n
@@ -65,8 +81,13 @@ type ResearchContext struct {
 	// Internal tracking
 	fetchFailed      bool
 	fetchError       string
+	snapshotKey      string
+	pageHTML         string // fetched page before conversion to text, for its links
 	pdfSearchDone    bool
+	pdfReadDone      bool
+	documentText     string
 	sourceSearchDone bool
+	fromDocuments    bool // page content is uploaded documents, not a fetched page
 }
 
 // ExtractedDetails contains structured RFP information extracted by the agent.
@@ -81,6 +102,22 @@ type ExtractedDetails struct {
 	Incumbent      string  `json:"incumbent,omitempty"`
 	Category       string  `json:"category,omitempty"`
 	VenueType      string  `json:"venue_type,omitempty"`
+
+	// Key dates and requirements
+	PreBidDate            string `json:"pre_bid_meeting_date,omitempty"`
+	PreBidMandatory       *bool  `json:"pre_bid_mandatory,omitempty"`
+	QuestionsDeadline     string `json:"questions_deadline,omitempty"`
+	SubmissionMethod      string `json:"submission_method,omitempty"`
+	SolicitationNumber    string `json:"solicitation_number,omitempty"`
+	ContactName           string `json:"contact_name,omitempty"`
+	ContactEmail          string `json:"contact_email,omitempty"`
+	BondRequirements      string `json:"bond_requirements,omitempty"`
+	InsuranceRequirements string `json:"insurance_requirements,omitempty"`
+	TermMonths            *int   `json:"term_months,omitempty"`
+	ContractTerm          string `json:"contract_term,omitempty"`
+
+	// Evidence holds per-field confidence and verbatim source snippets.
+	Evidence []models.FieldEvidence `json:"evidence,omitempty"`
 }
 
 // ResearchResult contains the outcome of researching a search result.
@@ -94,6 +131,16 @@ type ResearchResult struct {
 	FoundPDFs        []string          `json:"found_pdfs,omitempty"`
 	Steps            []ResearchStep    `json:"steps"`
 	Error            string            `json:"error,omitempty"`
+	AgentVersion     string            `json:"agent_version"`
+	Model            string            `json:"model"`
+	StartedAt        time.Time         `json:"started_at"`
+	DurationMs       int64             `json:"duration_ms"`
+
+	// SourceText is the text of the page the details were extracted from
+	SourceText string `json:"-"`
+	// DocumentText is the text of the PDFs the page linked to, which usually
+	// hold the full scope of work
+	DocumentText string `json:"-"`
 }
 
 // ResearchStep records a single step in the research process.
@@ -106,11 +153,17 @@ type ResearchStep struct {
 	Success       bool          `json:"success"`
 	TokensUsed    int           `json:"tokens_used,omitempty"`
 	DurationMs    int64         `json:"duration_ms"`
+	SnapshotKey   string        `json:"snapshot_key,omitempty"`
+	PromptVersion string        `json:"prompt_version,omitempty"` // for extract steps
 }
 
 // Research investigates a search result to extract RFP details.
 func (a *Agent) Research(ctx context.Context, result *models.SearchResult) (*ResearchResult, error) {
-	// Initialize context
+	return a.run(ctx, newResearchContext(result), nil), nil
+}
+
+// newResearchContext starts research on a search result.
+func newResearchContext(result *models.SearchResult) *ResearchContext {
 	rc := &ResearchContext{
 		ResultID:    result.ID,
 		OriginalURL: result.URL,
@@ -126,13 +179,22 @@ func (a *Agent) Research(ctx context.Context, result *models.SearchResult) (*Res
 	if rc.CurrentURL == "" {
 		rc.CurrentURL = rc.OriginalURL
 	}
+	return rc
+}
 
+// run takes research steps until the context reaches an outcome or the
+// step limit. Steps already taken before the loop, such as reading
+// uploaded documents, are recorded first and count toward the limit.
+func (a *Agent) run(ctx context.Context, rc *ResearchContext, steps []ResearchStep) *ResearchResult {
 	res := &ResearchResult{
-		ResultID: result.ID,
-		Steps:    make([]ResearchStep, 0),
+		ResultID:     rc.ResultID,
+		Steps:        append(make([]ResearchStep, 0), steps...),
+		AgentVersion: AgentVersion,
+		Model:        a.extractor.Model(),
+		StartedAt:    time.Now(),
 	}
 
-	stepCount := 0
+	stepCount := len(steps)
 	totalTokens := 0
 
 	// Research loop
@@ -146,7 +208,8 @@ func (a *Agent) Research(ctx context.Context, result *models.SearchResult) (*Res
 			res.Error = err.Error()
 			res.Status = StatusFailed
 			res.StepsTaken = stepCount
-			return res, nil
+			res.DurationMs = time.Since(res.StartedAt).Milliseconds()
+			return res
 		}
 
 		res.Steps = append(res.Steps, *step)
@@ -175,8 +238,11 @@ func (a *Agent) Research(ctx context.Context, result *models.SearchResult) (*Res
 	res.TotalTokens = totalTokens
 	res.ExtractedDetails = rc.ExtractedDetails
 	res.FoundPDFs = rc.FoundPDFs
+	res.SourceText = rc.PageContent
+	res.DocumentText = rc.documentText
+	res.DurationMs = time.Since(res.StartedAt).Milliseconds()
 
-	return res, nil
+	return res
 }
 
 // executeStep decides and executes the next research action.
@@ -200,6 +266,7 @@ func (a *Agent) executeStep(ctx context.Context, rc *ResearchContext, stepNumber
 	case "fetch_page":
 		err = a.actionFetchPage(ctx, rc)
 		step.InputSummary = rc.CurrentURL
+		step.SnapshotKey = rc.snapshotKey
 		if err == nil {
 			step.OutputSummary = fmt.Sprintf("Fetched %d chars", len(rc.PageContent))
 			step.Success = true
@@ -210,6 +277,7 @@ func (a *Agent) executeStep(ctx context.Context, rc *ResearchContext, stepNumber
 	case "extract_details":
 		tokensUsed, err = a.actionExtractDetails(ctx, rc)
 		step.InputSummary = "Page content analysis"
+		step.PromptVersion = a.extractor.RFPPromptVersion()
 		if err == nil && rc.ExtractedDetails != nil {
 			step.OutputSummary = fmt.Sprintf("Extracted: %s", rc.ExtractedDetails.Title)
 			step.Success = true
@@ -223,6 +291,12 @@ func (a *Agent) executeStep(ctx context.Context, rc *ResearchContext, stepNumber
 		step.OutputSummary = fmt.Sprintf("Found %d PDFs", len(rc.FoundPDFs))
 		step.Success = true
 
+	case "read_pdfs":
+		read := a.actionReadPDFs(ctx, rc)
+		step.InputSummary = fmt.Sprintf("Reading up to %d of %d PDFs", maxPDFs, len(rc.FoundPDFs))
+		step.OutputSummary = fmt.Sprintf("Read %d chars from %d PDFs", len(rc.documentText), read)
+		step.Success = true
+
 	case "mark_complete":
 		rc.Status = StatusResearched
 		step.InputSummary = "Research complete"
@@ -277,8 +351,8 @@ func (a *Agent) decideAction(rc *ResearchContext) Action {
 		}
 	}
 
-	// Check for login wall
-	if detectLoginWall(rc.PageContent) {
+	// Check for login wall; uploaded documents are already past it
+	if !rc.fromDocuments && detectLoginWall(rc.PageContent) {
 		return Action{
 			Name:      "mark_login_required",
 			Reasoning: "Detected login wall or authentication requirement. The page content indicates restricted access. Marking for manual upload.",
@@ -301,6 +375,14 @@ func (a *Agent) decideAction(rc *ResearchContext) Action {
 		}
 	}
 
+	// Read the PDFs found; the page is often just a summary of them
+	if !rc.pdfReadDone && len(rc.FoundPDFs) > 0 {
+		return Action{
+			Name:      "read_pdfs",
+			Reasoning: fmt.Sprintf("Reading the %d PDFs found for the full scope of work.", len(rc.FoundPDFs)),
+		}
+	}
+
 	// If we have enough details, mark complete
 	if rc.ExtractedDetails != nil && rc.ExtractedDetails.Title != "" {
 		return Action{
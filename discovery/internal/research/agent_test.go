@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/zachsouder/rfp/shared/models"
 )
@@ -39,9 +40,9 @@ func TestHtmlToText(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := htmlToText(tt.html)
+			result := HTMLToText(tt.html)
 			if !contains(result, tt.contains) {
-				t.Errorf("htmlToText() = %q, should contain %q", result, tt.contains)
+				t.Errorf("HTMLToText() = %q, should contain %q", result, tt.contains)
 			}
 		})
 	}
@@ -235,6 +236,55 @@ func TestAgent_ActionDiscoverPDFs(t *testing.T) {
 	}
 }
 
+func TestAgent_ActionDiscoverPDFs_PageLinks(t *testing.T) {
+	agent := NewAgent("fake-key")
+	rc := &ResearchContext{
+		CurrentURL:  "https://city.example.gov/bids/view?id=12",
+		PageContent: "RFP Document Specifications",
+		pageHTML: `
+			<a href="/files/rfp.pdf">RFP Document</a>
+			<a href="specs.pdf?v=2&amp;dl=1">Specifications</a>
+			<a href="https://city.example.gov/files/rfp.pdf">RFP Document</a>
+		`,
+	}
+
+	agent.actionDiscoverPDFs(rc)
+
+	want := []string{"https://city.example.gov/files/rfp.pdf", "https://city.example.gov/bids/specs.pdf?v=2&dl=1"}
+	if len(rc.FoundPDFs) != len(want) || rc.FoundPDFs[0] != want[0] || rc.FoundPDFs[1] != want[1] {
+		t.Errorf("FoundPDFs = %v, want %v", rc.FoundPDFs, want)
+	}
+}
+
+func TestAgent_ActionReadPDFs(t *testing.T) {
+	const doc = "%PDF-1.4\n1 0 obj\n<< /Length 41 >>\nstream\nBT /F1 12 Tf (Shuttle service scope) Tj ET\nendstream\nendobj\n%%EOF\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rfp.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte(doc))
+		case "/broken.pdf":
+			w.Write([]byte("not a pdf"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	agent := NewAgent("fake-key")
+	rc := &ResearchContext{
+		FoundPDFs:     []string{server.URL + "/missing.pdf", server.URL + "/broken.pdf", server.URL + "/rfp.pdf"},
+		pdfSearchDone: true,
+	}
+
+	if read := agent.actionReadPDFs(context.Background(), rc); read != 1 {
+		t.Errorf("read %d PDFs, want 1", read)
+	}
+	if !rc.pdfReadDone || !contains(rc.documentText, "Shuttle service scope") {
+		t.Errorf("documentText = %q", rc.documentText)
+	}
+}
+
 func TestAgent_Research_BasicFlow(t *testing.T) {
 	// Create a mock server that returns RFP-like content
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -276,6 +326,12 @@ func TestAgent_Research_BasicFlow(t *testing.T) {
 	if res.Steps[0].Action != "fetch_page" {
 		t.Errorf("Expected first action to be fetch_page, got %s", res.Steps[0].Action)
 	}
+	if res.AgentVersion != AgentVersion || res.Model != "gemini:gemini-3-flash-preview" {
+		t.Errorf("run metadata = version %q, model %q", res.AgentVersion, res.Model)
+	}
+	if res.StartedAt.IsZero() {
+		t.Error("Expected StartedAt to be set")
+	}
 }
 
 func contains(s, substr string) bool {
@@ -290,3 +346,68 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestAgent_ResearchDocuments(t *testing.T) {
+	agent := NewAgent("fake-key")
+	agent.maxSteps = 1 // Stop before extraction
+
+	result := &models.SearchResult{
+		ID:    2,
+		URL:   "https://city.bonfirehub.com/opportunities/123",
+		Title: "Parking RFP",
+	}
+	docs := []Document{
+		{Name: "rfp.pdf", URL: "https://files.example.com/uploads/2/rfp.pdf", Text: "Please log in. Password required. Parking Management Services"},
+		{Name: "addendum.pdf", Text: "Addendum 1"},
+	}
+
+	res, err := agent.ResearchDocuments(context.Background(), result, docs)
+	if err != nil {
+		t.Fatalf("ResearchDocuments() error = %v", err)
+	}
+	if len(res.Steps) == 0 || res.Steps[0].Action != "read_documents" || res.Steps[0].InputSummary != "rfp.pdf, addendum.pdf" {
+		t.Fatalf("first step = %+v, want read_documents", res.Steps)
+	}
+	if res.Status != StatusExhausted {
+		t.Errorf("Status = %s, want %s after the step limit", res.Status, StatusExhausted)
+	}
+	if len(res.FoundPDFs) != 1 || res.FoundPDFs[0] != docs[0].URL {
+		t.Errorf("FoundPDFs = %v, want the stored document", res.FoundPDFs)
+	}
+	if !contains(res.SourceText, "=== addendum.pdf ===\nAddendum 1") {
+		t.Errorf("SourceText = %q", res.SourceText)
+	}
+
+	// Login wording in a document doesn't send it back to the upload queue
+	rc := newResearchContext(result)
+	rc.PageContent = docs[0].Text
+	rc.fromDocuments = true
+	if action := agent.decideAction(rc); action.Name != "extract_details" {
+		t.Errorf("decideAction() = %s, want extract_details", action.Name)
+	}
+
+	if _, err := agent.ResearchDocuments(context.Background(), result, nil); err == nil {
+		t.Error("ResearchDocuments(nil) should fail")
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		input string
+		n     int
+		want  string
+	}{
+		{"parking", 20, "parking"},
+		{"parking", 4, "park"},
+		{"café lot", 4, "caf"}, // é is two bytes
+		{"café lot", 5, "café"},
+		{"日本", 2, ""},
+	}
+
+	for _, tt := range tests {
+		got := truncateRunes(tt.input, tt.n)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncateRunes(%q, %d) = %q, want %q", tt.input, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// AwardDetails contains award notice information extracted from a page.
+type AwardDetails struct {
+	IsAwardNotice       bool   `json:"is_award_notice"`
+	MatchesSolicitation bool   `json:"matches_solicitation"`
+	Awardee             string `json:"awardee"`
+	AwardAmount         string `json:"award_amount"`
+	AwardDate           string `json:"award_date"`
+	SolicitationTitle   string `json:"solicitation_title"`
+}
+
+// ExtractAwardDetails extracts award notice details for a known
+// solicitation. The page may be an award notice, bid tabulation, council
+// agenda, or an unrelated page; MatchesSolicitation reports whether it
+// describes the award of the given RFP.
+func (e *Extractor) ExtractAwardDetails(ctx context.Context, pageURL, pageContent, rfpTitle, rfpAgency string) (*AwardDetails, int, error) {
+	prompt, err := e.awardPrompt.Render(struct{ Title, Agency, URL, Content string }{rfpTitle, rfpAgency, pageURL, pageContent})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"is_award_notice": {"type": "boolean"},
+			"matches_solicitation": {"type": "boolean"},
+			"awardee": {"type": "string"},
+			"award_amount": {"type": "string"},
+			"award_date": {"type": "string"},
+			"solicitation_title": {"type": "string"}
+		},
+		"required": ["is_award_notice", "matches_solicitation"]
+	}`)
+
+	resp, tokens, err := e.llm.GenerateJSON(ctx, prompt, schema)
+	if err != nil {
+		return nil, tokens, err
+	}
+
+	var details AwardDetails
+	if err := json.Unmarshal([]byte(resp), &details); err != nil {
+		return nil, tokens, fmt.Errorf("failed to parse award details: %w", err)
+	}
+
+	return &details, tokens, nil
+}
@@ -0,0 +1,59 @@
+package research
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// FieldChange is an extracted field whose value differs between two runs.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// DiffDetails compares two extractions field by field, ignoring evidence.
+// Fields are keyed by their JSON names; a missing field has an empty value.
+func DiffDetails(before, after *ExtractedDetails) []FieldChange {
+	a, b := detailFields(before), detailFields(after)
+
+	names := make(map[string]bool, len(a)+len(b))
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+
+	var changes []FieldChange
+	for name := range names {
+		if a[name] != b[name] {
+			changes = append(changes, FieldChange{Field: name, Before: a[name], After: b[name]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// detailFields flattens extracted details to their JSON field names and
+// printable values.
+func detailFields(d *ExtractedDetails) map[string]string {
+	fields := make(map[string]string)
+	if d == nil {
+		return fields
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fields
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fields
+	}
+	delete(raw, "evidence")
+	for name, v := range raw {
+		fields[name] = fmt.Sprint(v)
+	}
+	return fields
+}
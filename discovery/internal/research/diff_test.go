@@ -0,0 +1,42 @@
+package research
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+func TestDiffDetails(t *testing.T) {
+	mandatory := true
+	months := 36
+	before := &ExtractedDetails{
+		Title:    "Parking Management Services",
+		Agency:   "City of Austin",
+		DueDate:  "2026-03-15",
+		Evidence: []models.FieldEvidence{{Field: "title", Confidence: 0.9}},
+	}
+	after := &ExtractedDetails{
+		Title:           "Parking Management Services",
+		Agency:          "City of Austin, Transportation Dept.",
+		PreBidMandatory: &mandatory,
+		TermMonths:      &months,
+	}
+
+	want := []FieldChange{
+		{Field: "agency", Before: "City of Austin", After: "City of Austin, Transportation Dept."},
+		{Field: "due_date", Before: "2026-03-15", After: ""},
+		{Field: "pre_bid_mandatory", Before: "", After: "true"},
+		{Field: "term_months", Before: "", After: "36"},
+	}
+	if got := DiffDetails(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffDetails() = %+v, want %+v", got, want)
+	}
+
+	if got := DiffDetails(before, before); len(got) != 0 {
+		t.Errorf("DiffDetails(same) = %+v, want no changes", got)
+	}
+	if got := DiffDetails(nil, &ExtractedDetails{Title: "X"}); len(got) != 1 || got[0].Field != "title" {
+		t.Errorf("DiffDetails(nil, ...) = %+v, want a title change", got)
+	}
+}
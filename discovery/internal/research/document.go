@@ -0,0 +1,54 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+// Document is a solicitation document supplied by hand for a search result,
+// such as a PDF a reviewer downloaded from behind a portal login.
+type Document struct {
+	Name string // file name, for the research trace
+	URL  string // where the stored copy can be fetched, if anywhere
+	Text string // text extracted from the document
+}
+
+// ResearchDocuments resumes research on a search result from documents
+// supplied by hand. Their text stands in for the fetched page, so the agent
+// goes straight to extraction without checking for a login wall or looking
+// for more PDF links; the documents themselves are the result's PDFs.
+func (a *Agent) ResearchDocuments(ctx context.Context, result *models.SearchResult, docs []Document) (*ResearchResult, error) {
+	if len(docs) == 0 {
+		return nil, errors.New("no documents to research")
+	}
+
+	rc := newResearchContext(result)
+	rc.fromDocuments = true
+	rc.pdfSearchDone = true
+	rc.pdfReadDone = true
+
+	names := make([]string, 0, len(docs))
+	texts := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		names = append(names, doc.Name)
+		texts = append(texts, fmt.Sprintf("=== %s ===\n%s", doc.Name, doc.Text))
+		if doc.URL != "" {
+			rc.FoundPDFs = append(rc.FoundPDFs, doc.URL)
+		}
+	}
+	rc.PageContent = strings.Join(texts, "\n\n")
+
+	read := ResearchStep{
+		StepNumber:    1,
+		Action:        "read_documents",
+		InputSummary:  strings.Join(names, ", "),
+		OutputSummary: fmt.Sprintf("Read %d chars from %d documents", len(rc.PageContent), len(docs)),
+		Reasoning:     "Documents were uploaded by hand for this result. Researching their text instead of the page.",
+		Success:       true,
+	}
+	return a.run(ctx, rc, []ResearchStep{read}), nil
+}
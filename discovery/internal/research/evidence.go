@@ -0,0 +1,85 @@
+package research
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+const (
+	// LowConfidenceThreshold is the confidence below which an extracted
+	// field is flagged for review.
+	LowConfidenceThreshold = 0.6
+
+	// unverifiedConfidenceCap caps the confidence of fields whose evidence
+	// could not be found verbatim in the source text.
+	unverifiedConfidenceCap = 0.3
+)
+
+// VerifyEvidence checks each evidence snippet against the source text and
+// caps the confidence of snippets that don't appear in it. Gemini sometimes
+// paraphrases or invents quotes, so a self-reported confidence is only
+// trusted when the quote is real.
+func VerifyEvidence(details *ExtractedDetails, sourceText string) {
+	source := normalizeForMatch(sourceText)
+
+	for i := range details.Evidence {
+		ev := &details.Evidence[i]
+		snippet := normalizeForMatch(ev.Evidence)
+		ev.Verified = snippet != "" && strings.Contains(source, snippet)
+		if !ev.Verified && ev.Confidence > unverifiedConfidenceCap {
+			ev.Confidence = unverifiedConfidenceCap
+		}
+	}
+}
+
+// ReviewFields returns the populated fields that need human review: those
+// with confidence below LowConfidenceThreshold or with no evidence at all.
+func ReviewFields(details *ExtractedDetails) []string {
+	evidence := make(map[string]models.FieldEvidence, len(details.Evidence))
+	for _, ev := range details.Evidence {
+		evidence[ev.Field] = ev
+	}
+
+	var fields []string
+	for _, field := range populatedFields(details) {
+		ev, ok := evidence[field]
+		if !ok || ev.Confidence < LowConfidenceThreshold {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// populatedFields returns the JSON names of the non-empty extracted fields.
+func populatedFields(details *ExtractedDetails) []string {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil
+	}
+
+	var fields []string
+	for field := range values {
+		if field == "evidence" {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// normalizeForMatch lowercases text and collapses whitespace so evidence
+// survives the whitespace differences introduced by HTML-to-text conversion.
+func normalizeForMatch(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
@@ -0,0 +1,66 @@
+package research
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+func TestVerifyEvidence(t *testing.T) {
+	source := "Request for Proposals\nParking Management Services\n\nProposals due:   March 15, 2024 at 2:00 PM"
+
+	details := &ExtractedDetails{
+		Title:   "Parking Management Services",
+		DueDate: "2024-03-15",
+		Agency:  "City of Springfield",
+		Evidence: []models.FieldEvidence{
+			{Field: "title", Confidence: 0.95, Evidence: "Parking Management Services"},
+			{Field: "due_date", Confidence: 0.9, Evidence: "proposals due: march 15, 2024"},
+			{Field: "agency", Confidence: 0.8, Evidence: "City of Springfield Procurement"},
+		},
+	}
+
+	VerifyEvidence(details, source)
+
+	byField := make(map[string]models.FieldEvidence)
+	for _, ev := range details.Evidence {
+		byField[ev.Field] = ev
+	}
+
+	if !byField["title"].Verified || byField["title"].Confidence != 0.95 {
+		t.Errorf("title evidence = %+v, want verified with confidence kept", byField["title"])
+	}
+	if !byField["due_date"].Verified {
+		t.Errorf("due_date evidence should match despite case and whitespace: %+v", byField["due_date"])
+	}
+	if byField["agency"].Verified {
+		t.Error("agency evidence is not in the source and should not be verified")
+	}
+	if byField["agency"].Confidence > unverifiedConfidenceCap {
+		t.Errorf("unverified confidence = %v, want <= %v", byField["agency"].Confidence, unverifiedConfidenceCap)
+	}
+}
+
+func TestReviewFields(t *testing.T) {
+	mandatory := true
+	details := &ExtractedDetails{
+		Title:           "Parking Management Services",
+		DueDate:         "2024-03-15",
+		Incumbent:       "ABC Parking",
+		PreBidMandatory: &mandatory,
+		Evidence: []models.FieldEvidence{
+			{Field: "title", Confidence: 0.95, Verified: true},
+			{Field: "due_date", Confidence: 0.4, Verified: true},
+			{Field: "pre_bid_mandatory", Confidence: 0.9, Verified: true},
+			{Field: "venue_type", Confidence: 0.2}, // not populated, ignored
+		},
+	}
+
+	got := ReviewFields(details)
+	want := []string{"due_date", "incumbent"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReviewFields() = %v, want %v", got, want)
+	}
+}
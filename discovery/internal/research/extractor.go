@@ -0,0 +1,107 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zachsouder/rfp/discovery/internal/llm"
+	"github.com/zachsouder/rfp/discovery/internal/prompts"
+)
+
+// Extractor pulls structured details out of page text with a language model.
+type Extractor struct {
+	llm         llm.Provider
+	rfpPrompt   *prompts.Prompt
+	awardPrompt *prompts.Prompt
+}
+
+// NewExtractor creates an extractor backed by a language model provider,
+// using the current prompt versions.
+func NewExtractor(provider llm.Provider) *Extractor {
+	return &Extractor{
+		llm:         provider,
+		rfpPrompt:   prompts.Current(prompts.ExtractRFP),
+		awardPrompt: prompts.Current(prompts.ExtractAward),
+	}
+}
+
+// WithRFPPrompt returns a copy of the extractor that uses p for
+// ExtractRFPDetails, e.g. a candidate version under evaluation. The
+// receiver is unchanged.
+func (e *Extractor) WithRFPPrompt(p *prompts.Prompt) *Extractor {
+	c := *e
+	c.rfpPrompt = p
+	return &c
+}
+
+// RFPPromptVersion identifies the prompt used by ExtractRFPDetails.
+func (e *Extractor) RFPPromptVersion() string {
+	return e.rfpPrompt.ID()
+}
+
+// Model identifies the provider and model used for extraction.
+func (e *Extractor) Model() string {
+	return e.llm.Name()
+}
+
+// ExtractRFPDetails extracts structured RFP information from page content.
+func (e *Extractor) ExtractRFPDetails(ctx context.Context, pageURL, pageContent string) (*ExtractedDetails, int, error) {
+	prompt, err := e.rfpPrompt.Render(struct{ URL, Content string }{pageURL, pageContent})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Define the response schema
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"title": {"type": "string"},
+			"agency": {"type": "string"},
+			"location_city": {"type": "string"},
+			"location_state": {"type": "string"},
+			"due_date": {"type": "string"},
+			"scope_summary": {"type": "string"},
+			"estimated_value": {"type": "string"},
+			"incumbent": {"type": "string"},
+			"category": {"type": "string"},
+			"venue_type": {"type": "string"},
+			"pre_bid_meeting_date": {"type": "string"},
+			"pre_bid_mandatory": {"type": "boolean"},
+			"questions_deadline": {"type": "string"},
+			"submission_method": {"type": "string", "enum": ["electronic", "email", "mail", "hand_delivery"]},
+			"solicitation_number": {"type": "string"},
+			"contact_name": {"type": "string"},
+			"contact_email": {"type": "string"},
+			"bond_requirements": {"type": "string"},
+			"insurance_requirements": {"type": "string"},
+			"term_months": {"type": "integer"},
+			"contract_term": {"type": "string"},
+			"evidence": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"field": {"type": "string"},
+						"confidence": {"type": "number"},
+						"evidence": {"type": "string"}
+					},
+					"required": ["field", "confidence", "evidence"]
+				}
+			}
+		}
+	}`)
+
+	resp, tokens, err := e.llm.GenerateJSON(ctx, prompt, schema)
+	if err != nil {
+		return nil, tokens, err
+	}
+
+	// Parse the response into ExtractedDetails
+	var details ExtractedDetails
+	if err := json.Unmarshal([]byte(resp), &details); err != nil {
+		return nil, tokens, fmt.Errorf("failed to parse extracted details: %w", err)
+	}
+
+	return &details, tokens, nil
+}
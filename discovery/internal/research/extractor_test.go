@@ -0,0 +1,25 @@
+package research
+
+import (
+	"testing"
+
+	"github.com/zachsouder/rfp/discovery/internal/prompts"
+)
+
+func TestExtractor_WithRFPPrompt(t *testing.T) {
+	e := NewExtractor(nil)
+	current := e.RFPPromptVersion()
+
+	candidate, err := prompts.Parse(prompts.ExtractRFP, "candidate", "Extract {{.URL}}")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	c := e.WithRFPPrompt(candidate)
+	if c.RFPPromptVersion() != candidate.ID() {
+		t.Errorf("copy prompt = %s, want %s", c.RFPPromptVersion(), candidate.ID())
+	}
+	if e.RFPPromptVersion() != current {
+		t.Errorf("original prompt = %s, want it unchanged at %s", e.RFPPromptVersion(), current)
+	}
+}
@@ -0,0 +1,222 @@
+package review
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zachsouder/rfp/discovery/internal/research"
+)
+
+// defaultListLimit caps GET /review when no limit is given.
+const defaultListLimit = 50
+
+// Service is the queue as used by the HTTP handler; *Queue implements it.
+type Service interface {
+	List(ctx context.Context, status string, limit int) ([]Item, error)
+	Get(ctx context.Context, resultID int) (*Detail, error)
+	Promote(ctx context.Context, resultID int, fields *research.ExtractedDetails, reviewer, reason string) (*Decision, error)
+	MarkDuplicate(ctx context.Context, resultID, rfpID int, reviewer, reason string) (*Decision, error)
+	Reject(ctx context.Context, resultID int, reviewer, reason string) (*Decision, error)
+	Requeue(ctx context.Context, resultID int, reviewer, reason string) (*Decision, error)
+}
+
+// decisionRequest is the body of the POST /review/{id}/... endpoints. The
+// reviewer comes from the credential, not the body.
+type decisionRequest struct {
+	Reason string                     `json:"reason"`
+	RFPID  int                        `json:"rfp_id,omitempty"` // duplicate
+	Fields *research.ExtractedDetails `json:"fields,omitempty"` // promote
+}
+
+// Tokens maps each bearer token to the reviewer it belongs to.
+type Tokens map[string]string
+
+// ParseTokens reads reviewer tokens from a comma-separated list of
+// reviewer:token pairs, e.g. "alice:s3cret,bob:hunter2".
+func ParseTokens(spec string) (Tokens, error) {
+	tokens := Tokens{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		reviewer, token, ok := strings.Cut(pair, ":")
+		reviewer, token = strings.TrimSpace(reviewer), strings.TrimSpace(token)
+		if !ok || reviewer == "" || token == "" {
+			return nil, fmt.Errorf("invalid reviewer token %q: want reviewer:token", reviewer)
+		}
+		if _, dup := tokens[token]; dup {
+			return nil, fmt.Errorf("reviewer token for %q is already in use", reviewer)
+		}
+		tokens[token] = reviewer
+	}
+	return tokens, nil
+}
+
+type reviewerKey struct{}
+
+// ReviewerFrom returns the reviewer whose token authenticated the request.
+func ReviewerFrom(ctx context.Context) string {
+	reviewer, _ := ctx.Value(reviewerKey{}).(string)
+	return reviewer
+}
+
+// NewHandler serves the review queue as JSON:
+//
+//	GET  /review?status=&limit=   queued results
+//	GET  /review/{id}             a result with its trace and decisions
+//	POST /review/{id}/promote     {"reason", "fields": {...}}
+//	POST /review/{id}/duplicate   {"reason", "rfp_id"}
+//	POST /review/{id}/reject      {"reason"}
+//	POST /review/{id}/requeue     {"reason"}
+//
+// Requests must carry one of tokens as a bearer token, and decisions are
+// recorded under that token's reviewer.
+func NewHandler(svc Service, tokens Tokens) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /review", func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultListLimit
+		if s := r.URL.Query().Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				writeError(w, http.StatusBadRequest, "invalid limit")
+				return
+			}
+			limit = n
+		}
+		items, err := svc.List(r.Context(), r.URL.Query().Get("status"), limit)
+		if err != nil {
+			writeQueueError(w, err)
+			return
+		}
+		if items == nil {
+			items = []Item{}
+		}
+		writeJSON(w, http.StatusOK, items)
+	})
+
+	mux.HandleFunc("GET /review/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := resultID(w, r)
+		if !ok {
+			return
+		}
+		d, err := svc.Get(r.Context(), id)
+		if err != nil {
+			writeQueueError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, d)
+	})
+
+	decide := func(fn func(ctx context.Context, id int, reviewer string, req decisionRequest) (*Decision, error)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			id, ok := resultID(w, r)
+			if !ok {
+				return
+			}
+			var req decisionRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+				return
+			}
+			dec, err := fn(r.Context(), id, ReviewerFrom(r.Context()), req)
+			if err != nil {
+				writeQueueError(w, err)
+				return
+			}
+			slog.Info("review decision", "result_id", id, "action", dec.Action, "reviewer", dec.Reviewer)
+			writeJSON(w, http.StatusOK, dec)
+		}
+	}
+
+	mux.HandleFunc("POST /review/{id}/promote", decide(func(ctx context.Context, id int, reviewer string, req decisionRequest) (*Decision, error) {
+		return svc.Promote(ctx, id, req.Fields, reviewer, req.Reason)
+	}))
+	mux.HandleFunc("POST /review/{id}/duplicate", decide(func(ctx context.Context, id int, reviewer string, req decisionRequest) (*Decision, error) {
+		if req.RFPID == 0 {
+			return nil, errBadRequest("rfp_id is required")
+		}
+		return svc.MarkDuplicate(ctx, id, req.RFPID, reviewer, req.Reason)
+	}))
+	mux.HandleFunc("POST /review/{id}/reject", decide(func(ctx context.Context, id int, reviewer string, req decisionRequest) (*Decision, error) {
+		return svc.Reject(ctx, id, reviewer, req.Reason)
+	}))
+	mux.HandleFunc("POST /review/{id}/requeue", decide(func(ctx context.Context, id int, reviewer string, req decisionRequest) (*Decision, error) {
+		return svc.Requeue(ctx, id, reviewer, req.Reason)
+	}))
+
+	return RequireToken(mux, tokens)
+}
+
+// RequireToken wraps h so requests must carry one of tokens as a bearer
+// token; the matching reviewer is available to h via ReviewerFrom. With no
+// tokens configured every request is refused.
+func RequireToken(h http.Handler, tokens Tokens) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(tokens) == 0 {
+			writeError(w, http.StatusServiceUnavailable, "review API disabled: no reviewer tokens configured")
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		reviewer := ""
+		for token, name := range tokens {
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+				reviewer = name
+			}
+		}
+		if !ok || reviewer == "" {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), reviewerKey{}, reviewer)))
+	})
+}
+
+// errBadRequest is a request validation error.
+type errBadRequest string
+
+func (e errBadRequest) Error() string { return string(e) }
+
+func resultID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid result ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// writeQueueError maps queue errors to HTTP statuses.
+func writeQueueError(w http.ResponseWriter, err error) {
+	var bad errBadRequest
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrRFPNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotInQueue):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrMissingReviewer), errors.Is(err, ErrMissingReason), errors.Is(err, ErrMissingTitle),
+		errors.Is(err, ErrInvalidStatus), errors.As(err, &bad):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		slog.Error("review request failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
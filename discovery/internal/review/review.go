@@ -0,0 +1,507 @@
+// Package review is the manual review queue: search results the research
+// agent couldn't finish, and the decisions reviewers make on them. A
+// reviewer can promote a result with the RFP fields filled in, mark it a
+// duplicate of an existing RFP, reject it, or send it back for research.
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zachsouder/rfp/discovery/internal/promotion"
+	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/shared/db"
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+var (
+	// ErrNotFound is returned for an unknown search result.
+	ErrNotFound = errors.New("search result not found")
+	// ErrNotInQueue is returned when deciding on a result that isn't awaiting review.
+	ErrNotInQueue = errors.New("search result is not awaiting review")
+	// ErrRFPNotFound is returned when marking a duplicate of an unknown RFP.
+	ErrRFPNotFound = errors.New("rfp not found")
+	// ErrMissingReviewer is returned when a decision doesn't say who made it.
+	ErrMissingReviewer = errors.New("reviewer is required")
+	// ErrMissingReason is returned when a decision doesn't say why.
+	ErrMissingReason = errors.New("reason is required")
+	// ErrMissingTitle is returned when promoting a result with no title.
+	ErrMissingTitle = errors.New("title is required")
+	// ErrInvalidStatus is returned when listing a status outside the queue.
+	ErrInvalidStatus = errors.New("not a review status")
+)
+
+// Action is a reviewer's decision on a queued result.
+type Action string
+
+const (
+	ActionPromote   Action = "promote"   // fill in RFP fields and promote
+	ActionDuplicate Action = "duplicate" // duplicate of an existing RFP
+	ActionReject    Action = "reject"    // not a relevant RFP
+	ActionRequeue   Action = "requeue"   // run research again
+)
+
+// StatusRejected is the research status of a result a reviewer rejected.
+const StatusRejected = "rejected"
+
+// Statuses are the research statuses that put a result in the queue.
+var Statuses = []string{
+	string(research.StatusNeedsManual),
+	string(research.StatusNeedsManualUpload),
+	string(research.StatusExhausted),
+}
+
+// InQueue reports whether a research status is awaiting review.
+func InQueue(status string) bool {
+	for _, s := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Item is a search result in the review queue.
+type Item struct {
+	ResultID    int        `json:"result_id"`
+	URL         string     `json:"url"`
+	FinalURL    string     `json:"final_url,omitempty"`
+	Title       string     `json:"title"`
+	Snippet     string     `json:"snippet,omitempty"`
+	Status      string     `json:"status"`
+	ContentType string     `json:"content_type,omitempty"`
+	HintAgency  string     `json:"hint_agency,omitempty"`
+	HintState   string     `json:"hint_state,omitempty"`
+	HintDueDate *time.Time `json:"hint_due_date,omitempty"`
+	Portal      string     `json:"portal,omitempty"`
+	Reason      string     `json:"reason,omitempty"` // why research stopped, from its last step
+	LastRunID   *int       `json:"last_run_id,omitempty"`
+	LastRunAt   *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Detail is a queued result with the trace of its latest research run and
+// the decisions made on it so far.
+type Detail struct {
+	Item
+	Steps            []models.ResearchStep      `json:"steps"`
+	ExtractedDetails *research.ExtractedDetails `json:"extracted_details,omitempty"`
+	FoundPDFs        []string                   `json:"found_pdfs,omitempty"`
+	Decisions        []Decision                 `json:"decisions"`
+}
+
+// Decision is a reviewer's decision on a result.
+type Decision struct {
+	ID             int                        `json:"id"`
+	SearchResultID int                        `json:"search_result_id"`
+	Action         Action                     `json:"action"`
+	Reviewer       string                     `json:"reviewer"`
+	Reason         string                     `json:"reason"`
+	PreviousStatus string                     `json:"previous_status"`
+	RFPID          *int                       `json:"rfp_id,omitempty"` // promoted to, or duplicate of
+	Fields         *research.ExtractedDetails `json:"fields,omitempty"` // entered when promoting
+	CreatedAt      time.Time                  `json:"created_at"`
+}
+
+// Promoter creates RFP records; *scheduler.Store implements it.
+type Promoter interface {
+	PromoteRFPTx(ctx context.Context, tx pgx.Tx, resultID int, rfp *models.RFP) (int, error)
+	LatestAwardee(ctx context.Context, rfp *models.RFP) (string, error)
+}
+
+// Queue reads the review queue and records decisions.
+type Queue struct {
+	db       *db.DB
+	promoter Promoter
+}
+
+// New creates a review queue.
+func New(database *db.DB, promoter Promoter) *Queue {
+	return &Queue{db: database, promoter: promoter}
+}
+
+// itemQuery selects queue items with the reason and time of their latest
+// research run.
+const itemQuery = `
+	SELECT sr.id, sr.url, COALESCE(sr.final_url, ''), COALESCE(sr.title, ''), COALESCE(sr.snippet, ''),
+	       COALESCE(sr.research_status, ''), COALESCE(sr.content_type, ''),
+	       COALESCE(sr.hint_agency, ''), COALESCE(sr.hint_state, ''), sr.hint_due_date, COALESCE(sr.portal, ''),
+	       COALESCE(step.reason, ''), run.id, run.started_at, sr.created_at
+	FROM discovery.search_results sr
+	LEFT JOIN LATERAL (
+		SELECT id, started_at FROM discovery.research_runs
+		WHERE search_result_id = sr.id
+		ORDER BY id DESC
+		LIMIT 1
+	) run ON true
+	LEFT JOIN LATERAL (
+		SELECT COALESCE(NULLIF(input_summary, ''), reasoning) AS reason FROM discovery.research_steps
+		WHERE search_result_id = sr.id
+		ORDER BY research_run_id DESC NULLS LAST, step_number DESC
+		LIMIT 1
+	) step ON true`
+
+func scanItem(row pgx.Row) (*Item, error) {
+	var it Item
+	err := row.Scan(
+		&it.ResultID, &it.URL, &it.FinalURL, &it.Title, &it.Snippet,
+		&it.Status, &it.ContentType,
+		&it.HintAgency, &it.HintState, &it.HintDueDate, &it.Portal,
+		&it.Reason, &it.LastRunID, &it.LastRunAt, &it.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &it, nil
+}
+
+// List returns queued results, oldest first. An empty status lists every
+// queue status.
+func (q *Queue) List(ctx context.Context, status string, limit int) ([]Item, error) {
+	statuses := Statuses
+	if status != "" {
+		if !InQueue(status) {
+			return nil, fmt.Errorf("%w: %q (want %s)", ErrInvalidStatus, status, strings.Join(Statuses, ", "))
+		}
+		statuses = []string{status}
+	}
+
+	rows, err := q.db.Query(ctx, itemQuery+`
+		WHERE sr.research_status = ANY($1)
+		ORDER BY sr.created_at, sr.id
+		LIMIT $2
+	`, statuses, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query review queue failed: %w", err)
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		it, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan review item failed: %w", err)
+		}
+		items = append(items, *it)
+	}
+	return items, rows.Err()
+}
+
+// Get returns a result with its latest research trace and decisions. It
+// works for results that have left the queue too.
+func (q *Queue) Get(ctx context.Context, resultID int) (*Detail, error) {
+	it, err := scanItem(q.db.QueryRow(ctx, itemQuery+`
+		WHERE sr.id = $1
+	`, resultID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query review item failed: %w", err)
+	}
+	d := &Detail{Item: *it, Steps: []models.ResearchStep{}, Decisions: []Decision{}}
+
+	if it.LastRunID != nil {
+		var details []byte
+		err := q.db.QueryRow(ctx, `
+			SELECT extracted_details, COALESCE(found_pdfs, '{}') FROM discovery.research_runs WHERE id = $1
+		`, *it.LastRunID).Scan(&details, &d.FoundPDFs)
+		if err != nil {
+			return nil, fmt.Errorf("query research run failed: %w", err)
+		}
+		if len(details) > 0 {
+			d.ExtractedDetails = &research.ExtractedDetails{}
+			if err := json.Unmarshal(details, d.ExtractedDetails); err != nil {
+				return nil, fmt.Errorf("parse extracted details failed: %w", err)
+			}
+		}
+	}
+
+	// Steps of the latest run, or those recorded before runs were
+	rows, err := q.db.Query(ctx, `
+		SELECT id, research_run_id, step_number, action, COALESCE(input_summary, ''), COALESCE(output_summary, ''),
+		       COALESCE(reasoning, ''), COALESCE(success, false), COALESCE(error_message, ''),
+		       COALESCE(tokens_used, 0), COALESCE(duration_ms, 0),
+		       COALESCE(snapshot_key, ''), COALESCE(prompt_version, ''), created_at
+		FROM discovery.research_steps
+		WHERE search_result_id = $1 AND research_run_id IS NOT DISTINCT FROM $2
+		ORDER BY step_number
+	`, resultID, it.LastRunID)
+	if err != nil {
+		return nil, fmt.Errorf("query research steps failed: %w", err)
+	}
+	for rows.Next() {
+		var step models.ResearchStep
+		if err := rows.Scan(
+			&step.ID, &step.ResearchRunID, &step.StepNumber, &step.Action, &step.InputSummary, &step.OutputSummary,
+			&step.Reasoning, &step.Success, &step.ErrorMessage, &step.TokensUsed, &step.DurationMs,
+			&step.SnapshotKey, &step.PromptVersion, &step.CreatedAt,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan research step failed: %w", err)
+		}
+		step.SearchResultID = resultID
+		d.Steps = append(d.Steps, step)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query research steps failed: %w", err)
+	}
+
+	rows, err = q.db.Query(ctx, `
+		SELECT id, action, reviewer, reason, previous_status, rfp_id, fields, created_at
+		FROM discovery.review_decisions
+		WHERE search_result_id = $1
+		ORDER BY id
+	`, resultID)
+	if err != nil {
+		return nil, fmt.Errorf("query review decisions failed: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		dec := Decision{SearchResultID: resultID}
+		var fields []byte
+		if err := rows.Scan(&dec.ID, &dec.Action, &dec.Reviewer, &dec.Reason, &dec.PreviousStatus, &dec.RFPID, &fields, &dec.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan review decision failed: %w", err)
+		}
+		if len(fields) > 0 {
+			dec.Fields = &research.ExtractedDetails{}
+			if err := json.Unmarshal(fields, dec.Fields); err != nil {
+				return nil, fmt.Errorf("parse decision fields failed: %w", err)
+			}
+		}
+		d.Decisions = append(d.Decisions, dec)
+	}
+	return d, rows.Err()
+}
+
+// Promote creates an RFP for a queued result. The reviewer's fields are
+// laid over whatever the latest research run extracted, and go through the
+// same parsing and classification as the agent's own promotions.
+func (q *Queue) Promote(ctx context.Context, resultID int, fields *research.ExtractedDetails, reviewer, reason string) (*Decision, error) {
+	dec := &Decision{SearchResultID: resultID, Action: ActionPromote, Reviewer: reviewer, Reason: reason, Fields: fields}
+	err := q.decide(ctx, dec, func(tx pgx.Tx, sr *models.SearchResult) error {
+		var extracted []byte
+		var pdfs []string
+		err := tx.QueryRow(ctx, `
+			SELECT extracted_details, found_pdfs FROM discovery.research_runs
+			WHERE search_result_id = $1
+			ORDER BY id DESC
+			LIMIT 1
+		`, resultID).Scan(&extracted, &pdfs)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("query research run failed: %w", err)
+		}
+		var base *research.ExtractedDetails
+		if len(extracted) > 0 {
+			base = &research.ExtractedDetails{}
+			if err := json.Unmarshal(extracted, base); err != nil {
+				return fmt.Errorf("parse extracted details failed: %w", err)
+			}
+		}
+
+		details := applyFields(base, fields, reviewer)
+		if strings.TrimSpace(details.Title) == "" && strings.TrimSpace(sr.Title) == "" {
+			return ErrMissingTitle
+		}
+
+		rfp := promotion.BuildRFP(sr, &research.ResearchResult{ExtractedDetails: details, FoundPDFs: pdfs})
+		if sr.ResearchStatus == string(research.StatusNeedsManualUpload) {
+			rfp.LoginRequired = true
+			rfp.LoginNotes = "Login wall found during research; promoted by " + reviewer
+		}
+		if rfp.Incumbent == "" {
+			incumbent, err := q.promoter.LatestAwardee(ctx, rfp)
+			if err != nil {
+				return err
+			}
+			rfp.Incumbent = incumbent
+		}
+
+		rfpID, err := q.promoter.PromoteRFPTx(ctx, tx, resultID, rfp)
+		if err != nil {
+			return err
+		}
+		dec.RFPID = &rfpID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
+
+// MarkDuplicate records that a queued result is an existing RFP.
+func (q *Queue) MarkDuplicate(ctx context.Context, resultID, rfpID int, reviewer, reason string) (*Decision, error) {
+	dec := &Decision{SearchResultID: resultID, Action: ActionDuplicate, Reviewer: reviewer, Reason: reason, RFPID: &rfpID}
+	err := q.decide(ctx, dec, func(tx pgx.Tx, sr *models.SearchResult) error {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM discovery.rfps WHERE id = $1)`, rfpID).Scan(&exists); err != nil {
+			return fmt.Errorf("look up rfp failed: %w", err)
+		}
+		if !exists {
+			return ErrRFPNotFound
+		}
+		return setStatus(ctx, tx, resultID, string(research.StatusSkipped), &rfpID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
+
+// Reject records that a queued result isn't a relevant RFP.
+func (q *Queue) Reject(ctx context.Context, resultID int, reviewer, reason string) (*Decision, error) {
+	dec := &Decision{SearchResultID: resultID, Action: ActionReject, Reviewer: reviewer, Reason: reason}
+	err := q.decide(ctx, dec, func(tx pgx.Tx, sr *models.SearchResult) error {
+		return setStatus(ctx, tx, resultID, StatusRejected, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
+
+// Requeue sends a queued result back to the research phase.
+func (q *Queue) Requeue(ctx context.Context, resultID int, reviewer, reason string) (*Decision, error) {
+	dec := &Decision{SearchResultID: resultID, Action: ActionRequeue, Reviewer: reviewer, Reason: reason}
+	err := q.decide(ctx, dec, func(tx pgx.Tx, sr *models.SearchResult) error {
+		return setStatus(ctx, tx, resultID, "pending", nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
+
+// decide locks a queued result, applies a decision to it and records the
+// decision, all in one transaction.
+func (q *Queue) decide(ctx context.Context, dec *Decision, apply func(tx pgx.Tx, sr *models.SearchResult) error) error {
+	dec.Reviewer = strings.TrimSpace(dec.Reviewer)
+	dec.Reason = strings.TrimSpace(dec.Reason)
+	if dec.Reviewer == "" {
+		return ErrMissingReviewer
+	}
+	if dec.Reason == "" {
+		return ErrMissingReason
+	}
+
+	return q.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var sr models.SearchResult
+		err := tx.QueryRow(ctx, `
+			SELECT id, url, COALESCE(final_url, ''), COALESCE(title, ''), COALESCE(snippet, ''),
+			       COALESCE(hint_agency, ''), COALESCE(hint_state, ''), hint_due_date,
+			       COALESCE(portal, ''), COALESCE(portal_agency, ''), COALESCE(portal_id, ''),
+			       COALESCE(research_status, '')
+			FROM discovery.search_results
+			WHERE id = $1
+			FOR UPDATE
+		`, dec.SearchResultID).Scan(
+			&sr.ID, &sr.URL, &sr.FinalURL, &sr.Title, &sr.Snippet,
+			&sr.HintAgency, &sr.HintState, &sr.HintDueDate,
+			&sr.Portal, &sr.PortalAgency, &sr.PortalID,
+			&sr.ResearchStatus,
+		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("look up search result failed: %w", err)
+		}
+		if !InQueue(sr.ResearchStatus) {
+			return fmt.Errorf("%w (status %s)", ErrNotInQueue, sr.ResearchStatus)
+		}
+		dec.PreviousStatus = sr.ResearchStatus
+
+		if err := apply(tx, &sr); err != nil {
+			return err
+		}
+
+		var fields []byte
+		if dec.Fields != nil {
+			if fields, err = json.Marshal(dec.Fields); err != nil {
+				return fmt.Errorf("marshal decision fields failed: %w", err)
+			}
+		}
+		err = tx.QueryRow(ctx, `
+			INSERT INTO discovery.review_decisions (search_result_id, action, reviewer, reason, previous_status, rfp_id, fields)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at
+		`, dec.SearchResultID, string(dec.Action), dec.Reviewer, dec.Reason, dec.PreviousStatus, dec.RFPID, fields,
+		).Scan(&dec.ID, &dec.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("insert review decision failed: %w", err)
+		}
+		return nil
+	})
+}
+
+// setStatus moves a result to a new research status, pointing it at the
+// RFP it duplicates if there is one.
+func setStatus(ctx context.Context, tx pgx.Tx, resultID int, status string, duplicateOf *int) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE discovery.search_results
+		SET research_status = $2, duplicate_of_id = COALESCE($3, duplicate_of_id)
+		WHERE id = $1
+	`, resultID, status, duplicateOf)
+	if err != nil {
+		return fmt.Errorf("update research status failed: %w", err)
+	}
+	return nil
+}
+
+// applyFields lays reviewer-entered fields over extracted details. Entered
+// fields replace the extraction's evidence for them with a note of who
+// entered them, so they aren't flagged for review again.
+func applyFields(base, fields *research.ExtractedDetails, reviewer string) *research.ExtractedDetails {
+	merged := make(map[string]json.RawMessage)
+	if base != nil {
+		data, _ := json.Marshal(base)
+		json.Unmarshal(data, &merged)
+	}
+
+	var entered []string
+	if fields != nil {
+		var values map[string]json.RawMessage
+		data, _ := json.Marshal(fields)
+		json.Unmarshal(data, &values)
+		for name, v := range values {
+			if name == "evidence" {
+				continue
+			}
+			merged[name] = v
+			entered = append(entered, name)
+		}
+	}
+	sort.Strings(entered)
+
+	out := &research.ExtractedDetails{}
+	data, _ := json.Marshal(merged)
+	json.Unmarshal(data, out)
+
+	isEntered := make(map[string]bool, len(entered))
+	for _, name := range entered {
+		isEntered[name] = true
+	}
+	var evidence []models.FieldEvidence
+	for _, ev := range out.Evidence {
+		if !isEntered[ev.Field] {
+			evidence = append(evidence, ev)
+		}
+	}
+	for _, name := range entered {
+		evidence = append(evidence, models.FieldEvidence{
+			Field:      name,
+			Confidence: 1,
+			Evidence:   "Entered by reviewer " + reviewer,
+		})
+	}
+	out.Evidence = evidence
+	return out
+}
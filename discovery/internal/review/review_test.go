@@ -0,0 +1,221 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/shared/models"
+)
+
+func TestInQueue(t *testing.T) {
+	for _, status := range []string{"needs_manual", "needs_manual_upload", "research_exhausted"} {
+		if !InQueue(status) {
+			t.Errorf("InQueue(%q) = false, want true", status)
+		}
+	}
+	for _, status := range []string{"pending", "researched", "skipped", StatusRejected, ""} {
+		if InQueue(status) {
+			t.Errorf("InQueue(%q) = true, want false", status)
+		}
+	}
+}
+
+func TestApplyFields(t *testing.T) {
+	base := &research.ExtractedDetails{
+		Title:   "Parking Services",
+		Agency:  "City of Austin",
+		DueDate: "March 15",
+		Evidence: []models.FieldEvidence{
+			{Field: "title", Confidence: 0.9, Evidence: "Parking Services", Verified: true},
+			{Field: "due_date", Confidence: 0.4, Evidence: "March 15"},
+		},
+	}
+	months := 36
+	fields := &research.ExtractedDetails{
+		DueDate:    "2026-03-15",
+		State:      "TX",
+		TermMonths: &months,
+	}
+
+	got := applyFields(base, fields, "alice")
+
+	if got.Title != "Parking Services" || got.Agency != "City of Austin" {
+		t.Errorf("extracted fields lost: %+v", got)
+	}
+	if got.DueDate != "2026-03-15" || got.State != "TX" || got.TermMonths == nil || *got.TermMonths != 36 {
+		t.Errorf("entered fields not applied: %+v", got)
+	}
+
+	evidence := make(map[string]models.FieldEvidence)
+	for _, ev := range got.Evidence {
+		evidence[ev.Field] = ev
+	}
+	if len(got.Evidence) != 4 {
+		t.Errorf("evidence = %+v, want title plus three entered fields", got.Evidence)
+	}
+	if ev := evidence["title"]; ev.Confidence != 0.9 {
+		t.Errorf("title evidence = %+v, want the extraction's", ev)
+	}
+	if ev := evidence["due_date"]; ev.Confidence != 1 || !strings.Contains(ev.Evidence, "alice") {
+		t.Errorf("due_date evidence = %+v, want entered by alice", ev)
+	}
+	// Agency had no evidence and wasn't entered, so it still needs review
+	if review := research.ReviewFields(got); len(review) != 1 || review[0] != "agency" {
+		t.Errorf("ReviewFields() = %v, want [agency]", review)
+	}
+
+	// Nothing extracted
+	got = applyFields(nil, &research.ExtractedDetails{Title: "Valet RFP"}, "bob")
+	if got.Title != "Valet RFP" || len(got.Evidence) != 1 {
+		t.Errorf("applyFields(nil, ...) = %+v", got)
+	}
+}
+
+// fakeService records the decisions the handler passes through.
+type fakeService struct {
+	calls []string
+}
+
+func (f *fakeService) List(ctx context.Context, status string, limit int) ([]Item, error) {
+	if status != "" && !InQueue(status) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, status)
+	}
+	f.calls = append(f.calls, fmt.Sprintf("list %s %d", status, limit))
+	return []Item{{ResultID: 7, Status: "needs_manual"}}, nil
+}
+
+func (f *fakeService) Get(ctx context.Context, resultID int) (*Detail, error) {
+	if resultID != 7 {
+		return nil, ErrNotFound
+	}
+	return &Detail{Item: Item{ResultID: 7}}, nil
+}
+
+func (f *fakeService) Promote(ctx context.Context, resultID int, fields *research.ExtractedDetails, reviewer, reason string) (*Decision, error) {
+	f.calls = append(f.calls, fmt.Sprintf("promote %d %s %s %s", resultID, fields.Title, reviewer, reason))
+	return &Decision{Action: ActionPromote, Reviewer: reviewer}, nil
+}
+
+func (f *fakeService) MarkDuplicate(ctx context.Context, resultID, rfpID int, reviewer, reason string) (*Decision, error) {
+	f.calls = append(f.calls, fmt.Sprintf("duplicate %d %d", resultID, rfpID))
+	return &Decision{Action: ActionDuplicate, Reviewer: reviewer}, nil
+}
+
+func (f *fakeService) Reject(ctx context.Context, resultID int, reviewer, reason string) (*Decision, error) {
+	if reason == "" {
+		return nil, ErrMissingReason
+	}
+	f.calls = append(f.calls, fmt.Sprintf("reject %d %s", resultID, reviewer))
+	return &Decision{Action: ActionReject, Reviewer: reviewer}, nil
+}
+
+func (f *fakeService) Requeue(ctx context.Context, resultID int, reviewer, reason string) (*Decision, error) {
+	return nil, fmt.Errorf("%w (status researched)", ErrNotInQueue)
+}
+
+func TestHandler(t *testing.T) {
+	svc := &fakeService{}
+	h := NewHandler(svc, Tokens{"secret": "alice"})
+
+	tests := []struct {
+		method, path, body string
+		wantStatus         int
+		wantBody           string
+	}{
+		{"GET", "/review", "", http.StatusOK, `"result_id":7`},
+		{"GET", "/review?status=needs_manual&limit=5", "", http.StatusOK, `"result_id":7`},
+		{"GET", "/review?status=pending", "", http.StatusBadRequest, "not a review status"},
+		{"GET", "/review?limit=0", "", http.StatusBadRequest, "invalid limit"},
+		{"GET", "/review/7", "", http.StatusOK, `"decisions"`},
+		{"GET", "/review/8", "", http.StatusNotFound, "not found"},
+		{"GET", "/review/x", "", http.StatusBadRequest, "invalid result ID"},
+		{"POST", "/review/7/promote", `{"reviewer": "mallory", "reason": "login wall", "fields": {"title": "Parking"}}`, http.StatusOK, `"action":"promote"`},
+		{"POST", "/review/7/duplicate", `{"reason": "same bid", "rfp_id": 12}`, http.StatusOK, `"action":"duplicate"`},
+		{"POST", "/review/7/duplicate", `{"reason": "same bid"}`, http.StatusBadRequest, "rfp_id is required"},
+		{"POST", "/review/7/reject", `{"reason": "towing, not parking"}`, http.StatusOK, `"action":"reject"`},
+		{"POST", "/review/7/reject", `{}`, http.StatusBadRequest, "reason is required"},
+		{"POST", "/review/7/requeue", `{"reason": "retry"}`, http.StatusConflict, "not awaiting review"},
+		{"POST", "/review/7/reject", `not json`, http.StatusBadRequest, "invalid request body"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus || !strings.Contains(rec.Body.String(), tt.wantBody) {
+			t.Errorf("%s %s = %d %s, want %d containing %q", tt.method, tt.path, rec.Code, rec.Body.String(), tt.wantStatus, tt.wantBody)
+		}
+	}
+
+	want := []string{
+		"list  50",
+		"list needs_manual 5",
+		"promote 7 Parking alice login wall",
+		"duplicate 7 12",
+		"reject 7 alice",
+	}
+	if strings.Join(svc.calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls = %q, want %q", svc.calls, want)
+	}
+}
+
+func TestHandler_Token(t *testing.T) {
+	svc := &fakeService{}
+	h := NewHandler(svc, Tokens{"secret": "alice", "hunter2": "bob"})
+
+	tests := []struct {
+		auth       string
+		wantStatus int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"secret", http.StatusUnauthorized},
+		{"Bearer hunter2", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/review/7/reject", strings.NewReader(`{"reason": "towing"}`))
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("Authorization %q: status = %d, want %d", tt.auth, rec.Code, tt.wantStatus)
+		}
+	}
+	if len(svc.calls) != 1 || svc.calls[0] != "reject 7 bob" {
+		t.Errorf("calls = %q, want one reject by bob", svc.calls)
+	}
+
+	// No tokens configured refuses everything rather than running open
+	req := httptest.NewRequest("GET", "/review", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	NewHandler(&fakeService{}, nil).ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without tokens: status = %d, want 503", rec.Code)
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	tokens, err := ParseTokens(" alice:s3cret, bob:hunter2 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens["s3cret"] != "alice" || tokens["hunter2"] != "bob" {
+		t.Errorf("tokens = %v", tokens)
+	}
+	if tokens, err := ParseTokens(""); err != nil || len(tokens) != 0 {
+		t.Errorf("empty spec = %v, %v", tokens, err)
+	}
+	for _, spec := range []string{"s3cret", "alice:", ":s3cret", "alice:x,bob:x"} {
+		if _, err := ParseTokens(spec); err == nil {
+			t.Errorf("ParseTokens(%q) succeeded, want error", spec)
+		}
+	}
+}
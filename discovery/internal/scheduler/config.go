@@ -16,10 +16,6 @@ type Config struct {
 	// Default: 5.
 	MaxConcurrency int
 
-	// ValidationDelay is the delay between URL validations to avoid rate limiting.
-	// Default: 500ms.
-	ValidationDelay time.Duration
-
 	// QueryDelay is the delay between search queries to avoid API rate limiting.
 	// Default: 500ms.
 	QueryDelay time.Duration
@@ -31,18 +27,79 @@ type Config struct {
 	// SkipSeenURLs controls whether to skip URLs that have already been processed.
 	// Default: true.
 	SkipSeenURLs bool
+
+	// ResearchBatchSize limits how many validated results are researched
+	// per cycle. Default: 20.
+	ResearchBatchSize int
+
+	// ListingBatchSize limits how many portal listing pages are expanded
+	// into their opportunities per cycle. Default: 10.
+	ListingBatchSize int
+
+	// ListingMaxEntries caps how many opportunities are taken from one
+	// listing page. Default: 50.
+	ListingMaxEntries int
+
+	// ListingReexpandAge is how long after a listing page was expanded it's
+	// expanded again to pick up newly posted opportunities. Default: 3 days.
+	ListingReexpandAge time.Duration
+
+	// DuplicateThreshold is the dedup match score at which a search result
+	// is skipped as a copy of an existing RFP instead of being researched.
+	// Default: 0.85.
+	DuplicateThreshold float64
+
+	// LifecycleRecheckAge is how long since an open RFP was last checked
+	// before its source page is fetched again. Default: 7 days.
+	LifecycleRecheckAge time.Duration
+
+	// LifecycleBatchSize limits how many RFPs are rechecked per cycle.
+	// Default: 50.
+	LifecycleBatchSize int
+
+	// AwardSearchAfter is how long after the due date to start looking for
+	// an award notice. Default: 14 days.
+	AwardSearchAfter time.Duration
+
+	// AwardRecheckAge is how long to wait before searching again for an RFP
+	// whose award was not found. Default: 30 days.
+	AwardRecheckAge time.Duration
+
+	// AwardBatchSize limits how many RFPs get award searches per cycle.
+	// Default: 10.
+	AwardBatchSize int
+
+	// StaleResearchAge is how long a result can be claimed for research
+	// before a cycle assumes its researcher died and puts it back.
+	// Default: 1 hour.
+	StaleResearchAge time.Duration
 }
 
 // DefaultConfig returns a Config with sensible defaults.
 func DefaultConfig() *Config {
 	return &Config{
-		Interval:        24 * time.Hour,
-		CycleTimeout:    30 * time.Minute,
-		MaxConcurrency:  5,
-		ValidationDelay: 500 * time.Millisecond,
-		QueryDelay:      500 * time.Millisecond,
-		RunOnStart:      true,
-		SkipSeenURLs:    true,
+		Interval:       24 * time.Hour,
+		CycleTimeout:   30 * time.Minute,
+		MaxConcurrency: 5,
+		QueryDelay:     500 * time.Millisecond,
+		RunOnStart:     true,
+		SkipSeenURLs:   true,
+
+		ResearchBatchSize:  20,
+		DuplicateThreshold: 0.85,
+
+		ListingBatchSize:   10,
+		ListingMaxEntries:  50,
+		ListingReexpandAge: 3 * 24 * time.Hour,
+
+		LifecycleRecheckAge: 7 * 24 * time.Hour,
+		LifecycleBatchSize:  50,
+
+		AwardSearchAfter: 14 * 24 * time.Hour,
+		AwardRecheckAge:  30 * 24 * time.Hour,
+		AwardBatchSize:   10,
+
+		StaleResearchAge: time.Hour,
 	}
 }
 
@@ -70,13 +127,6 @@ func WithMaxConcurrency(n int) Option {
 	}
 }
 
-// WithValidationDelay sets the delay between URL validations.
-func WithValidationDelay(d time.Duration) Option {
-	return func(c *Config) {
-		c.ValidationDelay = d
-	}
-}
-
 // WithQueryDelay sets the delay between search queries.
 func WithQueryDelay(d time.Duration) Option {
 	return func(c *Config) {
@@ -97,3 +147,83 @@ func WithSkipSeenURLs(b bool) Option {
 		c.SkipSeenURLs = b
 	}
 }
+
+// WithResearchBatchSize sets the max results researched per cycle.
+func WithResearchBatchSize(n int) Option {
+	return func(c *Config) {
+		c.ResearchBatchSize = n
+	}
+}
+
+// WithListingBatchSize sets the max listing pages expanded per cycle.
+func WithListingBatchSize(n int) Option {
+	return func(c *Config) {
+		c.ListingBatchSize = n
+	}
+}
+
+// WithListingMaxEntries sets the max opportunities taken from one listing.
+func WithListingMaxEntries(n int) Option {
+	return func(c *Config) {
+		c.ListingMaxEntries = n
+	}
+}
+
+// WithListingReexpandAge sets how often expanded listing pages are
+// expanded again.
+func WithListingReexpandAge(d time.Duration) Option {
+	return func(c *Config) {
+		c.ListingReexpandAge = d
+	}
+}
+
+// WithDuplicateThreshold sets the match score for skipping duplicates
+// before research.
+func WithDuplicateThreshold(score float64) Option {
+	return func(c *Config) {
+		c.DuplicateThreshold = score
+	}
+}
+
+// WithLifecycleRecheckAge sets how often open RFP source pages are rechecked.
+func WithLifecycleRecheckAge(d time.Duration) Option {
+	return func(c *Config) {
+		c.LifecycleRecheckAge = d
+	}
+}
+
+// WithLifecycleBatchSize sets the max RFPs rechecked per cycle.
+func WithLifecycleBatchSize(n int) Option {
+	return func(c *Config) {
+		c.LifecycleBatchSize = n
+	}
+}
+
+// WithAwardSearchAfter sets how long after the due date award searches start.
+func WithAwardSearchAfter(d time.Duration) Option {
+	return func(c *Config) {
+		c.AwardSearchAfter = d
+	}
+}
+
+// WithAwardRecheckAge sets how often award searches are retried.
+func WithAwardRecheckAge(d time.Duration) Option {
+	return func(c *Config) {
+		c.AwardRecheckAge = d
+	}
+}
+
+// WithAwardBatchSize sets the max RFPs searched for awards per cycle.
+func WithAwardBatchSize(n int) Option {
+	return func(c *Config) {
+		c.AwardBatchSize = n
+	}
+}
+
+// WithStaleResearchAge sets how long a research claim lasts before a cycle
+// puts the result back.
+func WithStaleResearchAge(d time.Duration) Option {
+	return func(c *Config) {
+		c.StaleResearchAge = d
+	}
+}
@@ -7,6 +7,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/zachsouder/rfp/discovery/internal/awards"
+	"github.com/zachsouder/rfp/discovery/internal/dedup"
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
+	"github.com/zachsouder/rfp/discovery/internal/lifecycle"
+	"github.com/zachsouder/rfp/discovery/internal/listing"
+	"github.com/zachsouder/rfp/discovery/internal/promotion"
 	"github.com/zachsouder/rfp/discovery/internal/research"
 	"github.com/zachsouder/rfp/discovery/internal/search"
 	"github.com/zachsouder/rfp/discovery/internal/validation"
@@ -21,6 +27,10 @@ type Scheduler struct {
 	search *search.Client
 	validator *validation.Validator
 	research  *research.Agent
+	lifecycle *lifecycle.Checker
+	awards    *awards.Finder
+	dedup     *dedup.Matcher
+	fetcher   *fetch.Fetcher
 
 	mu      sync.Mutex
 	running bool
@@ -38,6 +48,19 @@ type CycleStats struct {
 	ResultsNew      int
 	Validated       int
 	ValidationFailed int
+	ListingsExpanded int // Portal listing pages enumerated into opportunities
+	ListingEntries   int // Opportunities added from listing pages
+	Duplicates       int // Skipped before research as copies of existing RFPs
+	Researched       int
+	ResearchFailed   int
+	Promoted         int
+	NeedsManual      int // needs_manual, needs_manual_upload, or research_exhausted
+	RFPsClosed       int // Past due date
+	RFPsRechecked    int
+	RFPsChanged      int // Status changed by recheck
+	AwardsSearched   int
+	AwardsFound      int
+	IncumbentsSet    int // Rebids updated with a new incumbent
 }
 
 // New creates a new Scheduler.
@@ -53,9 +76,18 @@ func New(database *db.DB, searchClient *search.Client, validator *validation.Val
 		search:    searchClient,
 		validator: validator,
 		research:  researchAgent,
+		lifecycle: lifecycle.NewChecker(),
+		dedup:     dedup.NewSourceMatcher(dedup.NewPostgresSource(database)),
+		fetcher:   fetch.Default(),
 	}
 }
 
+// WithAwardFinder enables the award notice phase.
+func (s *Scheduler) WithAwardFinder(f *awards.Finder) *Scheduler {
+	s.awards = f
+	return s
+}
+
 // Run starts the scheduler and blocks until the stop channel is closed.
 func (s *Scheduler) Run(stop <-chan struct{}) {
 	s.mu.Lock()
@@ -124,6 +156,18 @@ func (s *Scheduler) runCycle() {
 		"results_skipped", stats.ResultsSkipped,
 		"validated", stats.Validated,
 		"validation_failed", stats.ValidationFailed,
+		"listings_expanded", stats.ListingsExpanded,
+		"listing_entries", stats.ListingEntries,
+		"duplicates", stats.Duplicates,
+		"researched", stats.Researched,
+		"promoted", stats.Promoted,
+		"needs_manual", stats.NeedsManual,
+		"rfps_closed", stats.RFPsClosed,
+		"rfps_rechecked", stats.RFPsRechecked,
+		"rfps_changed", stats.RFPsChanged,
+		"awards_searched", stats.AwardsSearched,
+		"awards_found", stats.AwardsFound,
+		"incumbents_set", stats.IncumbentsSet,
 	)
 }
 
@@ -133,6 +177,14 @@ func (s *Scheduler) executeCycle(ctx context.Context) (*CycleStats, error) {
 		StartTime: time.Now(),
 	}
 
+	// Results left researching by a process that died go back where they were
+	reset, err := s.store.ResetStaleResearch(ctx, time.Now().Add(-s.config.StaleResearchAge))
+	if err != nil {
+		slog.Warn("failed to reset stale research", "error", err)
+	} else if reset > 0 {
+		slog.Info("reset stale research claims", "count", reset)
+	}
+
 	// Load query configs
 	configs, err := s.store.LoadQueryConfigs(ctx)
 	if err != nil {
@@ -151,6 +203,28 @@ func (s *Scheduler) executeCycle(ctx context.Context) (*CycleStats, error) {
 		return nil, fmt.Errorf("validation phase failed: %w", err)
 	}
 
+	// Expansion phase
+	if err := s.executeExpansionPhase(ctx, stats); err != nil {
+		return nil, fmt.Errorf("expansion phase failed: %w", err)
+	}
+
+	// Research phase
+	if err := s.executeResearchPhase(ctx, stats); err != nil {
+		return nil, fmt.Errorf("research phase failed: %w", err)
+	}
+
+	// Lifecycle phase
+	if err := s.executeLifecyclePhase(ctx, stats); err != nil {
+		return nil, fmt.Errorf("lifecycle phase failed: %w", err)
+	}
+
+	// Award phase
+	if s.awards != nil {
+		if err := s.executeAwardPhase(ctx, stats); err != nil {
+			return nil, fmt.Errorf("award phase failed: %w", err)
+		}
+	}
+
 	stats.EndTime = time.Now()
 	stats.Duration = stats.EndTime.Sub(stats.StartTime)
 
@@ -189,9 +263,9 @@ func (s *Scheduler) executeSearchPhase(ctx context.Context, configs []models.Sea
 			// Save query with zero results
 			configID := cfg.ID
 			if configID == 0 {
-				_, err = s.store.SaveSearchQuery(ctx, cfg.QueryTemplate, nil, 0, "completed")
+				_, err = s.store.SaveSearchQuery(ctx, cfg.QueryTemplate, nil, 0, "completed", resp.PromptVersion)
 			} else {
-				_, err = s.store.SaveSearchQuery(ctx, cfg.QueryTemplate, &configID, 0, "completed")
+				_, err = s.store.SaveSearchQuery(ctx, cfg.QueryTemplate, &configID, 0, "completed", resp.PromptVersion)
 			}
 			if err != nil {
 				slog.Warn("failed to save empty query", "error", err)
@@ -237,7 +311,7 @@ func (s *Scheduler) executeSearchPhase(ctx context.Context, configs []models.Sea
 		if cfg.ID != 0 {
 			configIDPtr = &cfg.ID
 		}
-		_, savedResults, err := s.store.SaveSearchQueryAndResults(ctx, cfg.QueryTemplate, configIDPtr, newResults, "completed")
+		_, savedResults, err := s.store.SaveSearchQueryAndResults(ctx, cfg.QueryTemplate, configIDPtr, newResults, "completed", resp.PromptVersion)
 		if err != nil {
 			slog.Warn("failed to save query results", "name", cfg.Name, "error", err)
 			continue
@@ -317,9 +391,6 @@ func (s *Scheduler) executeValidationPhase(ctx context.Context, results []Search
 				"status", vr.Status,
 				"content_type", vr.ContentType,
 			)
-
-			// Rate limiting
-			time.Sleep(s.config.ValidationDelay)
 		}(sr)
 	}
 
@@ -332,3 +403,474 @@ func (s *Scheduler) executeValidationPhase(ctx context.Context, results []Search
 
 	return nil
 }
+
+// executeExpansionPhase enumerates the opportunities on validated portal
+// listing pages into child search results, keeps the parking-related ones,
+// and validates them so they're ready for research. Listings expanded more
+// than ListingReexpandAge ago are expanded again for new postings.
+func (s *Scheduler) executeExpansionPhase(ctx context.Context, stats *CycleStats) error {
+	staleBefore := time.Now().Add(-s.config.ListingReexpandAge)
+	listings, err := s.store.GetListingsToExpand(ctx, s.config.ListingBatchSize, staleBefore)
+	if err != nil {
+		return err
+	}
+	if len(listings) == 0 {
+		slog.Debug("no listings to expand")
+		return nil
+	}
+
+	slog.Info("starting expansion phase", "count", len(listings))
+
+	var children []SearchResultWithID
+	for _, parent := range listings {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		entries, err := s.expandListing(ctx, parent)
+		if err != nil {
+			slog.Warn("failed to expand listing", "result_id", parent.ID, "url", parent.URL, "error", err)
+			s.markListing(ctx, parent, StatusListingFailed)
+			continue
+		}
+		if entries == nil {
+			// No opportunity links, often a page rendered by JavaScript
+			slog.Debug("no opportunities found on listing", "result_id", parent.ID)
+			s.markListing(ctx, parent, StatusListingEmpty)
+			continue
+		}
+
+		saved, err := s.store.SaveListingEntries(ctx, parent, entries)
+		if err != nil {
+			slog.Warn("failed to save listing entries", "result_id", parent.ID, "error", err)
+			s.markListing(ctx, parent, StatusListingFailed)
+			continue
+		}
+
+		stats.ListingsExpanded++
+		stats.ListingEntries += len(saved)
+		children = append(children, saved...)
+	}
+
+	slog.Info("expansion phase complete",
+		"listings_expanded", stats.ListingsExpanded,
+		"entries", stats.ListingEntries,
+	)
+
+	return s.executeValidationPhase(ctx, children, stats)
+}
+
+// markListing records why a listing wasn't expanded, so it isn't picked up
+// again as pending. A listing expanded before keeps its status and is
+// tried again at its next re-expansion.
+func (s *Scheduler) markListing(ctx context.Context, parent models.SearchResult, status string) {
+	if parent.ResearchStatus == "expanded" {
+		if err := s.store.MarkListingChecked(ctx, parent.ID); err != nil {
+			slog.Warn("failed to mark listing checked", "result_id", parent.ID, "error", err)
+		}
+		return
+	}
+	if err := s.store.UpdateResearchStatus(ctx, parent.ID, status); err != nil {
+		slog.Warn("failed to update listing status", "result_id", parent.ID, "status", status, "error", err)
+	}
+}
+
+// expandListing fetches a listing page and returns its new, relevant
+// opportunities. It returns nil if the page has no opportunity links at all,
+// and an empty slice if it has links but none are new and relevant.
+func (s *Scheduler) expandListing(ctx context.Context, parent models.SearchResult) ([]listing.Entry, error) {
+	pageURL := parent.FinalURL
+	if pageURL == "" {
+		pageURL = parent.URL
+	}
+
+	resp, err := s.fetcher.Get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	all, err := listing.Extract(resp.FinalURL, resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parse listing failed: %w", err)
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+
+	entries := listing.Filter(all)
+	if len(entries) > s.config.ListingMaxEntries {
+		entries = entries[:s.config.ListingMaxEntries]
+	}
+
+	// Drop opportunities we've already seen from search or another listing
+	urls := make([]string, len(entries))
+	for i, e := range entries {
+		urls[i] = e.URL
+	}
+	existing, err := s.store.URLExistsBatch(ctx, urls)
+	if err != nil {
+		return nil, err
+	}
+	fresh := make([]listing.Entry, 0, len(entries))
+	for _, e := range entries {
+		if !existing[e.URL] {
+			fresh = append(fresh, e)
+		}
+	}
+
+	slog.Debug("expanded listing",
+		"result_id", parent.ID,
+		"links", len(all),
+		"relevant", len(entries),
+		"new", len(fresh),
+	)
+
+	return fresh, nil
+}
+
+// statusTimeout bounds recording a research outcome, which happens even
+// after the cycle's context is done.
+const statusTimeout = 30 * time.Second
+
+// executeResearchPhase researches validated results and promotes the ones
+// with complete details to RFPs.
+func (s *Scheduler) executeResearchPhase(ctx context.Context, stats *CycleStats) error {
+	pending, err := s.store.GetPendingResults(ctx, s.config.ResearchBatchSize)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		slog.Debug("no results to research")
+		return nil
+	}
+
+	slog.Info("starting research phase", "count", len(pending))
+
+	for i := range pending {
+		sr := &pending[i]
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		// Skip obvious mirrors of RFPs we already have
+		if match := s.findDuplicate(ctx, sr); match != nil {
+			if err := s.store.MarkDuplicate(ctx, sr.ID, match.MatchedRFPID); err != nil {
+				slog.Warn("failed to mark duplicate", "result_id", sr.ID, "error", err)
+				continue
+			}
+			stats.Duplicates++
+			slog.Info("skipped duplicate result",
+				"result_id", sr.ID,
+				"rfp_id", match.MatchedRFPID,
+				"score", match.MatchScore,
+				"reason", match.Reason,
+			)
+			continue
+		}
+
+		// Claim the result so a concurrent run can't research it too
+		if _, claimed, err := s.store.ClaimResearch(ctx, sr.ID, []string{"pending"}); err != nil || !claimed {
+			if err != nil {
+				slog.Warn("failed to claim result for research", "result_id", sr.ID, "error", err)
+			}
+			continue
+		}
+
+		res, err := s.research.Research(ctx, sr)
+
+		// Record the outcome even if the cycle ran out of time meanwhile
+		done, cancel := context.WithTimeout(context.WithoutCancel(ctx), statusTimeout)
+		if err != nil && ctx.Err() != nil {
+			if err := s.store.UpdateResearchStatus(done, sr.ID, "pending"); err != nil {
+				slog.Warn("failed to requeue result", "result_id", sr.ID, "error", err)
+			}
+			cancel()
+			return ctx.Err()
+		}
+		s.finishResearch(done, sr, res, err, stats)
+		cancel()
+	}
+
+	slog.Info("research phase complete",
+		"duplicates", stats.Duplicates,
+		"researched", stats.Researched,
+		"failed", stats.ResearchFailed,
+		"promoted", stats.Promoted,
+		"needs_manual", stats.NeedsManual,
+	)
+
+	return nil
+}
+
+// finishResearch records a research run and its outcome: the result's new
+// status, or the RFP it's promoted to. A result that can't be promoted goes
+// back to pending to be researched again.
+func (s *Scheduler) finishResearch(ctx context.Context, sr *models.SearchResult, res *research.ResearchResult, err error, stats *CycleStats) {
+	if res != nil {
+		if _, err := s.store.SaveResearchRun(ctx, sr.ID, res, TriggerScheduler); err != nil {
+			slog.Warn("failed to save research run", "result_id", sr.ID, "error", err)
+		}
+	}
+	if err != nil || res.Status == research.StatusFailed {
+		stats.ResearchFailed++
+		if err == nil {
+			err = fmt.Errorf("%s", res.Error)
+		}
+		slog.Warn("research failed", "result_id", sr.ID, "url", sr.URL, "error", err)
+		if err := s.store.UpdateResearchStatus(ctx, sr.ID, string(research.StatusFailed)); err != nil {
+			slog.Warn("failed to update research status", "result_id", sr.ID, "error", err)
+		}
+		return
+	}
+	stats.Researched++
+
+	if res.Status != research.StatusResearched {
+		stats.NeedsManual++
+		if err := s.store.UpdateResearchStatus(ctx, sr.ID, string(res.Status)); err != nil {
+			slog.Warn("failed to update research status", "result_id", sr.ID, "error", err)
+		}
+		return
+	}
+
+	rfp := promotion.BuildRFP(sr, res)
+	if rfp == nil {
+		stats.NeedsManual++
+		if err := s.store.UpdateResearchStatus(ctx, sr.ID, string(research.StatusNeedsManual)); err != nil {
+			slog.Warn("failed to update research status", "result_id", sr.ID, "error", err)
+		}
+		return
+	}
+
+	// Carry forward the winner of the agency's last award for this scope
+	if rfp.Incumbent == "" {
+		incumbent, err := s.store.LatestAwardee(ctx, rfp)
+		if err != nil {
+			slog.Warn("failed to look up previous awardee", "result_id", sr.ID, "error", err)
+		}
+		rfp.Incumbent = incumbent
+	}
+
+	rfpID, err := s.store.PromoteRFP(ctx, sr.ID, rfp)
+	if err != nil {
+		slog.Warn("failed to promote result", "result_id", sr.ID, "error", err)
+		if err := s.store.UpdateResearchStatus(ctx, sr.ID, "pending"); err != nil {
+			slog.Warn("failed to requeue result", "result_id", sr.ID, "error", err)
+		}
+		return
+	}
+	stats.Promoted++
+
+	slog.Info("promoted rfp",
+		"result_id", sr.ID,
+		"rfp_id", rfpID,
+		"title", rfp.Title,
+		"review_fields", rfp.ReviewFields,
+	)
+}
+
+// findDuplicate checks a search result's hints against existing RFPs and
+// returns the match if it's strong enough to skip research. Lookup errors
+// are logged and treated as no match so research still runs.
+func (s *Scheduler) findDuplicate(ctx context.Context, sr *models.SearchResult) *dedup.MatchResult {
+	c := dedup.Candidate{
+		Title:    sr.Title,
+		Agency:   sr.HintAgency,
+		State:    sr.HintState,
+		URL:      sr.URL,
+		Portal:   sr.Portal,
+		PortalID: sr.PortalID,
+	}
+	if sr.HintDueDate != nil {
+		c.DueDate = sr.HintDueDate.Format("2006-01-02")
+	}
+
+	match, err := s.dedup.CheckContext(ctx, c)
+	if err != nil {
+		slog.Warn("duplicate check failed", "result_id", sr.ID, "error", err)
+		return nil
+	}
+	if !match.FoundMatch || match.MatchScore < s.config.DuplicateThreshold {
+		return nil
+	}
+	// A shared solicitation number alone doesn't justify skipping research:
+	// numbers like 24-001 recur across agencies and hints are noisy, and a
+	// skipped result is never researched or reviewed
+	if onlySolicitationNumber(match) {
+		slog.Debug("not skipping result matched only by solicitation number",
+			"result_id", sr.ID, "rfp_id", match.MatchedRFPID, "reason", match.Reason)
+		return nil
+	}
+	return match
+}
+
+// onlySolicitationNumber reports whether a match rests on nothing but a
+// shared solicitation number.
+func onlySolicitationNumber(match *dedup.MatchResult) bool {
+	if len(match.Signals) == 0 {
+		return false
+	}
+	for _, sig := range match.Signals {
+		if sig.Name != dedup.SignalSolicitation {
+			return false
+		}
+	}
+	return true
+}
+
+// executeLifecyclePhase closes past-due RFPs and rechecks source pages of
+// open RFPs for cancellation, closure, or award notices.
+func (s *Scheduler) executeLifecyclePhase(ctx context.Context, stats *CycleStats) error {
+	closed, err := s.store.CloseExpiredRFPs(ctx)
+	if err != nil {
+		return err
+	}
+	stats.RFPsClosed = closed
+
+	cutoff := time.Now().Add(-s.config.LifecycleRecheckAge)
+	rfps, err := s.store.GetRFPsForRecheck(ctx, cutoff, s.config.LifecycleBatchSize)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("starting lifecycle phase", "closed", closed, "recheck", len(rfps))
+
+	for _, rfp := range rfps {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		result := s.lifecycle.Check(ctx, rfp.SourceURL)
+		stats.RFPsRechecked++
+
+		if result.Error != "" {
+			slog.Debug("lifecycle recheck failed", "rfp_id", rfp.ID, "url", rfp.SourceURL, "error", result.Error)
+		}
+
+		if result.Changed(rfp.LifecycleStatus) {
+			if err := s.store.UpdateRFPLifecycle(ctx, rfp.ID, result.Status, result.Reason); err != nil {
+				slog.Warn("failed to update rfp lifecycle", "rfp_id", rfp.ID, "error", err)
+				continue
+			}
+			stats.RFPsChanged++
+			slog.Info("rfp lifecycle changed",
+				"rfp_id", rfp.ID,
+				"from", rfp.LifecycleStatus,
+				"to", result.Status,
+				"reason", result.Reason,
+			)
+		} else if err := s.store.MarkRFPChecked(ctx, rfp.ID); err != nil {
+			slog.Warn("failed to mark rfp checked", "rfp_id", rfp.ID, "error", err)
+		}
+	}
+
+	slog.Info("lifecycle phase complete",
+		"closed", stats.RFPsClosed,
+		"rechecked", stats.RFPsRechecked,
+		"changed", stats.RFPsChanged,
+	)
+
+	return nil
+}
+
+// executeAwardPhase searches for award notices on RFPs past their due date,
+// records the winner, and carries it forward as the incumbent on rebids.
+func (s *Scheduler) executeAwardPhase(ctx context.Context, stats *CycleStats) error {
+	dueBefore := time.Now().Add(-s.config.AwardSearchAfter)
+	checkedBefore := time.Now().Add(-s.config.AwardRecheckAge)
+	rfps, err := s.store.GetRFPsForAwardSearch(ctx, dueBefore, checkedBefore, s.config.AwardBatchSize)
+	if err != nil {
+		return err
+	}
+
+	if len(rfps) == 0 {
+		slog.Debug("no rfps need award search")
+		return nil
+	}
+
+	slog.Info("starting award phase", "count", len(rfps))
+
+	for _, rfp := range rfps {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		award, err := s.awards.Find(ctx, rfp)
+		stats.AwardsSearched++
+
+		switch {
+		case err != nil:
+			// Left unchecked so it's retried next cycle rather than after
+			// the recheck period
+			slog.Warn("award search failed", "rfp_id", rfp.ID, "error", err)
+		case award == nil:
+			if err := s.store.MarkAwardChecked(ctx, rfp.ID); err != nil {
+				slog.Warn("failed to mark award checked", "rfp_id", rfp.ID, "error", err)
+			}
+		default:
+			if err := s.store.SaveAward(ctx, rfp.ID, award); err != nil {
+				slog.Warn("failed to save award", "rfp_id", rfp.ID, "error", err)
+				continue
+			}
+			stats.AwardsFound++
+			slog.Info("award found",
+				"rfp_id", rfp.ID,
+				"awardee", award.Awardee,
+				"source", award.SourceURL,
+			)
+
+			// Rebids are judged against the award date just found
+			rfp.AwardDate = award.Date
+			updated, err := s.updateRebidIncumbents(ctx, rfp, award.Awardee)
+			if err != nil {
+				slog.Warn("failed to update rebid incumbents", "rfp_id", rfp.ID, "error", err)
+			}
+			stats.IncumbentsSet += updated
+		}
+
+		// Rate limiting between searches
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.config.QueryDelay):
+		}
+	}
+
+	slog.Info("award phase complete",
+		"searched", stats.AwardsSearched,
+		"found", stats.AwardsFound,
+		"incumbents_set", stats.IncumbentsSet,
+	)
+
+	return nil
+}
+
+// updateRebidIncumbents sets the incumbent on later solicitations for the
+// same agency and scope that don't already name one.
+func (s *Scheduler) updateRebidIncumbents(ctx context.Context, awarded models.RFP, awardee string) (int, error) {
+	candidates, err := s.store.GetIncumbentCandidates(ctx, awarded.State)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []int
+	for _, c := range candidates {
+		if awards.IsRebid(awarded, c) {
+			ids = append(ids, c.ID)
+		}
+	}
+
+	return s.store.SetIncumbent(ctx, ids, awardee)
+}
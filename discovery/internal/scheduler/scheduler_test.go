@@ -1,8 +1,13 @@
 package scheduler
 
 import (
+	"context"
 	"testing"
 	"time"
+
+	"github.com/zachsouder/rfp/discovery/internal/dedup"
+	"github.com/zachsouder/rfp/discovery/internal/validation"
+	"github.com/zachsouder/rfp/shared/models"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -20,10 +25,6 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("expected MaxConcurrency to be 5, got %d", cfg.MaxConcurrency)
 	}
 
-	if cfg.ValidationDelay != 500*time.Millisecond {
-		t.Errorf("expected ValidationDelay to be 500ms, got %v", cfg.ValidationDelay)
-	}
-
 	if cfg.QueryDelay != 500*time.Millisecond {
 		t.Errorf("expected QueryDelay to be 500ms, got %v", cfg.QueryDelay)
 	}
@@ -35,6 +36,50 @@ func TestDefaultConfig(t *testing.T) {
 	if !cfg.SkipSeenURLs {
 		t.Error("expected SkipSeenURLs to be true")
 	}
+
+	if cfg.ResearchBatchSize != 20 {
+		t.Errorf("expected ResearchBatchSize to be 20, got %d", cfg.ResearchBatchSize)
+	}
+
+	if cfg.ListingBatchSize != 10 {
+		t.Errorf("expected ListingBatchSize to be 10, got %d", cfg.ListingBatchSize)
+	}
+
+	if cfg.ListingMaxEntries != 50 {
+		t.Errorf("expected ListingMaxEntries to be 50, got %d", cfg.ListingMaxEntries)
+	}
+
+	if cfg.ListingReexpandAge != 72*time.Hour {
+		t.Errorf("expected ListingReexpandAge to be 72h, got %v", cfg.ListingReexpandAge)
+	}
+
+	if cfg.DuplicateThreshold != 0.85 {
+		t.Errorf("expected DuplicateThreshold to be 0.85, got %v", cfg.DuplicateThreshold)
+	}
+
+	if cfg.LifecycleRecheckAge != 7*24*time.Hour {
+		t.Errorf("expected LifecycleRecheckAge to be 168h, got %v", cfg.LifecycleRecheckAge)
+	}
+
+	if cfg.LifecycleBatchSize != 50 {
+		t.Errorf("expected LifecycleBatchSize to be 50, got %d", cfg.LifecycleBatchSize)
+	}
+
+	if cfg.AwardSearchAfter != 14*24*time.Hour {
+		t.Errorf("expected AwardSearchAfter to be 336h, got %v", cfg.AwardSearchAfter)
+	}
+
+	if cfg.AwardRecheckAge != 30*24*time.Hour {
+		t.Errorf("expected AwardRecheckAge to be 720h, got %v", cfg.AwardRecheckAge)
+	}
+
+	if cfg.AwardBatchSize != 10 {
+		t.Errorf("expected AwardBatchSize to be 10, got %d", cfg.AwardBatchSize)
+	}
+
+	if cfg.StaleResearchAge != time.Hour {
+		t.Errorf("expected StaleResearchAge to be 1h, got %v", cfg.StaleResearchAge)
+	}
 }
 
 func TestConfigOptions(t *testing.T) {
@@ -55,11 +100,6 @@ func TestConfigOptions(t *testing.T) {
 		t.Errorf("expected MaxConcurrency to be 10, got %d", cfg.MaxConcurrency)
 	}
 
-	WithValidationDelay(1 * time.Second)(cfg)
-	if cfg.ValidationDelay != 1*time.Second {
-		t.Errorf("expected ValidationDelay to be 1s, got %v", cfg.ValidationDelay)
-	}
-
 	WithQueryDelay(2 * time.Second)(cfg)
 	if cfg.QueryDelay != 2*time.Second {
 		t.Errorf("expected QueryDelay to be 2s, got %v", cfg.QueryDelay)
@@ -74,6 +114,61 @@ func TestConfigOptions(t *testing.T) {
 	if cfg.SkipSeenURLs {
 		t.Error("expected SkipSeenURLs to be false")
 	}
+
+	WithResearchBatchSize(5)(cfg)
+	if cfg.ResearchBatchSize != 5 {
+		t.Errorf("expected ResearchBatchSize to be 5, got %d", cfg.ResearchBatchSize)
+	}
+
+	WithListingBatchSize(3)(cfg)
+	if cfg.ListingBatchSize != 3 {
+		t.Errorf("expected ListingBatchSize to be 3, got %d", cfg.ListingBatchSize)
+	}
+
+	WithListingMaxEntries(15)(cfg)
+	if cfg.ListingMaxEntries != 15 {
+		t.Errorf("expected ListingMaxEntries to be 15, got %d", cfg.ListingMaxEntries)
+	}
+
+	WithListingReexpandAge(24 * time.Hour)(cfg)
+	if cfg.ListingReexpandAge != 24*time.Hour {
+		t.Errorf("expected ListingReexpandAge to be 24h, got %v", cfg.ListingReexpandAge)
+	}
+
+	WithDuplicateThreshold(0.95)(cfg)
+	if cfg.DuplicateThreshold != 0.95 {
+		t.Errorf("expected DuplicateThreshold to be 0.95, got %v", cfg.DuplicateThreshold)
+	}
+
+	WithLifecycleRecheckAge(48 * time.Hour)(cfg)
+	if cfg.LifecycleRecheckAge != 48*time.Hour {
+		t.Errorf("expected LifecycleRecheckAge to be 48h, got %v", cfg.LifecycleRecheckAge)
+	}
+
+	WithLifecycleBatchSize(10)(cfg)
+	if cfg.LifecycleBatchSize != 10 {
+		t.Errorf("expected LifecycleBatchSize to be 10, got %d", cfg.LifecycleBatchSize)
+	}
+
+	WithAwardSearchAfter(7 * 24 * time.Hour)(cfg)
+	if cfg.AwardSearchAfter != 7*24*time.Hour {
+		t.Errorf("expected AwardSearchAfter to be 168h, got %v", cfg.AwardSearchAfter)
+	}
+
+	WithAwardRecheckAge(60 * 24 * time.Hour)(cfg)
+	if cfg.AwardRecheckAge != 60*24*time.Hour {
+		t.Errorf("expected AwardRecheckAge to be 1440h, got %v", cfg.AwardRecheckAge)
+	}
+
+	WithAwardBatchSize(5)(cfg)
+	if cfg.AwardBatchSize != 5 {
+		t.Errorf("expected AwardBatchSize to be 5, got %d", cfg.AwardBatchSize)
+	}
+
+	WithStaleResearchAge(2 * time.Hour)(cfg)
+	if cfg.StaleResearchAge != 2*time.Hour {
+		t.Errorf("expected StaleResearchAge to be 2h, got %v", cfg.StaleResearchAge)
+	}
 }
 
 func TestCycleStats(t *testing.T) {
@@ -103,3 +198,116 @@ func TestCycleStats(t *testing.T) {
 		t.Errorf("expected ResultsNew to be 15, got %d", stats.ResultsNew)
 	}
 }
+
+func TestFindDuplicate(t *testing.T) {
+	awardedDue := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	existing := []models.RFP{
+		{ID: 1, Title: "Parking Management Services FY 2024-2025", Agency: "City of Tampa", State: "FL",
+			SourceURL: "https://tampa.gov/bids/parking"},
+		{ID: 2, Title: "Janitorial Services RFP #24-117", Agency: "City of Tampa", State: "FL"},
+		{ID: 3, Title: "Parking Management Services", Agency: "City of Austin", State: "TX",
+			DueDate: &awardedDue, LifecycleStatus: models.LifecycleAwarded},
+		{ID: 4, Title: "Valet Parking Services", Agency: "City of Austin", State: "TX",
+			LifecycleStatus: models.LifecycleOpen},
+	}
+	s := &Scheduler{config: DefaultConfig(), dedup: dedup.NewMatcher(existing)}
+
+	tests := []struct {
+		name    string
+		result  models.SearchResult
+		wantRFP int // 0 means not skipped
+	}{
+		{
+			name: "different agency sharing a fiscal year",
+			result: models.SearchResult{ID: 10, Title: "Valet Parking Operations 2024-2025",
+				HintAgency: "City of Orlando", HintState: "FL"},
+		},
+		{
+			name: "same fiscal year in a different state",
+			result: models.SearchResult{ID: 11, Title: "Parking Enforcement FY 2024-2025",
+				HintAgency: "Maricopa County", HintState: "AZ"},
+		},
+		{
+			name: "solicitation number shared with another agency",
+			result: models.SearchResult{ID: 12, Title: "Parking Garage Operations RFP #24-117",
+				HintAgency: "City of Orlando", HintState: "FL"},
+		},
+		{
+			name: "undated rebid of an awarded RFP",
+			result: models.SearchResult{ID: 14, Title: "Parking Management Services",
+				HintAgency: "City of Austin", HintState: "TX"},
+		},
+		{
+			name: "undated result for an open RFP",
+			result: models.SearchResult{ID: 15, Title: "Valet Parking Services",
+				HintAgency: "City of Austin", HintState: "TX"},
+			wantRFP: 4,
+		},
+		{
+			name: "same source URL",
+			result: models.SearchResult{ID: 13, Title: "Parking Management Services",
+				URL: "https://www.tampa.gov/bids/parking/"},
+			wantRFP: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		match := s.findDuplicate(context.Background(), &tt.result)
+		switch {
+		case tt.wantRFP == 0 && match != nil:
+			t.Errorf("%s: skipped as duplicate of RFP %d (%s)", tt.name, match.MatchedRFPID, match.Reason)
+		case tt.wantRFP != 0 && (match == nil || match.MatchedRFPID != tt.wantRFP):
+			t.Errorf("%s: got %+v, want duplicate of RFP %d", tt.name, match, tt.wantRFP)
+		}
+	}
+}
+
+func TestManualResult(t *testing.T) {
+	vr := &validation.Result{
+		Valid:       true,
+		FinalURL:    "https://cityofaustin.bonfirehub.com/opportunities/123456",
+		ContentType: validation.ContentTypeRFPPage,
+	}
+	sr := manualResult("https://bit.ly/austin-parking", "City of Austin Parking RFP", vr)
+
+	// Ready for the research phase, as GetPendingResults selects
+	if sr.ResearchStatus != "pending" || !sr.URLValidated || sr.URLValid == nil || !*sr.URLValid {
+		t.Errorf("result = %+v, want validated and pending", sr)
+	}
+	if sr.ContentType != "rfp_page" || sr.FinalURL != vr.FinalURL {
+		t.Errorf("ContentType = %q, FinalURL = %q", sr.ContentType, sr.FinalURL)
+	}
+	if sr.Portal != "bonfire" || sr.PortalID != "123456" || sr.HintAgency == "" {
+		t.Errorf("portal = %q %q, agency hint = %q", sr.Portal, sr.PortalID, sr.HintAgency)
+	}
+
+	// A dead link is recorded as checked and invalid, not left for a check
+	// that never comes
+	sr = manualResult("https://example.gov/gone", "Manual research", &validation.Result{Status: validation.StatusNotFound})
+	if !sr.URLValidated || sr.URLValid == nil || *sr.URLValid || sr.FinalURL != "https://example.gov/gone" {
+		t.Errorf("dead link = %+v", sr)
+	}
+}
+
+func TestMatchSeenURLs(t *testing.T) {
+	seen := map[string]bool{
+		"https://agency.gov/bids/1":                        true,
+		dedup.CanonicalURL("https://city.gov/rfp?b=2&a=1"): true,
+	}
+	got := matchSeenURLs([]string{
+		"https://agency.gov/bids/1",
+		"https://www.city.gov/rfp/?a=1&b=2&utm_source=x",
+		"https://city.gov/rfp?a=3",
+		"not a url",
+	}, seen)
+
+	if !got["https://agency.gov/bids/1"] {
+		t.Error("exact URL should be seen")
+	}
+	if !got["https://www.city.gov/rfp/?a=1&b=2&utm_source=x"] {
+		t.Error("URL with the same canonical form should be seen")
+	}
+	if got["https://city.gov/rfp?a=3"] || got["not a url"] {
+		t.Errorf("unseen URLs reported as seen: %v", got)
+	}
+}
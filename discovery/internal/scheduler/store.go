@@ -2,9 +2,20 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/zachsouder/rfp/discovery/internal/agency"
+	"github.com/zachsouder/rfp/discovery/internal/awards"
+	"github.com/zachsouder/rfp/discovery/internal/dedup"
+	"github.com/zachsouder/rfp/discovery/internal/geo"
+	"github.com/zachsouder/rfp/discovery/internal/listing"
+	"github.com/zachsouder/rfp/discovery/internal/portal"
+	"github.com/zachsouder/rfp/discovery/internal/research"
 	"github.com/zachsouder/rfp/discovery/internal/search"
 	"github.com/zachsouder/rfp/discovery/internal/validation"
 	"github.com/zachsouder/rfp/shared/db"
@@ -58,13 +69,13 @@ func (s *Store) LoadQueryConfigs(ctx context.Context) ([]models.SearchQueryConfi
 
 // SaveSearchQuery persists a search query execution record.
 // Returns the query ID.
-func (s *Store) SaveSearchQuery(ctx context.Context, queryText string, configID *int, resultsCount int, status string) (int, error) {
+func (s *Store) SaveSearchQuery(ctx context.Context, queryText string, configID *int, resultsCount int, status, promptVersion string) (int, error) {
 	var id int
 	err := s.db.QueryRow(ctx, `
-		INSERT INTO discovery.search_queries (query_text, query_config_id, results_count, status)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO discovery.search_queries (query_text, query_config_id, results_count, status, prompt_version)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
-	`, queryText, configID, resultsCount, status).Scan(&id)
+	`, queryText, configID, resultsCount, status, nullString(promptVersion)).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("insert search query failed: %w", err)
 	}
@@ -74,56 +85,87 @@ func (s *Store) SaveSearchQuery(ctx context.Context, queryText string, configID
 // SaveSearchResult persists a search result.
 // Returns the result ID.
 func (s *Store) SaveSearchResult(ctx context.Context, queryID int, result search.Result) (int, error) {
+	h := dedup.ExtractHints(result.Title, result.Snippet, result.URL)
+	p := portalColumnsFor(result.URL)
 	var id int
 	err := s.db.QueryRow(ctx, `
-		INSERT INTO discovery.search_results (query_id, url, title, snippet)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO discovery.search_results (query_id, url, title, snippet, hint_agency, hint_state, hint_due_date,
+			portal, portal_agency, portal_id, portal_page, canonical_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
-	`, queryID, result.URL, result.Title, result.Snippet).Scan(&id)
+	`, queryID, result.URL, result.Title, result.Snippet,
+		nullString(h.Agency), nullString(h.State), h.DueDate,
+		p.portal, p.agency, p.id, p.page, nullString(dedup.CanonicalURL(result.URL))).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("insert search result failed: %w", err)
 	}
 	return id, nil
 }
 
-// URLExists checks if a URL has already been processed.
+// URLExists checks if a URL has already been processed, under any spelling
+// of it.
 func (s *Store) URLExists(ctx context.Context, url string) (bool, error) {
-	var exists bool
-	err := s.db.QueryRow(ctx, `
-		SELECT EXISTS(
-			SELECT 1 FROM discovery.search_results WHERE url = $1
-		)
-	`, url).Scan(&exists)
+	existing, err := s.URLExistsBatch(ctx, []string{url})
 	if err != nil {
-		return false, fmt.Errorf("check url exists failed: %w", err)
+		return false, err
 	}
-	return exists, nil
+	return existing[url], nil
 }
 
 // URLExistsBatch checks multiple URLs at once and returns a map of URL -> exists.
+// URLs match on their canonical form, so a result saved with "www." or
+// reordered query parameters counts as seen.
 func (s *Store) URLExistsBatch(ctx context.Context, urls []string) (map[string]bool, error) {
 	if len(urls) == 0 {
 		return make(map[string]bool), nil
 	}
 
+	canonical := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if c := dedup.CanonicalURL(u); c != "" {
+			canonical = append(canonical, c)
+		}
+	}
+
 	rows, err := s.db.Query(ctx, `
-		SELECT url FROM discovery.search_results WHERE url = ANY($1)
-	`, urls)
+		SELECT url, COALESCE(canonical_url, '') FROM discovery.search_results
+		WHERE url = ANY($1) OR canonical_url = ANY($2)
+	`, urls, canonical)
 	if err != nil {
 		return nil, fmt.Errorf("batch url check failed: %w", err)
 	}
 	defer rows.Close()
 
-	result := make(map[string]bool)
+	seen := make(map[string]bool)
 	for rows.Next() {
-		var url string
-		if err := rows.Scan(&url); err != nil {
+		var url, canon string
+		if err := rows.Scan(&url, &canon); err != nil {
 			return nil, fmt.Errorf("scan url failed: %w", err)
 		}
-		result[url] = true
+		seen[url] = true
+		if canon != "" {
+			seen[canon] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return result, rows.Err()
+	return matchSeenURLs(urls, seen), nil
+}
+
+// matchSeenURLs maps each URL to whether it, or its canonical form, is in
+// seen.
+func matchSeenURLs(urls []string, seen map[string]bool) map[string]bool {
+	result := make(map[string]bool)
+	for _, u := range urls {
+		if seen[u] {
+			result[u] = true
+		} else if c := dedup.CanonicalURL(u); c != "" && seen[c] {
+			result[u] = true
+		}
+	}
+	return result
 }
 
 // UpdateValidation updates a search result with validation results.
@@ -152,13 +194,186 @@ func (s *Store) UpdateResearchStatus(ctx context.Context, resultID int, status s
 	return nil
 }
 
+// ClaimResearch atomically marks a result as researching if its status is
+// one of from, remembering the status it had. It returns that status, or
+// false if the result wasn't in one of them.
+func (s *Store) ClaimResearch(ctx context.Context, resultID int, from []string) (string, bool, error) {
+	var previous string
+	err := s.db.QueryRow(ctx, `
+		UPDATE discovery.search_results
+		SET research_status = 'researching', research_started_at = NOW(),
+		    research_resume_status = research_status
+		WHERE id = $1 AND research_status = ANY($2)
+		RETURNING research_resume_status
+	`, resultID, from).Scan(&previous)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("claim research failed: %w", err)
+	}
+	return previous, true, nil
+}
+
+// ResetStaleResearch puts results claimed for research before the cutoff
+// back to the status they were claimed from, for when the process
+// researching them died. It returns how many were reset.
+func (s *Store) ResetStaleResearch(ctx context.Context, cutoff time.Time) (int, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE discovery.search_results
+		SET research_status = COALESCE(research_resume_status, 'pending'),
+		    research_started_at = NULL, research_resume_status = NULL
+		WHERE research_status = 'researching'
+		  AND (research_started_at IS NULL OR research_started_at < $1)
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("reset stale research failed: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// Research run triggers.
+const (
+	TriggerScheduler = "scheduler"
+	TriggerManual    = "manual"
+	TriggerUpload    = "upload" // resumed from uploaded documents
+)
+
+// SaveResearchRun records a research run and its steps for a search
+// result. Earlier runs are kept. Returns the run ID.
+func (s *Store) SaveResearchRun(ctx context.Context, resultID int, res *research.ResearchResult, trigger string) (int, error) {
+	var details []byte
+	if res.ExtractedDetails != nil {
+		var err error
+		details, err = json.Marshal(res.ExtractedDetails)
+		if err != nil {
+			return 0, fmt.Errorf("marshal extracted details failed: %w", err)
+		}
+	}
+
+	var runID int
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `
+			INSERT INTO discovery.research_runs (search_result_id, trigger, status, success, error_message,
+				steps_taken, total_tokens, duration_ms, extracted_details, found_pdfs,
+				agent_version, model, started_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			RETURNING id
+		`, resultID, trigger, string(res.Status), res.Success, nullString(res.Error),
+			res.StepsTaken, res.TotalTokens, res.DurationMs, details, res.FoundPDFs,
+			res.AgentVersion, nullString(res.Model), res.StartedAt).Scan(&runID)
+		if err != nil {
+			return fmt.Errorf("insert research run failed: %w", err)
+		}
+
+		for _, step := range res.Steps {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO discovery.research_steps (search_result_id, research_run_id, step_number, action,
+					input_summary, output_summary, reasoning, success, tokens_used, duration_ms,
+					snapshot_key, prompt_version)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			`, resultID, runID, step.StepNumber, step.Action,
+				step.InputSummary, step.OutputSummary, step.Reasoning, step.Success, step.TokensUsed, step.DurationMs,
+				nullString(step.SnapshotKey), nullString(step.PromptVersion))
+			if err != nil {
+				return fmt.Errorf("insert research step failed: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("save research run failed: %w", err)
+	}
+	return runID, nil
+}
+
+// FindResultByURL returns the earliest search result whose URL or final
+// URL is url, or nil if there is none.
+func (s *Store) FindResultByURL(ctx context.Context, url string) (*models.SearchResult, error) {
+	var r models.SearchResult
+	err := s.db.QueryRow(ctx, `
+		SELECT id, COALESCE(query_id, 0), url, COALESCE(title, ''), COALESCE(snippet, ''), COALESCE(final_url, ''),
+		       COALESCE(hint_agency, ''), COALESCE(hint_state, ''), hint_due_date, research_status
+		FROM discovery.search_results
+		WHERE url = $1 OR final_url = $1
+		ORDER BY id
+		LIMIT 1
+	`, url).Scan(
+		&r.ID, &r.QueryID, &r.URL, &r.Title, &r.Snippet, &r.FinalURL,
+		&r.HintAgency, &r.HintState, &r.HintDueDate, &r.ResearchStatus,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find result by url failed: %w", err)
+	}
+	return &r, nil
+}
+
+// SaveManualResult records a search result for a URL researched by hand,
+// outside any search query, along with the URL's validation. Returns the
+// result ID.
+func (s *Store) SaveManualResult(ctx context.Context, url, title string, vr *validation.Result) (int, error) {
+	sr := manualResult(url, title, vr)
+	var id int
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO discovery.search_results (url, title, snippet, hint_agency, hint_state, hint_due_date,
+			url_validated, url_valid, final_url, content_type, snapshot_key,
+			portal, portal_agency, portal_id, portal_page, canonical_url)
+		VALUES ($1, $2, '', $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id
+	`, sr.URL, sr.Title, nullString(sr.HintAgency), nullString(sr.HintState), sr.HintDueDate,
+		sr.URLValidated, sr.URLValid, sr.FinalURL, sr.ContentType, nullString(vr.SnapshotKey),
+		nullString(sr.Portal), nullString(sr.PortalAgency), nullString(sr.PortalID), nullString(sr.PortalPage),
+		nullString(dedup.CanonicalURL(sr.URL))).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("insert manual search result failed: %w", err)
+	}
+	return id, nil
+}
+
+// manualResult builds the search result for a URL researched by hand. It
+// is validated as search results are during a cycle, so a valid page is
+// pending research like any other and the pipeline picks it up.
+func manualResult(url, title string, vr *validation.Result) *models.SearchResult {
+	h := dedup.ExtractHints(title, "", url)
+	valid := vr.Valid
+	sr := &models.SearchResult{
+		URL:            url,
+		Title:          title,
+		URLValidated:   true,
+		URLValid:       &valid,
+		FinalURL:       vr.FinalURL,
+		ContentType:    string(vr.ContentType),
+		HintAgency:     h.Agency,
+		HintState:      h.State,
+		HintDueDate:    h.DueDate,
+		ResearchStatus: "pending",
+	}
+	if sr.FinalURL == "" {
+		sr.FinalURL = url
+	}
+
+	// The final URL refines what the requested one says about the portal
+	for _, u := range []string{url, sr.FinalURL} {
+		if m := portal.Parse(u); m != nil {
+			sr.Portal, sr.PortalAgency, sr.PortalID, sr.PortalPage = m.Portal, m.Agency, m.ID, string(m.Page)
+		}
+	}
+	return sr
+}
+
 // GetPendingResults returns search results that need research.
 func (s *Store) GetPendingResults(ctx context.Context, limit int) ([]models.SearchResult, error) {
 	rows, err := s.db.Query(ctx, `
-		SELECT id, query_id, url, title, snippet, url_validated, url_valid, final_url, content_type,
-		       hint_agency, hint_state, hint_due_date, research_status, promoted_rfp_id, duplicate_of_id, created_at
+		SELECT id, COALESCE(query_id, 0), url, title, snippet, url_validated, url_valid, final_url, content_type,
+		       COALESCE(hint_agency, ''), COALESCE(hint_state, ''), hint_due_date,
+		       COALESCE(portal, ''), COALESCE(portal_agency, ''), COALESCE(portal_id, ''), COALESCE(portal_page, ''),
+		       research_status, promoted_rfp_id, duplicate_of_id, created_at
 		FROM discovery.search_results
 		WHERE research_status = 'pending' AND url_validated = true AND url_valid = true
+		  AND NOT (content_type = 'portal_listing' AND parent_id IS NULL)
 		ORDER BY created_at ASC
 		LIMIT $1
 	`, limit)
@@ -174,6 +389,7 @@ func (s *Store) GetPendingResults(ctx context.Context, limit int) ([]models.Sear
 			&r.ID, &r.QueryID, &r.URL, &r.Title, &r.Snippet,
 			&r.URLValidated, &r.URLValid, &r.FinalURL, &r.ContentType,
 			&r.HintAgency, &r.HintState, &r.HintDueDate,
+			&r.Portal, &r.PortalAgency, &r.PortalID, &r.PortalPage,
 			&r.ResearchStatus, &r.PromotedRFPID, &r.DuplicateOfID, &r.CreatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("scan result failed: %w", err)
@@ -184,20 +400,162 @@ func (s *Store) GetPendingResults(ctx context.Context, limit int) ([]models.Sear
 	return results, rows.Err()
 }
 
+// MarkDuplicate skips research for a search result that matches an
+// existing RFP.
+func (s *Store) MarkDuplicate(ctx context.Context, resultID, rfpID int) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE discovery.search_results
+		SET research_status = 'skipped', duplicate_of_id = $2
+		WHERE id = $1
+	`, resultID, rfpID)
+	if err != nil {
+		return fmt.Errorf("mark duplicate failed: %w", err)
+	}
+	return nil
+}
+
+// GetListingsToExpand returns validated portal listing pages that haven't
+// been expanded or researched, then those last expanded before
+// staleBefore, which may have posted new opportunities since. Results
+// enumerated from a listing aren't expanded.
+func (s *Store) GetListingsToExpand(ctx context.Context, limit int, staleBefore time.Time) ([]models.SearchResult, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, COALESCE(query_id, 0), url, title, COALESCE(final_url, ''), research_status
+		FROM discovery.search_results
+		WHERE url_valid = true AND content_type = 'portal_listing' AND parent_id IS NULL
+		  AND (research_status = 'pending'
+		       OR (research_status = 'expanded' AND (expanded_at IS NULL OR expanded_at < $2)))
+		ORDER BY expanded_at ASC NULLS FIRST, created_at ASC
+		LIMIT $1
+	`, limit, staleBefore)
+	if err != nil {
+		return nil, fmt.Errorf("query listings failed: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(&r.ID, &r.QueryID, &r.URL, &r.Title, &r.FinalURL, &r.ResearchStatus); err != nil {
+			return nil, fmt.Errorf("scan listing failed: %w", err)
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// Research statuses of portal listings that weren't expanded. A listing
+// indexes many opportunities, so it's never researched as a single RFP.
+const (
+	StatusListingFailed = "listing_failed" // fetching it or saving its entries failed
+	StatusListingEmpty  = "listing_empty"  // no opportunity links, often a page rendered by JavaScript
+)
+
+// SaveListingEntries stores the opportunities found on a listing page as
+// child search results and marks the listing expanded so it isn't
+// researched as a single RFP. The expansion time decides when the listing
+// is expanded again.
+func (s *Store) SaveListingEntries(ctx context.Context, parent models.SearchResult, entries []listing.Entry) ([]SearchResultWithID, error) {
+	var saved []SearchResultWithID
+
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		for _, e := range entries {
+			h := dedup.ExtractHints(e.Title, e.Context, e.URL)
+			if e.ClosingDate != nil {
+				h.DueDate = e.ClosingDate
+			}
+			p := portalColumnsFor(e.URL)
+
+			var id int
+			err := tx.QueryRow(ctx, `
+				INSERT INTO discovery.search_results (query_id, parent_id, url, title, snippet, hint_agency, hint_state, hint_due_date,
+					portal, portal_agency, portal_id, portal_page, canonical_url)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+				RETURNING id
+			`, parent.QueryID, parent.ID, e.URL, e.Title, e.Context,
+				nullString(h.Agency), nullString(h.State), h.DueDate,
+				p.portal, p.agency, p.id, p.page, nullString(dedup.CanonicalURL(e.URL))).Scan(&id)
+			if err != nil {
+				return fmt.Errorf("insert listing entry failed: %w", err)
+			}
+			saved = append(saved, SearchResultWithID{
+				ID:     id,
+				URL:    e.URL,
+				Title:  e.Title,
+				Result: search.Result{URL: e.URL, Title: e.Title, Snippet: e.Context},
+			})
+		}
+
+		_, err := tx.Exec(ctx, `
+			UPDATE discovery.search_results
+			SET research_status = 'expanded', expanded_at = NOW()
+			WHERE id = $1
+		`, parent.ID)
+		if err != nil {
+			return fmt.Errorf("mark listing expanded failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+// MarkListingChecked records that an expanded listing was looked at again,
+// even when nothing could be taken from it, so it waits for the next
+// re-expansion.
+func (s *Store) MarkListingChecked(ctx context.Context, resultID int) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE discovery.search_results SET expanded_at = NOW() WHERE id = $1
+	`, resultID)
+	if err != nil {
+		return fmt.Errorf("mark listing checked failed: %w", err)
+	}
+	return nil
+}
+
 // SaveSearchResultWithTx persists a search result within a transaction.
 func (s *Store) SaveSearchResultWithTx(ctx context.Context, tx pgx.Tx, queryID int, result search.Result) (int, error) {
+	h := dedup.ExtractHints(result.Title, result.Snippet, result.URL)
+	p := portalColumnsFor(result.URL)
 	var id int
 	err := tx.QueryRow(ctx, `
-		INSERT INTO discovery.search_results (query_id, url, title, snippet)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO discovery.search_results (query_id, url, title, snippet, hint_agency, hint_state, hint_due_date,
+			portal, portal_agency, portal_id, portal_page, canonical_url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id
-	`, queryID, result.URL, result.Title, result.Snippet).Scan(&id)
+	`, queryID, result.URL, result.Title, result.Snippet,
+		nullString(h.Agency), nullString(h.State), h.DueDate,
+		p.portal, p.agency, p.id, p.page, nullString(dedup.CanonicalURL(result.URL))).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("insert search result failed: %w", err)
 	}
 	return id, nil
 }
 
+// portalColumns holds the search_results portal columns for a URL.
+type portalColumns struct {
+	portal, agency, id, page *string
+}
+
+// portalColumnsFor parses a URL with the portal registry. The columns are
+// all nil for URLs that aren't on a known portal.
+func portalColumnsFor(rawURL string) portalColumns {
+	m := portal.Parse(rawURL)
+	if m == nil {
+		return portalColumns{}
+	}
+	return portalColumns{
+		portal: nullString(m.Portal),
+		agency: nullString(m.Agency),
+		id:     nullString(m.ID),
+		page:   nullString(string(m.Page)),
+	}
+}
+
 // SearchResultWithID pairs a search result with its database ID.
 type SearchResultWithID struct {
 	ID     int
@@ -208,29 +566,34 @@ type SearchResultWithID struct {
 
 // SaveSearchQueryAndResults persists a query and its results in a transaction.
 // Returns the query ID and a slice of result IDs.
-func (s *Store) SaveSearchQueryAndResults(ctx context.Context, queryText string, configID *int, results []search.Result, status string) (int, []SearchResultWithID, error) {
+func (s *Store) SaveSearchQueryAndResults(ctx context.Context, queryText string, configID *int, results []search.Result, status, promptVersion string) (int, []SearchResultWithID, error) {
 	var queryID int
 	var savedResults []SearchResultWithID
 
 	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
 		// Save the query
 		err := tx.QueryRow(ctx, `
-			INSERT INTO discovery.search_queries (query_text, query_config_id, results_count, status)
-			VALUES ($1, $2, $3, $4)
+			INSERT INTO discovery.search_queries (query_text, query_config_id, results_count, status, prompt_version)
+			VALUES ($1, $2, $3, $4, $5)
 			RETURNING id
-		`, queryText, configID, len(results), status).Scan(&queryID)
+		`, queryText, configID, len(results), status, nullString(promptVersion)).Scan(&queryID)
 		if err != nil {
 			return fmt.Errorf("insert search query failed: %w", err)
 		}
 
 		// Save each result
 		for _, r := range results {
+			h := dedup.ExtractHints(r.Title, r.Snippet, r.URL)
+			p := portalColumnsFor(r.URL)
 			var resultID int
 			err := tx.QueryRow(ctx, `
-				INSERT INTO discovery.search_results (query_id, url, title, snippet)
-				VALUES ($1, $2, $3, $4)
+				INSERT INTO discovery.search_results (query_id, url, title, snippet, hint_agency, hint_state, hint_due_date,
+					portal, portal_agency, portal_id, portal_page, canonical_url)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 				RETURNING id
-			`, queryID, r.URL, r.Title, r.Snippet).Scan(&resultID)
+			`, queryID, r.URL, r.Title, r.Snippet,
+				nullString(h.Agency), nullString(h.State), h.DueDate,
+				p.portal, p.agency, p.id, p.page, nullString(dedup.CanonicalURL(r.URL))).Scan(&resultID)
 			if err != nil {
 				return fmt.Errorf("insert search result failed: %w", err)
 			}
@@ -259,13 +622,376 @@ func (s *Store) UpdateValidationResult(ctx context.Context, resultID int, vr *va
 		contentType = string(vr.ContentType)
 	}
 
+	// A final URL on a portal refines what was parsed from the search URL
+	p := portalColumnsFor(vr.FinalURL)
+
 	_, err := s.db.Exec(ctx, `
 		UPDATE discovery.search_results
-		SET url_validated = true, url_valid = $2, final_url = $3, content_type = $4
+		SET url_validated = true, url_valid = $2, final_url = $3, content_type = $4,
+		    portal = COALESCE($5, portal), portal_agency = COALESCE($6, portal_agency),
+		    portal_id = COALESCE($7, portal_id), portal_page = COALESCE($8, portal_page),
+		    snapshot_key = COALESCE($9, snapshot_key)
 		WHERE id = $1
-	`, resultID, vr.Valid, vr.FinalURL, contentType)
+	`, resultID, vr.Valid, vr.FinalURL, contentType, p.portal, p.agency, p.id, p.page, nullString(vr.SnapshotKey))
 	if err != nil {
 		return fmt.Errorf("update validation failed: %w", err)
 	}
 	return nil
 }
+
+// CloseExpiredRFPs marks open RFPs whose due date has passed as closed.
+// Returns the number of RFPs closed.
+func (s *Store) CloseExpiredRFPs(ctx context.Context) (int, error) {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE discovery.rfps
+		SET lifecycle_status = 'closed', status_changed_at = NOW(),
+		    status_reason = 'Due date passed', is_active = false
+		WHERE lifecycle_status IN ('open', 'unknown') AND due_date < CURRENT_DATE
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("close expired rfps failed: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// GetRFPsForRecheck returns active RFPs not checked since the cutoff,
+// least recently checked first.
+func (s *Store) GetRFPsForRecheck(ctx context.Context, cutoff time.Time, limit int) ([]models.RFP, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, title, source_url, lifecycle_status
+		FROM discovery.rfps
+		WHERE lifecycle_status IN ('open', 'unknown')
+		  AND source_url <> ''
+		  AND (last_checked IS NULL OR last_checked < $1)
+		ORDER BY last_checked ASC NULLS FIRST
+		LIMIT $2
+	`, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query rfps for recheck failed: %w", err)
+	}
+	defer rows.Close()
+
+	var rfps []models.RFP
+	for rows.Next() {
+		var r models.RFP
+		if err := rows.Scan(&r.ID, &r.Title, &r.SourceURL, &r.LifecycleStatus); err != nil {
+			return nil, fmt.Errorf("scan rfp failed: %w", err)
+		}
+		rfps = append(rfps, r)
+	}
+
+	return rfps, rows.Err()
+}
+
+// UpdateRFPLifecycle sets an RFP's lifecycle status and keeps is_active in sync.
+func (s *Store) UpdateRFPLifecycle(ctx context.Context, rfpID int, status, reason string) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE discovery.rfps
+		SET lifecycle_status = $2, status_reason = $3, status_changed_at = NOW(),
+		    is_active = $4, last_checked = NOW()
+		WHERE id = $1
+	`, rfpID, status, reason, models.IsLifecycleActive(status))
+	if err != nil {
+		return fmt.Errorf("update rfp lifecycle failed: %w", err)
+	}
+	return nil
+}
+
+// MarkRFPChecked records that an RFP's source page was rechecked.
+func (s *Store) MarkRFPChecked(ctx context.Context, rfpID int) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE discovery.rfps SET last_checked = NOW() WHERE id = $1
+	`, rfpID)
+	if err != nil {
+		return fmt.Errorf("mark rfp checked failed: %w", err)
+	}
+	return nil
+}
+
+// GetRFPsForAwardSearch returns RFPs due before dueBefore that have no
+// awardee and haven't been searched since checkedBefore. RFPs more than a
+// year past due are not searched; award notices rarely surface that late.
+func (s *Store) GetRFPsForAwardSearch(ctx context.Context, dueBefore, checkedBefore time.Time, limit int) ([]models.RFP, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, title, agency, state, source_url, due_date, category
+		FROM discovery.rfps
+		WHERE awardee IS NULL
+		  AND lifecycle_status IN ('closed', 'awarded', 'unknown')
+		  AND due_date < $1
+		  AND due_date > CURRENT_DATE - INTERVAL '1 year'
+		  AND (award_checked_at IS NULL OR award_checked_at < $2)
+		ORDER BY award_checked_at ASC NULLS FIRST, due_date DESC
+		LIMIT $3
+	`, dueBefore, checkedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query rfps for award search failed: %w", err)
+	}
+	defer rows.Close()
+
+	var rfps []models.RFP
+	for rows.Next() {
+		var r models.RFP
+		var agency, state, sourceURL, category *string
+		if err := rows.Scan(&r.ID, &r.Title, &agency, &state, &sourceURL, &r.DueDate, &category); err != nil {
+			return nil, fmt.Errorf("scan rfp failed: %w", err)
+		}
+		r.Agency = deref(agency)
+		r.State = deref(state)
+		r.SourceURL = deref(sourceURL)
+		r.Category = deref(category)
+		rfps = append(rfps, r)
+	}
+
+	return rfps, rows.Err()
+}
+
+// SaveAward records an award on an RFP and marks it awarded.
+func (s *Store) SaveAward(ctx context.Context, rfpID int, award *awards.Award) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE discovery.rfps
+		SET awardee = $2, award_amount = $3, award_date = $4, award_source_url = $5,
+		    award_checked_at = NOW(),
+		    lifecycle_status = 'awarded', status_changed_at = NOW(),
+		    status_reason = 'Award notice found', is_active = false
+		WHERE id = $1
+	`, rfpID, award.Awardee, award.Amount, award.Date, award.SourceURL)
+	if err != nil {
+		return fmt.Errorf("save award failed: %w", err)
+	}
+	return nil
+}
+
+// MarkAwardChecked records an award search that found nothing.
+func (s *Store) MarkAwardChecked(ctx context.Context, rfpID int) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE discovery.rfps SET award_checked_at = NOW() WHERE id = $1
+	`, rfpID)
+	if err != nil {
+		return fmt.Errorf("mark award checked failed: %w", err)
+	}
+	return nil
+}
+
+// GetIncumbentCandidates returns RFPs in a state with no incumbent recorded.
+// The state matches however it was written, code or full name.
+func (s *Store) GetIncumbentCandidates(ctx context.Context, state string) ([]models.RFP, error) {
+	spellings := dedup.StateSpellings(state)
+	if spellings == nil {
+		return nil, nil
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, title, agency, state, due_date, posted_date, category
+		FROM discovery.rfps
+		WHERE UPPER(TRIM(state)) = ANY($1) AND (incumbent IS NULL OR incumbent = '')
+	`, spellings)
+	if err != nil {
+		return nil, fmt.Errorf("query incumbent candidates failed: %w", err)
+	}
+	defer rows.Close()
+
+	var rfps []models.RFP
+	for rows.Next() {
+		var r models.RFP
+		var agency, rfpState, category *string
+		if err := rows.Scan(&r.ID, &r.Title, &agency, &rfpState, &r.DueDate, &r.PostedDate, &category); err != nil {
+			return nil, fmt.Errorf("scan rfp failed: %w", err)
+		}
+		r.Agency = deref(agency)
+		r.State = deref(rfpState)
+		r.Category = deref(category)
+		rfps = append(rfps, r)
+	}
+
+	return rfps, rows.Err()
+}
+
+// SetIncumbent sets the incumbent on the given RFPs.
+// Returns the number of RFPs updated.
+func (s *Store) SetIncumbent(ctx context.Context, rfpIDs []int, incumbent string) (int, error) {
+	if len(rfpIDs) == 0 {
+		return 0, nil
+	}
+
+	tag, err := s.db.Exec(ctx, `
+		UPDATE discovery.rfps SET incumbent = $2
+		WHERE id = ANY($1) AND (incumbent IS NULL OR incumbent = '')
+	`, rfpIDs, incumbent)
+	if err != nil {
+		return 0, fmt.Errorf("set incumbent failed: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// PromoteRFP inserts an RFP for a researched search result and links the
+// result to it. Returns the new RFP ID.
+func (s *Store) PromoteRFP(ctx context.Context, resultID int, rfp *models.RFP) (int, error) {
+	var rfpID int
+	err := s.db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		rfpID, err = s.PromoteRFPTx(ctx, tx, resultID, rfp)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rfpID, nil
+}
+
+// PromoteRFPTx is PromoteRFP within the caller's transaction.
+func (s *Store) PromoteRFPTx(ctx context.Context, tx pgx.Tx, resultID int, rfp *models.RFP) (int, error) {
+	var evidence []byte
+	if len(rfp.FieldEvidence) > 0 {
+		var err error
+		evidence, err = json.Marshal(rfp.FieldEvidence)
+		if err != nil {
+			return 0, fmt.Errorf("marshal field evidence failed: %w", err)
+		}
+	}
+
+	var rawValues []byte
+	if len(rfp.RawValues) > 0 {
+		var err error
+		rawValues, err = json.Marshal(rfp.RawValues)
+		if err != nil {
+			return 0, fmt.Errorf("marshal raw values failed: %w", err)
+		}
+	}
+
+	reviewFields := rfp.ReviewFields
+	if reviewFields == nil {
+		reviewFields = []string{}
+	}
+
+	if rfp.Latitude == nil {
+		s.geocode(ctx, rfp)
+	}
+
+	if rfp.AgencyID == nil {
+		var err error
+		if rfp.AgencyID, _, err = agency.Resolve(ctx, tx, rfp.Agency, rfp.State); err != nil {
+			return 0, err
+		}
+	}
+
+	var rfpID int
+	err := tx.QueryRow(ctx, `
+		INSERT INTO discovery.rfps (
+			title, agency, state, city, source_url, portal, portal_id, portal_agency,
+			posted_date, due_date, due_at, category, venue_type, scope_keywords,
+			term_months, base_term_months, renewal_months,
+			estimated_value, estimated_value_max, value_not_to_exceed,
+			incumbent, login_required, login_notes, pdf_urls,
+			pre_bid_date, pre_bid_mandatory, questions_deadline, submission_method,
+			solicitation_number, contact_name, contact_email,
+			bond_requirements, insurance_requirements,
+			field_evidence, review_fields, raw_values, raw_content, lifecycle_status, is_active,
+			latitude, longitude, geo_precision, agency_id
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8,
+			$9, $10, $11, $12, $13, $14,
+			$15, $16, $17,
+			$18, $19, $20,
+			$21, $22, $23, $24,
+			$25, $26, $27, $28,
+			$29, $30, $31,
+			$32, $33,
+			$34, $35, $36, $37, $38, $39,
+			$40, $41, $42, $43
+		)
+		RETURNING id
+	`,
+		rfp.Title, nullString(rfp.Agency), nullString(rfp.State), nullString(rfp.City),
+		nullString(rfp.SourceURL), nullString(rfp.Portal), nullString(rfp.PortalID), nullString(rfp.PortalAgency),
+		rfp.PostedDate, rfp.DueDate, rfp.DueAt, nullString(rfp.Category), nullString(rfp.VenueType), rfp.ScopeKeywords,
+		rfp.TermMonths, rfp.BaseTermMonths, rfp.RenewalMonths,
+		rfp.EstimatedValue, rfp.EstimatedValueMax, rfp.ValueNotToExceed,
+		nullString(rfp.Incumbent), rfp.LoginRequired, nullString(rfp.LoginNotes), rfp.PDFURLs,
+		rfp.PreBidDate, rfp.PreBidMandatory, rfp.QuestionsDeadline, nullString(rfp.SubmissionMethod),
+		nullString(rfp.SolicitationNumber), nullString(rfp.ContactName), nullString(rfp.ContactEmail),
+		nullString(rfp.BondRequirements), nullString(rfp.InsuranceRequirements),
+		evidence, reviewFields, rawValues, nullString(rfp.RawContent), rfp.LifecycleStatus, models.IsLifecycleActive(rfp.LifecycleStatus),
+		rfp.Latitude, rfp.Longitude, nullString(rfp.GeoPrecision), rfp.AgencyID,
+	).Scan(&rfpID)
+	if err != nil {
+		return 0, fmt.Errorf("insert rfp failed: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE discovery.search_results
+		SET research_status = 'researched', promoted_rfp_id = $2
+		WHERE id = $1
+	`, resultID, rfpID)
+	if err != nil {
+		return 0, fmt.Errorf("link search result failed: %w", err)
+	}
+
+	return rfpID, nil
+}
+
+// geocode sets an RFP's coordinates from the gazetteer. It reads outside
+// the promotion's transaction so a failed lookup can't abort it, and a
+// failure only leaves the RFP without coordinates.
+func (s *Store) geocode(ctx context.Context, rfp *models.RFP) {
+	p, err := geo.Geocode(ctx, s.db, rfp.City, rfp.State, rfp.Agency)
+	if err != nil {
+		slog.Warn("failed to geocode rfp", "title", rfp.Title, "error", err)
+		return
+	}
+	if p != nil {
+		rfp.Latitude, rfp.Longitude, rfp.GeoPrecision = &p.Latitude, &p.Longitude, p.Precision
+	}
+}
+
+// LatestAwardee returns the most recent awardee for the RFP's agency and
+// scope, or an empty string if none is recorded.
+func (s *Store) LatestAwardee(ctx context.Context, rfp *models.RFP) (string, error) {
+	spellings := dedup.StateSpellings(rfp.State)
+	if spellings == nil {
+		return "", nil
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, title, agency, state, due_date, award_date, category, awardee
+		FROM discovery.rfps
+		WHERE UPPER(TRIM(state)) = ANY($1) AND awardee IS NOT NULL
+		ORDER BY award_date DESC NULLS LAST, due_date DESC
+	`, spellings)
+	if err != nil {
+		return "", fmt.Errorf("query previous awards failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var awarded models.RFP
+		var agency, state, category *string
+		if err := rows.Scan(&awarded.ID, &awarded.Title, &agency, &state, &awarded.DueDate, &awarded.AwardDate, &category, &awarded.Awardee); err != nil {
+			return "", fmt.Errorf("scan award failed: %w", err)
+		}
+		awarded.Agency = deref(agency)
+		awarded.State = deref(state)
+		awarded.Category = deref(category)
+
+		if awards.IsRebid(awarded, *rfp) {
+			return awarded.Awardee, nil
+		}
+	}
+
+	return "", rows.Err()
+}
+
+// nullString converts an empty string to NULL.
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// deref returns the string value of a nullable column.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
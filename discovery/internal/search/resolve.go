@@ -0,0 +1,127 @@
+package search
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
+)
+
+const (
+	// groundingRedirectHost serves the redirect URLs Gemini cites in place of
+	// the pages it grounded on.
+	groundingRedirectHost = "vertexaisearch.cloud.google.com"
+
+	resolveTimeout   = 10 * time.Second
+	maxResolveHops   = 5
+	maxResolverCache = 5000
+)
+
+// isGroundingRedirect reports whether a URL is a Vertex AI grounding
+// redirect.
+func isGroundingRedirect(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && strings.EqualFold(u.Hostname(), groundingRedirectHost)
+}
+
+// Resolver follows grounding redirects to the pages they point to. Results
+// are cached, so a redirect cited by several answers is requested once.
+// It's safe for concurrent use.
+type Resolver struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewResolver creates a grounding redirect resolver.
+func NewResolver() *Resolver {
+	return &Resolver{
+		client: &http.Client{
+			Timeout: resolveTimeout,
+			// Read each Location ourselves rather than fetching the destination
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cache: make(map[string]string),
+	}
+}
+
+// Resolve returns the canonical destination of a grounding redirect, or ""
+// if it can't be resolved. Other URLs are returned canonicalized.
+func (r *Resolver) Resolve(ctx context.Context, rawURL string) string {
+	if !isGroundingRedirect(rawURL) {
+		return cleanURL(rawURL)
+	}
+
+	r.mu.Lock()
+	resolved, ok := r.cache[rawURL]
+	r.mu.Unlock()
+	if ok {
+		return resolved
+	}
+
+	target := rawURL
+	for hop := 0; hop < maxResolveHops && isGroundingRedirect(target); hop++ {
+		next, err := r.location(ctx, target)
+		if err != nil || next == "" {
+			slog.Debug("grounding redirect not resolved", "url", rawURL, "error", err)
+			return ""
+		}
+		target = next
+	}
+	if isGroundingRedirect(target) {
+		return ""
+	}
+
+	resolved = cleanURL(target)
+	if resolved == "" {
+		return ""
+	}
+
+	r.mu.Lock()
+	if len(r.cache) >= maxResolverCache {
+		r.cache = make(map[string]string)
+	}
+	r.cache[rawURL] = resolved
+	r.mu.Unlock()
+
+	return resolved
+}
+
+// location returns where a redirect URL points, trying HEAD first and
+// falling back to GET for servers that don't redirect HEAD requests.
+func (r *Resolver) location(ctx context.Context, rawURL string) (string, error) {
+	var lastErr error
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("User-Agent", fetch.DefaultUserAgent)
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+
+		loc := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || loc == "" {
+			continue
+		}
+		next, err := resp.Request.URL.Parse(loc)
+		if err != nil {
+			return "", err
+		}
+		return next.String(), nil
+	}
+	return "", lastErr
+}
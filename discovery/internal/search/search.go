@@ -15,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/zachsouder/rfp/discovery/internal/prompts"
 	"github.com/zachsouder/rfp/shared/models"
 )
 
@@ -29,6 +30,7 @@ type Client struct {
 	apiKey     string
 	model      string
 	httpClient *http.Client
+	resolver   *Resolver
 }
 
 // NewClient creates a new Gemini search client.
@@ -39,6 +41,7 @@ func NewClient(apiKey string) *Client {
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
+		resolver: NewResolver(),
 	}
 }
 
@@ -58,12 +61,13 @@ type Result struct {
 
 // SearchResponse contains the results of a search operation.
 type SearchResponse struct {
-	Query        string        `json:"query"`
-	Model        string        `json:"model"`
-	Results      []Result      `json:"results"`
-	ResultsCount int           `json:"results_count"`
-	TokensUsed   int           `json:"tokens_used"`
-	DurationMs   int64         `json:"duration_ms"`
+	Query         string   `json:"query"`
+	Model         string   `json:"model"`
+	Results       []Result `json:"results"`
+	ResultsCount  int      `json:"results_count"`
+	TokensUsed    int      `json:"tokens_used"`
+	DurationMs    int64    `json:"duration_ms"`
+	PromptVersion string   `json:"prompt_version"` // e.g. "search@v1"
 }
 
 // geminiRequest represents the request payload to Gemini API.
@@ -135,15 +139,32 @@ type segment struct {
 
 // Search executes a search query using Gemini with Google Search grounding.
 func (c *Client) Search(ctx context.Context, query string) (*SearchResponse, error) {
+	return c.searchWithPrompt(ctx, query, prompts.Current(prompts.Search))
+}
+
+// SearchAwardNotices searches for award notices, bid tabulations, and
+// contract approvals for a solicitation that has already closed.
+func (c *Client) SearchAwardNotices(ctx context.Context, query string) (*SearchResponse, error) {
+	return c.searchWithPrompt(ctx, query, prompts.Current(prompts.AwardSearch))
+}
+
+// searchWithPrompt runs a grounded search with the given prompt template.
+func (c *Client) searchWithPrompt(ctx context.Context, query string, tmpl *prompts.Prompt) (*SearchResponse, error) {
+	prompt, err := renderSearchPrompt(tmpl, query)
+	if err != nil {
+		return nil, err
+	}
+
 	startTime := time.Now()
 
-	prompt := buildSearchPrompt(query)
 	resp, err := c.callGeminiWithGrounding(ctx, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("gemini API call failed: %w", err)
 	}
 
-	results := parseGroundingResults(resp)
+	results := parseGroundingResults(resp, func(rawURL string) string {
+		return c.resolver.Resolve(ctx, rawURL)
+	})
 	durationMs := time.Since(startTime).Milliseconds()
 
 	tokensUsed := 0
@@ -152,12 +173,13 @@ func (c *Client) Search(ctx context.Context, query string) (*SearchResponse, err
 	}
 
 	return &SearchResponse{
-		Query:        query,
-		Model:        c.model,
-		Results:      results,
-		ResultsCount: len(results),
-		TokensUsed:   tokensUsed,
-		DurationMs:   durationMs,
+		Query:         query,
+		Model:         c.model,
+		Results:       results,
+		ResultsCount:  len(results),
+		TokensUsed:    tokensUsed,
+		DurationMs:    durationMs,
+		PromptVersion: tmpl.ID(),
 	}, nil
 }
 
@@ -195,16 +217,9 @@ func (c *Client) ExecuteQueries(ctx context.Context, configs []models.SearchQuer
 	return responses, nil
 }
 
-// buildSearchPrompt constructs the prompt for Gemini search.
-func buildSearchPrompt(query string) string {
-	return fmt.Sprintf(`Search the web for: %s
-
-Find relevant RFP (Request for Proposal) listings related to parking services. For each result found, I need:
-- The exact URL of the listing
-- The title of the page
-- A brief description or snippet
-
-Focus on actual procurement listings from portals like Bonfire, OpenGov, PlanetBids, BidNet, or government agency websites.`, query)
+// renderSearchPrompt fills in a search prompt template with the query.
+func renderSearchPrompt(tmpl *prompts.Prompt, query string) (string, error) {
+	return tmpl.Render(struct{ Query string }{query})
 }
 
 // callGeminiWithGrounding makes the API call with search grounding enabled.
@@ -263,28 +278,50 @@ func (c *Client) callGeminiWithGrounding(ctx context.Context, prompt string) (*g
 }
 
 // parseGroundingResults extracts search results from Gemini response.
-func parseGroundingResults(resp *geminiResponse) []Result {
+// Grounding redirects are replaced by their destinations using resolve;
+// with a nil resolve they're dropped.
+func parseGroundingResults(resp *geminiResponse, resolve func(rawURL string) string) []Result {
 	var results []Result
 	seenURLs := make(map[string]bool)
 
+	resolveURL := func(rawURL string) string {
+		if !isGroundingRedirect(rawURL) {
+			return cleanURL(rawURL)
+		}
+		if resolve == nil {
+			return ""
+		}
+		return resolve(rawURL)
+	}
+
+	var answer strings.Builder
+	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
+		for _, part := range resp.Candidates[0].Content.Parts {
+			answer.WriteString(part.Text)
+			answer.WriteString("\n")
+		}
+	}
+	descriptions := describeURLs(answer.String())
+
 	// Extract from grounding metadata (most reliable source)
 	if len(resp.Candidates) > 0 && resp.Candidates[0].GroundingMetadata != nil {
 		meta := resp.Candidates[0].GroundingMetadata
-		for _, chunk := range meta.GroundingChunks {
+		snippets := supportSnippets(meta)
+		for i, chunk := range meta.GroundingChunks {
 			if chunk.Web != nil && chunk.Web.URI != "" {
-				cleanedURL := cleanURL(chunk.Web.URI)
+				cleanedURL := resolveURL(chunk.Web.URI)
 				if cleanedURL == "" || seenURLs[cleanedURL] {
 					continue
 				}
-				// Skip Google redirect URLs
-				if strings.Contains(cleanedURL, "vertexaisearch.cloud.google.com") {
-					continue
-				}
 				seenURLs[cleanedURL] = true
+				snippet := descriptions[cleanedURL]
+				if snippet == "" {
+					snippet = snippets[i]
+				}
 				results = append(results, Result{
 					URL:     cleanedURL,
 					Title:   chunk.Web.Title,
-					Snippet: "",
+					Snippet: snippet,
 					Source:  "grounding_chunk",
 				})
 			}
@@ -292,22 +329,25 @@ func parseGroundingResults(resp *geminiResponse) []Result {
 	}
 
 	// Also extract URLs from response text as fallback
-	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
-		for _, part := range resp.Candidates[0].Content.Parts {
-			textResults := extractURLsFromText(part.Text)
-			for _, r := range textResults {
-				if !seenURLs[r.URL] {
-					seenURLs[r.URL] = true
-					results = append(results, r)
-				}
+	for _, r := range extractURLsFromText(answer.String()) {
+		if isGroundingRedirect(r.URL) {
+			if r.URL = resolveURL(r.URL); r.URL == "" {
+				continue
 			}
+			r.Title = extractTitleFromURL(r.URL)
+		}
+		if !seenURLs[r.URL] {
+			seenURLs[r.URL] = true
+			r.Snippet = descriptions[r.URL]
+			results = append(results, r)
 		}
 	}
 
 	return results
 }
 
-// extractURLsFromText finds URLs in response text.
+// extractURLsFromText finds URLs in response text. Grounding redirects are
+// returned as found, for the caller to resolve.
 func extractURLsFromText(text string) []Result {
 	var results []Result
 
@@ -320,10 +360,6 @@ func extractURLsFromText(text string) []Result {
 		if cleanedURL == "" {
 			continue
 		}
-		// Skip Google redirect URLs
-		if strings.Contains(cleanedURL, "vertexaisearch.cloud.google.com") {
-			continue
-		}
 		results = append(results, Result{
 			URL:     cleanedURL,
 			Title:   extractTitleFromURL(cleanedURL),
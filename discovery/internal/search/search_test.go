@@ -1,8 +1,15 @@
 package search
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync/atomic"
 	"testing"
+
+	"github.com/zachsouder/rfp/discovery/internal/prompts"
 )
 
 func TestCleanURL(t *testing.T) {
@@ -156,13 +163,202 @@ func TestDefaultQueryConfigs(t *testing.T) {
 
 func TestBuildSearchPrompt(t *testing.T) {
 	query := "parking RFP site:example.com"
-	prompt := buildSearchPrompt(query)
+	prompt, err := renderSearchPrompt(prompts.Current(prompts.Search), query)
+	if err != nil {
+		t.Fatalf("renderSearchPrompt: %v", err)
+	}
 
 	if !contains(prompt, query) {
-		t.Errorf("buildSearchPrompt(%q) should contain the query", query)
+		t.Errorf("Search prompt for %q should contain the query", query)
 	}
 	if !contains(prompt, "RFP") {
-		t.Error("buildSearchPrompt should mention RFP")
+		t.Error("Search prompt should mention RFP")
+	}
+}
+
+func TestBuildAwardSearchPrompt(t *testing.T) {
+	query := `"Parking Management Services" City of Austin award`
+	prompt, err := renderSearchPrompt(prompts.Current(prompts.AwardSearch), query)
+	if err != nil {
+		t.Fatalf("renderSearchPrompt: %v", err)
+	}
+
+	if !contains(prompt, query) {
+		t.Errorf("AwardSearch prompt for %q should contain the query", query)
+	}
+	if !contains(prompt, "award") {
+		t.Error("AwardSearch prompt should mention awards")
+	}
+}
+
+func TestParseGroundingResults_Snippets(t *testing.T) {
+	answer := `Here are parking RFPs I found:
+
+1. **Parking Management Services RFP - City of Fresno**
+   * **URL:** https://www.bidnetdirect.com/california/cityoffresno/solicitations/open-bids/Parking/0000298765
+   * **Description:** The City of Fresno seeks a vendor to operate downtown garages. Proposals are due March 3, 2025.
+
+2. **Citation Processing Services**
+   https://example.gov/bids/citation-processing
+   Processing of parking citations for the Parks Department.
+
+3. **Multiple parking bids** at https://a.example.com/bids/1 and https://b.example.com/bids/2
+
+I hope this helps.`
+
+	resp := &geminiResponse{
+		Candidates: []geminiCandidate{{
+			Content: &geminiCandidateContent{Parts: []geminiPart{{Text: answer}}},
+			GroundingMetadata: &groundingMetadata{
+				GroundingChunks: []groundingChunk{
+					{Web: &webChunk{URI: "https://cityofaustin.bonfirehub.com/opportunities/123", Title: "bonfirehub.com"}},
+					{Web: &webChunk{URI: "https://example.gov/bids/citation-processing", Title: "example.gov"}},
+				},
+				GroundingSupports: []groundingSupport{
+					{Segment: &segment{Text: "The City of Austin is requesting proposals for **valet parking**."}, GroundingChunkIndices: []int{0}},
+					{Segment: &segment{Text: "Responses close April 1."}, GroundingChunkIndices: []int{0, 1}},
+				},
+			},
+		}},
+	}
+
+	snippets := make(map[string]string)
+	for _, r := range parseGroundingResults(resp, nil) {
+		snippets[r.URL] = r.Snippet
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		// Grounding supports citing the chunk
+		{"https://cityofaustin.bonfirehub.com/opportunities/123",
+			"The City of Austin is requesting proposals for valet parking. Responses close April 1."},
+		// The model's own description takes precedence over supports
+		{"https://example.gov/bids/citation-processing",
+			"Processing of parking citations for the Parks Department."},
+		// Labeled description from the answer text
+		{"https://www.bidnetdirect.com/california/cityoffresno/solicitations/open-bids/Parking/0000298765",
+			"The City of Fresno seeks a vendor to operate downtown garages. Proposals are due March 3, 2025."},
+		// Items citing several pages get no snippet
+		{"https://a.example.com/bids/1", ""},
+	}
+	for _, tt := range tests {
+		got, ok := snippets[tt.url]
+		if !ok {
+			t.Errorf("no result for %s", tt.url)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("snippet for %s = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateSnippet(t *testing.T) {
+	long := strings.Repeat("parking garage ", 50)
+	got := truncateSnippet(long)
+	if len(got) > maxSnippetLength+3 || !strings.HasSuffix(got, "...") {
+		t.Errorf("truncateSnippet() = %d chars %q, want <= %d ending in ...", len(got), got, maxSnippetLength+3)
+	}
+	if got := truncateSnippet("short"); got != "short" {
+		t.Errorf("truncateSnippet(short) = %q", got)
+	}
+}
+
+func TestParseGroundingResults_ResolvesRedirects(t *testing.T) {
+	redirect := "https://vertexaisearch.cloud.google.com/grounding-api-redirect/AbC123"
+	dead := "https://vertexaisearch.cloud.google.com/grounding-api-redirect/Gone"
+	resp := &geminiResponse{
+		Candidates: []geminiCandidate{{
+			Content: &geminiCandidateContent{Parts: []geminiPart{{Text: "See " + redirect + " for details."}}},
+			GroundingMetadata: &groundingMetadata{
+				GroundingChunks: []groundingChunk{
+					{Web: &webChunk{URI: redirect, Title: "bonfirehub.com"}},
+					{Web: &webChunk{URI: dead, Title: "example.gov"}},
+				},
+			},
+		}},
+	}
+	resolve := func(rawURL string) string {
+		if rawURL == redirect {
+			return "https://cityofaustin.bonfirehub.com/opportunities/123"
+		}
+		return ""
+	}
+
+	results := parseGroundingResults(resp, resolve)
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1: %+v", len(results), results)
+	}
+	if results[0].URL != "https://cityofaustin.bonfirehub.com/opportunities/123" || results[0].Title != "bonfirehub.com" {
+		t.Errorf("result = %+v, want the resolved bonfire URL", results[0])
+	}
+
+	if got := parseGroundingResults(resp, nil); len(got) != 0 {
+		t.Errorf("without a resolver got %d results, want redirects dropped", len(got))
+	}
+}
+
+// hostRewriter sends every request to a test server, keeping the path.
+type hostRewriter struct {
+	target *url.URL
+}
+
+func (h hostRewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = h.target.Scheme
+	req.URL.Host = h.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/grounding-api-redirect/head":
+			w.Header().Set("Location", "https://www.Example.gov/bids/42?utm_source=gemini")
+			w.WriteHeader(http.StatusFound)
+		case "/grounding-api-redirect/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Location", "https://example.gov/bids/43")
+			w.WriteHeader(http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	r := NewResolver()
+	r.client.Transport = hostRewriter{target: target}
+	ctx := context.Background()
+
+	base := "https://vertexaisearch.cloud.google.com/grounding-api-redirect/"
+	if got, want := r.Resolve(ctx, base+"head"), "https://www.example.gov/bids/42"; got != want {
+		t.Errorf("Resolve(head) = %q, want %q", got, want)
+	}
+	if got, want := r.Resolve(ctx, base+"get-only"), "https://example.gov/bids/43"; got != want {
+		t.Errorf("Resolve(get-only) = %q, want %q", got, want)
+	}
+	if got := r.Resolve(ctx, base+"missing"); got != "" {
+		t.Errorf("Resolve(missing) = %q, want empty", got)
+	}
+
+	// Resolved redirects come from the cache
+	before := requests.Load()
+	r.Resolve(ctx, base+"head")
+	if requests.Load() != before {
+		t.Error("cached redirect was requested again")
+	}
+
+	// Other URLs are canonicalized without a request
+	if got, want := r.Resolve(ctx, "https://EXAMPLE.gov/rfp?utm_medium=x"), "https://example.gov/rfp"; got != want {
+		t.Errorf("Resolve(plain) = %q, want %q", got, want)
 	}
 }
 
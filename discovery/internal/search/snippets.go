@@ -0,0 +1,192 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxSnippetLength caps snippets built from the answer text.
+const maxSnippetLength = 500
+
+var (
+	// listItemPattern matches the start of a top-level numbered or bulleted
+	// item in the answer text.
+	listItemPattern = regexp.MustCompile(`^(\d+[.)]|[*•-])\s+`)
+
+	// descriptionLabelPattern matches a labeled description line, e.g.
+	// "* **Description:** The City is seeking...".
+	descriptionLabelPattern = regexp.MustCompile(`(?i)^[*•-]?\s*(\*\*)?(description|snippet|summary)(\*\*)?\s*:\s*(\*\*)?\s*`)
+
+	// fieldLabelPattern matches the other labeled lines of an item, which
+	// aren't part of its description.
+	fieldLabelPattern = regexp.MustCompile(`(?i)^[*•-]?\s*(\*\*)?(url|link|title|source)(\*\*)?\s*:`)
+
+	markdownLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	urlInTextPattern    = regexp.MustCompile(`https?://[^\s\)\]"'<>]+`)
+)
+
+// supportSnippets maps each grounding chunk index to the answer text it
+// supports, joining the segments that cite it.
+func supportSnippets(meta *groundingMetadata) map[int]string {
+	segments := make(map[int][]string)
+	for _, support := range meta.GroundingSupports {
+		if support.Segment == nil {
+			continue
+		}
+		text := cleanSnippet(support.Segment.Text)
+		if text == "" {
+			continue
+		}
+		for _, i := range support.GroundingChunkIndices {
+			if !containsString(segments[i], text) {
+				segments[i] = append(segments[i], text)
+			}
+		}
+	}
+
+	snippets := make(map[int]string, len(segments))
+	for i, texts := range segments {
+		snippets[i] = truncateSnippet(strings.Join(texts, " "))
+	}
+	return snippets
+}
+
+// describeURLs extracts the model's own description of each result from the
+// answer text, keyed by cleaned URL. The answer lists one result per item;
+// an item's description is its labeled description line, or otherwise its
+// text apart from the title and URL.
+func describeURLs(text string) map[string]string {
+	descriptions := make(map[string]string)
+	for _, item := range answerItems(text) {
+		urls := itemURLs(item)
+		if len(urls) != 1 {
+			// Items citing several pages don't describe any one of them
+			continue
+		}
+		if desc := itemDescription(item); desc != "" {
+			descriptions[urls[0]] = desc
+		}
+	}
+	return descriptions
+}
+
+// answerItems splits answer text into list items or paragraphs.
+func answerItems(text string) [][]string {
+	var items [][]string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			items = append(items, current)
+			current = nil
+		}
+	}
+
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			// A blank line ends a paragraph, but not an item whose fields
+			// follow it
+			if len(current) > 0 && !listItemPattern.MatchString(current[0]) {
+				flush()
+			}
+			blank = true
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			flush()
+			blank = false
+			continue
+		}
+
+		topLevel := line == trimmed && !isFieldLine(trimmed)
+		switch {
+		case topLevel && listItemPattern.MatchString(trimmed):
+			flush()
+		case topLevel && blank:
+			// An unindented paragraph after an item, such as closing remarks
+			flush()
+		}
+		current = append(current, trimmed)
+		blank = false
+	}
+	flush()
+	return items
+}
+
+// itemURLs returns the distinct cleaned URLs in an item.
+func itemURLs(item []string) []string {
+	var urls []string
+	for _, line := range item {
+		for _, match := range urlInTextPattern.FindAllString(line, -1) {
+			if u := cleanURL(match); u != "" && !containsString(urls, u) {
+				urls = append(urls, u)
+			}
+		}
+	}
+	return urls
+}
+
+// itemDescription returns an item's labeled description, falling back to
+// the lines that aren't the title or another labeled field.
+func itemDescription(item []string) string {
+	for _, line := range item {
+		if loc := descriptionLabelPattern.FindStringIndex(line); loc != nil {
+			return truncateSnippet(cleanSnippet(line[loc[1]:]))
+		}
+	}
+
+	var parts []string
+	for i, line := range item {
+		if isFieldLine(line) {
+			continue
+		}
+		text := line
+		if i == 0 {
+			// The first line is usually the bolded title; keep any text
+			// after it
+			text = listItemPattern.ReplaceAllString(text, "")
+			if idx := strings.LastIndex(text, "**"); strings.HasPrefix(text, "**") && idx > 1 {
+				text = text[idx+2:]
+			}
+		}
+		if cleaned := cleanSnippet(urlInTextPattern.ReplaceAllString(text, "")); cleaned != "" {
+			parts = append(parts, cleaned)
+		}
+	}
+	return truncateSnippet(strings.Join(parts, " "))
+}
+
+func isFieldLine(line string) bool {
+	return fieldLabelPattern.MatchString(line) || descriptionLabelPattern.MatchString(line)
+}
+
+// cleanSnippet strips markdown from answer text and collapses whitespace.
+func cleanSnippet(text string) string {
+	text = markdownLinkPattern.ReplaceAllString(text, "$1")
+	text = listItemPattern.ReplaceAllString(strings.TrimSpace(text), "")
+	text = strings.NewReplacer("**", "", "__", "", "`", "").Replace(text)
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.Trim(text, " -–:*")
+}
+
+// truncateSnippet cuts a snippet to maxSnippetLength at a word boundary.
+func truncateSnippet(s string) string {
+	if len(s) <= maxSnippetLength {
+		return s
+	}
+	cut := strings.LastIndex(s[:maxSnippetLength], " ")
+	if cut < maxSnippetLength/2 {
+		cut = maxSnippetLength
+	}
+	return strings.TrimSpace(s[:cut]) + "..."
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
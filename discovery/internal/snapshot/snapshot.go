@@ -0,0 +1,257 @@
+// Package snapshot archives the raw pages fetched during validation and
+// research to object storage, so the page an RFP was extracted from can be
+// examined after the agency changes or removes it.
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
+)
+
+const (
+	keyPrefix     = "snapshots/"
+	bodyKeyPrefix = keyPrefix + "bodies/"
+	contentType   = "application/gzip"
+
+	// maxKnownKeys bounds the in-memory set of keys already archived.
+	maxKnownKeys = 10000
+)
+
+// Envelope is an archived page: the response body with the request and
+// response metadata needed to make sense of it. Each fetch is archived as
+// its own envelope; the body is stored separately under BodyKey, once per
+// distinct content, and filled back in by Get.
+type Envelope struct {
+	URL        string      `json:"url"`
+	FinalURL   string      `json:"final_url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	FetchedAt  time.Time   `json:"fetched_at"`
+	SHA256     string      `json:"sha256"`
+	Truncated  bool        `json:"truncated,omitempty"`
+	BodyKey    string      `json:"body_key,omitempty"`
+
+	// Body holds text content. Bodies that aren't valid UTF-8 are kept in
+	// BodyBase64 instead so they round-trip unchanged.
+	Body       string `json:"body,omitempty"`
+	BodyBase64 []byte `json:"body_base64,omitempty"`
+}
+
+// Content returns the archived response body.
+func (e *Envelope) Content() []byte {
+	if e.BodyBase64 != nil {
+		return e.BodyBase64
+	}
+	return []byte(e.Body)
+}
+
+// ObjectStore is the subset of the R2 client used for snapshots.
+type ObjectStore interface {
+	Upload(ctx context.Context, key string, body io.Reader, contentType string) error
+	Download(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// Store archives responses to object storage. Every fetch gets its own small
+// envelope with that fetch's metadata, while bodies are stored under
+// content-hash keys, so a page fetched repeatedly without changes is stored
+// once. It implements fetch.Archiver and is safe for concurrent use.
+type Store struct {
+	objects ObjectStore
+
+	mu    sync.Mutex
+	known map[string]bool
+}
+
+// New creates a snapshot store backed by objects.
+func New(objects ObjectStore) *Store {
+	return &Store{
+		objects: objects,
+		known:   make(map[string]bool),
+	}
+}
+
+// Archive stores a snapshot of a response and returns its key, which is
+// unique to this fetch.
+func (s *Store) Archive(ctx context.Context, res *fetch.Response) (string, error) {
+	env := NewEnvelope(res, time.Now())
+	env.BodyKey = BodyKey(env.SHA256)
+	if err := s.archiveBody(ctx, env.BodyKey, res.Body); err != nil {
+		return "", err
+	}
+
+	// The envelope without its body, keyed by its own digest
+	meta := *env
+	meta.Body, meta.BodyBase64 = "", nil
+	raw, err := json.Marshal(&meta)
+	if err != nil {
+		return "", fmt.Errorf("encode snapshot failed: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	key := Key(hex.EncodeToString(sum[:]))
+
+	data, err := Encode(&meta)
+	if err != nil {
+		return "", err
+	}
+	if err := s.objects.Upload(ctx, key, bytes.NewReader(data), contentType); err != nil {
+		return "", fmt.Errorf("upload snapshot failed: %w", err)
+	}
+	return key, nil
+}
+
+// archiveBody stores a body under its content key unless it's there already.
+func (s *Store) archiveBody(ctx context.Context, key string, body []byte) error {
+	if s.isKnown(key) {
+		return nil
+	}
+	exists, err := s.objects.Exists(ctx, key)
+	if err != nil {
+		return fmt.Errorf("check snapshot body failed: %w", err)
+	}
+	if !exists {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return fmt.Errorf("compress snapshot body failed: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("compress snapshot body failed: %w", err)
+		}
+		if err := s.objects.Upload(ctx, key, &buf, contentType); err != nil {
+			return fmt.Errorf("upload snapshot body failed: %w", err)
+		}
+	}
+	s.remember(key)
+	return nil
+}
+
+// Get retrieves an archived snapshot by key, with its body.
+func (s *Store) Get(ctx context.Context, key string) (*Envelope, error) {
+	r, err := s.objects.Download(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("download snapshot failed: %w", err)
+	}
+	defer r.Close()
+	env, err := Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	if env.BodyKey == "" {
+		return nil, fmt.Errorf("snapshot %s has no body key", key)
+	}
+
+	br, err := s.objects.Download(ctx, env.BodyKey)
+	if err != nil {
+		return nil, fmt.Errorf("download snapshot body failed: %w", err)
+	}
+	defer br.Close()
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("decompress snapshot body failed: %w", err)
+	}
+	defer zr.Close()
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot body failed: %w", err)
+	}
+	env.setBody(body)
+	return env, nil
+}
+
+func (s *Store) isKnown(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.known[key]
+}
+
+func (s *Store) remember(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.known) >= maxKnownKeys {
+		s.known = make(map[string]bool)
+	}
+	s.known[key] = true
+}
+
+// NewEnvelope builds the envelope for a response.
+func NewEnvelope(res *fetch.Response, fetchedAt time.Time) *Envelope {
+	sum := sha256.Sum256(res.Body)
+	env := &Envelope{
+		URL:        res.URL,
+		FinalURL:   res.FinalURL,
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		FetchedAt:  fetchedAt.UTC(),
+		SHA256:     hex.EncodeToString(sum[:]),
+		Truncated:  res.Truncated,
+	}
+	env.setBody(res.Body)
+	return env
+}
+
+// setBody sets the body, as text if it's valid UTF-8.
+func (e *Envelope) setBody(body []byte) {
+	if utf8.Valid(body) {
+		e.Body = string(body)
+	} else {
+		e.BodyBase64 = body
+	}
+}
+
+// Key returns the object key for an envelope's SHA-256 hex digest. Keys
+// are sharded by the digest's first byte to keep listings manageable.
+func Key(digest string) string {
+	if len(digest) < 2 {
+		return keyPrefix + digest + ".json.gz"
+	}
+	return keyPrefix + digest[:2] + "/" + digest + ".json.gz"
+}
+
+// BodyKey returns the object key for a body's SHA-256 hex digest.
+func BodyKey(digest string) string {
+	if len(digest) < 2 {
+		return bodyKeyPrefix + digest + ".gz"
+	}
+	return bodyKeyPrefix + digest[:2] + "/" + digest + ".gz"
+}
+
+// Encode serializes an envelope as gzipped JSON.
+func Encode(env *Envelope) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(env); err != nil {
+		return nil, fmt.Errorf("encode snapshot failed: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress snapshot failed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode reads a gzipped JSON envelope.
+func Decode(r io.Reader) (*Envelope, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompress snapshot failed: %w", err)
+	}
+	defer zr.Close()
+
+	var env Envelope
+	if err := json.NewDecoder(zr).Decode(&env); err != nil {
+		return nil, fmt.Errorf("decode snapshot failed: %w", err)
+	}
+	return &env, nil
+}
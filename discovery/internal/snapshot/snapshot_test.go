@@ -0,0 +1,161 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
+)
+
+// memStore is an in-memory ObjectStore.
+type memStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	uploads int
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: make(map[string][]byte)}
+}
+
+func (m *memStore) Upload(ctx context.Context, key string, body io.Reader, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	m.uploads++
+	return nil
+}
+
+func (m *memStore) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("no such key: %s", key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memStore) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.objects[key]
+	return ok, nil
+}
+
+func testResponse(body string) *fetch.Response {
+	return &fetch.Response{
+		URL:        "https://example.gov/bids/1",
+		FinalURL:   "https://example.gov/bids/1?view=full",
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:       []byte(body),
+	}
+}
+
+func TestStore_ArchiveAndGet(t *testing.T) {
+	objects := newMemStore()
+	s := New(objects)
+	ctx := context.Background()
+
+	key, err := s.Archive(ctx, testResponse("<html>Parking RFP</html>"))
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if !strings.HasPrefix(key, "snapshots/") || !strings.HasSuffix(key, ".json.gz") {
+		t.Errorf("key = %q, want snapshots/...json.gz", key)
+	}
+
+	env, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if env.URL != "https://example.gov/bids/1" || env.FinalURL != "https://example.gov/bids/1?view=full" {
+		t.Errorf("urls = %s, %s", env.URL, env.FinalURL)
+	}
+	if env.StatusCode != http.StatusOK || env.Header.Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("status = %d content-type = %q", env.StatusCode, env.Header.Get("Content-Type"))
+	}
+	if string(env.Content()) != "<html>Parking RFP</html>" {
+		t.Errorf("body = %q", env.Content())
+	}
+	if env.BodyKey != BodyKey(env.SHA256) {
+		t.Errorf("body key %q doesn't match digest %s", env.BodyKey, env.SHA256)
+	}
+}
+
+func TestStore_StoresBodiesOnce(t *testing.T) {
+	objects := newMemStore()
+	ctx := context.Background()
+
+	s := New(objects)
+	first, _ := s.Archive(ctx, testResponse("same page"))
+	gone := testResponse("same page")
+	gone.StatusCode = http.StatusNotFound
+	second, _ := s.Archive(ctx, gone)
+	other, _ := s.Archive(ctx, testResponse("changed page"))
+
+	if first == second || first == other {
+		t.Errorf("fetches share a key: %s, %s, %s", first, second, other)
+	}
+	// Two bodies and three envelopes
+	if objects.uploads != 5 {
+		t.Errorf("uploads = %d, want 5", objects.uploads)
+	}
+
+	// Each fetch keeps its own metadata
+	env, err := s.Get(ctx, second)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if env.StatusCode != http.StatusNotFound || string(env.Content()) != "same page" {
+		t.Errorf("second fetch = %d %q, want 404 %q", env.StatusCode, env.Content(), "same page")
+	}
+
+	// A fresh store finds the existing body instead of uploading it again
+	if _, err := New(objects).Archive(ctx, testResponse("same page")); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if objects.uploads != 6 {
+		t.Errorf("uploads = %d after re-archiving, want 6", objects.uploads)
+	}
+}
+
+func TestEnvelope_BinaryBody(t *testing.T) {
+	body := []byte{0x25, 0x50, 0x44, 0x46, 0xff, 0xfe, 0x00}
+	res := testResponse("")
+	res.Body = body
+
+	data, err := Encode(NewEnvelope(res, time.Now()))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	env, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if env.Body != "" || !bytes.Equal(env.Content(), body) {
+		t.Errorf("body = %q base64 = %v, want binary round trip", env.Body, env.BodyBase64)
+	}
+}
+
+func TestKey(t *testing.T) {
+	digest := "ab12cd34"
+	if got, want := Key(digest), "snapshots/ab/ab12cd34.json.gz"; got != want {
+		t.Errorf("Key(%q) = %q, want %q", digest, got, want)
+	}
+	if got, want := BodyKey(digest), "snapshots/bodies/ab/ab12cd34.gz"; got != want {
+		t.Errorf("BodyKey(%q) = %q, want %q", digest, got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zachsouder/rfp/discovery/internal/pdf"
+	"github.com/zachsouder/rfp/discovery/internal/review"
+)
+
+// uploadTimeout bounds reading an upload, well past the server's default
+// read timeout.
+const uploadTimeout = 2 * time.Minute
+
+// resumeTimeout bounds research from uploaded documents, which runs after
+// the response is sent. Results still researching past it are put back in
+// the queue by the scheduler.
+const resumeTimeout = 20 * time.Minute
+
+// Uploader is the service as used by the HTTP handler; *Service implements it.
+type Uploader interface {
+	Attach(ctx context.Context, resultID int, filename string, data []byte, uploadedBy string) (*Document, error)
+	Resume(ctx context.Context, resultID int) (*Outcome, error)
+}
+
+// NewHandler serves document uploads:
+//
+//	POST /review/{id}/documents   multipart form: file (one or more)
+//
+// The documents are stored and the response lists them. Research then
+// resumes in the background; its outcome shows up in the result's review
+// detail. Requests must carry one of tokens as a bearer token, and the
+// documents are recorded as uploaded by that token's reviewer.
+func NewHandler(svc Uploader, tokens review.Tokens) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /review/{id}/documents", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid result ID")
+			return
+		}
+
+		http.NewResponseController(w).SetReadDeadline(time.Now().Add(uploadTimeout))
+		r.Body = http.MaxBytesReader(w, r.Body, 4*MaxSize)
+		if err := r.ParseMultipartForm(MaxSize); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid upload: "+err.Error())
+			return
+		}
+		files := r.MultipartForm.File["file"]
+		if len(files) == 0 {
+			writeError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+
+		var docs []*Document
+		for _, fh := range files {
+			if fh.Size > MaxSize {
+				writeUploadError(w, ErrTooLarge)
+				return
+			}
+			f, err := fh.Open()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid upload: "+err.Error())
+				return
+			}
+			data, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid upload: "+err.Error())
+				return
+			}
+			doc, err := svc.Attach(r.Context(), id, fh.Filename, data, review.ReviewerFrom(r.Context()))
+			if err != nil {
+				writeUploadError(w, err)
+				return
+			}
+			docs = append(docs, doc)
+		}
+
+		// Research outlives the request
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), resumeTimeout)
+		go func() {
+			defer cancel()
+			out, err := svc.Resume(ctx, id)
+			if err != nil {
+				slog.Warn("research from uploaded documents failed", "result_id", id, "error", err)
+				return
+			}
+			slog.Info("researched uploaded documents", "result_id", id, "status", out.Status, "rfp_id", out.RFPID)
+		}()
+
+		writeJSON(w, http.StatusAccepted, docs)
+	})
+
+	return review.RequireToken(mux, tokens)
+}
+
+// writeUploadError maps upload errors to HTTP statuses.
+func writeUploadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, review.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, review.ErrNotInQueue):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrTooLarge), errors.Is(err, ErrEmpty), errors.Is(err, ErrMissingUploader),
+		errors.Is(err, pdf.ErrNotPDF), errors.Is(err, pdf.ErrEncrypted), errors.Is(err, pdf.ErrNoText), errors.Is(err, pdf.ErrGarbled):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		slog.Error("upload request failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,329 @@
+// Package upload attaches solicitation documents to search results by hand
+// and resumes research from them. It's the way in for login-walled results
+// (needs_manual_upload): someone with a portal account downloads the PDF,
+// uploads it here, and the agent extracts details from its text and
+// promotes the result as it would any other.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/zachsouder/rfp/discovery/internal/pdf"
+	"github.com/zachsouder/rfp/discovery/internal/promotion"
+	"github.com/zachsouder/rfp/discovery/internal/research"
+	"github.com/zachsouder/rfp/discovery/internal/review"
+	"github.com/zachsouder/rfp/discovery/internal/scheduler"
+	"github.com/zachsouder/rfp/shared/db"
+	"github.com/zachsouder/rfp/shared/models"
+	"github.com/zachsouder/rfp/shared/r2"
+)
+
+// MaxSize is the largest document accepted.
+const MaxSize = 50 * 1024 * 1024
+
+// finishTimeout bounds recording the outcome of research once it's done,
+// which happens even if the caller has gone away.
+const finishTimeout = 30 * time.Second
+
+var (
+	// ErrTooLarge is returned for documents over MaxSize.
+	ErrTooLarge = fmt.Errorf("document is larger than %d MB", MaxSize/1024/1024)
+	// ErrEmpty is returned for an empty upload.
+	ErrEmpty = errors.New("document is empty")
+	// ErrMissingUploader is returned when an upload doesn't say who made it.
+	ErrMissingUploader = errors.New("uploader is required")
+	// ErrNoDocuments is returned when resuming a result with nothing uploaded.
+	ErrNoDocuments = errors.New("no documents uploaded for this result")
+)
+
+// Document is a document uploaded for a search result.
+type Document struct {
+	ID             int       `json:"id"`
+	SearchResultID int       `json:"search_result_id"`
+	Filename       string    `json:"filename"`
+	R2Key          string    `json:"r2_key"`
+	Size           int64     `json:"size_bytes"`
+	SHA256         string    `json:"sha256"`
+	TextChars      int       `json:"text_chars"`
+	UploadedBy     string    `json:"uploaded_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Outcome is the result of resuming research from uploaded documents.
+type Outcome struct {
+	SearchResultID int      `json:"search_result_id"`
+	RunID          int      `json:"run_id"`
+	Status         string   `json:"status"`
+	RFPID          *int     `json:"rfp_id,omitempty"`
+	ReviewFields   []string `json:"review_fields,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// Service stores uploaded documents and researches them.
+type Service struct {
+	db        *db.DB
+	store     *scheduler.Store
+	agent     *research.Agent
+	objects   *r2.Client
+	accountID string
+}
+
+// New creates an upload service that keeps documents in objects.
+func New(database *db.DB, store *scheduler.Store, agent *research.Agent, objects *r2.Client, accountID string) *Service {
+	return &Service{
+		db:        database,
+		store:     store,
+		agent:     agent,
+		objects:   objects,
+		accountID: accountID,
+	}
+}
+
+// Attach stores a PDF for a search result awaiting review and records it
+// with its extracted text. Uploading the same file again is a no-op.
+func (s *Service) Attach(ctx context.Context, resultID int, filename string, data []byte, uploadedBy string) (*Document, error) {
+	uploadedBy = strings.TrimSpace(uploadedBy)
+	if uploadedBy == "" {
+		return nil, ErrMissingUploader
+	}
+	if len(data) == 0 {
+		return nil, ErrEmpty
+	}
+	if len(data) > MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	sr, err := s.loadResult(ctx, resultID)
+	if err != nil {
+		return nil, err
+	}
+	if !review.InQueue(sr.ResearchStatus) {
+		return nil, fmt.Errorf("%w (status %s)", review.ErrNotInQueue, sr.ResearchStatus)
+	}
+
+	text, err := pdf.ExtractText(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	doc := &Document{
+		SearchResultID: resultID,
+		Filename:       path.Base(strings.ReplaceAll(filename, "\\", "/")),
+		Size:           int64(len(data)),
+		SHA256:         hex.EncodeToString(sum[:]),
+		TextChars:      len(text),
+		UploadedBy:     uploadedBy,
+	}
+	doc.R2Key = objectKey(resultID, doc.SHA256, doc.Filename)
+
+	if err := s.objects.Upload(ctx, doc.R2Key, bytes.NewReader(data), "application/pdf"); err != nil {
+		return nil, fmt.Errorf("store document failed: %w", err)
+	}
+
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO discovery.result_documents (search_result_id, filename, r2_key, content_type,
+			size_bytes, sha256, text_content, uploaded_by)
+		VALUES ($1, $2, $3, 'application/pdf', $4, $5, $6, $7)
+		ON CONFLICT (search_result_id, sha256) DO UPDATE SET filename = EXCLUDED.filename
+		RETURNING id, r2_key, uploaded_by, created_at
+	`, resultID, doc.Filename, doc.R2Key, doc.Size, doc.SHA256, text, uploadedBy,
+	).Scan(&doc.ID, &doc.R2Key, &doc.UploadedBy, &doc.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert result document failed: %w", err)
+	}
+	return doc, nil
+}
+
+// Resume researches a queued result from its uploaded documents and
+// promotes it if the agent finishes. Results that were behind a login keep
+// login_required on the RFP, with a note of who supplied the documents. If
+// research fails the result goes back to the queue as it was. The result is
+// claimed before research starts, so concurrent resumes research it once.
+func (s *Service) Resume(ctx context.Context, resultID int) (*Outcome, error) {
+	sr, err := s.loadResult(ctx, resultID)
+	if err != nil {
+		return nil, err
+	}
+	if !review.InQueue(sr.ResearchStatus) {
+		return nil, fmt.Errorf("%w (status %s)", review.ErrNotInQueue, sr.ResearchStatus)
+	}
+
+	docs, uploaders, err := s.loadDocuments(ctx, resultID)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrNoDocuments
+	}
+
+	previous, claimed, err := s.store.ClaimResearch(ctx, resultID, review.Statuses)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("%w (already being researched or decided)", review.ErrNotInQueue)
+	}
+	sr.ResearchStatus = previous
+
+	res, err := s.agent.ResearchDocuments(ctx, sr, docs)
+	if err != nil {
+		s.restoreStatus(ctx, resultID, previous)
+		return nil, err
+	}
+
+	// Record the outcome even if the caller has gone away
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finishTimeout)
+	defer cancel()
+
+	out := &Outcome{SearchResultID: resultID, Status: string(res.Status), Error: res.Error}
+	if out.RunID, err = s.store.SaveResearchRun(ctx, resultID, res, scheduler.TriggerUpload); err != nil {
+		slog.Warn("failed to save research run", "result_id", resultID, "error", err)
+	}
+
+	if res.Status == research.StatusFailed {
+		s.restoreStatus(ctx, resultID, previous)
+		out.Status = previous
+		return out, nil
+	}
+
+	var rfp *models.RFP
+	if res.Status == research.StatusResearched {
+		rfp = promotion.BuildRFP(sr, res)
+	}
+	if rfp == nil {
+		if res.Status == research.StatusResearched {
+			out.Status = string(research.StatusNeedsManual)
+		}
+		if err := s.store.UpdateResearchStatus(ctx, resultID, out.Status); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+
+	if previous == string(research.StatusNeedsManualUpload) {
+		rfp.LoginRequired = true
+		rfp.LoginNotes = loginNotes(sr.Portal, uploaders)
+	}
+	if rfp.Incumbent == "" {
+		incumbent, err := s.store.LatestAwardee(ctx, rfp)
+		if err != nil {
+			slog.Warn("failed to look up previous awardee", "result_id", resultID, "error", err)
+		}
+		rfp.Incumbent = incumbent
+	}
+
+	rfpID, err := s.store.PromoteRFP(ctx, resultID, rfp)
+	if err != nil {
+		s.restoreStatus(ctx, resultID, previous)
+		return nil, err
+	}
+	out.RFPID = &rfpID
+	out.ReviewFields = rfp.ReviewFields
+	return out, nil
+}
+
+// restoreStatus puts a result back in the queue after research from its
+// documents failed, even if ctx is done.
+func (s *Service) restoreStatus(ctx context.Context, resultID int, status string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finishTimeout)
+	defer cancel()
+	if err := s.store.UpdateResearchStatus(ctx, resultID, status); err != nil {
+		slog.Warn("failed to restore research status", "result_id", resultID, "status", status, "error", err)
+	}
+}
+
+func (s *Service) loadResult(ctx context.Context, resultID int) (*models.SearchResult, error) {
+	var sr models.SearchResult
+	err := s.db.QueryRow(ctx, `
+		SELECT id, url, COALESCE(final_url, ''), COALESCE(title, ''), COALESCE(snippet, ''),
+		       COALESCE(hint_agency, ''), COALESCE(hint_state, ''), hint_due_date,
+		       COALESCE(portal, ''), COALESCE(portal_agency, ''), COALESCE(portal_id, ''),
+		       COALESCE(research_status, '')
+		FROM discovery.search_results
+		WHERE id = $1
+	`, resultID).Scan(
+		&sr.ID, &sr.URL, &sr.FinalURL, &sr.Title, &sr.Snippet,
+		&sr.HintAgency, &sr.HintState, &sr.HintDueDate,
+		&sr.Portal, &sr.PortalAgency, &sr.PortalID,
+		&sr.ResearchStatus,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, review.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("look up search result failed: %w", err)
+	}
+	return &sr, nil
+}
+
+// loadDocuments returns a result's uploaded documents, oldest first, and
+// who uploaded them.
+func (s *Service) loadDocuments(ctx context.Context, resultID int) ([]research.Document, []string, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT filename, r2_key, text_content, uploaded_by
+		FROM discovery.result_documents
+		WHERE search_result_id = $1
+		ORDER BY id
+	`, resultID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query result documents failed: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []research.Document
+	var uploaders []string
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var doc research.Document
+		var key, uploader string
+		if err := rows.Scan(&doc.Name, &key, &doc.Text, &uploader); err != nil {
+			return nil, nil, fmt.Errorf("scan result document failed: %w", err)
+		}
+		doc.URL = s.objects.GetPublicURL(s.accountID, key)
+		docs = append(docs, doc)
+		if !seen[uploader] {
+			seen[uploader] = true
+			uploaders = append(uploaders, uploader)
+		}
+	}
+	return docs, uploaders, rows.Err()
+}
+
+// objectKey is where an uploaded document is stored.
+// Format: uploads/{result_id}/{sha256 prefix}-{filename}
+func objectKey(resultID int, sum, filename string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, filename)
+	if len(name) > 100 {
+		name = name[len(name)-100:]
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".pdf") {
+		name += ".pdf"
+	}
+	return fmt.Sprintf("uploads/%d/%s-%s", resultID, sum[:12], name)
+}
+
+// loginNotes describes how a login-walled RFP's documents were obtained.
+func loginNotes(portal string, uploaders []string) string {
+	where := "portal"
+	if portal != "" {
+		where = portal
+	}
+	return fmt.Sprintf("Documents are behind a %s login; uploaded by %s", where, strings.Join(uploaders, ", "))
+}
@@ -0,0 +1,155 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zachsouder/rfp/discovery/internal/pdf"
+	"github.com/zachsouder/rfp/discovery/internal/review"
+)
+
+func TestObjectKey(t *testing.T) {
+	sum := "0123456789abcdef0123"
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"RFP 2026-014.pdf", "uploads/7/0123456789ab-RFP_2026-014.pdf"},
+		{"addendum", "uploads/7/0123456789ab-addendum.pdf"},
+		{"Spéc.PDF", "uploads/7/0123456789ab-Sp_c.PDF"},
+	}
+	for _, tt := range tests {
+		if got := objectKey(7, sum, tt.filename); got != tt.want {
+			t.Errorf("objectKey(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestLoginNotes(t *testing.T) {
+	got := loginNotes("bonfire", []string{"alice", "bob"})
+	if got != "Documents are behind a bonfire login; uploaded by alice, bob" {
+		t.Errorf("loginNotes() = %q", got)
+	}
+	if got := loginNotes("", []string{"alice"}); !strings.Contains(got, "behind a portal login") {
+		t.Errorf("loginNotes() without portal = %q", got)
+	}
+}
+
+// fakeUploader records attachments and signals when research resumes.
+type fakeUploader struct {
+	attached []string
+	resumed  chan int
+}
+
+func (f *fakeUploader) Attach(ctx context.Context, resultID int, filename string, data []byte, uploadedBy string) (*Document, error) {
+	if resultID != 7 {
+		return nil, review.ErrNotFound
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, pdf.ErrNotPDF
+	}
+	f.attached = append(f.attached, fmt.Sprintf("%s by %s", filename, uploadedBy))
+	return &Document{SearchResultID: resultID, Filename: filename, UploadedBy: uploadedBy}, nil
+}
+
+func (f *fakeUploader) Resume(ctx context.Context, resultID int) (*Outcome, error) {
+	f.resumed <- resultID
+	return &Outcome{SearchResultID: resultID, Status: "researched"}, nil
+}
+
+func multipartBody(t *testing.T, files map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for name, content := range files {
+		fw, err := w.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+	}
+	w.Close()
+	return &buf, w.FormDataContentType()
+}
+
+func TestHandler(t *testing.T) {
+	svc := &fakeUploader{resumed: make(chan int, 1)}
+	h := NewHandler(svc, review.Tokens{"secret": "alice"})
+
+	body, contentType := multipartBody(t, map[string]string{"rfp.pdf": "%PDF-1.4 ..."})
+	req := httptest.NewRequest("POST", "/review/7/documents", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"filename":"rfp.pdf"`) {
+		t.Fatalf("upload = %d %s", rec.Code, rec.Body.String())
+	}
+	if len(svc.attached) != 1 || svc.attached[0] != "rfp.pdf by alice" {
+		t.Errorf("attached = %v", svc.attached)
+	}
+	select {
+	case id := <-svc.resumed:
+		if id != 7 {
+			t.Errorf("resumed result %d, want 7", id)
+		}
+	case <-time.After(time.Second):
+		t.Error("research didn't resume")
+	}
+
+	tests := []struct {
+		path       string
+		files      map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/review/8/documents", map[string]string{"rfp.pdf": "%PDF-1.4"}, http.StatusNotFound, "not found"},
+		{"/review/7/documents", map[string]string{"rfp.docx": "PK..."}, http.StatusBadRequest, "not a PDF"},
+		{"/review/7/documents", nil, http.StatusBadRequest, "file is required"},
+		{"/review/x/documents", nil, http.StatusBadRequest, "invalid result ID"},
+	}
+	for _, tt := range tests {
+		body, contentType := multipartBody(t, tt.files)
+		req := httptest.NewRequest("POST", tt.path, body)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus || !strings.Contains(rec.Body.String(), tt.wantBody) {
+			t.Errorf("POST %s = %d %s, want %d containing %q", tt.path, rec.Code, rec.Body.String(), tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+func TestHandler_Token(t *testing.T) {
+	svc := &fakeUploader{resumed: make(chan int, 1)}
+
+	body, contentType := multipartBody(t, map[string]string{"rfp.pdf": "%PDF-1.4"})
+	req := httptest.NewRequest("POST", "/review/7/documents", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec := httptest.NewRecorder()
+	NewHandler(svc, review.Tokens{"secret": "alice"}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", rec.Code)
+	}
+
+	body, contentType = multipartBody(t, map[string]string{"rfp.pdf": "%PDF-1.4"})
+	req = httptest.NewRequest("POST", "/review/7/documents", body)
+	req.Header.Set("Content-Type", contentType)
+	rec = httptest.NewRecorder()
+	NewHandler(svc, nil).ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without tokens: status = %d, want 503", rec.Code)
+	}
+	if len(svc.attached) != 0 {
+		t.Errorf("attached = %v, want nothing", svc.attached)
+	}
+}
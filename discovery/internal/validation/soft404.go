@@ -0,0 +1,184 @@
+package validation
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxSoft404TextLength is the longest page whose whole text is checked for
+// generic "not found" and "expired" wording. Real error pages are short; on
+// longer pages the wording is only trusted in the title and headings, since a
+// listing page can mention other closed bids.
+const maxSoft404TextLength = 3000
+
+// portalErrorTemplates are the messages procurement portals show, with a 200
+// status, for removed or expired listings. Keys are matched against the
+// final URL's host.
+var portalErrorTemplates = map[string][]string{
+	"bonfirehub.com": {
+		"this opportunity is no longer available",
+		"the opportunity you are looking for could not be found",
+		"this opportunity has been removed",
+	},
+	"opengov.com": {
+		"this project is no longer available",
+		"project not found",
+		"this project has been removed",
+	},
+	"planetbids.com": {
+		"bid not found",
+		"this bid is no longer available",
+		"the bid you are looking for does not exist",
+	},
+	"bidnetdirect.com": {
+		"the solicitation you requested is not available",
+		"solicitation not found",
+	},
+	"publicpurchase.com": {
+		"bid not found",
+		"the bid you requested does not exist",
+	},
+	"ionwave.net": {
+		"this bid is no longer available",
+		"the requested bid could not be found",
+	},
+	"demandstar.com": {
+		"this bid is no longer available",
+		"bid not found",
+	},
+}
+
+// Generic soft-404 wording, anchored on listing nouns so that ordinary uses
+// of "closed" or "not found" don't match.
+var soft404Patterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(error\s*)?404\b[\s\-:|]*(page\s+)?not\s+found\b`),
+	regexp.MustCompile(`(?i)\b(page|document|file|opportunity|solicitation|bid|rfp|rfq|listing|posting|project)\s+(you\s+(are\s+looking\s+for|requested)\s+)?(could\s+not\s+be\s+found|cannot\s+be\s+found|can't\s+be\s+found|was\s+not\s+found|not\s+found|does\s+not\s+exist|no\s+longer\s+exists)\b`),
+	regexp.MustCompile(`(?i)\b(page|document|opportunity|solicitation|bid|rfp|rfq|listing|posting|project)\s+is\s+no\s+longer\s+(available|active|posted)\b`),
+	regexp.MustCompile(`(?i)\b(opportunity|solicitation|bid|rfp|rfq|rfb|ifb|listing|posting)\s+has\s+(expired|closed|been\s+(closed|removed|archived|withdrawn))\b`),
+	regexp.MustCompile(`(?i)\bthis\s+(opportunity|solicitation|bid|rfp|rfq|listing|posting)\s+(is\s+)?(closed|expired)\b`),
+}
+
+var (
+	titleTagPattern   = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	headingTagPattern = regexp.MustCompile(`(?is)<h[1-3][^>]*>(.*?)</h[1-3]>`)
+	nonTextPattern    = regexp.MustCompile(`(?is)<(script|style|noscript|nav|header|footer)[^>]*>.*?</(script|style|noscript|nav|header|footer)>`)
+	tagPattern        = regexp.MustCompile(`(?s)<[^>]+>`)
+)
+
+// Paths that generic landing pages live at.
+var (
+	homePaths = map[string]bool{
+		"": true, "/": true, "/home": true, "/index": true, "/index.html": true, "/index.htm": true,
+		"/index.php": true, "/index.aspx": true, "/default.aspx": true, "/default.asp": true,
+	}
+	searchPathPattern = regexp.MustCompile(`(?i)(^|/)(search|results|opportunities|solicitations|bids|open-bids|current-bids|bid-opportunities)(/|\.\w+)?$`)
+	errorPathPattern  = regexp.MustCompile(`(?i)(^|/)(404|error|errors|not-?found|page-?not-?found|pagenotfound|expired)([/._-]|$)`)
+	searchParams      = []string{"q", "query", "search", "keyword", "keywords"}
+)
+
+// detectSoft404 reports why a 2xx response is really a missing or expired
+// listing, or returns "" if it looks like a real page.
+func detectSoft404(requestedURL, finalURL, body string) string {
+	if reason := soft404Redirect(requestedURL, finalURL); reason != "" {
+		return reason
+	}
+
+	bodyLower := strings.ToLower(body)
+	if final, err := url.Parse(finalURL); err == nil {
+		host := strings.ToLower(final.Hostname())
+		for domain, phrases := range portalErrorTemplates {
+			if host != domain && !strings.HasSuffix(host, "."+domain) {
+				continue
+			}
+			for _, phrase := range phrases {
+				if strings.Contains(bodyLower, phrase) {
+					return fmt.Sprintf("Portal error page: %q", phrase)
+				}
+			}
+		}
+	}
+
+	prominent := prominentText(body)
+	text := visibleText(body)
+	if len(text) <= maxSoft404TextLength {
+		prominent += "\n" + text
+	}
+	for _, pattern := range soft404Patterns {
+		if match := pattern.FindString(prominent); match != "" {
+			return fmt.Sprintf("Page says %q", strings.Join(strings.Fields(match), " "))
+		}
+	}
+
+	return ""
+}
+
+// soft404Redirect flags a deep link that redirected to a homepage, a search
+// page, or an error page.
+func soft404Redirect(requestedURL, finalURL string) string {
+	if finalURL == "" || finalURL == requestedURL {
+		return ""
+	}
+	requested, err := url.Parse(requestedURL)
+	if err != nil {
+		return ""
+	}
+	final, err := url.Parse(finalURL)
+	if err != nil {
+		return ""
+	}
+
+	finalPath := strings.TrimSuffix(strings.ToLower(final.Path), "/")
+	if errorPathPattern.MatchString(finalPath) && !errorPathPattern.MatchString(strings.ToLower(requested.Path)) {
+		return "Redirected to an error page"
+	}
+
+	// Landing on a homepage or search page only means something if we
+	// asked for a specific page
+	requestedPath := strings.TrimSuffix(strings.ToLower(requested.Path), "/")
+	if homePaths[requestedPath] || isSearchPage(requested, requestedPath) {
+		return ""
+	}
+	if homePaths[finalPath] && final.RawQuery == "" {
+		return "Redirected to the site homepage"
+	}
+	if isSearchPage(final, finalPath) {
+		return "Redirected to a search page"
+	}
+	return ""
+}
+
+// isSearchPage reports whether a URL looks like a search or listing page.
+func isSearchPage(u *url.URL, path string) bool {
+	if searchPathPattern.MatchString(path) {
+		return true
+	}
+	query := u.Query()
+	for _, param := range searchParams {
+		if query.Has(param) {
+			return true
+		}
+	}
+	return false
+}
+
+// prominentText returns the page title and top-level headings.
+func prominentText(body string) string {
+	var parts []string
+	for _, pattern := range []*regexp.Regexp{titleTagPattern, headingTagPattern} {
+		for _, match := range pattern.FindAllStringSubmatch(body, 10) {
+			parts = append(parts, tagPattern.ReplaceAllString(match[1], " "))
+		}
+	}
+	return html.UnescapeString(strings.Join(parts, "\n"))
+}
+
+// visibleText strips markup and page chrome, collapsing whitespace.
+func visibleText(body string) string {
+	text := nonTextPattern.ReplaceAllString(body, " ")
+	text = titleTagPattern.ReplaceAllString(text, " ")
+	text = tagPattern.ReplaceAllString(text, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
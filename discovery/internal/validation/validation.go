@@ -4,25 +4,18 @@ package validation
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
-	"io"
-	"net"
-	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
 	"time"
-)
 
-const (
-	defaultTimeout     = 15 * time.Second
-	connectTimeout     = 10 * time.Second
-	maxRedirects       = 10
-	defaultUserAgent   = "Mozilla/5.0 (compatible; RFPBot/1.0)"
-	maxBodyReadBytes   = 512 * 1024 // 512KB for content detection
+	"github.com/zachsouder/rfp/discovery/internal/fetch"
+	"github.com/zachsouder/rfp/discovery/internal/portal"
 )
 
+const maxBodyReadBytes = 512 * 1024 // 512KB for content detection
+
 // Status represents the validation status of a URL.
 type Status string
 
@@ -39,6 +32,8 @@ const (
 	StatusTimeout          Status = "timeout"
 	StatusSSLError         Status = "ssl_error"
 	StatusTooManyRedirects Status = "too_many_redirects"
+	StatusDisallowed       Status = "robots_disallowed"
+	StatusSoft404          Status = "soft_404" // 2xx page for a missing or expired listing
 )
 
 // ContentType represents the detected type of content at the URL.
@@ -64,41 +59,18 @@ type Result struct {
 	ContentType    ContentType `json:"content_type,omitempty"`
 	ContentMIME    string      `json:"content_mime,omitempty"`
 	DurationMs     int64       `json:"duration_ms,omitempty"`
+	SnapshotKey    string      `json:"snapshot_key,omitempty"`
 }
 
 // Validator handles URL validation.
 type Validator struct {
-	httpClient *http.Client
-	userAgent  string
+	fetcher *fetch.Fetcher
 }
 
-// NewValidator creates a new URL validator.
+// NewValidator creates a new URL validator using the shared fetcher.
 func NewValidator() *Validator {
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			MinVersion: tls.VersionTLS12,
-		},
-		DialContext: (&net.Dialer{
-			Timeout: connectTimeout,
-		}).DialContext,
-		DisableKeepAlives:     true,
-		MaxIdleConns:          10,
-		IdleConnTimeout:       30 * time.Second,
-		ResponseHeaderTimeout: defaultTimeout,
-	}
-
 	return &Validator{
-		httpClient: &http.Client{
-			Transport: transport,
-			Timeout:   defaultTimeout,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if len(via) >= maxRedirects {
-					return http.ErrUseLastResponse
-				}
-				return nil
-			},
-		},
-		userAgent: defaultUserAgent,
+		fetcher: fetch.Default(),
 	}
 }
 
@@ -106,126 +78,83 @@ func NewValidator() *Validator {
 func (v *Validator) Validate(ctx context.Context, rawURL string) *Result {
 	startTime := time.Now()
 
-	// Validate URL format
-	parsed, err := url.Parse(rawURL)
-	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
-		return &Result{
-			Valid:      false,
-			Status:     StatusInvalidURL,
-			Error:      "Invalid URL format",
-			DurationMs: time.Since(startTime).Milliseconds(),
-		}
-	}
-
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
-	if err != nil {
-		return &Result{
-			Valid:      false,
-			Status:     StatusInvalidURL,
-			Error:      fmt.Sprintf("Failed to create request: %v", err),
-			DurationMs: time.Since(startTime).Milliseconds(),
-		}
-	}
-
-	req.Header.Set("User-Agent", v.userAgent)
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Accept-Encoding", "gzip, deflate")
-
-	// Execute request
-	resp, err := v.httpClient.Do(req)
+	resp, err := v.fetcher.Do(ctx, fetch.Request{URL: rawURL, MaxBytes: maxBodyReadBytes})
 	if err != nil {
-		return v.handleError(err, rawURL, startTime)
+		return v.handleError(err, startTime)
 	}
-	defer resp.Body.Close()
-
-	// Read a portion of the body for content detection
-	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyReadBytes))
-	bodyText := string(bodyBytes)
 
-	// Build result
 	result := &Result{
 		HTTPCode:      resp.StatusCode,
-		FinalURL:      resp.Request.URL.String(),
+		FinalURL:      resp.FinalURL,
+		RedirectCount: resp.Redirects,
 		ContentMIME:   resp.Header.Get("Content-Type"),
 		DurationMs:    time.Since(startTime).Milliseconds(),
-		WasRedirected: resp.Request.URL.String() != rawURL,
-	}
-
-	// Count redirects from response chain
-	if result.WasRedirected {
-		// The Request in the Response is the final request after redirects
-		// We don't have direct access to redirect count, but we know it happened
-		result.RedirectCount = 1 // Minimum, actual may be higher
+		WasRedirected: resp.FinalURL != rawURL,
+		SnapshotKey:   resp.SnapshotKey,
 	}
 
 	// Handle HTTP status codes
-	switch {
-	case resp.StatusCode == 404:
-		result.Valid = false
-		result.Status = StatusNotFound
-		result.Error = "Page not found (404)"
-	case resp.StatusCode >= 400 && resp.StatusCode < 500:
-		result.Valid = false
-		result.Status = StatusClientError
-		result.Error = fmt.Sprintf("HTTP error %d", resp.StatusCode)
-	case resp.StatusCode >= 500:
-		result.Valid = false
-		result.Status = StatusServerError
-		result.Error = fmt.Sprintf("Server error %d", resp.StatusCode)
-	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+	result.Status = statusForKind(resp.Kind())
+	switch result.Status {
+	case StatusValid:
 		result.Valid = true
 		if result.WasRedirected {
 			result.Status = StatusValidRedirected
-		} else {
-			result.Status = StatusValid
 		}
+		if reason := detectSoft404(rawURL, result.FinalURL, string(resp.Body)); reason != "" {
+			result.Valid = false
+			result.Status = StatusSoft404
+			result.Error = reason
+		}
+	case StatusNotFound:
+		result.Error = fmt.Sprintf("Page not found (%d)", resp.StatusCode)
+	case StatusServerError:
+		result.Error = fmt.Sprintf("Server error %d", resp.StatusCode)
 	default:
-		// 3xx codes that weren't followed (shouldn't happen with our config)
-		result.Valid = false
-		result.Status = StatusClientError
-		result.Error = fmt.Sprintf("Unexpected HTTP status %d", resp.StatusCode)
+		result.Error = fmt.Sprintf("HTTP error %d", resp.StatusCode)
 	}
 
 	// Detect content type
-	result.ContentType = v.detectContentType(result.ContentMIME, result.FinalURL, bodyText)
+	result.ContentType = v.detectContentType(result.ContentMIME, result.FinalURL, string(resp.Body))
 
 	return result
 }
 
-// handleError converts network errors to appropriate status codes.
-func (v *Validator) handleError(err error, rawURL string, startTime time.Time) *Result {
+// handleError converts fetch errors to validation results.
+func (v *Validator) handleError(err error, startTime time.Time) *Result {
 	result := &Result{
 		Valid:      false,
+		Status:     statusForKind(fetch.KindOf(err)),
 		Error:      err.Error(),
 		DurationMs: time.Since(startTime).Milliseconds(),
 	}
 
-	errStr := err.Error()
-	switch {
-	case strings.Contains(errStr, "no such host"):
-		result.Status = StatusDNSError
-		result.Error = "DNS lookup failed"
-	case strings.Contains(errStr, "connection refused"):
-		result.Status = StatusConnectionRefused
-		result.Error = "Connection refused"
-	case strings.Contains(errStr, "timeout") || strings.Contains(errStr, "deadline exceeded"):
-		result.Status = StatusTimeout
-		result.Error = "Request timed out"
-	case strings.Contains(errStr, "certificate") || strings.Contains(errStr, "tls") || strings.Contains(errStr, "x509"):
-		result.Status = StatusSSLError
-		result.Error = "SSL/TLS error"
-	case strings.Contains(errStr, "redirect"):
-		result.Status = StatusTooManyRedirects
-		result.Error = "Too many redirects"
-	default:
-		result.Status = StatusConnectionFailed
+	if msg, ok := errorMessages[result.Status]; ok {
+		result.Error = msg
 	}
-
 	return result
 }
 
+// errorMessages are the user-facing errors for failed fetches.
+var errorMessages = map[Status]string{
+	StatusInvalidURL:        "Invalid URL format",
+	StatusDisallowed:        "Disallowed by robots.txt",
+	StatusDNSError:          "DNS lookup failed",
+	StatusConnectionRefused: "Connection refused",
+	StatusTimeout:           "Request timed out",
+	StatusSSLError:          "SSL/TLS error",
+	StatusTooManyRedirects:  "Too many redirects",
+}
+
+// statusForKind maps a fetch classification to a validation status. The
+// values are shared, so this is a conversion.
+func statusForKind(kind fetch.Kind) Status {
+	if kind == fetch.KindOK {
+		return StatusValid
+	}
+	return Status(kind)
+}
+
 // detectContentType analyzes the response to determine what kind of page this is.
 func (v *Validator) detectContentType(mimeType, finalURL, bodyText string) ContentType {
 	// Check MIME type first
@@ -313,29 +242,11 @@ func (v *Validator) detectLoginWall(bodyLower, finalURL string) bool {
 	return false
 }
 
-// detectPortalListing checks if URL is from a known procurement portal.
+// detectPortalListing checks if URL is a procurement portal page other than
+// a single opportunity.
 func (v *Validator) detectPortalListing(finalURL string) bool {
-	portalDomains := []string{
-		"bonfirehub.com",
-		"opengov.com",
-		"planetbids.com",
-		"bidnet.com",
-		"publicpurchase.com",
-		"bidsync.com",
-		"ionwave.net",
-		"vendorregistry.com",
-		"negometrix.com",
-		"procurato.com",
-	}
-
-	finalURLLower := strings.ToLower(finalURL)
-	for _, domain := range portalDomains {
-		if strings.Contains(finalURLLower, domain) {
-			return true
-		}
-	}
-
-	return false
+	m := portal.Parse(finalURL)
+	return m != nil && m.Page == portal.PageListing
 }
 
 // detectRFPPage checks if content appears to be an RFP page.
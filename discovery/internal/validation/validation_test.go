@@ -318,3 +318,66 @@ func TestValidateBatch_ContextCancel(t *testing.T) {
 		t.Errorf("Expected fewer results due to cancellation, got %d", len(results))
 	}
 }
+
+func TestDetectSoft404(t *testing.T) {
+	longListing := "<h1>Open Bids</h1>" + strings.Repeat("<p>Janitorial services RFP 24-001, due 4/1/2024.</p>", 80) +
+		"<p>Bid 23-114 has closed.</p>"
+
+	tests := []struct {
+		name      string
+		requested string
+		final     string
+		body      string
+		soft404   bool
+	}{
+		{"real rfp page", "https://city.gov/bids/rfp-24-001", "https://city.gov/bids/rfp-24-001",
+			"<title>RFP 24-001 Parking Management</title><p>Proposals due April 1.</p>", false},
+		{"redirect to homepage", "https://city.gov/bids/rfp-24-001", "https://city.gov/", "<h1>Welcome</h1>", true},
+		{"redirect to search", "https://city.gov/bids/rfp-24-001", "https://city.gov/search?q=rfp-24-001", "<h1>Search</h1>", true},
+		{"redirect to bid list", "https://city.gov/bids/view/991", "https://city.gov/bids", "<h1>Bids</h1>", true},
+		{"redirect to error page", "https://city.gov/bids/rfp-24-001", "https://city.gov/error.aspx?code=404", "<p>Oops</p>", true},
+		{"homepage to homepage", "https://city.gov/", "https://www.city.gov/", "<h1>Welcome</h1>", false},
+		{"moved page", "https://city.gov/bids/rfp-24-001", "https://city.gov/procurement/rfp-24-001", "<p>RFP 24-001</p>", false},
+		{"portal template", "https://vendor.bonfirehub.com/opportunities/1234", "https://vendor.bonfirehub.com/opportunities/1234",
+			"<div class=\"alert\">This opportunity is no longer available.</div>", true},
+		{"template for other portal", "https://city.gov/bids/5", "https://city.gov/bids/5",
+			longListing + "<p>Project not found</p>", false},
+		{"404 in title", "https://city.gov/bids/5", "https://city.gov/bids/5",
+			"<title>404 - Page Not Found</title>" + strings.Repeat("<p>Links</p>", 1000), true},
+		{"expired listing", "https://county.gov/rfp/77", "https://county.gov/rfp/77",
+			"<h2>Notice</h2><p>This solicitation has expired and is no longer accepting responses.</p>", true},
+		{"closed in heading", "https://county.gov/rfp/77", "https://county.gov/rfp/77",
+			"<h1>This RFP is closed</h1>" + longListing, true},
+		{"closed bid on long listing", "https://county.gov/bids/list/2", "https://county.gov/bids/list/2", longListing, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := detectSoft404(tt.requested, tt.final, tt.body)
+			if (reason != "") != tt.soft404 {
+				t.Errorf("detectSoft404() = %q, want soft404=%v", reason, tt.soft404)
+			}
+		})
+	}
+}
+
+func TestValidator_Validate_Soft404(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("<html><body><p>The page you requested could not be found.</p></body></html>"))
+	}))
+	defer server.Close()
+
+	v := NewValidator()
+	result := v.Validate(context.Background(), server.URL+"/bids/42")
+
+	if result.Valid {
+		t.Error("Expected valid=false for soft 404")
+	}
+	if result.Status != StatusSoft404 {
+		t.Errorf("Expected status=%s, got %s", StatusSoft404, result.Status)
+	}
+	if result.Error == "" {
+		t.Error("Expected a reason in Error")
+	}
+}
@@ -0,0 +1,23 @@
+-- RFP lifecycle status
+-- Replaces the never-updated is_active flag with an explicit status.
+-- is_active is kept in sync (true for open/unknown) for existing readers.
+
+ALTER TABLE discovery.rfps
+    ADD COLUMN lifecycle_status  TEXT NOT NULL DEFAULT 'open',
+    ADD COLUMN status_changed_at TIMESTAMPTZ,
+    ADD COLUMN status_reason     TEXT;
+
+ALTER TABLE discovery.rfps
+    ADD CONSTRAINT rfps_lifecycle_status_check
+    CHECK (lifecycle_status IN ('open', 'closed', 'awarded', 'cancelled', 'unknown'));
+
+-- Backfill: anything already past due is closed
+UPDATE discovery.rfps
+SET lifecycle_status = 'closed',
+    status_changed_at = NOW(),
+    status_reason = 'Due date passed',
+    is_active = false
+WHERE due_date < CURRENT_DATE;
+
+CREATE INDEX idx_rfps_lifecycle_status ON discovery.rfps(lifecycle_status);
+CREATE INDEX idx_rfps_last_checked ON discovery.rfps(last_checked);
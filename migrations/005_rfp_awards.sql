@@ -0,0 +1,12 @@
+-- RFP award notices
+-- Populated by the award discovery job for RFPs past their due date
+
+ALTER TABLE discovery.rfps
+    ADD COLUMN awardee          TEXT,
+    ADD COLUMN award_amount     DECIMAL(14, 2),
+    ADD COLUMN award_date       DATE,
+    ADD COLUMN award_source_url TEXT,
+    ADD COLUMN award_checked_at TIMESTAMPTZ;
+
+CREATE INDEX idx_rfps_award_pending ON discovery.rfps(due_date)
+    WHERE awardee IS NULL;
@@ -0,0 +1,16 @@
+-- RFP key dates and requirements
+-- Extracted by the research agent alongside the core RFP details
+
+ALTER TABLE discovery.rfps
+    ADD COLUMN pre_bid_date           TIMESTAMPTZ,
+    ADD COLUMN pre_bid_mandatory      BOOLEAN,
+    ADD COLUMN questions_deadline     TIMESTAMPTZ,
+    ADD COLUMN submission_method      TEXT,             -- electronic, email, mail, hand_delivery
+    ADD COLUMN solicitation_number    TEXT,
+    ADD COLUMN contact_name           TEXT,
+    ADD COLUMN contact_email          TEXT,
+    ADD COLUMN bond_requirements      TEXT,
+    ADD COLUMN insurance_requirements TEXT;
+
+CREATE INDEX idx_rfps_solicitation_number ON discovery.rfps(solicitation_number)
+    WHERE solicitation_number IS NOT NULL;
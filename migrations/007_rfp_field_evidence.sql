@@ -0,0 +1,10 @@
+-- Per-field provenance for extracted RFP details
+-- field_evidence holds [{field, confidence, evidence, verified}, ...]
+-- review_fields lists low-confidence fields flagged for human review
+
+ALTER TABLE discovery.rfps
+    ADD COLUMN field_evidence JSONB,
+    ADD COLUMN review_fields  TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_rfps_needs_review ON discovery.rfps(discovered_at)
+    WHERE cardinality(review_fields) > 0;
@@ -0,0 +1,14 @@
+-- Typed values parsed from extracted RFP text
+-- due_at holds the exact deadline when a time of day was given (due_date keeps the calendar date)
+-- raw_values keeps the extracted text of values that could not be parsed, keyed by field name
+
+ALTER TABLE discovery.rfps
+    ADD COLUMN due_at              TIMESTAMPTZ,
+    ADD COLUMN base_term_months    INTEGER,
+    ADD COLUMN renewal_months      INTEGER,
+    ADD COLUMN estimated_value_max DECIMAL(14,2),
+    ADD COLUMN value_not_to_exceed BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN raw_values          JSONB;
+
+-- Ranges and totals over $10B shouldn't overflow
+ALTER TABLE discovery.rfps ALTER COLUMN estimated_value TYPE DECIMAL(14,2);
@@ -0,0 +1,40 @@
+-- Indexed keys for dedup candidate retrieval
+-- The functions are deliberately loose versions of the Go normalization in
+-- discovery/internal/dedup: they only prefilter, and the Go matcher does the
+-- exact scoring.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Lowercase, drop "City of"-style prefixes and punctuation, collapse spaces
+CREATE OR REPLACE FUNCTION discovery.normalize_agency(agency TEXT) RETURNS TEXT AS $$
+    SELECT NULLIF(trim(regexp_replace(regexp_replace(
+        regexp_replace(lower(agency), '^(city|town|county|state|village) of ', ''),
+        '[^a-z0-9\s]', '', 'g'), '\s+', ' ', 'g')), '')
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Host and path without scheme, "www.", query, fragment or trailing slash
+CREATE OR REPLACE FUNCTION discovery.url_key(url TEXT) RETURNS TEXT AS $$
+    SELECT NULLIF(regexp_replace(regexp_replace(
+        lower(split_part(split_part(url, '#', 1), '?', 1)),
+        '^https?://(www\.)?', ''), '/+$', ''), '')
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Uppercase alphanumerics, matching dedup.NormalizeSolicitationNumber
+CREATE OR REPLACE FUNCTION discovery.solicitation_key(number TEXT) RETURNS TEXT AS $$
+    SELECT NULLIF(upper(regexp_replace(number, '[^a-zA-Z0-9]', '', 'g')), '')
+$$ LANGUAGE SQL IMMUTABLE;
+
+ALTER TABLE discovery.rfps
+    ADD COLUMN agency_key       TEXT GENERATED ALWAYS AS (discovery.normalize_agency(agency)) STORED,
+    ADD COLUMN url_key          TEXT GENERATED ALWAYS AS (discovery.url_key(source_url)) STORED,
+    ADD COLUMN solicitation_key TEXT GENERATED ALWAYS AS (discovery.solicitation_key(solicitation_number)) STORED;
+
+CREATE INDEX idx_rfps_state_due_date ON discovery.rfps(state, due_date);
+CREATE INDEX idx_rfps_agency_key_trgm ON discovery.rfps USING GIN (agency_key gin_trgm_ops);
+CREATE INDEX idx_rfps_title_trgm ON discovery.rfps USING GIN (lower(title) gin_trgm_ops);
+CREATE INDEX idx_rfps_url_key ON discovery.rfps(url_key);
+CREATE INDEX idx_rfps_portal_id ON discovery.rfps(portal, portal_id) WHERE portal_id IS NOT NULL;
+CREATE INDEX idx_rfps_solicitation_key ON discovery.rfps(solicitation_key) WHERE solicitation_key IS NOT NULL;
+
+-- Superseded by idx_rfps_solicitation_key
+DROP INDEX IF EXISTS discovery.idx_rfps_solicitation_number;
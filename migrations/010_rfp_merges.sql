@@ -0,0 +1,15 @@
+-- History of RFP merges, with what's needed to undo them
+-- snapshot holds the pre-merge rows and the IDs of everything re-pointed
+
+CREATE TABLE discovery.rfp_merges (
+    id              SERIAL PRIMARY KEY,
+    kept_rfp_id     INTEGER NOT NULL,
+    dropped_rfp_id  INTEGER NOT NULL,
+    merged_by       TEXT,
+    snapshot        JSONB NOT NULL,
+    merged_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    unmerged_at     TIMESTAMPTZ
+);
+
+CREATE INDEX idx_rfp_merges_kept ON discovery.rfp_merges(kept_rfp_id);
+CREATE INDEX idx_rfp_merges_dropped ON discovery.rfp_merges(dropped_rfp_id);
@@ -0,0 +1,15 @@
+-- Response cache for the shared fetcher
+-- Pages are revalidated with ETag / Last-Modified instead of refetched in full
+
+CREATE TABLE discovery.fetch_cache (
+    url             TEXT PRIMARY KEY,
+    final_url       TEXT NOT NULL,
+    status_code     INTEGER NOT NULL,
+    content_type    TEXT,
+    etag            TEXT,
+    last_modified   TEXT,
+    body            BYTEA NOT NULL,
+    fetched_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_fetch_cache_fetched_at ON discovery.fetch_cache(fetched_at);
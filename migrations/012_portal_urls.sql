@@ -0,0 +1,15 @@
+-- Portal, agency slug and opportunity ID parsed from portal URLs
+-- Values come from discovery/internal/portal; search results are parsed on
+-- insert and again from the final URL after validation.
+
+ALTER TABLE discovery.search_results
+    ADD COLUMN portal        TEXT,
+    ADD COLUMN portal_agency TEXT,
+    ADD COLUMN portal_id     TEXT,
+    ADD COLUMN portal_page   TEXT; -- opportunity, listing
+
+CREATE INDEX idx_search_results_portal_id ON discovery.search_results(portal, portal_id)
+    WHERE portal_id IS NOT NULL;
+
+ALTER TABLE discovery.rfps
+    ADD COLUMN portal_agency TEXT;
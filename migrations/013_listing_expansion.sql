@@ -0,0 +1,9 @@
+-- Opportunities enumerated from portal listing pages
+-- Each link found on a listing becomes a child search result; the listing
+-- itself is marked research_status = 'expanded' instead of being researched.
+
+ALTER TABLE discovery.search_results
+    ADD COLUMN parent_id INTEGER REFERENCES discovery.search_results(id);
+
+CREATE INDEX idx_search_results_parent_id ON discovery.search_results(parent_id)
+    WHERE parent_id IS NOT NULL;
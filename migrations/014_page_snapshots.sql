@@ -0,0 +1,9 @@
+-- Raw page snapshots archived to R2
+-- Pages fetched during validation and research are stored gzipped under
+-- snapshots/<sha256 prefix>/<sha256>.json.gz; these columns hold the keys.
+
+ALTER TABLE discovery.search_results
+    ADD COLUMN snapshot_key TEXT;
+
+ALTER TABLE discovery.research_steps
+    ADD COLUMN snapshot_key TEXT;
@@ -0,0 +1,9 @@
+-- Prompt versions
+-- Prompts are versioned templates in discovery/internal/prompts; each search
+-- query and research step records the version it ran with, e.g. "search@v1".
+
+ALTER TABLE discovery.search_queries
+    ADD COLUMN prompt_version TEXT;
+
+ALTER TABLE discovery.research_steps
+    ADD COLUMN prompt_version TEXT;
@@ -0,0 +1,31 @@
+-- Research runs
+-- One row per research agent run on a search result, holding its outcome,
+-- totals and extraction payload. Steps reference the run that produced them
+-- and carry their own timing and token counts.
+
+CREATE TABLE discovery.research_runs (
+    id                SERIAL PRIMARY KEY,
+    search_result_id  INTEGER NOT NULL REFERENCES discovery.search_results(id),
+    trigger           TEXT NOT NULL DEFAULT 'scheduler', -- scheduler, manual
+    status            TEXT NOT NULL,
+    success           BOOLEAN NOT NULL,
+    error_message     TEXT,
+    steps_taken       INTEGER NOT NULL DEFAULT 0,
+    total_tokens      INTEGER NOT NULL DEFAULT 0,
+    duration_ms       BIGINT,
+    extracted_details JSONB,
+    found_pdfs        TEXT[],
+    agent_version     TEXT NOT NULL,
+    model             TEXT,
+    started_at        TIMESTAMPTZ NOT NULL,
+    completed_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_research_runs_search_result ON discovery.research_runs(search_result_id);
+
+ALTER TABLE discovery.research_steps
+    ADD COLUMN research_run_id INTEGER REFERENCES discovery.research_runs(id),
+    ADD COLUMN tokens_used     INTEGER,
+    ADD COLUMN duration_ms     BIGINT;
+
+CREATE INDEX idx_research_steps_run ON discovery.research_steps(research_run_id);
@@ -0,0 +1,21 @@
+-- Manual review queue decisions
+-- Search results the research agent couldn't finish (needs_manual,
+-- needs_manual_upload, research_exhausted) wait for a reviewer, who
+-- promotes, marks a duplicate, rejects or re-queues each one. Every
+-- decision is kept with who made it and why.
+
+CREATE TABLE discovery.review_decisions (
+    id               SERIAL PRIMARY KEY,
+    search_result_id INTEGER NOT NULL REFERENCES discovery.search_results(id),
+    action           TEXT NOT NULL, -- promote, duplicate, reject, requeue
+    reviewer         TEXT NOT NULL,
+    reason           TEXT NOT NULL,
+    previous_status  TEXT NOT NULL,
+    rfp_id           INTEGER REFERENCES discovery.rfps(id), -- promoted to, or duplicate of
+    fields           JSONB,                                -- RFP fields entered when promoting
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_review_decisions_search_result ON discovery.review_decisions(search_result_id);
+CREATE INDEX idx_search_results_review ON discovery.search_results(created_at)
+    WHERE research_status IN ('needs_manual', 'needs_manual_upload', 'research_exhausted');
@@ -0,0 +1,20 @@
+-- Documents uploaded by hand for search results
+-- Login-walled results (needs_manual_upload) can't be fetched, but a person
+-- can download the solicitation and upload it. The file is kept in R2 with
+-- its extracted text, and research resumes from the text.
+
+CREATE TABLE discovery.result_documents (
+    id               SERIAL PRIMARY KEY,
+    search_result_id INTEGER NOT NULL REFERENCES discovery.search_results(id),
+    filename         TEXT NOT NULL,
+    r2_key           TEXT NOT NULL,
+    content_type     TEXT NOT NULL,
+    size_bytes       BIGINT NOT NULL,
+    sha256           TEXT NOT NULL,
+    text_content     TEXT NOT NULL,
+    uploaded_by      TEXT NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (search_result_id, sha256)
+);
+
+CREATE INDEX idx_result_documents_search_result ON discovery.result_documents(search_result_id);
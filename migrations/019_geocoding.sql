@@ -0,0 +1,43 @@
+-- Offline geocoding and company locations
+-- RFPs get coordinates from a US place gazetteer at promotion, so they can
+-- be scored and filtered by distance from our own venues. The gazetteer is
+-- loaded with `rfp-cli discovery gazetteer load`, from the bundled set of
+-- major cities or a Census Bureau Gazetteer places file.
+
+CREATE TABLE discovery.places (
+    id        SERIAL PRIMARY KEY,
+    state     TEXT NOT NULL,             -- two-letter code
+    name      TEXT NOT NULL,             -- as in the gazetteer, e.g. "Austin city"
+    name_key  TEXT NOT NULL,             -- normalized for matching, e.g. "austin"
+    latitude  DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL
+);
+
+CREATE INDEX idx_places_lookup ON discovery.places(state, name_key);
+
+ALTER TABLE discovery.rfps
+    ADD COLUMN latitude      DOUBLE PRECISION,
+    ADD COLUMN longitude     DOUBLE PRECISION,
+    ADD COLUMN geo_precision TEXT; -- place, or state when only the state was found
+
+-- Where the company operates from; distances are measured to the nearest
+CREATE TABLE client.company_locations (
+    id         SERIAL PRIMARY KEY,
+    name       TEXT NOT NULL UNIQUE,
+    city       TEXT,
+    state      TEXT,
+    latitude   DOUBLE PRECISION NOT NULL,
+    longitude  DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Great-circle distance in miles
+CREATE FUNCTION discovery.distance_miles(lat1 DOUBLE PRECISION, lon1 DOUBLE PRECISION,
+                                         lat2 DOUBLE PRECISION, lon2 DOUBLE PRECISION)
+RETURNS DOUBLE PRECISION
+LANGUAGE SQL IMMUTABLE STRICT AS $$
+    SELECT 2 * 3958.8 * asin(sqrt(
+        power(sin(radians(lat2 - lat1) / 2), 2) +
+        cos(radians(lat1)) * cos(radians(lat2)) * power(sin(radians(lon2 - lon1) / 2), 2)
+    ))
+$$;
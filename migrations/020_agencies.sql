@@ -0,0 +1,34 @@
+-- Canonical agencies
+-- Agency names were only normalized when comparing RFPs, so there was no
+-- way to see what an agency has put out before. Each agency now has a
+-- canonical name, the other spellings it has appeared under, and its
+-- procurement portal, and RFPs are linked to it at promotion. Existing RFPs
+-- are linked with `rfp-cli discovery agency link`.
+
+CREATE TABLE discovery.agencies (
+    id          SERIAL PRIMARY KEY,
+    name        TEXT NOT NULL,
+    name_key    TEXT NOT NULL,   -- normalized for matching, e.g. "city of tampa"
+    state       TEXT NOT NULL DEFAULT '',
+    agency_type TEXT,            -- city, county, university, authority, airport, state, other
+    portal_url  TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (name_key, state)
+);
+
+-- Other names an agency has appeared under, e.g. "Tampa, City of"
+CREATE TABLE discovery.agency_aliases (
+    id         SERIAL PRIMARY KEY,
+    agency_id  INTEGER NOT NULL REFERENCES discovery.agencies(id) ON DELETE CASCADE,
+    alias      TEXT NOT NULL,
+    alias_key  TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (agency_id, alias_key)
+);
+
+CREATE INDEX idx_agency_aliases_key ON discovery.agency_aliases(alias_key);
+
+ALTER TABLE discovery.rfps ADD COLUMN agency_id INTEGER REFERENCES discovery.agencies(id);
+
+CREATE INDEX idx_rfps_agency ON discovery.rfps(agency_id);
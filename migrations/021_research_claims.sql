@@ -0,0 +1,12 @@
+-- Research claims
+-- A result is claimed by setting research_status = 'researching'. If the
+-- process researching it dies, the row would be stuck there; the claim
+-- time and the status to go back to let each cycle return stale claims to
+-- where they came from.
+
+ALTER TABLE discovery.search_results
+    ADD COLUMN research_started_at TIMESTAMPTZ,
+    ADD COLUMN research_resume_status TEXT;
+
+CREATE INDEX idx_search_results_researching ON discovery.search_results(research_started_at)
+    WHERE research_status = 'researching';
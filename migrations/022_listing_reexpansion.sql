@@ -0,0 +1,16 @@
+-- Listing re-expansion
+-- Portal listing pages gain new opportunities after they're first expanded,
+-- so expanded listings are enumerated again once expanded_at is old enough.
+-- canonical_url (dedup.CanonicalURL) lets entries seen under another URL
+-- spelling be skipped; rows saved before it are matched on their raw URL.
+
+ALTER TABLE discovery.search_results
+    ADD COLUMN expanded_at TIMESTAMPTZ,
+    ADD COLUMN canonical_url TEXT;
+
+UPDATE discovery.search_results SET expanded_at = created_at WHERE research_status = 'expanded';
+
+CREATE INDEX idx_search_results_expanded ON discovery.search_results(expanded_at)
+    WHERE research_status = 'expanded';
+CREATE INDEX idx_search_results_canonical_url ON discovery.search_results(canonical_url)
+    WHERE canonical_url IS NOT NULL;
@@ -33,6 +33,26 @@ type Config struct {
 	SMTPPass string
 	SMTPFrom string
 
+	// Page fetching
+	FetchUserAgent string
+	FetchProxyURL  string
+	FetchCAFile    string
+	FetchCache     string // postgres, disk, or off
+	FetchCacheDir  string
+	FetchSnapshots string // r2 or off
+
+	// Language models, as provider:model specs, e.g.
+	// gemini:gemini-3-flash-preview or openai:qwen2.5:14b
+	LLMExtractionModel string
+	LLMAwardModel      string // defaults to the extraction model
+	LLMBaseURL         string // endpoint for the openai provider
+	LLMAPIKey          string // key for the openai provider, if it needs one
+	SearchModel        string // Gemini model for grounded search
+
+	// Review queue API on the discovery service: comma-separated
+	// reviewer:token pairs; the API is disabled when empty
+	ReviewAPITokens string
+
 	// Optional
 	LogLevel string
 }
@@ -44,20 +64,32 @@ func Load() *Config {
 	_ = godotenv.Load()
 
 	return &Config{
-		DatabaseURL:       getEnv("DATABASE_URL", ""),
-		GeminiAPIKey:      getEnv("GEMINI_API_KEY", ""),
-		R2AccountID:       getEnv("R2_ACCOUNT_ID", ""),
-		R2AccessKeyID:     getEnv("R2_ACCESS_KEY_ID", ""),
-		R2SecretAccessKey: getEnv("R2_SECRET_ACCESS_KEY", ""),
-		R2Bucket:          getEnv("R2_BUCKET", "rfp-documents"),
-		SessionSecret:     getEnv("SESSION_SECRET", ""),
-		BaseURL:           getEnv("BASE_URL", "http://localhost:8080"),
-		SMTPHost:          getEnv("SMTP_HOST", ""),
-		SMTPPort:          getEnvInt("SMTP_PORT", 587),
-		SMTPUser:          getEnv("SMTP_USER", ""),
-		SMTPPass:          getEnv("SMTP_PASS", ""),
-		SMTPFrom:          getEnv("SMTP_FROM", ""),
-		LogLevel:          getEnv("LOG_LEVEL", "info"),
+		DatabaseURL:        getEnv("DATABASE_URL", ""),
+		GeminiAPIKey:       getEnv("GEMINI_API_KEY", ""),
+		R2AccountID:        getEnv("R2_ACCOUNT_ID", ""),
+		R2AccessKeyID:      getEnv("R2_ACCESS_KEY_ID", ""),
+		R2SecretAccessKey:  getEnv("R2_SECRET_ACCESS_KEY", ""),
+		R2Bucket:           getEnv("R2_BUCKET", "rfp-documents"),
+		SessionSecret:      getEnv("SESSION_SECRET", ""),
+		BaseURL:            getEnv("BASE_URL", "http://localhost:8080"),
+		SMTPHost:           getEnv("SMTP_HOST", ""),
+		SMTPPort:           getEnvInt("SMTP_PORT", 587),
+		SMTPUser:           getEnv("SMTP_USER", ""),
+		SMTPPass:           getEnv("SMTP_PASS", ""),
+		SMTPFrom:           getEnv("SMTP_FROM", ""),
+		FetchUserAgent:     getEnv("FETCH_USER_AGENT", ""),
+		FetchProxyURL:      getEnv("FETCH_PROXY_URL", ""),
+		FetchCAFile:        getEnv("FETCH_CA_FILE", ""),
+		FetchCache:         getEnv("FETCH_CACHE", "postgres"),
+		FetchCacheDir:      getEnv("FETCH_CACHE_DIR", ".cache/fetch"),
+		FetchSnapshots:     getEnv("FETCH_SNAPSHOTS", "r2"),
+		LLMExtractionModel: getEnv("LLM_EXTRACTION_MODEL", "gemini:gemini-3-flash-preview"),
+		LLMAwardModel:      getEnv("LLM_AWARD_MODEL", ""),
+		LLMBaseURL:         getEnv("LLM_BASE_URL", "http://localhost:11434/v1"),
+		LLMAPIKey:          getEnv("LLM_API_KEY", ""),
+		SearchModel:        getEnv("SEARCH_MODEL", ""),
+		ReviewAPITokens:    getEnv("REVIEW_API_TOKENS", ""),
+		LogLevel:           getEnv("LOG_LEVEL", "info"),
 	}
 }
 
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// invertedAgencyForm matches names written "Tampa, City of".
+var invertedAgencyForm = regexp.MustCompile(`^(.+?),\s*(city|town|county|village|borough|township|state|commonwealth)\s+of$`)
+
+var nonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+// AgencyKey normalizes an agency name for matching, as stored in the
+// agencies' name_key and alias_key columns. It keeps the form of
+// government, so the City and County of the same name stay apart: "Tampa,
+// City of" and "The City of Tampa" both become "city of tampa".
+func AgencyKey(name string) string {
+	s := strings.ToLower(strings.TrimSpace(name))
+	s = strings.TrimPrefix(s, "the ")
+	if m := invertedAgencyForm.FindStringSubmatch(s); m != nil {
+		s = m[2] + " of " + m[1]
+	}
+	s = strings.ReplaceAll(s, "&", " and ")
+	return strings.TrimSpace(nonAlnum.ReplaceAllString(s, " "))
+}
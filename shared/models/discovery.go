@@ -45,16 +45,48 @@ type SearchResult struct {
 	HintState   string     `json:"hint_state,omitempty"`
 	HintDueDate *time.Time `json:"hint_due_date,omitempty"`
 
+	// Parsed from the portal URL
+	Portal       string `json:"portal,omitempty"`
+	PortalAgency string `json:"portal_agency,omitempty"` // agency slug on the portal
+	PortalID     string `json:"portal_id,omitempty"`
+	PortalPage   string `json:"portal_page,omitempty"` // opportunity, listing
+
 	// Research status
-	ResearchStatus string `json:"research_status"` // pending, in_progress, completed, failed, skipped
+	ResearchStatus string `json:"research_status"` // pending, researching, researched, needs_manual, needs_manual_upload, research_exhausted, failed, skipped, expanded, rejected
 	PromotedRFPID  *int   `json:"promoted_rfp_id,omitempty"`
 	DuplicateOfID  *int   `json:"duplicate_of_id,omitempty"`
+
+	// ParentID is the listing page this result was enumerated from
+	ParentID *int `json:"parent_id,omitempty"`
+
+	// SnapshotKey locates the archived copy of the page fetched during validation
+	SnapshotKey string `json:"snapshot_key,omitempty"`
+}
+
+// ResearchRun is one run of the research agent on a search result.
+type ResearchRun struct {
+	ID               int             `json:"id"`
+	SearchResultID   int             `json:"search_result_id"`
+	Trigger          string          `json:"trigger"` // scheduler, manual
+	Status           string          `json:"status"`
+	Success          bool            `json:"success"`
+	ErrorMessage     string          `json:"error_message,omitempty"`
+	StepsTaken       int             `json:"steps_taken"`
+	TotalTokens      int             `json:"total_tokens"`
+	DurationMs       int64           `json:"duration_ms"`
+	ExtractedDetails json.RawMessage `json:"extracted_details,omitempty"`
+	FoundPDFs        []string        `json:"found_pdfs,omitempty"`
+	AgentVersion     string          `json:"agent_version"`
+	Model            string          `json:"model,omitempty"`
+	StartedAt        time.Time       `json:"started_at"`
+	CompletedAt      time.Time       `json:"completed_at"`
 }
 
 // ResearchStep represents a step in the research agent's process.
 type ResearchStep struct {
 	ID             int       `json:"id"`
 	SearchResultID int       `json:"search_result_id"`
+	ResearchRunID  *int      `json:"research_run_id,omitempty"` // nil for steps recorded before runs were
 	StepNumber     int       `json:"step_number"`
 	Action         string    `json:"action"` // fetch_page, extract_details, find_pdf, check_login, decide
 	InputSummary   string    `json:"input_summary,omitempty"`
@@ -62,6 +94,10 @@ type ResearchStep struct {
 	Reasoning      string    `json:"reasoning,omitempty"`
 	Success        bool      `json:"success"`
 	ErrorMessage   string    `json:"error_message,omitempty"`
+	TokensUsed     int       `json:"tokens_used,omitempty"`
+	DurationMs     int64     `json:"duration_ms,omitempty"`
+	SnapshotKey    string    `json:"snapshot_key,omitempty"`   // archived page, for fetch steps
+	PromptVersion  string    `json:"prompt_version,omitempty"` // e.g. extract_rfp@v1, for extract steps
 	CreatedAt      time.Time `json:"created_at"`
 }
 
@@ -73,24 +109,53 @@ type RFP struct {
 	State  string `json:"state,omitempty"`
 	City   string `json:"city,omitempty"`
 
+	// AgencyID links the RFP to its canonical agency
+	AgencyID *int `json:"agency_id,omitempty"`
+
+	// Coordinates from the offline gazetteer; nil when the location is unknown
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+	GeoPrecision string   `json:"geo_precision,omitempty"` // place, or state for the state's center
+
 	// Source
-	SourceURL string `json:"source_url,omitempty"`
-	Portal    string `json:"portal,omitempty"`    // bonfire, opengov, bidnet, planetbids, direct
-	PortalID  string `json:"portal_id,omitempty"` // ID within portal
+	SourceURL    string `json:"source_url,omitempty"`
+	Portal       string `json:"portal,omitempty"`        // bonfire, opengov, bidnet, planetbids, direct
+	PortalID     string `json:"portal_id,omitempty"`     // ID within portal
+	PortalAgency string `json:"portal_agency,omitempty"` // agency slug within portal
 
 	// Dates
 	PostedDate *time.Time `json:"posted_date,omitempty"`
 	DueDate    *time.Time `json:"due_date,omitempty"`
+	DueAt      *time.Time `json:"due_at,omitempty"` // exact deadline, when a time of day was given
 
 	// Classification
-	Category      string   `json:"category,omitempty"`   // parking, valet, event_ops, transit, enforcement
-	VenueType     string   `json:"venue_type,omitempty"` // arena, stadium, convention_center, airport, municipal
+	Category      string   `json:"category,omitempty"`   // parking, valet, event_ops, transit, enforcement, equipment
+	VenueType     string   `json:"venue_type,omitempty"` // see classify.Venue* for the vocabulary
 	ScopeKeywords []string `json:"scope_keywords,omitempty"`
 
+	// Key dates and requirements
+	PreBidDate            *time.Time `json:"pre_bid_date,omitempty"`
+	PreBidMandatory       *bool      `json:"pre_bid_mandatory,omitempty"`
+	QuestionsDeadline     *time.Time `json:"questions_deadline,omitempty"`
+	SubmissionMethod      string     `json:"submission_method,omitempty"` // electronic, email, mail, hand_delivery
+	SolicitationNumber    string     `json:"solicitation_number,omitempty"`
+	ContactName           string     `json:"contact_name,omitempty"`
+	ContactEmail          string     `json:"contact_email,omitempty"`
+	BondRequirements      string     `json:"bond_requirements,omitempty"`
+	InsuranceRequirements string     `json:"insurance_requirements,omitempty"`
+
 	// Contract details
-	TermMonths     *int     `json:"term_months,omitempty"`
-	EstimatedValue *float64 `json:"estimated_value,omitempty"`
-	Incumbent      string   `json:"incumbent,omitempty"`
+	TermMonths        *int     `json:"term_months,omitempty"`      // total, including renewals
+	BaseTermMonths    *int     `json:"base_term_months,omitempty"` // initial term
+	RenewalMonths     *int     `json:"renewal_months,omitempty"`   // all renewal options combined
+	EstimatedValue    *float64 `json:"estimated_value,omitempty"`  // low end when a range was given
+	EstimatedValueMax *float64 `json:"estimated_value_max,omitempty"`
+	ValueNotToExceed  bool     `json:"value_not_to_exceed,omitempty"`
+	Incumbent         string   `json:"incumbent,omitempty"`
+
+	// RawValues keeps the extracted text of values that could not be parsed,
+	// keyed by extraction field name.
+	RawValues map[string]string `json:"raw_values,omitempty"`
 
 	// Access
 	LoginRequired bool   `json:"login_required"`
@@ -99,6 +164,22 @@ type RFP struct {
 	// Documents
 	PDFURLs []string `json:"pdf_urls,omitempty"`
 
+	// Lifecycle
+	LifecycleStatus string     `json:"lifecycle_status"` // open, closed, awarded, cancelled, unknown
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	StatusReason    string     `json:"status_reason,omitempty"`
+
+	// Award
+	Awardee        string     `json:"awardee,omitempty"`
+	AwardAmount    *float64   `json:"award_amount,omitempty"`
+	AwardDate      *time.Time `json:"award_date,omitempty"`
+	AwardSourceURL string     `json:"award_source_url,omitempty"`
+	AwardCheckedAt *time.Time `json:"award_checked_at,omitempty"`
+
+	// Provenance
+	FieldEvidence []FieldEvidence `json:"field_evidence,omitempty"`
+	ReviewFields  []string        `json:"review_fields,omitempty"` // low-confidence fields flagged for review
+
 	// Metadata
 	RawContent   string    `json:"raw_content,omitempty"`
 	DiscoveredAt time.Time `json:"discovered_at"`
@@ -106,6 +187,41 @@ type RFP struct {
 	IsActive     bool      `json:"is_active"`
 }
 
+// FieldEvidence records where an extracted RFP field came from.
+type FieldEvidence struct {
+	Field      string  `json:"field"`              // extraction field name, e.g. due_date
+	Confidence float64 `json:"confidence"`         // 0.0-1.0
+	Evidence   string  `json:"evidence,omitempty"` // verbatim snippet from the source
+	Verified   bool    `json:"verified"`           // evidence was found in the source text
+}
+
+// RFP lifecycle statuses.
+const (
+	LifecycleOpen      = "open"
+	LifecycleClosed    = "closed"
+	LifecycleAwarded   = "awarded"
+	LifecycleCancelled = "cancelled"
+	LifecycleUnknown   = "unknown"
+)
+
+// IsLifecycleActive reports whether an RFP with the given lifecycle status may
+// still be accepting submissions. Unknown counts as active so that RFPs whose
+// source page disappeared are not silently dropped.
+func IsLifecycleActive(status string) bool {
+	return status == LifecycleOpen || status == LifecycleUnknown
+}
+
+// Agency is a canonical public agency that issues RFPs.
+type Agency struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	State     string    `json:"state,omitempty"`
+	Type      string    `json:"type,omitempty"` // city, county, university, authority, airport, state, other
+	PortalURL string    `json:"portal_url,omitempty"`
+	Aliases   []string  `json:"aliases,omitempty"` // other names it appears under
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // Source represents a monitored data source.
 type Source struct {
 	ID         int             `json:"id"`